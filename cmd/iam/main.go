@@ -40,7 +40,10 @@ func run() (err error) {
 	}()
 
 	// Initialize the HTTP server with configuration and logger.
-	server := server.New(logger, cfg)
+	server, err := server.New(logger, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to construct server : %v", err)
+	}
 
 	// Start serving HTTP requests.
 	server.ListenAndServe()
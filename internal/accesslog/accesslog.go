@@ -0,0 +1,101 @@
+// Package accesslog logs one structured entry per HTTP request handled by the Goa muxer.
+// Entries are logged through the request logger, so they carry the request ID, tenant and
+// trace of the request. Credentials never reach the log: Authorization headers and
+// sensitive query parameters are redacted with package redact.
+package accesslog
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	goahttp "goa.design/goa/v3/http"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
+)
+
+// Logger logs the requests handled by the Goa endpoints of a muxer.
+type Logger struct {
+	mux    goahttp.ResolverMuxer // Muxer resolving the pattern of the route a request matched
+	cfg    *config.AccessLog     // Access log settings
+	sample func() float64        // Source of the numbers successful requests are sampled with
+}
+
+// New creates the access log of the requests handled by the muxer. Its middleware must be
+// added to the muxer before any endpoint is mounted.
+func New(mux goahttp.ResolverMuxer, cfg *config.AccessLog) *Logger {
+	return &Logger{mux: mux, cfg: cfg, sample: rand.Float64}
+}
+
+// Middleware logs each request once handled. Failed requests are always logged, while
+// successful ones are sampled at the configured rate.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	if !l.cfg.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		if rw.status < http.StatusBadRequest && l.sample() >= l.cfg.SuccessSampleRate {
+			return
+		}
+
+		md := requestctx.MetadataFromContext(r.Context())
+		fields := []any{
+			"method", r.Method,
+			"route", l.mux.ResolvePattern(r),
+			"status", rw.status,
+			"latencyMs", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", rw.bytes,
+			"clientIp", md.IPAddress,
+			"userAgent", md.UserAgent,
+		}
+		if subject := requestctx.Subject(r.Context()); subject != "" {
+			fields = append(fields, "subject", subject)
+		}
+		if r.URL.RawQuery != "" {
+			fields = append(fields, "query", redact.RedactQuery(r.URL.Query()))
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			fields = append(fields, "authorization", redact.RedactAuthorization(auth))
+		}
+
+		log := logger.FromContext(r.Context())
+		if rw.status >= http.StatusInternalServerError {
+			log.Warnw("http request", fields...)
+			return
+		}
+		log.Infow("http request", fields...)
+	})
+}
+
+// responseRecorder captures the status code and the number of body bytes written to a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status code before writing it.
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the body bytes written.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -1,7 +1,10 @@
 // Package config defines configuration types used across the IAM service.
 package config
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Environment defines the type for representing different runtime environments.
 type Environment string
@@ -13,8 +16,13 @@ var (
 )
 
 // Logging holds settings for how logging should behave in different environments.
+// RedactionMode is "mask", "hash" or "tokenize" and decides how identifying values, such as
+// email addresses, are redacted; RedactionKey keys the hashes and tokens, a random key being
+// used when it is empty.
 type Logging struct {
-	Level string `json:"level"`
+	Level         string `json:"level"`
+	RedactionMode string `json:"redactionMode"`
+	RedactionKey  string `json:"redactionKey"`
 }
 
 // Server holds HTTP server configuration.
@@ -34,23 +42,186 @@ type Application struct {
 	Environment Environment `json:"environment"`
 }
 
+// Auth holds token signing settings and session limits. Session limits apply
+// independently of token expiry, and a zero value disables the limit.
 type Auth struct {
-	Issuer              string        `json:"issuer"`
-	Secret              string        `json:"secret"`
-	Audience            string        `json:"audience"`
-	AccessTokenExpTime  time.Duration `json:"accessTokenExpTime"`
-	RefreshTokenExpTime time.Duration `json:"refreshTokenExpTime"`
+	Issuer                 string        `json:"issuer"`
+	Secret                 string        `json:"secret"`
+	Audience               string        `json:"audience"`
+	AccessTokenExpTime     time.Duration `json:"accessTokenExpTime"`
+	RefreshTokenExpTime    time.Duration `json:"refreshTokenExpTime"`
+	SessionIdleTimeout     time.Duration `json:"sessionIdleTimeout"`
+	SessionAbsoluteTimeout time.Duration `json:"sessionAbsoluteTimeout"`
+	AdminEmails            []string      `json:"adminEmails"`
+}
+
+// WebAuthn holds relying party settings for passkey registration and login.
+type WebAuthn struct {
+	RPID             string        `json:"rpId"`
+	RPDisplayName    string        `json:"rpDisplayName"`
+	RPOrigins        []string      `json:"rpOrigins"`
+	ChallengeTimeout time.Duration `json:"challengeTimeout"`
+}
+
+// OAuth holds OAuth 2.0 authorization server settings.
+type OAuth struct {
+	AuthorizationCodeExpTime time.Duration `json:"authorizationCodeExpTime"`
+	TokenEndpointURL         string        `json:"tokenEndpointUrl"`
+	DeviceCodeExpTime        time.Duration `json:"deviceCodeExpTime"`
+	DevicePollInterval       time.Duration `json:"devicePollInterval"`
+	DeviceVerificationURI    string        `json:"deviceVerificationUri"`
+}
+
+// OIDC holds OpenID Connect ID token settings. When no signing key file is
+// configured an ephemeral key is generated at startup.
+type OIDC struct {
+	SigningKeyFile string        `json:"signingKeyFile"`
+	IDTokenExpTime time.Duration `json:"idTokenExpTime"`
+}
+
+// IdentityProvider describes an upstream OpenID Connect provider users can sign in with.
+type IdentityProvider struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// Federation holds settings for signing in through upstream identity providers.
+// Providers are read from a JSON file holding an array of identity providers.
+type Federation struct {
+	ProvidersFile string             `json:"providersFile"`
+	Providers     []IdentityProvider `json:"providers"`
+	CallbackURL   string             `json:"callbackUrl"`
+	StateExpTime  time.Duration      `json:"stateExpTime"`
+}
+
+// SAMLProvider describes an upstream SAML 2.0 identity provider. Attribute names select
+// the assertion attributes holding the user's profile, matched by name or friendly name.
+type SAMLProvider struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	MetadataFile       string `json:"metadataFile"`
+	NameIDFormat       string `json:"nameIdFormat"`
+	EmailAttribute     string `json:"emailAttribute"`
+	FirstNameAttribute string `json:"firstNameAttribute"`
+	LastNameAttribute  string `json:"lastNameAttribute"`
+	JITProvisioning    bool   `json:"jitProvisioning"`
+	AllowIDPInitiated  bool   `json:"allowIdpInitiated"`
+}
+
+// SAML holds settings for signing in through SAML 2.0 identity providers. Providers are
+// read from a JSON file holding an array of SAML providers. When no certificate and key
+// are configured an ephemeral self-signed pair is generated at startup.
+type SAML struct {
+	BaseURL         string         `json:"baseUrl"`
+	CertificateFile string         `json:"certificateFile"`
+	KeyFile         string         `json:"keyFile"`
+	ProvidersFile   string         `json:"providersFile"`
+	Providers       []SAMLProvider `json:"providers"`
+	RequestExpTime  time.Duration  `json:"requestExpTime"`
+}
+
+// SCIM holds settings for the SCIM 2.0 provisioning API. The base URL is used to build
+// resource locations, and list responses never return more than MaxResults resources.
+type SCIM struct {
+	BaseURL    string `json:"baseUrl"`
+	MaxResults int    `json:"maxResults"`
+}
+
+// Tenancy holds settings for routing requests to organizations. The organization is taken
+// from the header when present, then from the subdomain of the base domain, and defaults to
+// the default organization, which is created at startup and hosts the platform admins.
+type Tenancy struct {
+	Header              string `json:"header"`
+	BaseDomain          string `json:"baseDomain"`
+	DefaultOrganization string `json:"defaultOrganization"`
+}
+
+// Invitations holds settings for inviting people into organizations. Pending invitations
+// can be accepted until they expire, after which they're removed. The tokens of the
+// invitations sent to the admin emails at startup are written to AdminTokensFile, if set.
+type Invitations struct {
+	ExpTime         time.Duration `json:"expTime"`
+	AdminTokensFile string        `json:"adminTokensFile"`
+}
+
+// Audit holds settings for the audit log. Events are kept in memory, and lost on restart,
+// unless a file is configured.
+type Audit struct {
+	File string `json:"file"`
+}
+
+// Webhooks holds settings for delivering domain events to webhook subscriptions. A failed
+// delivery is retried after InitialBackoff, doubling the wait after each further failure up
+// to MaxBackoff, and dead-lettered after MaxAttempts attempts. Endpoints resolving to
+// private, loopback or link-local addresses are refused unless AllowPrivateNetworks is set.
+type Webhooks struct {
+	MaxAttempts          int           `json:"maxAttempts"`
+	InitialBackoff       time.Duration `json:"initialBackoff"`
+	MaxBackoff           time.Duration `json:"maxBackoff"`
+	Timeout              time.Duration `json:"timeout"`
+	PollInterval         time.Duration `json:"pollInterval"`
+	AllowPrivateNetworks bool          `json:"allowPrivateNetworks"`
+}
+
+// AccessLog holds settings for the HTTP access log. Successful requests are logged with a
+// probability of SuccessSampleRate, between 0 and 1, while failed requests are always logged.
+type AccessLog struct {
+	Enabled           bool    `json:"enabled"`
+	SuccessSampleRate float64 `json:"successSampleRate"`
+}
+
+// Tracing holds settings for exporting OpenTelemetry traces. Exporter is "none", "stdout" for
+// local testing, or "otlp" to send spans over OTLP/HTTP to Endpoint, a host and port. An
+// empty Endpoint falls back to the standard OTEL_EXPORTER_OTLP_* environment variables.
+type Tracing struct {
+	Exporter string `json:"exporter"`
+	Endpoint string `json:"endpoint"`
+	Insecure bool   `json:"insecure"`
+}
+
+// Health holds settings for the health probes. Each dependency check must complete within
+// CheckTimeout, and the server isn't ready while domain events wait longer than MaxOutboxLag
+// to be published. On shutdown, the server reports itself not ready for ShutdownDelay before
+// draining connections, giving load balancers time to stop routing requests to it.
+type Health struct {
+	CheckTimeout  time.Duration `json:"checkTimeout"`
+	MaxOutboxLag  time.Duration `json:"maxOutboxLag"`
+	ShutdownDelay time.Duration `json:"shutdownDelay"`
+}
+
+// Authorization holds settings for the policy engine. Without a policy file, actions are
+// allowed when the principal's roles grant a permission of the same name.
+type Authorization struct {
+	PolicyFile string `json:"policyFile"`
 }
 
 type Config struct {
-	Server      *Server      `json:"server"`
-	Auth        *Auth        `json:"auth"`
-	Logging     *Logging     `json:"logging"`
-	Application *Application `json:"application"`
+	Server        *Server        `json:"server"`
+	Auth          *Auth          `json:"auth"`
+	WebAuthn      *WebAuthn      `json:"webAuthn"`
+	OAuth         *OAuth         `json:"oauth"`
+	OIDC          *OIDC          `json:"oidc"`
+	Federation    *Federation    `json:"federation"`
+	SAML          *SAML          `json:"saml"`
+	SCIM          *SCIM          `json:"scim"`
+	Tenancy       *Tenancy       `json:"tenancy"`
+	Invitations   *Invitations   `json:"invitations"`
+	Authorization *Authorization `json:"authorization"`
+	Audit         *Audit         `json:"audit"`
+	Webhooks      *Webhooks      `json:"webhooks"`
+	Tracing       *Tracing       `json:"tracing"`
+	AccessLog     *AccessLog     `json:"accessLog"`
+	Health        *Health        `json:"health"`
+	Logging       *Logging       `json:"logging"`
+	Application   *Application   `json:"application"`
 }
 
 func Load() (*Config, error) {
-	return &Config{
+	cfg := &Config{
 		Server: &Server{
 			Host:            getEnv("SERVER_HOST", "0.0.0.0"),
 			Port:            getEnvInt("SERVER_PORT", 8080),
@@ -60,19 +231,108 @@ func Load() (*Config, error) {
 			ShutdownTimeout: getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", time.Second*30),
 		},
 		Auth: &Auth{
-			Audience:            getEnv("AUTH_AUDIENCE", "http://localhost:8080"),
-			Issuer:              getEnv("AUTH_ISSUER", "https://issuer.iam.support"),
-			AccessTokenExpTime:  getEnvDuration("AUTH_ACCESS_TOKEN_EXP_TIME", time.Hour),
-			RefreshTokenExpTime: getEnvDuration("AUTH_REFRESH_TOKEN_EXP_TIME", time.Hour*24*60),
-			Secret:              getEnv("AUTH_SECRET", "9916ce66f41d25276ab5923ce5e62ef7fbb6e046bb3072a507bf0362bae0d63d"),
+			Audience:               getEnv("AUTH_AUDIENCE", "http://localhost:8080"),
+			Issuer:                 getEnv("AUTH_ISSUER", "https://issuer.iam.support"),
+			AccessTokenExpTime:     getEnvDuration("AUTH_ACCESS_TOKEN_EXP_TIME", time.Hour),
+			RefreshTokenExpTime:    getEnvDuration("AUTH_REFRESH_TOKEN_EXP_TIME", time.Hour*24*60),
+			Secret:                 getEnv("AUTH_SECRET", "9916ce66f41d25276ab5923ce5e62ef7fbb6e046bb3072a507bf0362bae0d63d"),
+			SessionIdleTimeout:     getEnvDuration("AUTH_SESSION_IDLE_TIMEOUT", time.Hour*24),
+			SessionAbsoluteTimeout: getEnvDuration("AUTH_SESSION_ABSOLUTE_TIMEOUT", time.Hour*24*30),
+			AdminEmails:            getEnvSlice("AUTH_ADMIN_EMAILS", nil),
+		},
+		WebAuthn: &WebAuthn{
+			RPID:             getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName:    getEnv("WEBAUTHN_RP_DISPLAY_NAME", "IAM Platform"),
+			RPOrigins:        getEnvSlice("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:8080"}),
+			ChallengeTimeout: getEnvDuration("WEBAUTHN_CHALLENGE_TIMEOUT", time.Minute*5),
+		},
+		OAuth: &OAuth{
+			AuthorizationCodeExpTime: getEnvDuration("OAUTH_AUTHORIZATION_CODE_EXP_TIME", time.Minute*10),
+			TokenEndpointURL:         getEnv("OAUTH_TOKEN_ENDPOINT_URL", "http://localhost:8080/api/v1/oauth/token"),
+			DeviceCodeExpTime:        getEnvDuration("OAUTH_DEVICE_CODE_EXP_TIME", time.Minute*10),
+			DevicePollInterval:       getEnvDuration("OAUTH_DEVICE_POLL_INTERVAL", time.Second*5),
+			DeviceVerificationURI:    getEnv("OAUTH_DEVICE_VERIFICATION_URI", "http://localhost:8080/device"),
+		},
+		OIDC: &OIDC{
+			SigningKeyFile: getEnv("OIDC_SIGNING_KEY_FILE", ""),
+			IDTokenExpTime: getEnvDuration("OIDC_ID_TOKEN_EXP_TIME", time.Hour),
+		},
+		Federation: &Federation{
+			ProvidersFile: getEnv("FEDERATION_PROVIDERS_FILE", ""),
+			CallbackURL:   getEnv("FEDERATION_CALLBACK_URL", "http://localhost:8080/api/v1/auth/federation/callback"),
+			StateExpTime:  getEnvDuration("FEDERATION_STATE_EXP_TIME", time.Minute*10),
+		},
+		SAML: &SAML{
+			BaseURL:         getEnv("SAML_BASE_URL", "http://localhost:8080/api/v1/auth/saml"),
+			CertificateFile: getEnv("SAML_CERTIFICATE_FILE", ""),
+			KeyFile:         getEnv("SAML_KEY_FILE", ""),
+			ProvidersFile:   getEnv("SAML_PROVIDERS_FILE", ""),
+			RequestExpTime:  getEnvDuration("SAML_REQUEST_EXP_TIME", time.Minute*10),
+		},
+		SCIM: &SCIM{
+			BaseURL:    getEnv("SCIM_BASE_URL", "http://localhost:8080/api/v1/scim/v2"),
+			MaxResults: getEnvInt("SCIM_MAX_RESULTS", 100),
+		},
+		Tenancy: &Tenancy{
+			Header:              getEnv("TENANCY_HEADER", "X-Tenant-ID"),
+			BaseDomain:          getEnv("TENANCY_BASE_DOMAIN", ""),
+			DefaultOrganization: getEnv("TENANCY_DEFAULT_ORGANIZATION", "default"),
+		},
+		Invitations: &Invitations{
+			ExpTime:         getEnvDuration("INVITATION_EXP_TIME", time.Hour*24*7),
+			AdminTokensFile: getEnv("INVITATION_ADMIN_TOKENS_FILE", ""),
+		},
+		Authorization: &Authorization{
+			PolicyFile: getEnv("AUTHZ_POLICY_FILE", ""),
+		},
+		Audit: &Audit{
+			File: getEnv("AUDIT_LOG_FILE", ""),
+		},
+		Webhooks: &Webhooks{
+			MaxAttempts:          getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff:       getEnvDuration("WEBHOOK_INITIAL_BACKOFF", time.Second*10),
+			MaxBackoff:           getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			Timeout:              getEnvDuration("WEBHOOK_TIMEOUT", time.Second*10),
+			PollInterval:         getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+			AllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Tracing: &Tracing{
+			Exporter: getEnv("TRACING_EXPORTER", "none"),
+			Endpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
+			Insecure: getEnvBool("TRACING_OTLP_INSECURE", false),
+		},
+		AccessLog: &AccessLog{
+			Enabled:           getEnvBool("ACCESS_LOG_ENABLED", true),
+			SuccessSampleRate: getEnvFloat("ACCESS_LOG_SUCCESS_SAMPLE_RATE", 1),
+		},
+		Health: &Health{
+			CheckTimeout:  getEnvDuration("HEALTH_CHECK_TIMEOUT", time.Second*2),
+			MaxOutboxLag:  getEnvDuration("HEALTH_MAX_OUTBOX_LAG", time.Minute),
+			ShutdownDelay: getEnvDuration("HEALTH_SHUTDOWN_DELAY", 0),
 		},
 		Logging: &Logging{
-			Level: getEnv("LOG_LEVEL", "INFO"),
+			Level:         getEnv("LOG_LEVEL", "INFO"),
+			RedactionMode: getEnv("LOG_REDACTION_MODE", "mask"),
+			RedactionKey:  getEnv("LOG_REDACTION_KEY", ""),
 		},
 		Application: &Application{
 			Version:     getEnv("APP_VERSION", "0.1.0"),
 			Service:     getEnv("SERVICE_NAME", "IAM-PLATFORM"),
 			Environment: ToEnvironment(getEnv("APP_ENVIRONMENT", "development")),
 		},
-	}, nil
+	}
+
+	if path := cfg.Federation.ProvidersFile; path != "" {
+		if err := readJSONFile(path, &cfg.Federation.Providers); err != nil {
+			return nil, fmt.Errorf("load identity providers: %w", err)
+		}
+	}
+
+	if path := cfg.SAML.ProvidersFile; path != "" {
+		if err := readJSONFile(path, &cfg.SAML.Providers); err != nil {
+			return nil, fmt.Errorf("load saml providers: %w", err)
+		}
+	}
+
+	return cfg, nil
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"strconv"
 	"strings"
@@ -41,6 +42,19 @@ func getEnvBool(key string, fallback bool) bool {
 	return boolVal
 }
 
+func getEnvFloat(key string, fallback float64) float64 {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	floatVal, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fallback
+	}
+	return floatVal
+}
+
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	val, ok := os.LookupEnv(key)
 	if !ok {
@@ -54,6 +68,25 @@ func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	return durationVal
 }
 
+func getEnvSlice(key string, fallback []string) []string {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	var values []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
 func ToEnvironment(str string) Environment {
 	switch strings.ToLower(str) {
 	case "prod", "production":
@@ -64,3 +97,11 @@ func ToEnvironment(str string) Environment {
 		return EnvironmentDevelopment
 	}
 }
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
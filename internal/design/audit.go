@@ -0,0 +1,239 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// AuditEvent describes an event recorded in the audit log.
+var AuditEvent = dsl.Type("AuditEvent", func() {
+	dsl.Description("A security relevant action recorded in the organization's audit log.")
+
+	dsl.Attribute("id", dsl.String, "Event's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("5b0f5c1e-8d2a-4f3b-9c6d-7e8f9a0b1c2d")
+	})
+
+	dsl.Attribute("sequence", dsl.Int64, "Position of the event in the organization's chain of events", func() {
+		dsl.Example(42)
+	})
+
+	dsl.Attribute("type", dsl.String, "What happened", func() {
+		dsl.Example("auth.signin")
+	})
+
+	dsl.Attribute("outcome", dsl.String, "Whether the action succeeded", func() {
+		dsl.Enum("success", "failure")
+		dsl.Example("success")
+	})
+
+	dsl.Attribute("actorId", dsl.String, "Principal that performed the action", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("targetId", dsl.String, "Entity the action was performed on", func() {
+		dsl.Example("9a3b2c1d-4e5f-4a6b-8c7d-0e1f2a3b4c5d")
+	})
+
+	dsl.Attribute("ipAddress", dsl.String, "IP address of the client", func() {
+		dsl.Example("203.0.113.7")
+	})
+
+	dsl.Attribute("userAgent", dsl.String, "User-Agent of the client", func() {
+		dsl.Example("Mozilla/5.0")
+	})
+
+	dsl.Attribute("reason", dsl.String, "Why the action failed", func() {
+		dsl.Example("user with email j***@acme.com doesn't exist")
+	})
+
+	dsl.Attribute("details", dsl.MapOf(dsl.String, dsl.String), "Additional context of the action", func() {
+		dsl.Example(map[string]string{"method": "password"})
+	})
+
+	dsl.Attribute("occurredAt", dsl.String, "Timestamp when the action happened", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("prevHash", dsl.String, "Hash of the previous event, empty for the first event", func() {
+		dsl.Example("")
+	})
+
+	dsl.Attribute("hash", dsl.String, "SHA-256 hash of the event, covering the previous hash", func() {
+		dsl.Example("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	})
+
+	dsl.Required("id", "sequence", "type", "outcome", "occurredAt", "prevHash", "hash")
+})
+
+// AuditVerification describes the outcome of verifying the audit log.
+var AuditVerification = dsl.Type("AuditVerification", func() {
+	dsl.Description("Whether the organization's chain of audit events is intact.")
+
+	dsl.Attribute("valid", dsl.Boolean, "Whether every event is unchanged and in place")
+	dsl.Attribute("totalEvents", dsl.Int, "Number of events verified", func() {
+		dsl.Example(42)
+	})
+	dsl.Attribute("error", dsl.String, "First problem found in the chain", func() {
+		dsl.Example("event 7 was modified")
+	})
+	dsl.Attribute("headSequence", dsl.Int64, "Sequence of the last event, to keep outside the audit log as an anchor", func() {
+		dsl.Example(42)
+	})
+	dsl.Attribute("headHash", dsl.String, "Hash of the last event, to keep outside the audit log as an anchor", func() {
+		dsl.Example("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	})
+
+	dsl.Required("valid", "totalEvents")
+})
+
+// ListAuditEventsRequest defines the payload for querying the audit log.
+var ListAuditEventsRequest = dsl.Type("ListAuditEventsRequest", func() {
+	dsl.Description("Payload for querying the audit log of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("from", dsl.String, "Only events that occurred at or after this time", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("to", dsl.String, "Only events that occurred before this time", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-02-01T00:00:00Z")
+	})
+
+	dsl.Attribute("actorId", dsl.String, "Only events performed by this principal", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("type", dsl.ArrayOf(dsl.String), "Only events of these types", func() {
+		dsl.Example([]string{"auth.signin"})
+	})
+
+	dsl.Attribute("limit", dsl.Int, "Maximum number of events to return", func() {
+		dsl.Minimum(1)
+		dsl.Maximum(1000)
+		dsl.Default(100)
+	})
+
+	dsl.Required("token")
+})
+
+// VerifyAuditLogRequest defines the payload for verifying the audit log.
+var VerifyAuditLogRequest = dsl.Type("VerifyAuditLogRequest", func() {
+	dsl.Description("Payload for verifying the audit log of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("anchorSequence", dsl.Int64, "Head sequence reported by an earlier verification", func() {
+		dsl.Minimum(1)
+		dsl.Example(42)
+	})
+
+	dsl.Attribute("anchorHash", dsl.String, "Head hash reported by an earlier verification", func() {
+		dsl.Example("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	})
+
+	dsl.Required("token")
+})
+
+// ListAuditEventsResponse defines the response listing audit events.
+var ListAuditEventsResponse = dsl.Type("ListAuditEventsResponse", func() {
+	dsl.Description("Response returned when querying the audit log.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(AuditEvent), "The matching events, newest first")
+
+	dsl.Required("success", "message", "data")
+})
+
+// VerifyAuditLogResponse defines the response returning the outcome of a verification.
+var VerifyAuditLogResponse = dsl.Type("VerifyAuditLogResponse", func() {
+	dsl.Description("Response returned when verifying the audit log.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", AuditVerification, "The outcome of the verification")
+
+	dsl.Required("success", "message", "data")
+})
+
+// AuditService defines the audit log endpoints.
+var _ = dsl.Service("audit", func() {
+	dsl.Description("Audit service querying the append-only log of security events.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the permission to read the audit log")
+
+	// Base URL path for all HTTP endpoints in the audit service.
+	dsl.HTTP(func() {
+		dsl.Path("/audit")
+	})
+
+	// --- Method: listEvents ---
+	dsl.Method("listEvents", func() {
+		dsl.Description("Queries the audit log of the organization, newest events first. Requires the audit:read permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListAuditEventsRequest)
+		dsl.Result(ListAuditEventsResponse)
+
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("forbidden")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/events")
+			dsl.Param("from")
+			dsl.Param("to")
+			dsl.Param("actorId")
+			dsl.Param("type")
+			dsl.Param("limit")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListAuditEventsResponse)
+			})
+		})
+	})
+
+	// --- Method: verify ---
+	dsl.Method("verify", func() {
+		dsl.Description("Verifies that no event of the organization's audit log was modified, removed or reordered, and that the log still holds the head reported by an earlier verification when given as an anchor. Requires the audit:read permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(VerifyAuditLogRequest)
+		dsl.Result(VerifyAuditLogResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("forbidden")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/verify")
+			dsl.Param("anchorSequence")
+			dsl.Param("anchorHash")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(VerifyAuditLogResponse)
+			})
+		})
+	})
+})
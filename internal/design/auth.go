@@ -68,7 +68,7 @@ var SigninRequest = dsl.Type("SigninRequest", func() {
 
 // TokenPayload defines the structure of the JWT tokens.
 var TokenPayload = dsl.Type("TokenPayload", func() {
-	dsl.Description("JWT access and refresh tokens issued after successful authentication.")
+	dsl.Description("JWT access and refresh tokens and an ID token issued after successful authentication.")
 
 	dsl.Attribute("accessToken", dsl.String, "JWT access token", func() {
 		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
@@ -78,6 +78,10 @@ var TokenPayload = dsl.Type("TokenPayload", func() {
 		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
 	})
 
+	dsl.Attribute("idToken", dsl.String, "OpenID Connect ID token describing the authenticated user", func() {
+		dsl.Example("eyJhbGciOiJSUzI1NiIsImtpZCI6IjVtM...")
+	})
+
 	dsl.Required("accessToken", "refreshToken")
 })
 
@@ -91,6 +95,17 @@ var TokenResponse = dsl.Type("TokenResponse", func() {
 	dsl.Attribute("data", TokenPayload)
 })
 
+// RefreshRequest defines the payload for the refresh endpoint.
+var RefreshRequest = dsl.Type("RefreshRequest", func() {
+	dsl.Description("Payload for exchanging a refresh token for a new token pair.")
+
+	dsl.Attribute("refreshToken", dsl.String, "JWT refresh token issued at signin", func() {
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("refreshToken")
+})
+
 // SignoutRequest defines the payload for the signout endpoint.
 var SignoutRequest = dsl.Type("SignoutRequest", func() {
 	dsl.Description("Payload for user signout.")
@@ -109,7 +124,554 @@ var SignoutResponse = dsl.Type("SignoutResponse", func() {
 	dsl.Extend(SuccessResponse)
 })
 
+// PasskeyRegistrationOptionsRequest defines the payload for starting a passkey registration.
+var PasskeyRegistrationOptionsRequest = dsl.Type("PasskeyRegistrationOptionsRequest", func() {
+	dsl.Description("Payload for starting a passkey registration for the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// PasskeyRelyingParty identifies the relying party in passkey creation options.
+var PasskeyRelyingParty = dsl.Type("PasskeyRelyingParty", func() {
+	dsl.Description("Relying party the passkey is scoped to.")
+
+	dsl.Attribute("id", dsl.String, "Relying party identifier (domain)", func() {
+		dsl.Example("localhost")
+	})
+
+	dsl.Attribute("name", dsl.String, "Human readable relying party name", func() {
+		dsl.Example("IAM Platform")
+	})
+
+	dsl.Required("id", "name")
+})
+
+// PasskeyUserEntity identifies the user account in passkey creation options.
+var PasskeyUserEntity = dsl.Type("PasskeyUserEntity", func() {
+	dsl.Description("User account the passkey is created for.")
+
+	dsl.Attribute("id", dsl.String, "Base64url encoded user handle", func() {
+		dsl.Example("NGQyZWZkZTYtNDQ4YS00YzI2LWE2OWEtMjZjMmY5YTZkZTRh")
+	})
+
+	dsl.Attribute("name", dsl.String, "Account name, usually the email address", func() {
+		dsl.Example("john@gmail.com")
+	})
+
+	dsl.Attribute("displayName", dsl.String, "Human readable account name", func() {
+		dsl.Example("John Doe")
+	})
+
+	dsl.Required("id", "name", "displayName")
+})
+
+// PasskeyCredentialParameter describes a public key algorithm accepted by the relying party.
+var PasskeyCredentialParameter = dsl.Type("PasskeyCredentialParameter", func() {
+	dsl.Description("Public key credential type and COSE algorithm accepted by the relying party.")
+
+	dsl.Attribute("type", dsl.String, "Credential type", func() {
+		dsl.Example("public-key")
+	})
+
+	dsl.Attribute("alg", dsl.Int64, "COSE algorithm identifier", func() {
+		dsl.Example(-7)
+	})
+
+	dsl.Required("type", "alg")
+})
+
+// PasskeyCredentialDescriptor references an existing credential.
+var PasskeyCredentialDescriptor = dsl.Type("PasskeyCredentialDescriptor", func() {
+	dsl.Description("Reference to a registered public key credential.")
+
+	dsl.Attribute("type", dsl.String, "Credential type", func() {
+		dsl.Example("public-key")
+	})
+
+	dsl.Attribute("id", dsl.String, "Base64url encoded credential ID", func() {
+		dsl.Example("AQIDBAUGBwgJCgsMDQ4PEA")
+	})
+
+	dsl.Required("type", "id")
+})
+
+// PasskeyCreationOptions defines the options passed to navigator.credentials.create.
+var PasskeyCreationOptions = dsl.Type("PasskeyCreationOptions", func() {
+	dsl.Description("Public key credential creation options for a passkey registration ceremony.")
+
+	dsl.Attribute("challenge", dsl.String, "Base64url encoded registration challenge", func() {
+		dsl.Example("q7G1lVqK0xX4Lqz8fYbqX7jvS1oVbqS2kE9bM8rjL9o")
+	})
+
+	dsl.Attribute("rp", PasskeyRelyingParty, "Relying party information")
+	dsl.Attribute("user", PasskeyUserEntity, "User account information")
+	dsl.Attribute("pubKeyCredParams", dsl.ArrayOf(PasskeyCredentialParameter), "Accepted public key algorithms")
+	dsl.Attribute("excludeCredentials", dsl.ArrayOf(PasskeyCredentialDescriptor), "Credentials already registered by the user")
+
+	dsl.Attribute("timeout", dsl.Int64, "Ceremony timeout in milliseconds", func() {
+		dsl.Example(300000)
+	})
+
+	dsl.Attribute("attestation", dsl.String, "Attestation conveyance preference", func() {
+		dsl.Example("none")
+	})
+
+	dsl.Required("challenge", "rp", "user", "pubKeyCredParams", "excludeCredentials", "timeout", "attestation")
+})
+
+// PasskeyRegistrationOptionsResponse defines the response containing passkey creation options.
+var PasskeyRegistrationOptionsResponse = dsl.Type("PasskeyRegistrationOptionsResponse", func() {
+	dsl.Description("Response containing the options needed to register a passkey.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", PasskeyCreationOptions)
+})
+
+// PasskeyAttestation defines the authenticator response to a registration ceremony.
+var PasskeyAttestation = dsl.Type("PasskeyAttestation", func() {
+	dsl.Description("Authenticator attestation response produced by navigator.credentials.create.")
+
+	dsl.Attribute("clientDataJSON", dsl.String, "Base64url encoded client data")
+	dsl.Attribute("attestationObject", dsl.String, "Base64url encoded CBOR attestation object")
+
+	dsl.Required("clientDataJSON", "attestationObject")
+})
+
+// PasskeyRegistrationRequest defines the payload for completing a passkey registration.
+var PasskeyRegistrationRequest = dsl.Type("PasskeyRegistrationRequest", func() {
+	dsl.Description("Payload for completing a passkey registration for the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Base64url encoded credential ID")
+	dsl.Attribute("type", dsl.String, "Credential type", func() {
+		dsl.Enum("public-key")
+	})
+	dsl.Attribute("response", PasskeyAttestation, "Authenticator attestation response")
+
+	dsl.Required("token", "id", "type", "response")
+})
+
+// PasskeyRegistrationResponse defines the response returned after a passkey is registered.
+var PasskeyRegistrationResponse = dsl.Type("PasskeyRegistrationResponse", func() {
+	dsl.Description("Response indicating that the passkey has been registered successfully.")
+	dsl.Extend(SuccessResponse)
+})
+
+// PasskeySigninOptionsRequest defines the payload for starting a passkey signin.
+var PasskeySigninOptionsRequest = dsl.Type("PasskeySigninOptionsRequest", func() {
+	dsl.Description("Payload for starting a passkey signin. Omit the email to use a discoverable credential.")
+
+	dsl.Attribute("email", dsl.String, "User's email address", func() {
+		dsl.Format(dsl.FormatEmail)
+		dsl.Example("user@example.com")
+	})
+})
+
+// PasskeyRequestOptions defines the options passed to navigator.credentials.get.
+var PasskeyRequestOptions = dsl.Type("PasskeyRequestOptions", func() {
+	dsl.Description("Public key credential request options for a passkey signin ceremony.")
+
+	dsl.Attribute("challenge", dsl.String, "Base64url encoded signin challenge", func() {
+		dsl.Example("q7G1lVqK0xX4Lqz8fYbqX7jvS1oVbqS2kE9bM8rjL9o")
+	})
+
+	dsl.Attribute("rpId", dsl.String, "Relying party identifier", func() {
+		dsl.Example("localhost")
+	})
+
+	dsl.Attribute("allowCredentials", dsl.ArrayOf(PasskeyCredentialDescriptor), "Credentials allowed to answer the challenge")
+
+	dsl.Attribute("timeout", dsl.Int64, "Ceremony timeout in milliseconds", func() {
+		dsl.Example(300000)
+	})
+
+	dsl.Attribute("userVerification", dsl.String, "User verification requirement", func() {
+		dsl.Example("preferred")
+	})
+
+	dsl.Required("challenge", "rpId", "allowCredentials", "timeout", "userVerification")
+})
+
+// PasskeySigninOptionsResponse defines the response containing passkey request options.
+var PasskeySigninOptionsResponse = dsl.Type("PasskeySigninOptionsResponse", func() {
+	dsl.Description("Response containing the options needed to sign in with a passkey.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", PasskeyRequestOptions)
+})
+
+// PasskeyAssertion defines the authenticator response to a signin ceremony.
+var PasskeyAssertion = dsl.Type("PasskeyAssertion", func() {
+	dsl.Description("Authenticator assertion response produced by navigator.credentials.get.")
+
+	dsl.Attribute("clientDataJSON", dsl.String, "Base64url encoded client data")
+	dsl.Attribute("authenticatorData", dsl.String, "Base64url encoded authenticator data")
+	dsl.Attribute("signature", dsl.String, "Base64url encoded assertion signature")
+	dsl.Attribute("userHandle", dsl.String, "Base64url encoded user handle")
+
+	dsl.Required("clientDataJSON", "authenticatorData", "signature")
+})
+
+// PasskeySigninRequest defines the payload for completing a passkey signin.
+var PasskeySigninRequest = dsl.Type("PasskeySigninRequest", func() {
+	dsl.Description("Payload for completing a passkey signin.")
+
+	dsl.Attribute("id", dsl.String, "Base64url encoded credential ID")
+	dsl.Attribute("type", dsl.String, "Credential type", func() {
+		dsl.Enum("public-key")
+	})
+	dsl.Attribute("response", PasskeyAssertion, "Authenticator assertion response")
+
+	dsl.Required("id", "type", "response")
+})
+
+// Session describes an active signin of the authenticated user.
+var Session = dsl.Type("Session", func() {
+	dsl.Description("An active session created when the user signed in.")
+
+	dsl.Attribute("id", dsl.String, "Session's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("9b1c0e7e-6d7a-4a8e-9d8c-3f1f0a2b4c5d")
+	})
+
+	dsl.Attribute("ipAddress", dsl.String, "IP address the session was created from", func() {
+		dsl.Example("203.0.113.10")
+	})
+
+	dsl.Attribute("userAgent", dsl.String, "User agent the session was created from", func() {
+		dsl.Example("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5)")
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the session was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("lastUsedAt", dsl.String, "Timestamp when the session was last used", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T01:30:00Z")
+	})
+
+	dsl.Attribute("current", dsl.Boolean, "Whether this is the session of the calling token", func() {
+		dsl.Example(true)
+	})
+
+	dsl.Required("id", "ipAddress", "userAgent", "createdAt", "lastUsedAt", "current")
+})
+
+// ListSessionsRequest defines the payload for listing the authenticated user's sessions.
+var ListSessionsRequest = dsl.Type("ListSessionsRequest", func() {
+	dsl.Description("Payload for listing the sessions of the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// ListSessionsResponse defines the response containing the authenticated user's sessions.
+var ListSessionsResponse = dsl.Type("ListSessionsResponse", func() {
+	dsl.Description("Response returned when listing the sessions of the authenticated user.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(Session), "Active sessions of the user")
+
+	dsl.Required("success", "message", "data")
+})
+
+// RevokeSessionRequest defines the payload for revoking one of the authenticated user's sessions.
+var RevokeSessionRequest = dsl.Type("RevokeSessionRequest", func() {
+	dsl.Description("Payload for revoking a session of the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Session's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("9b1c0e7e-6d7a-4a8e-9d8c-3f1f0a2b4c5d")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// RevokeOtherSessionsRequest defines the payload for revoking all but the current session.
+var RevokeOtherSessionsRequest = dsl.Type("RevokeOtherSessionsRequest", func() {
+	dsl.Description("Payload for revoking every session of the authenticated user except the current one.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// RevokeSessionResponse defines the response returned after sessions are revoked.
+var RevokeSessionResponse = dsl.Type("RevokeSessionResponse", func() {
+	dsl.Description("Response indicating that the session(s) have been revoked successfully.")
+	dsl.Extend(SuccessResponse)
+})
+
 // AuthService defines the authentication and authorization service interface.
+// IdentityProvider describes an upstream identity provider users can sign in with.
+var IdentityProvider = dsl.Type("IdentityProvider", func() {
+	dsl.Description("An upstream OpenID Connect identity provider.")
+
+	dsl.Attribute("id", dsl.String, "Provider's identifier", func() {
+		dsl.Example("google")
+	})
+
+	dsl.Attribute("name", dsl.String, "Provider's display name", func() {
+		dsl.Example("Google")
+	})
+
+	dsl.Required("id", "name")
+})
+
+// ListIdentityProvidersResponse defines the response listing the configured identity providers.
+var ListIdentityProvidersResponse = dsl.Type("ListIdentityProvidersResponse", func() {
+	dsl.Description("Response returned when listing the configured identity providers.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(IdentityProvider), "Configured identity providers")
+
+	dsl.Required("success", "message", "data")
+})
+
+// FederatedSigninRequest defines the payload for starting a signin with an identity provider.
+var FederatedSigninRequest = dsl.Type("FederatedSigninRequest", func() {
+	dsl.Description("Payload for starting a signin with an upstream identity provider.")
+
+	dsl.Attribute("provider", dsl.String, "Identity provider to sign in with", func() {
+		dsl.Example("google")
+	})
+
+	dsl.Required("provider")
+})
+
+// FederatedAuthorization holds the provider URL the user must visit to authenticate.
+var FederatedAuthorization = dsl.Type("FederatedAuthorization", func() {
+	dsl.Description("Authorization request to send the user to at the identity provider.")
+
+	dsl.Attribute("authorizationUrl", dsl.String, "Provider URL the user must be redirected to", func() {
+		dsl.Example("https://accounts.google.com/o/oauth2/v2/auth?client_id=...&state=...")
+	})
+
+	dsl.Attribute("state", dsl.String, "Opaque state returned by the provider to the callback", func() {
+		dsl.Example("Zm9vYmFyYmF6cXV4")
+	})
+
+	dsl.Attribute("expiresAt", dsl.String, "Timestamp after which the request can no longer be completed", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:10:00Z")
+	})
+
+	dsl.Required("authorizationUrl", "state", "expiresAt")
+})
+
+// FederatedSigninResponse defines the response for starting a signin with an identity provider.
+var FederatedSigninResponse = dsl.Type("FederatedSigninResponse", func() {
+	dsl.Description("Response containing the authorization request for the identity provider.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", FederatedAuthorization)
+
+	dsl.Required("success", "message", "data")
+})
+
+// FederatedBeginResponse defines the response for starting a signin or link with an identity
+// provider through OpenID Connect.
+var FederatedBeginResponse = dsl.Type("FederatedBeginResponse", func() {
+	dsl.Description("Response containing the authorization request for the identity provider and the secret binding it to the client.")
+	dsl.Extend(FederatedSigninResponse)
+
+	dsl.Attribute("binding", dsl.String, "Secret the client must present with the state to complete the request")
+
+	dsl.Required("binding")
+})
+
+// FederatedCallbackRequest defines the parameters the identity provider redirects back with.
+var FederatedCallbackRequest = dsl.Type("FederatedCallbackRequest", func() {
+	dsl.Description("Authorization response returned by the identity provider.")
+
+	dsl.Attribute("state", dsl.String, "State issued when the signin was started")
+	dsl.Attribute("binding", dsl.String, "Secret issued with the state to the client that started the signin")
+	dsl.Attribute("code", dsl.String, "Authorization code issued by the provider")
+	dsl.Attribute("error", dsl.String, "Error code returned by the provider", func() {
+		dsl.Example("access_denied")
+	})
+	dsl.Attribute("error_description", dsl.String, "Human readable error returned by the provider")
+
+	dsl.Required("state")
+})
+
+// LinkIdentityRequest defines the payload for linking an identity provider to the authenticated user.
+var LinkIdentityRequest = dsl.Type("LinkIdentityRequest", func() {
+	dsl.Description("Payload for linking an upstream identity to the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("provider", dsl.String, "Identity provider to link", func() {
+		dsl.Example("google")
+	})
+
+	dsl.Required("token", "provider")
+})
+
+// LinkedIdentity describes an upstream identity linked to a user.
+var LinkedIdentity = dsl.Type("LinkedIdentity", func() {
+	dsl.Description("An upstream identity linked to the user's account.")
+
+	dsl.Attribute("provider", dsl.String, "Identity provider the identity belongs to", func() {
+		dsl.Example("google")
+	})
+
+	dsl.Attribute("subject", dsl.String, "Subject identifier issued by the provider", func() {
+		dsl.Example("110169484474386276334")
+	})
+
+	dsl.Attribute("email", dsl.String, "Email address reported by the provider when linked", func() {
+		dsl.Example("john@gmail.com")
+	})
+
+	dsl.Attribute("linkedAt", dsl.String, "Timestamp when the identity was linked", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("provider", "subject", "email", "linkedAt")
+})
+
+// ListLinkedIdentitiesRequest defines the payload for listing the authenticated user's linked identities.
+var ListLinkedIdentitiesRequest = dsl.Type("ListLinkedIdentitiesRequest", func() {
+	dsl.Description("Payload for listing the identities linked to the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// ListLinkedIdentitiesResponse defines the response listing the authenticated user's linked identities.
+var ListLinkedIdentitiesResponse = dsl.Type("ListLinkedIdentitiesResponse", func() {
+	dsl.Description("Response returned when listing the identities linked to the authenticated user.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(LinkedIdentity), "Linked identities of the user")
+
+	dsl.Required("success", "message", "data")
+})
+
+// UnlinkIdentityRequest defines the payload for unlinking an identity provider from the authenticated user.
+var UnlinkIdentityRequest = dsl.Type("UnlinkIdentityRequest", func() {
+	dsl.Description("Payload for unlinking an upstream identity from the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("provider", dsl.String, "Identity provider to unlink", func() {
+		dsl.Example("google")
+	})
+
+	dsl.Required("token", "provider")
+})
+
+// UnlinkIdentityResponse defines the response returned after an identity has been unlinked.
+var UnlinkIdentityResponse = dsl.Type("UnlinkIdentityResponse", func() {
+	dsl.Description("Response indicating that the identity has been unlinked successfully.")
+	dsl.Extend(SuccessResponse)
+})
+
+// SAMLProviderRequest defines the payload of requests addressing a SAML identity provider.
+var SAMLProviderRequest = dsl.Type("SAMLProviderRequest", func() {
+	dsl.Description("Payload addressing a configured SAML identity provider.")
+
+	dsl.Attribute("provider", dsl.String, "SAML identity provider", func() {
+		dsl.Example("okta")
+	})
+
+	dsl.Required("provider")
+})
+
+// SAMLMetadataResult defines the headers of the service provider metadata document.
+var SAMLMetadataResult = dsl.Type("SAMLMetadataResult", func() {
+	dsl.Description("Service provider metadata document to register with the identity provider.")
+
+	dsl.Attribute("contentType", dsl.String, "Media type of the metadata document", func() {
+		dsl.Example("application/samlmetadata+xml")
+	})
+
+	dsl.Required("contentType")
+})
+
+// SAMLAssertionRequest defines the form an identity provider posts with the HTTP-POST binding.
+var SAMLAssertionRequest = dsl.Type("SAMLAssertionRequest", func() {
+	dsl.Description("SAML response posted by the identity provider to the assertion consumer service.")
+
+	dsl.Attribute("provider", dsl.String, "SAML identity provider", func() {
+		dsl.Example("okta")
+	})
+
+	dsl.Attribute("SAMLResponse", dsl.String, "Base64 encoded SAML response", func() {
+		dsl.Example("PHNhbWxwOlJlc3BvbnNlIHhtbG5zOnNhbWxwPSJ1cm46b2FzaXM6bmFtZXM6dGM6U0FNTDoyLjA6cHJvdG9jb2wiIC4uLg==")
+	})
+
+	dsl.Attribute("RelayState", dsl.String, "Relay state issued when the signin was started")
+
+	dsl.Required("provider", "SAMLResponse")
+})
+
+// federationBindingCookie names the cookie binding a federated signin or link to the browser
+// that started it, so a state lured into another browser can't complete it.
+const federationBindingCookie = "iam_federation_binding"
+
+// federationBindingResponse sets the binding of a started federated signin or link as a
+// cookie only sent back to the federation endpoints.
+func federationBindingResponse() {
+	dsl.Body(func() {
+		dsl.Attribute("success")
+		dsl.Attribute("message")
+		dsl.Attribute("data")
+	})
+	dsl.Cookie("binding:" + federationBindingCookie)
+	dsl.CookiePath("/api/v1/auth/federation")
+	dsl.CookieHTTPOnly()
+	dsl.CookieSecure()
+	dsl.CookieSameSite(dsl.CookieSameSiteLax)
+}
+
 var _ = dsl.Service("auth", func() {
 	dsl.Description("The auth service handles user registration, authentication, and token issuance.")
 
@@ -167,6 +729,7 @@ var _ = dsl.Service("auth", func() {
 
 		dsl.Error("not_found")
 		dsl.Error("invalid_credentials")
+		dsl.Error("internal_server_error")
 
 		dsl.HTTP(func() {
 			dsl.POST("/signin")
@@ -181,6 +744,29 @@ var _ = dsl.Service("auth", func() {
 		})
 	})
 
+	// --- Method: refresh ---
+	dsl.Method("refresh", func() {
+		dsl.Description("Exchanges a refresh token for a new JWT access and refresh token within the same session.")
+
+		dsl.Payload(RefreshRequest)
+		dsl.Result(TokenResponse)
+
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("invalid_credentials")
+
+		dsl.HTTP(func() {
+			dsl.POST("/refresh")
+			dsl.Body(func() {
+				dsl.Attribute("refreshToken")
+			})
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(TokenResponse)
+			})
+		})
+	})
+
 	// --- Method: signout ---
 	dsl.Method("signout", func() {
 		dsl.Description("Logs out an authenticated user by invalidating the access or refresh token.")
@@ -192,6 +778,7 @@ var _ = dsl.Service("auth", func() {
 		dsl.Error("not_found")
 		dsl.Error("unauthorized")
 		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
 
 		dsl.HTTP(func() {
 			dsl.POST("/signout")
@@ -200,4 +787,324 @@ var _ = dsl.Service("auth", func() {
 			})
 		})
 	})
+	// --- Method: beginPasskeyRegistration ---
+	dsl.Method("beginPasskeyRegistration", func() {
+		dsl.Description("Starts a passkey registration ceremony for the authenticated user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(PasskeyRegistrationOptionsRequest)
+		dsl.Result(PasskeyRegistrationOptionsResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+
+		dsl.HTTP(func() {
+			dsl.POST("/passkeys/register/begin")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(PasskeyRegistrationOptionsResponse)
+			})
+		})
+	})
+
+	// --- Method: finishPasskeyRegistration ---
+	dsl.Method("finishPasskeyRegistration", func() {
+		dsl.Description("Verifies the authenticator attestation and stores the new passkey.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(PasskeyRegistrationRequest)
+		dsl.Result(PasskeyRegistrationResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+
+		dsl.HTTP(func() {
+			dsl.POST("/passkeys/register/finish")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(PasskeyRegistrationResponse)
+			})
+		})
+	})
+
+	// --- Method: beginPasskeySignin ---
+	dsl.Method("beginPasskeySignin", func() {
+		dsl.Description("Starts a passkey signin ceremony, optionally scoped to the user with the given email.")
+
+		dsl.Payload(PasskeySigninOptionsRequest)
+		dsl.Result(PasskeySigninOptionsResponse)
+
+		dsl.HTTP(func() {
+			dsl.POST("/passkeys/signin/begin")
+			dsl.Body(func() {
+				dsl.Attribute("email")
+			})
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(PasskeySigninOptionsResponse)
+			})
+		})
+	})
+
+	// --- Method: finishPasskeySignin ---
+	dsl.Method("finishPasskeySignin", func() {
+		dsl.Description("Verifies the passkey assertion and returns a JWT access and refresh token.")
+
+		dsl.Payload(PasskeySigninRequest)
+		dsl.Result(TokenResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("invalid_credentials")
+
+		dsl.HTTP(func() {
+			dsl.POST("/passkeys/signin/finish")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(TokenResponse)
+			})
+		})
+	})
+	// --- Method: listSessions ---
+	dsl.Method("listSessions", func() {
+		dsl.Description("Lists the active sessions of the authenticated user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListSessionsRequest)
+		dsl.Result(ListSessionsResponse)
+
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/sessions")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListSessionsResponse)
+			})
+		})
+	})
+
+	// --- Method: revokeSession ---
+	dsl.Method("revokeSession", func() {
+		dsl.Description("Revokes one of the authenticated user's sessions, invalidating its tokens.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(RevokeSessionRequest)
+		dsl.Result(RevokeSessionResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/sessions/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(RevokeSessionResponse)
+			})
+		})
+	})
+
+	// --- Method: revokeOtherSessions ---
+	dsl.Method("revokeOtherSessions", func() {
+		dsl.Description("Revokes every session of the authenticated user except the current one.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(RevokeOtherSessionsRequest)
+		dsl.Result(RevokeSessionResponse)
+
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/sessions")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(RevokeSessionResponse)
+			})
+		})
+	})
+
+	// --- Method: listIdentityProviders ---
+	dsl.Method("listIdentityProviders", func() {
+		dsl.Description("Lists the upstream identity providers users can sign in with.")
+
+		dsl.Result(ListIdentityProvidersResponse)
+
+		dsl.HTTP(func() {
+			dsl.GET("/federation/providers")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListIdentityProvidersResponse)
+			})
+		})
+	})
+
+	// --- Method: beginFederatedSignin ---
+	dsl.Method("beginFederatedSignin", func() {
+		dsl.Description("Starts a signin with an upstream identity provider and returns the URL to send the user to.")
+
+		dsl.Payload(FederatedSigninRequest)
+		dsl.Result(FederatedBeginResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/federation/{provider}/signin")
+			dsl.Response(dsl.StatusOK, federationBindingResponse)
+		})
+	})
+
+	// --- Method: federatedCallback ---
+	dsl.Method("federatedCallback", func() {
+		dsl.Description("Completes a signin or link with an upstream identity provider and returns a JWT access and refresh token.")
+
+		dsl.Payload(FederatedCallbackRequest)
+		dsl.Result(TokenResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+		dsl.Error("invalid_credentials")
+
+		dsl.HTTP(func() {
+			dsl.GET("/federation/callback")
+			dsl.Param("state")
+			dsl.Cookie("binding:" + federationBindingCookie)
+			dsl.Param("code")
+			dsl.Param("error")
+			dsl.Param("error_description")
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(TokenResponse)
+			})
+		})
+	})
+
+	// --- Method: beginIdentityLink ---
+	dsl.Method("beginIdentityLink", func() {
+		dsl.Description("Starts linking an upstream identity to the authenticated user and returns the URL to send the user to.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(LinkIdentityRequest)
+		dsl.Result(FederatedBeginResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/federation/{provider}/link")
+			dsl.Response(dsl.StatusOK, federationBindingResponse)
+		})
+	})
+
+	// --- Method: listLinkedIdentities ---
+	dsl.Method("listLinkedIdentities", func() {
+		dsl.Description("Lists the upstream identities linked to the authenticated user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListLinkedIdentitiesRequest)
+		dsl.Result(ListLinkedIdentitiesResponse)
+
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/federation/identities")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListLinkedIdentitiesResponse)
+			})
+		})
+	})
+
+	// --- Method: unlinkIdentity ---
+	dsl.Method("unlinkIdentity", func() {
+		dsl.Description("Unlinks an upstream identity from the authenticated user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(UnlinkIdentityRequest)
+		dsl.Result(UnlinkIdentityResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/federation/identities/{provider}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(UnlinkIdentityResponse)
+			})
+		})
+	})
+
+	// --- Method: samlMetadata ---
+	dsl.Method("samlMetadata", func() {
+		dsl.Description("Returns the SAML service provider metadata to register with an identity provider.")
+
+		dsl.Payload(SAMLProviderRequest)
+		dsl.Result(SAMLMetadataResult)
+
+		dsl.Error("not_found")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/saml/{provider}/metadata")
+			dsl.SkipResponseBodyEncodeDecode()
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Header("contentType:Content-Type")
+			})
+		})
+	})
+
+	// --- Method: beginSamlSignin ---
+	dsl.Method("beginSamlSignin", func() {
+		dsl.Description("Starts a signin with a SAML identity provider and returns the HTTP-Redirect binding URL to send the user to.")
+
+		dsl.Payload(SAMLProviderRequest)
+		dsl.Result(FederatedSigninResponse)
+
+		dsl.Error("not_found")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/saml/{provider}/signin")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(FederatedSigninResponse)
+			})
+		})
+	})
+
+	// --- Method: samlAssertionConsumer ---
+	dsl.Method("samlAssertionConsumer", func() {
+		dsl.Description("Verifies a SAML response posted by an identity provider and returns a JWT access and refresh token.")
+
+		dsl.Payload(SAMLAssertionRequest)
+		dsl.Result(TokenResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+		dsl.Error("invalid_credentials")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/saml/{provider}/acs")
+			dsl.Body(func() {
+				dsl.Attribute("SAMLResponse")
+				dsl.Attribute("RelayState")
+			})
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(TokenResponse)
+			})
+		})
+	})
 })
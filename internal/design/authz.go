@@ -0,0 +1,348 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// AuthzEntity describes a principal or resource taking part in an authorization check.
+var AuthzEntity = dsl.Type("AuthzEntity", func() {
+	dsl.Description("A principal or resource. Users are looked up, so their id, email, status, roles, permissions and groups can't be overridden.")
+
+	dsl.Attribute("type", dsl.String, "Type of the entity", func() {
+		dsl.Pattern(`^[a-z][a-z0-9_-]*$`)
+		dsl.Example("user")
+	})
+
+	dsl.Attribute("id", dsl.String, "Identifier of the entity within its type", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("attributes", dsl.MapOf(dsl.String, dsl.Any), "Attributes policy conditions can refer to", func() {
+		dsl.Example(map[string]any{"orgUnit": "sales"})
+	})
+
+	dsl.Required("type")
+})
+
+// AuthzCheck describes a single authorization question.
+var AuthzCheck = dsl.Type("AuthzCheck", func() {
+	dsl.Description("Whether the subject may perform the action on the resource.")
+
+	dsl.Attribute("subject", AuthzEntity, "Principal performing the action")
+	dsl.Attribute("action", dsl.String, "Action to perform", func() {
+		dsl.MinLength(1)
+		dsl.Example("users:read")
+	})
+	dsl.Attribute("resource", AuthzEntity, "Resource the action is performed on")
+	dsl.Attribute("context", dsl.MapOf(dsl.String, dsl.Any), "Attributes of the circumstances of the action", func() {
+		dsl.Example(map[string]any{"mfa": true})
+	})
+
+	dsl.Required("subject", "action", "resource")
+})
+
+// RuleEvaluation describes why a policy rule did or didn't match a check.
+var RuleEvaluation = dsl.Type("RuleEvaluation", func() {
+	dsl.Description("Outcome of a policy rule for a check.")
+
+	dsl.Attribute("ruleId", dsl.String, "Rule that was evaluated", func() {
+		dsl.Example("managers-read-org-unit")
+	})
+	dsl.Attribute("effect", dsl.String, "Effect of the rule", func() {
+		dsl.Enum("allow", "deny")
+	})
+	dsl.Attribute("matched", dsl.Boolean, "Whether the rule matched the check")
+	dsl.Attribute("reason", dsl.String, "Why the rule matched or the first reason it didn't", func() {
+		dsl.Example("resource.orgUnit equals principal.orgUnit")
+	})
+
+	dsl.Required("ruleId", "effect", "matched", "reason")
+})
+
+// AuthzDecision describes the outcome of a check.
+var AuthzDecision = dsl.Type("AuthzDecision", func() {
+	dsl.Description("Outcome of an authorization check.")
+
+	dsl.Attribute("allowed", dsl.Boolean, "Whether the action is allowed")
+	dsl.Attribute("ruleId", dsl.String, "Rule that decided the check, absent when no rule matched", func() {
+		dsl.Example("managers-read-org-unit")
+	})
+	dsl.Attribute("reason", dsl.String, "Explanation of the decision", func() {
+		dsl.Example("allowed by rule managers-read-org-unit: resource.orgUnit equals principal.orgUnit")
+	})
+	dsl.Attribute("explanation", dsl.ArrayOf(RuleEvaluation), "Outcome of every rule in policy order, returned when requested")
+
+	dsl.Required("allowed", "reason")
+})
+
+// CheckRequest defines the payload for a single authorization check.
+var CheckRequest = dsl.Type("CheckRequest", func() {
+	dsl.Description("Payload asking whether a subject may perform an action on a resource.")
+	dsl.Extend(AuthzCheck)
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("explain", dsl.Boolean, "Whether to return the outcome of every rule", func() {
+		dsl.Default(false)
+	})
+
+	dsl.Required("token", "subject", "action", "resource")
+})
+
+// BatchCheckRequest defines the payload for several authorization checks.
+var BatchCheckRequest = dsl.Type("BatchCheckRequest", func() {
+	dsl.Description("Payload asking several authorization questions at once.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("checks", dsl.ArrayOf(AuthzCheck), "Checks to evaluate", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(100)
+	})
+
+	dsl.Attribute("explain", dsl.Boolean, "Whether to return the outcome of every rule", func() {
+		dsl.Default(false)
+	})
+
+	dsl.Required("token", "checks")
+})
+
+// CheckResponse defines the response returned for a single check.
+var CheckResponse = dsl.Type("CheckResponse", func() {
+	dsl.Description("Response returning the outcome of a check.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", AuthzDecision, "The decision")
+
+	dsl.Required("success", "message", "data")
+})
+
+// BatchCheckResponse defines the response returned for several checks.
+var BatchCheckResponse = dsl.Type("BatchCheckResponse", func() {
+	dsl.Description("Response returning the outcome of several checks.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(AuthzDecision), "The decisions, in the order of the checks")
+
+	dsl.Required("success", "message", "data")
+})
+
+// Relationship describes a relationship tuple.
+var Relationship = dsl.Type("Relationship", func() {
+	dsl.Description("A relationship between a subject and an object, written object#relation@subject.")
+
+	dsl.Attribute("object", dsl.String, "Object in type:id form", func() {
+		dsl.Example("document:readme")
+	})
+	dsl.Attribute("relation", dsl.String, "Relation the subject holds on the object", func() {
+		dsl.Example("viewer")
+	})
+	dsl.Attribute("subject", dsl.String, "Subject in type:id form, or a set of subjects in type:id#relation form", func() {
+		dsl.Example("group:0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11#member")
+	})
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the relationship was written", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("object", "relation", "subject", "createdAt")
+})
+
+// RelationshipRequest defines the payload identifying a relationship.
+var RelationshipRequest = dsl.Type("RelationshipRequest", func() {
+	dsl.Description("Payload identifying a relationship.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("object", dsl.String, "Object in type:id form", func() {
+		dsl.Pattern(`^[a-z][a-z0-9_-]*:[^#@\s]+$`)
+		dsl.Example("document:readme")
+	})
+	dsl.Attribute("relation", dsl.String, "Relation the subject holds on the object", func() {
+		dsl.Pattern(`^[a-z][a-z0-9_]*$`)
+		dsl.Example("viewer")
+	})
+	dsl.Attribute("subject", dsl.String, "Subject in type:id form, or a set of subjects in type:id#relation form", func() {
+		dsl.Pattern(`^[a-z][a-z0-9_-]*:[^#@\s]+(#[a-z][a-z0-9_]*)?$`)
+		dsl.Example("user:4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Required("token", "object", "relation", "subject")
+})
+
+// ListRelationshipsRequest defines the payload for listing relationships.
+var ListRelationshipsRequest = dsl.Type("ListRelationshipsRequest", func() {
+	dsl.Description("Payload for listing the relationships of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("object", dsl.String, "Only list the relationships on this object", func() {
+		dsl.Example("document:readme")
+	})
+
+	dsl.Required("token")
+})
+
+// RelationshipResponse defines the response returning a relationship.
+var RelationshipResponse = dsl.Type("RelationshipResponse", func() {
+	dsl.Description("Response returning a relationship.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", Relationship, "The relationship")
+
+	dsl.Required("success", "message", "data")
+})
+
+// ListRelationshipsResponse defines the response listing relationships.
+var ListRelationshipsResponse = dsl.Type("ListRelationshipsResponse", func() {
+	dsl.Description("Response returned when listing relationships.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(Relationship), "The relationships, ordered by object, relation and subject")
+
+	dsl.Required("success", "message", "data")
+})
+
+// DeleteRelationshipResponse defines the response returned after a relationship is deleted.
+var DeleteRelationshipResponse = dsl.Type("DeleteRelationshipResponse", func() {
+	dsl.Description("Response indicating that the relationship has been deleted successfully.")
+	dsl.Extend(SuccessResponse)
+})
+
+// authzMethodErrors declares the errors every authorization method may return.
+func authzMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("internal_server_error")
+}
+
+// AuthzService defines the authorization endpoints.
+var _ = dsl.Service("authz", func() {
+	dsl.Description("Authorization service evaluating attribute and relationship based policies.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the required permission")
+
+	// Base URL path for all HTTP endpoints in the authorization service.
+	dsl.HTTP(func() {
+		dsl.Path("/authz")
+	})
+
+	// --- Method: check ---
+	dsl.Method("check", func() {
+		dsl.Description("Checks whether a subject may perform an action on a resource. Users may check themselves, other subjects require the access:check permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(CheckRequest)
+		dsl.Result(CheckResponse)
+		authzMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.POST("/check")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(CheckResponse)
+			})
+		})
+	})
+
+	// --- Method: batchCheck ---
+	dsl.Method("batchCheck", func() {
+		dsl.Description("Evaluates up to 100 checks at once.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(BatchCheckRequest)
+		dsl.Result(BatchCheckResponse)
+		authzMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.POST("/check/batch")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(BatchCheckResponse)
+			})
+		})
+	})
+
+	// --- Method: listRelationships ---
+	dsl.Method("listRelationships", func() {
+		dsl.Description("Lists the relationships of the organization.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListRelationshipsRequest)
+		dsl.Result(ListRelationshipsResponse)
+		authzMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/relationships")
+			dsl.Param("object")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListRelationshipsResponse)
+			})
+		})
+	})
+
+	// --- Method: writeRelationship ---
+	dsl.Method("writeRelationship", func() {
+		dsl.Description("Writes a relationship.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(RelationshipRequest)
+		dsl.Result(RelationshipResponse)
+		authzMethodErrors()
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/relationships")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(RelationshipResponse)
+			})
+		})
+	})
+
+	// --- Method: deleteRelationship ---
+	dsl.Method("deleteRelationship", func() {
+		dsl.Description("Deletes a relationship.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(RelationshipRequest)
+		dsl.Result(DeleteRelationshipResponse)
+		authzMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/relationships")
+			dsl.Param("object")
+			dsl.Param("relation")
+			dsl.Param("subject")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(DeleteRelationshipResponse)
+			})
+		})
+	})
+})
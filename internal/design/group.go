@@ -0,0 +1,477 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// Group describes a named collection of users granting roles to its members.
+var Group = dsl.Type("Group", func() {
+	dsl.Description("A group of users. Members inherit the roles of the group and of every group it is nested in.")
+
+	dsl.Attribute("id", dsl.String, "Group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0f8fad5b-d9cb-469f-a165-70867728950e")
+	})
+
+	dsl.Attribute("displayName", dsl.String, "Unique name of the group", func() {
+		dsl.Example("Engineering")
+	})
+
+	dsl.Attribute("description", dsl.String, "Purpose of the group", func() {
+		dsl.Example("Everyone building the product")
+	})
+
+	dsl.Attribute("externalId", dsl.String, "Identifier of the group in the system that provisioned it", func() {
+		dsl.Example("00g1abcd2EFGH3ijk4l5")
+	})
+
+	dsl.Attribute("roles", dsl.ArrayOf(dsl.String), "Roles granted to the members of the group", func() {
+		dsl.Example([]string{"admin"})
+	})
+
+	dsl.Attribute("members", dsl.ArrayOf(dsl.String), "IDs of the users directly belonging to the group", func() {
+		dsl.Example([]string{"4d2efde6-448a-4c26-a69a-26c2f9a6de4a"})
+	})
+
+	dsl.Attribute("subgroups", dsl.ArrayOf(dsl.String), "IDs of the groups nested in the group, whose members belong to it as well", func() {
+		dsl.Example([]string{"7c9e6679-7425-40de-944b-e07fc1f90ae7"})
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the group was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("updatedAt", dsl.String, "Timestamp when the group was last updated", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-06-15T13:45:30Z")
+	})
+
+	dsl.Required("id", "displayName", "roles", "members", "subgroups", "createdAt", "updatedAt")
+})
+
+// UserAccess describes the groups, roles and permissions a user effectively holds.
+var UserAccess = dsl.Type("UserAccess", func() {
+	dsl.Description("The access a user holds directly and through the groups it belongs to.")
+
+	dsl.Attribute("userId", dsl.String, "User's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("groups", dsl.ArrayOf(dsl.String), "IDs of the groups the user belongs to, directly or through nesting", func() {
+		dsl.Example([]string{"0f8fad5b-d9cb-469f-a165-70867728950e"})
+	})
+
+	dsl.Attribute("roles", dsl.ArrayOf(dsl.String), "Roles granted to the user or inherited from its groups", func() {
+		dsl.Example([]string{"admin"})
+	})
+
+	dsl.Attribute("permissions", dsl.ArrayOf(dsl.String), "Permissions granted by the roles", func() {
+		dsl.Example([]string{"groups:manage", "users:impersonate"})
+	})
+
+	dsl.Required("userId", "groups", "roles", "permissions")
+})
+
+// ListGroupsRequest defines the payload for listing groups.
+var ListGroupsRequest = dsl.Type("ListGroupsRequest", func() {
+	dsl.Description("Payload for listing every group.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// GroupRequest defines the payload for operations on a single group.
+var GroupRequest = dsl.Type("GroupRequest", func() {
+	dsl.Description("Payload identifying a group.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0f8fad5b-d9cb-469f-a165-70867728950e")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// CreateGroupRequest defines the payload for creating a group.
+var CreateGroupRequest = dsl.Type("CreateGroupRequest", func() {
+	dsl.Description("Payload for creating a group.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("displayName", dsl.String, "Unique name of the group", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(100)
+		dsl.Example("Engineering")
+	})
+
+	dsl.Attribute("description", dsl.String, "Purpose of the group", func() {
+		dsl.MaxLength(500)
+		dsl.Example("Everyone building the product")
+	})
+
+	dsl.Attribute("roles", dsl.ArrayOf(dsl.String), "Roles granted to the members of the group", func() {
+		dsl.Example([]string{"admin"})
+	})
+
+	dsl.Required("token", "displayName")
+})
+
+// UpdateGroupRequest defines the payload for updating a group. Omitted attributes are left unchanged.
+var UpdateGroupRequest = dsl.Type("UpdateGroupRequest", func() {
+	dsl.Description("Payload for updating the name, description or roles of a group.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0f8fad5b-d9cb-469f-a165-70867728950e")
+	})
+
+	dsl.Attribute("displayName", dsl.String, "Unique name of the group", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(100)
+		dsl.Example("Platform Engineering")
+	})
+
+	dsl.Attribute("description", dsl.String, "Purpose of the group", func() {
+		dsl.MaxLength(500)
+		dsl.Example("Everyone running the platform")
+	})
+
+	dsl.Attribute("roles", dsl.ArrayOf(dsl.String), "Roles granted to the members of the group, replacing the current ones", func() {
+		dsl.Example([]string{"admin"})
+	})
+
+	dsl.Required("token", "id")
+})
+
+// GroupMemberRequest defines the payload for adding a user to or removing it from a group.
+var GroupMemberRequest = dsl.Type("GroupMemberRequest", func() {
+	dsl.Description("Payload identifying a group and a user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0f8fad5b-d9cb-469f-a165-70867728950e")
+	})
+
+	dsl.Attribute("userId", dsl.String, "User's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Required("token", "id", "userId")
+})
+
+// SubgroupRequest defines the payload for nesting a group in another or removing it.
+var SubgroupRequest = dsl.Type("SubgroupRequest", func() {
+	dsl.Description("Payload identifying a parent group and a nested group.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Parent group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0f8fad5b-d9cb-469f-a165-70867728950e")
+	})
+
+	dsl.Attribute("subgroupId", dsl.String, "Nested group's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	})
+
+	dsl.Required("token", "id", "subgroupId")
+})
+
+// UserAccessRequest defines the payload for resolving the effective access of a user.
+var UserAccessRequest = dsl.Type("UserAccessRequest", func() {
+	dsl.Description("Payload identifying the user whose access is resolved.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("userId", dsl.String, "User's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Required("token", "userId")
+})
+
+// GroupResponse defines the response returning a single group.
+var GroupResponse = dsl.Type("GroupResponse", func() {
+	dsl.Description("Response returning a group.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", Group, "The group")
+
+	dsl.Required("success", "message", "data")
+})
+
+// ListGroupsResponse defines the response listing groups.
+var ListGroupsResponse = dsl.Type("ListGroupsResponse", func() {
+	dsl.Description("Response returned when listing groups.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(Group), "Every group, ordered by name")
+
+	dsl.Required("success", "message", "data")
+})
+
+// DeleteGroupResponse defines the response returned after a group is deleted.
+var DeleteGroupResponse = dsl.Type("DeleteGroupResponse", func() {
+	dsl.Description("Response indicating that the group has been deleted successfully.")
+	dsl.Extend(SuccessResponse)
+})
+
+// UserAccessResponse defines the response returning the effective access of a user.
+var UserAccessResponse = dsl.Type("UserAccessResponse", func() {
+	dsl.Description("Response returning the access a user effectively holds.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", UserAccess, "The user's effective access")
+
+	dsl.Required("success", "message", "data")
+})
+
+// groupMethodErrors declares the errors every group method may return.
+func groupMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("internal_server_error")
+}
+
+// GroupService defines the group management endpoints.
+var _ = dsl.Service("group", func() {
+	dsl.Description("Group management service for organizing users into nested groups that grant roles to their members.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the permission to manage groups")
+	dsl.Error("nesting_cycle", ConflictError, "Nesting the group would make it a member of itself")
+
+	// Base URL path for all HTTP endpoints in the group service.
+	dsl.HTTP(func() {
+		dsl.Path("/groups")
+	})
+
+	// --- Method: list ---
+	dsl.Method("list", func() {
+		dsl.Description("Lists every group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListGroupsRequest)
+		dsl.Result(ListGroupsResponse)
+		groupMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListGroupsResponse)
+			})
+		})
+	})
+
+	// --- Method: get ---
+	dsl.Method("get", func() {
+		dsl.Description("Retrieves a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GroupRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.GET("/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: create ---
+	dsl.Method("create", func() {
+		dsl.Description("Creates a group granting the given roles to its members.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(CreateGroupRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("bad_request")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: update ---
+	dsl.Method("update", func() {
+		dsl.Description("Updates the name, description or roles of a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(UpdateGroupRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("bad_request")
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.PATCH("/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: delete ---
+	dsl.Method("delete", func() {
+		dsl.Description("Deletes a group, removing it from the groups it is nested in.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GroupRequest)
+		dsl.Result(DeleteGroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(DeleteGroupResponse)
+			})
+		})
+	})
+
+	// --- Method: addMember ---
+	dsl.Method("addMember", func() {
+		dsl.Description("Adds a user to a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GroupMemberRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.PUT("/{id}/members/{userId}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: removeMember ---
+	dsl.Method("removeMember", func() {
+		dsl.Description("Removes a user from a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GroupMemberRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/{id}/members/{userId}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: addSubgroup ---
+	dsl.Method("addSubgroup", func() {
+		dsl.Description("Nests a group in another, making its members members of the parent group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(SubgroupRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+		dsl.Error("nesting_cycle")
+
+		dsl.HTTP(func() {
+			dsl.PUT("/{id}/subgroups/{subgroupId}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: removeSubgroup ---
+	dsl.Method("removeSubgroup", func() {
+		dsl.Description("Removes a nested group from its parent group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(SubgroupRequest)
+		dsl.Result(GroupResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/{id}/subgroups/{subgroupId}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GroupResponse)
+			})
+		})
+	})
+
+	// --- Method: getUserAccess ---
+	dsl.Method("getUserAccess", func() {
+		dsl.Description("Resolves the groups, roles and permissions a user holds, directly or through nested groups. Users may resolve their own access.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(UserAccessRequest)
+		dsl.Result(UserAccessResponse)
+		groupMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.GET("/access/{userId}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(UserAccessResponse)
+			})
+		})
+	})
+})
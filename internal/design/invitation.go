@@ -0,0 +1,332 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// Invitation describes an invitation to join an organization.
+var Invitation = dsl.Type("Invitation", func() {
+	dsl.Description("An invitation for a person to join the organization, optionally with a role.")
+
+	dsl.Attribute("id", dsl.String, "Invitation's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("7c1e4a8b-2f0d-4d6e-9b3a-5e8f1c2d3a4b")
+	})
+
+	dsl.Attribute("email", dsl.String, "Email address of the invited person", func() {
+		dsl.Format(dsl.FormatEmail)
+		dsl.Example("jane@acme.com")
+	})
+
+	dsl.Attribute("role", dsl.String, "Role granted when the invitation is accepted", func() {
+		dsl.Example("admin")
+	})
+
+	dsl.Attribute("status", dsl.String, "Lifecycle state of the invitation", func() {
+		dsl.Enum("pending", "accepted", "revoked")
+		dsl.Example("pending")
+	})
+
+	dsl.Attribute("inviterId", dsl.String, "ID of the user who sent the invitation", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("userId", dsl.String, "ID of the user who accepted the invitation", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("9a3b2c1d-4e5f-4a6b-8c7d-0e1f2a3b4c5d")
+	})
+
+	dsl.Attribute("expiresAt", dsl.String, "Timestamp after which the invitation can no longer be accepted", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-08T00:00:00Z")
+	})
+
+	dsl.Attribute("acceptedAt", dsl.String, "Timestamp when the invitation was accepted", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-02T09:30:00Z")
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the invitation was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("updatedAt", dsl.String, "Timestamp when the invitation was last updated", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("id", "email", "status", "inviterId", "expiresAt", "createdAt", "updatedAt")
+})
+
+// ListInvitationsRequest defines the payload for listing invitations.
+var ListInvitationsRequest = dsl.Type("ListInvitationsRequest", func() {
+	dsl.Description("Payload for listing the invitations of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// InvitationRequest defines the payload for operations on a single invitation.
+var InvitationRequest = dsl.Type("InvitationRequest", func() {
+	dsl.Description("Payload identifying an invitation.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Invitation's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("7c1e4a8b-2f0d-4d6e-9b3a-5e8f1c2d3a4b")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// CreateInvitationRequest defines the payload for inviting a person to the organization.
+var CreateInvitationRequest = dsl.Type("CreateInvitationRequest", func() {
+	dsl.Description("Payload for inviting a person to the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("email", dsl.String, "Email address of the person to invite", func() {
+		dsl.Format(dsl.FormatEmail)
+		dsl.Example("jane@acme.com")
+	})
+
+	dsl.Attribute("role", dsl.String, "Role granted when the invitation is accepted", func() {
+		dsl.Example("admin")
+	})
+
+	dsl.Required("token", "email")
+})
+
+// AcceptInvitationRequest defines the payload for accepting an invitation.
+var AcceptInvitationRequest = dsl.Type("AcceptInvitationRequest", func() {
+	dsl.Description("Payload for accepting an invitation. People without an account in the organization sign up with the name and password, users of the organization authenticate with their access token.")
+
+	dsl.Attribute("inviteToken", dsl.String, "Secret token of the invitation", func() {
+		dsl.Example("Jq3vXk0mB1yS7cT9zR2eW4uN6pL8aD5fH0gK1jM3nQ")
+	})
+
+	dsl.Attribute("authorization", dsl.String, "Bearer access token of the invited user, required when the user already has an account", func() {
+		dsl.Example("Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("firstName", dsl.String, "User's first name", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(50)
+		dsl.Pattern("^[a-zA-Z\\s]+$")
+		dsl.Example("Jane")
+	})
+
+	dsl.Attribute("lastName", dsl.String, "User's last name", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(50)
+		dsl.Pattern("^[a-zA-Z\\s]+$")
+		dsl.Example("Doe")
+	})
+
+	dsl.Attribute("password", dsl.String, "User's password (8-128 characters)", func() {
+		dsl.MinLength(8)
+		dsl.MaxLength(128)
+		dsl.Example("secure-password")
+	})
+
+	dsl.Attribute("confirmPassword", dsl.String, "Password confirmation (must match password)", func() {
+		dsl.MinLength(8)
+		dsl.MaxLength(128)
+		dsl.Example("secure-password")
+	})
+
+	dsl.Required("inviteToken")
+})
+
+// InvitationResponse defines the response returning a single invitation.
+var InvitationResponse = dsl.Type("InvitationResponse", func() {
+	dsl.Description("Response returning an invitation.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", Invitation, "The invitation")
+
+	dsl.Required("success", "message", "data")
+})
+
+// IssuedInvitationResponse defines the response returned when an invitation token is issued.
+var IssuedInvitationResponse = dsl.Type("IssuedInvitationResponse", func() {
+	dsl.Description("Response returning an invitation and its secret token, which is only returned in this response.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", Invitation, "The invitation")
+	dsl.Attribute("inviteToken", dsl.String, "Secret token the invited person accepts the invitation with", func() {
+		dsl.Example("Jq3vXk0mB1yS7cT9zR2eW4uN6pL8aD5fH0gK1jM3nQ")
+	})
+
+	dsl.Required("success", "message", "data", "inviteToken")
+})
+
+// ListInvitationsResponse defines the response listing invitations.
+var ListInvitationsResponse = dsl.Type("ListInvitationsResponse", func() {
+	dsl.Description("Response returned when listing invitations.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(Invitation), "The invitations of the organization, newest first")
+
+	dsl.Required("success", "message", "data")
+})
+
+// AcceptInvitationResponse defines the response returned after an invitation is accepted.
+var AcceptInvitationResponse = dsl.Type("AcceptInvitationResponse", func() {
+	dsl.Description("Response returning the user who joined the organization.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", User, "The user who accepted the invitation")
+
+	dsl.Required("success", "message", "data")
+})
+
+// invitationMethodErrors declares the errors every invitation management method may return.
+func invitationMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("internal_server_error")
+}
+
+// InvitationService defines the invitation endpoints.
+var _ = dsl.Service("invitation", func() {
+	dsl.Description("Invitation service for onboarding people into an organization.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the permission for the operation")
+	dsl.Error("invitation_expired", ValidationError, "Invitation has expired")
+	dsl.Error("password_mismatch", ValidationError, "Password and confirmation password do not match")
+
+	// Base URL path for all HTTP endpoints in the invitation service.
+	dsl.HTTP(func() {
+		dsl.Path("/invitations")
+	})
+
+	// --- Method: list ---
+	dsl.Method("list", func() {
+		dsl.Description("Lists the invitations of the organization.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListInvitationsRequest)
+		dsl.Result(ListInvitationsResponse)
+		invitationMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListInvitationsResponse)
+			})
+		})
+	})
+
+	// --- Method: invite ---
+	dsl.Method("invite", func() {
+		dsl.Description("Invites a person to the organization. The returned token is handed to the person to accept the invitation with.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(CreateInvitationRequest)
+		dsl.Result(IssuedInvitationResponse)
+		invitationMethodErrors()
+		dsl.Error("bad_request")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(IssuedInvitationResponse)
+			})
+		})
+	})
+
+	// --- Method: resend ---
+	dsl.Method("resend", func() {
+		dsl.Description("Issues a new token for a pending invitation and extends its expiry. The previous token stops working.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(InvitationRequest)
+		dsl.Result(IssuedInvitationResponse)
+		invitationMethodErrors()
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/{id}/resend")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(IssuedInvitationResponse)
+			})
+		})
+	})
+
+	// --- Method: revoke ---
+	dsl.Method("revoke", func() {
+		dsl.Description("Revokes a pending invitation so it can no longer be accepted.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(InvitationRequest)
+		dsl.Result(InvitationResponse)
+		invitationMethodErrors()
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(InvitationResponse)
+			})
+		})
+	})
+
+	// --- Method: accept ---
+	dsl.Method("accept", func() {
+		dsl.Description("Accepts an invitation. An existing user of the organization with the invited email, authenticated by their access token, is granted the role, anyone else signs up.")
+
+		dsl.Payload(AcceptInvitationRequest)
+		dsl.Result(AcceptInvitationResponse)
+		dsl.Error("not_found")
+		dsl.Error("bad_request")
+		dsl.Error("invitation_expired")
+		dsl.Error("password_mismatch")
+		dsl.Error("conflict")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("forbidden")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/accept")
+			dsl.Header("authorization:Authorization")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(AcceptInvitationResponse)
+			})
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// logLevels lists the levels loggers can be set to.
+var logLevels = []any{"debug", "info", "warn", "error"}
+
+// LogLevelOverride describes the level of a named logger.
+var LogLevelOverride = dsl.Type("LogLevelOverride", func() {
+	dsl.Description("The level of a named logger, such as the logger of a service, and of the loggers named after it.")
+
+	dsl.Attribute("name", dsl.String, "Name of the logger, the Goa service name for service loggers", func() {
+		dsl.Example("auth")
+	})
+
+	dsl.Attribute("level", dsl.String, "Minimum level of the entries written", func() {
+		dsl.Enum(logLevels...)
+		dsl.Example("debug")
+	})
+
+	dsl.Attribute("revertAt", dsl.String, "Timestamp when the override is reverted, if ever", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:15:00Z")
+	})
+
+	dsl.Required("name", "level")
+})
+
+// LogLevels describes the global level and the overrides of named loggers.
+var LogLevels = dsl.Type("LogLevels", func() {
+	dsl.Description("The levels loggers currently write entries at.")
+
+	dsl.Attribute("level", dsl.String, "Minimum level of the entries written by loggers without an override", func() {
+		dsl.Enum(logLevels...)
+		dsl.Example("info")
+	})
+
+	dsl.Attribute("revertAt", dsl.String, "Timestamp when the global level is reverted, if ever", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:15:00Z")
+	})
+
+	dsl.Attribute("overrides", dsl.ArrayOf(LogLevelOverride), "Levels of named loggers, sorted by name")
+
+	dsl.Required("level", "overrides")
+})
+
+// GetLogLevelsRequest defines the payload for reading the log levels.
+var GetLogLevelsRequest = dsl.Type("GetLogLevelsRequest", func() {
+	dsl.Description("Payload for reading the log levels.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// SetLogLevelRequest defines the payload for changing the global log level.
+var SetLogLevelRequest = dsl.Type("SetLogLevelRequest", func() {
+	dsl.Description("Payload for changing the level of loggers without an override.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("level", dsl.String, "Minimum level of the entries written", func() {
+		dsl.Enum(logLevels...)
+		dsl.Example("debug")
+	})
+
+	dsl.Attribute("ttl", dsl.String, "Duration after which the previous level is restored, such as 15m; the change is permanent without it", func() {
+		dsl.Example("15m")
+	})
+
+	dsl.Required("token", "level")
+})
+
+// SetLogLevelOverrideRequest defines the payload for changing the level of a named logger.
+var SetLogLevelOverrideRequest = dsl.Type("SetLogLevelOverrideRequest", func() {
+	dsl.Description("Payload for changing the level of a named logger and of the loggers named after it.")
+	dsl.Extend(SetLogLevelRequest)
+
+	dsl.Attribute("name", dsl.String, "Name of the logger, the Goa service name for service loggers", func() {
+		dsl.Pattern(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+		dsl.MaxLength(100)
+		dsl.Example("auth")
+	})
+
+	dsl.Required("token", "name", "level")
+})
+
+// DeleteLogLevelOverrideRequest defines the payload for removing the level of a named logger.
+var DeleteLogLevelOverrideRequest = dsl.Type("DeleteLogLevelOverrideRequest", func() {
+	dsl.Description("Payload identifying the named logger whose override is removed.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("name", dsl.String, "Name of the logger", func() {
+		dsl.Example("auth")
+	})
+
+	dsl.Required("token", "name")
+})
+
+// LogLevelsResponse defines the response returning the log levels.
+var LogLevelsResponse = dsl.Type("LogLevelsResponse", func() {
+	dsl.Description("Response returning the log levels.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", LogLevels, "The log levels in effect")
+
+	dsl.Required("success", "message", "data")
+})
+
+// loggingMethodErrors declares the errors every logging method may return.
+func loggingMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+}
+
+// LoggingService defines the runtime log level endpoints.
+var _ = dsl.Service("logging", func() {
+	dsl.Description("Logging service controlling, without a redeploy, the level loggers write entries at.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller isn't a platform admin allowed to manage logging")
+
+	// Base URL path for all HTTP endpoints in the logging service.
+	dsl.HTTP(func() {
+		dsl.Path("/logging")
+	})
+
+	// --- Method: getLevels ---
+	dsl.Method("getLevels", func() {
+		dsl.Description("Returns the global log level and the overrides of named loggers. Requires a platform admin holding the logging:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GetLogLevelsRequest)
+		dsl.Result(LogLevelsResponse)
+		loggingMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/levels")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(LogLevelsResponse)
+			})
+		})
+	})
+
+	// --- Method: setLevel ---
+	dsl.Method("setLevel", func() {
+		dsl.Description("Changes the level of loggers without an override, optionally restoring the previous level after a TTL. Requires a platform admin holding the logging:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(SetLogLevelRequest)
+		dsl.Result(LogLevelsResponse)
+		loggingMethodErrors()
+		dsl.Error("bad_request")
+
+		dsl.HTTP(func() {
+			dsl.PUT("/levels")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(LogLevelsResponse)
+			})
+		})
+	})
+
+	// --- Method: setOverride ---
+	dsl.Method("setOverride", func() {
+		dsl.Description("Changes the level of a named logger, such as the logger of one service, optionally restoring the previous level after a TTL. Requires a platform admin holding the logging:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(SetLogLevelOverrideRequest)
+		dsl.Result(LogLevelsResponse)
+		loggingMethodErrors()
+		dsl.Error("bad_request")
+
+		dsl.HTTP(func() {
+			dsl.PUT("/levels/{name}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(LogLevelsResponse)
+			})
+		})
+	})
+
+	// --- Method: deleteOverride ---
+	dsl.Method("deleteOverride", func() {
+		dsl.Description("Removes the level of a named logger, which then writes entries at the level of its closest parent. Requires a platform admin holding the logging:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(DeleteLogLevelOverrideRequest)
+		dsl.Result(LogLevelsResponse)
+		loggingMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/levels/{name}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(LogLevelsResponse)
+			})
+		})
+	})
+})
@@ -0,0 +1,798 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// OAuthError defines the error body returned by the OAuth endpoints as described in RFC 6749.
+var OAuthError = dsl.Type("OAuthError", func() {
+	dsl.Description("OAuth 2.0 error response as defined in RFC 6749 section 5.2.")
+
+	dsl.Attribute("error", dsl.String, "OAuth error code", func() {
+		dsl.Meta("struct:error:name")
+		dsl.Meta("struct:field:name", "Code")
+		dsl.Example("invalid_grant")
+	})
+
+	dsl.Attribute("error_description", dsl.String, "Human readable error description", func() {
+		dsl.Example("Authorization code is invalid or expired")
+	})
+
+	dsl.Required("error")
+})
+
+// RegisterClientRequest defines the payload for registering an OAuth client.
+var RegisterClientRequest = dsl.Type("RegisterClientRequest", func() {
+	dsl.Description("Payload for registering an OAuth client owned by the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("name", dsl.String, "Human readable client name", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(100)
+		dsl.Example("Acme Dashboard")
+	})
+
+	dsl.Attribute("type", dsl.String, "Client type as defined in RFC 6749 section 2.1", func() {
+		dsl.Enum("public", "confidential")
+		dsl.Example("public")
+	})
+
+	dsl.Attribute("redirectUris", dsl.ArrayOf(dsl.String, func() {
+		dsl.Format(dsl.FormatURI)
+	}), "Redirect URIs the client may use, matched exactly, required for the authorization code grant", func() {
+		dsl.Example([]string{"https://dashboard.acme.com/callback"})
+	})
+
+	dsl.Attribute("scopes", dsl.ArrayOf(dsl.String), "Scopes the client may request: openid, profile, email, and the permissions clients may be granted that the registering user holds", func() {
+		dsl.Example([]string{"openid", "profile", "email"})
+	})
+
+	dsl.Attribute("grantTypes", dsl.ArrayOf(dsl.String, func() {
+		dsl.Enum("authorization_code", "refresh_token", "client_credentials", "urn:ietf:params:oauth:grant-type:device_code", "urn:ietf:params:oauth:grant-type:token-exchange")
+	}), "Grant types the client may use, defaults to authorization_code and refresh_token", func() {
+		dsl.Example([]string{"authorization_code", "refresh_token"})
+	})
+
+	dsl.Attribute("tokenEndpointAuthMethod", dsl.String, "How the client authenticates at the token endpoint", func() {
+		dsl.Enum("none", "client_secret_basic", "client_secret_post", "private_key_jwt")
+		dsl.Example("client_secret_basic")
+	})
+
+	dsl.Attribute("publicKey", dsl.String, "PEM encoded public key used to verify private_key_jwt client assertions", func() {
+		dsl.Example("-----BEGIN PUBLIC KEY-----\n...\n-----END PUBLIC KEY-----")
+	})
+
+	dsl.Attribute("audience", dsl.ArrayOf(dsl.String), "Audiences the client may request client credentials tokens for", func() {
+		dsl.Example([]string{"https://billing.acme.com"})
+	})
+
+	dsl.Required("token", "name", "type")
+})
+
+// OAuthClient describes a registered OAuth client.
+var OAuthClient = dsl.Type("OAuthClient", func() {
+	dsl.Description("A registered OAuth client.")
+
+	dsl.Attribute("clientId", dsl.String, "Client identifier", func() {
+		dsl.Example("c0b7c3a4-5e7d-4b8b-9a4b-8a1e2f3d4c5b")
+	})
+
+	dsl.Attribute("clientSecret", dsl.String, "Client secret, only returned once at registration for confidential clients", func() {
+		dsl.Example("Vq2G6rQH3yBq9fX5cN4mW8tZ1kP7sL0d")
+	})
+
+	dsl.Attribute("name", dsl.String, "Human readable client name", func() {
+		dsl.Example("Acme Dashboard")
+	})
+
+	dsl.Attribute("type", dsl.String, "Client type", func() {
+		dsl.Example("public")
+	})
+
+	dsl.Attribute("redirectUris", dsl.ArrayOf(dsl.String), "Registered redirect URIs")
+	dsl.Attribute("scopes", dsl.ArrayOf(dsl.String), "Scopes the client may request")
+	dsl.Attribute("grantTypes", dsl.ArrayOf(dsl.String), "Grant types the client may use")
+
+	dsl.Attribute("tokenEndpointAuthMethod", dsl.String, "How the client authenticates at the token endpoint", func() {
+		dsl.Example("client_secret_basic")
+	})
+
+	dsl.Attribute("audience", dsl.ArrayOf(dsl.String), "Audiences the client may request client credentials tokens for")
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the client was registered", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("clientId", "name", "type", "redirectUris", "scopes", "grantTypes", "tokenEndpointAuthMethod", "createdAt")
+})
+
+// RegisterClientResponse defines the response returned after an OAuth client is registered.
+var RegisterClientResponse = dsl.Type("RegisterClientResponse", func() {
+	dsl.Description("Response returned after registering an OAuth client.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", OAuthClient)
+})
+
+// AuthorizeRequest defines the authorization request parameters of RFC 6749 section 4.1.1 and RFC 7636.
+var AuthorizeRequest = dsl.Type("AuthorizeRequest", func() {
+	dsl.Description("Authorization request for the authorization code flow, made on behalf of the authenticated user.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("response_type", dsl.String, "Must be code", func() {
+		dsl.Example("code")
+	})
+
+	dsl.Attribute("client_id", dsl.String, "Client identifier", func() {
+		dsl.Example("c0b7c3a4-5e7d-4b8b-9a4b-8a1e2f3d4c5b")
+	})
+
+	dsl.Attribute("redirect_uri", dsl.String, "Redirect URI, must exactly match a registered URI", func() {
+		dsl.Example("https://dashboard.acme.com/callback")
+	})
+
+	dsl.Attribute("scope", dsl.String, "Space separated list of requested scopes", func() {
+		dsl.Example("openid profile")
+	})
+
+	dsl.Attribute("state", dsl.String, "Opaque value returned unchanged to the client", func() {
+		dsl.Example("af0ifjsldkj")
+	})
+
+	dsl.Attribute("code_challenge", dsl.String, "PKCE code challenge", func() {
+		dsl.Example("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+	})
+
+	dsl.Attribute("code_challenge_method", dsl.String, "PKCE code challenge method", func() {
+		dsl.Example("S256")
+	})
+
+	dsl.Attribute("nonce", dsl.String, "OpenID Connect nonce echoed in the ID token", func() {
+		dsl.Example("n-0S6_WzA2Mj")
+	})
+
+	dsl.Required("token", "response_type", "client_id")
+})
+
+// AuthorizeRedirect holds the redirect the user agent should follow to return to the client.
+var AuthorizeRedirect = dsl.Type("AuthorizeRedirect", func() {
+	dsl.Description("Location the user agent must be sent to, carrying either the authorization code or an error.")
+
+	dsl.Attribute("redirectTo", dsl.String, "Redirect URI with the authorization response parameters", func() {
+		dsl.Example("https://dashboard.acme.com/callback?code=SplxlOBeZQQYbYS6WxSbIA&state=af0ifjsldkj")
+	})
+
+	dsl.Required("redirectTo")
+})
+
+// AuthorizeResponse defines the response returned by the authorization endpoint.
+var AuthorizeResponse = dsl.Type("AuthorizeResponse", func() {
+	dsl.Description("Response containing the redirect back to the client.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", AuthorizeRedirect)
+})
+
+// GrantConsentRequest defines the payload for granting a client access to scopes.
+var GrantConsentRequest = dsl.Type("GrantConsentRequest", func() {
+	dsl.Description("Payload for granting an OAuth client access to the authenticated user's account.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("clientId", dsl.String, "Client identifier", func() {
+		dsl.Example("c0b7c3a4-5e7d-4b8b-9a4b-8a1e2f3d4c5b")
+	})
+
+	dsl.Attribute("scope", dsl.String, "Space separated list of scopes to grant", func() {
+		dsl.Example("openid profile")
+	})
+
+	dsl.Required("token", "clientId", "scope")
+})
+
+// GrantConsentResponse defines the response returned after consent is recorded.
+var GrantConsentResponse = dsl.Type("GrantConsentResponse", func() {
+	dsl.Description("Response indicating that consent has been recorded.")
+	dsl.Extend(SuccessResponse)
+})
+
+// TokenRequest defines the token endpoint parameters of RFC 6749 sections 4.1.3, 4.4 and 6.
+var TokenRequest = dsl.Type("TokenRequest", func() {
+	dsl.Description("Token request, sent as application/x-www-form-urlencoded or JSON.")
+
+	dsl.Attribute("grant_type", dsl.String, "Grant type", func() {
+		dsl.Example("authorization_code")
+	})
+
+	dsl.Attribute("code", dsl.String, "Authorization code received from the authorization endpoint")
+	dsl.Attribute("device_code", dsl.String, "Device code received from the device authorization endpoint")
+	dsl.Attribute("redirect_uri", dsl.String, "Redirect URI used in the authorization request")
+	dsl.Attribute("code_verifier", dsl.String, "PKCE code verifier")
+	dsl.Attribute("refresh_token", dsl.String, "Refresh token previously issued to the client")
+	dsl.Attribute("scope", dsl.String, "Space separated list of requested scopes")
+	dsl.Attribute("audience", dsl.String, "Space separated list of audiences requested for a client credentials or exchanged token")
+	dsl.Attribute("subject_token", dsl.String, "Token representing the party on whose behalf the request is made (RFC 8693)")
+	dsl.Attribute("subject_token_type", dsl.String, "Type of the subject token", func() {
+		dsl.Example("urn:ietf:params:oauth:token-type:access_token")
+	})
+	dsl.Attribute("actor_token", dsl.String, "Token representing the party acting on behalf of the subject (RFC 8693)")
+	dsl.Attribute("actor_token_type", dsl.String, "Type of the actor token", func() {
+		dsl.Example("urn:ietf:params:oauth:token-type:access_token")
+	})
+	dsl.Attribute("requested_token_type", dsl.String, "Type of token requested from a token exchange", func() {
+		dsl.Example("urn:ietf:params:oauth:token-type:access_token")
+	})
+	dsl.Attribute("requested_subject", dsl.String, "ID of the user to impersonate, requires the users:impersonate permission", func() {
+		dsl.Format(dsl.FormatUUID)
+	})
+
+	clientAuthentication()
+
+	dsl.Required("grant_type")
+})
+
+// OAuthTokenResponse defines the successful token response of RFC 6749 section 5.1.
+var OAuthTokenResponse = dsl.Type("OAuthTokenResponse", func() {
+	dsl.Description("OAuth 2.0 access token response.")
+
+	dsl.Attribute("access_token", dsl.String, "Access token", func() {
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("token_type", dsl.String, "Token type", func() {
+		dsl.Example("Bearer")
+	})
+
+	dsl.Attribute("expires_in", dsl.Int64, "Access token lifetime in seconds", func() {
+		dsl.Example(3600)
+	})
+
+	dsl.Attribute("refresh_token", dsl.String, "Refresh token", func() {
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("scope", dsl.String, "Space separated list of granted scopes", func() {
+		dsl.Example("openid profile")
+	})
+
+	dsl.Attribute("id_token", dsl.String, "OpenID Connect ID token, issued when the openid scope was granted", func() {
+		dsl.Example("eyJhbGciOiJSUzI1NiIsImtpZCI6IjVtM...")
+	})
+
+	dsl.Attribute("issued_token_type", dsl.String, "Type of the token issued by a token exchange", func() {
+		dsl.Example("urn:ietf:params:oauth:token-type:access_token")
+	})
+
+	dsl.Required("access_token", "token_type", "expires_in")
+})
+
+// clientAuthentication declares the client authentication parameters accepted by the
+// token, introspection and revocation endpoints.
+func clientAuthentication() {
+	dsl.Attribute("authorization", dsl.String, "HTTP Basic client credentials", func() {
+		dsl.Example("Basic YzBiN2MzYTQ6c2VjcmV0")
+	})
+
+	dsl.Attribute("client_id", dsl.String, "Client identifier, when not using HTTP Basic authentication")
+	dsl.Attribute("client_secret", dsl.String, "Client secret, when not using HTTP Basic authentication")
+	dsl.Attribute("client_assertion_type", dsl.String, "Client assertion type, must be urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	dsl.Attribute("client_assertion", dsl.String, "Signed JWT authenticating a private_key_jwt client (RFC 7523)")
+}
+
+// IntrospectRequest defines the introspection request of RFC 7662 section 2.1.
+var IntrospectRequest = dsl.Type("IntrospectRequest", func() {
+	dsl.Description("Token introspection request, sent as application/x-www-form-urlencoded or JSON.")
+
+	dsl.Attribute("token", dsl.String, "The token to introspect", func() {
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("token_type_hint", dsl.String, "Hint about the type of the token", func() {
+		dsl.Example("access_token")
+	})
+
+	clientAuthentication()
+
+	dsl.Required("token")
+})
+
+// IntrospectResponse defines the introspection response of RFC 7662 section 2.2.
+var IntrospectResponse = dsl.Type("IntrospectResponse", func() {
+	dsl.Description("Token introspection response. Only active is returned for inactive tokens.")
+
+	dsl.Attribute("active", dsl.Boolean, "Whether the token is currently active", func() {
+		dsl.Example(true)
+	})
+
+	dsl.Attribute("scope", dsl.String, "Space separated list of scopes associated with the token", func() {
+		dsl.Example("openid profile")
+	})
+
+	dsl.Attribute("client_id", dsl.String, "Client the token was issued to", func() {
+		dsl.Example("c0b7c3a4-5e7d-4b8b-9a4b-8a1e2f3d4c5b")
+	})
+
+	dsl.Attribute("sub", dsl.String, "Subject of the token, a user or client identifier", func() {
+		dsl.Example("2ffd648c-e205-45dc-b2e9-c61731f32f39")
+	})
+
+	dsl.Attribute("token_type", dsl.String, "Type of the token", func() {
+		dsl.Example("Bearer")
+	})
+
+	dsl.Attribute("exp", dsl.Int64, "Expiry time as seconds since the Unix epoch", func() {
+		dsl.Example(1735689600)
+	})
+
+	dsl.Attribute("iat", dsl.Int64, "Issue time as seconds since the Unix epoch", func() {
+		dsl.Example(1735686000)
+	})
+
+	dsl.Attribute("nbf", dsl.Int64, "Time before which the token is not valid as seconds since the Unix epoch", func() {
+		dsl.Example(1735686001)
+	})
+
+	dsl.Attribute("aud", dsl.ArrayOf(dsl.String), "Intended audiences of the token")
+
+	dsl.Attribute("iss", dsl.String, "Issuer of the token", func() {
+		dsl.Example("https://issuer.iam.support")
+	})
+
+	dsl.Attribute("jti", dsl.String, "Unique identifier of the token", func() {
+		dsl.Example("221a0970-a96f-42dd-826f-c64193527339")
+	})
+
+	dsl.Required("active")
+})
+
+// RevokeRequest defines the revocation request of RFC 7009 section 2.1.
+var RevokeRequest = dsl.Type("RevokeRequest", func() {
+	dsl.Description("Token revocation request, sent as application/x-www-form-urlencoded or JSON.")
+
+	dsl.Attribute("token", dsl.String, "The token to revoke", func() {
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("token_type_hint", dsl.String, "Hint about the type of the token", func() {
+		dsl.Example("refresh_token")
+	})
+
+	clientAuthentication()
+
+	dsl.Required("token")
+})
+
+// UserInfoRequest defines the payload of the userinfo endpoint.
+var UserInfoRequest = dsl.Type("UserInfoRequest", func() {
+	dsl.Description("Request for the claims of the user the access token was issued for.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// UserInfo defines the standard claims returned by the userinfo endpoint (OpenID Connect Core section 5.3).
+var UserInfo = dsl.Type("UserInfo", func() {
+	dsl.Description("Standard claims about the authenticated user, filtered by the granted scopes.")
+
+	dsl.Attribute("sub", dsl.String, "Subject identifier of the user", func() {
+		dsl.Example("2ffd648c-e205-45dc-b2e9-c61731f32f39")
+	})
+
+	dsl.Attribute("name", dsl.String, "Full name, released with the profile scope", func() {
+		dsl.Example("John Doe")
+	})
+
+	dsl.Attribute("given_name", dsl.String, "Given name, released with the profile scope", func() {
+		dsl.Example("John")
+	})
+
+	dsl.Attribute("family_name", dsl.String, "Family name, released with the profile scope", func() {
+		dsl.Example("Doe")
+	})
+
+	dsl.Attribute("email", dsl.String, "Email address, released with the email scope", func() {
+		dsl.Example("john@gmail.com")
+	})
+
+	dsl.Attribute("email_verified", dsl.Boolean, "Whether the email address is verified, released with the email scope", func() {
+		dsl.Example(true)
+	})
+
+	dsl.Required("sub")
+})
+
+// JWK defines a public signing key in JSON Web Key format (RFC 7517).
+var JWK = dsl.Type("JWK", func() {
+	dsl.Description("Public key verifying ID token signatures.")
+
+	dsl.Attribute("kty", dsl.String, "Key type", func() {
+		dsl.Example("RSA")
+	})
+
+	dsl.Attribute("kid", dsl.String, "Key identifier", func() {
+		dsl.Example("NjVBRjY5MDlCMUIwNzU4RTA2QzZFMDQ4QzQ2MDAyQjVDNjk1RTM2Qg")
+	})
+
+	dsl.Attribute("use", dsl.String, "Intended use of the key", func() {
+		dsl.Example("sig")
+	})
+
+	dsl.Attribute("alg", dsl.String, "Signing algorithm", func() {
+		dsl.Example("RS256")
+	})
+
+	dsl.Attribute("n", dsl.String, "RSA modulus")
+	dsl.Attribute("e", dsl.String, "RSA public exponent")
+
+	dsl.Required("kty", "kid", "use", "alg", "n", "e")
+})
+
+// JWKSResponse defines the JSON Web Key Set of the ID token signing keys.
+var JWKSResponse = dsl.Type("JWKSResponse", func() {
+	dsl.Description("JSON Web Key Set containing the ID token signing keys.")
+
+	dsl.Attribute("keys", dsl.ArrayOf(JWK), "Signing keys")
+
+	dsl.Required("keys")
+})
+
+// DeviceAuthorizationRequest defines the device authorization request of RFC 8628 section 3.1.
+var DeviceAuthorizationRequest = dsl.Type("DeviceAuthorizationRequest", func() {
+	dsl.Description("Device authorization request, sent as application/x-www-form-urlencoded or JSON.")
+
+	dsl.Attribute("scope", dsl.String, "Space separated list of requested scopes", func() {
+		dsl.Example("openid profile")
+	})
+
+	clientAuthentication()
+})
+
+// DeviceAuthorizationResponse defines the device authorization response of RFC 8628 section 3.2.
+var DeviceAuthorizationResponse = dsl.Type("DeviceAuthorizationResponse", func() {
+	dsl.Description("Codes the device uses to poll for tokens and the user uses to approve the device.")
+
+	dsl.Attribute("device_code", dsl.String, "Device verification code used when polling the token endpoint", func() {
+		dsl.Example("GmRhmhcxhwAzkoEqiMEg_DnyEysNkuNhszIySk9eS")
+	})
+
+	dsl.Attribute("user_code", dsl.String, "Code the user enters on the verification page", func() {
+		dsl.Example("WDJB-MJHT")
+	})
+
+	dsl.Attribute("verification_uri", dsl.String, "Page where the user enters the user code", func() {
+		dsl.Example("https://iam.acme.com/device")
+	})
+
+	dsl.Attribute("verification_uri_complete", dsl.String, "Verification page with the user code already filled in", func() {
+		dsl.Example("https://iam.acme.com/device?user_code=WDJB-MJHT")
+	})
+
+	dsl.Attribute("expires_in", dsl.Int64, "Lifetime of the codes in seconds", func() {
+		dsl.Example(600)
+	})
+
+	dsl.Attribute("interval", dsl.Int64, "Minimum number of seconds between polling requests", func() {
+		dsl.Example(5)
+	})
+
+	dsl.Required("device_code", "user_code", "verification_uri", "verification_uri_complete", "expires_in", "interval")
+})
+
+// VerifyDeviceRequest defines the payload used by a user to approve or deny a device.
+var VerifyDeviceRequest = dsl.Type("VerifyDeviceRequest", func() {
+	dsl.Description("Payload for approving or denying a device authorization request.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("userCode", dsl.String, "User code displayed by the device", func() {
+		dsl.Example("WDJB-MJHT")
+	})
+
+	dsl.Attribute("approve", dsl.Boolean, "Whether to approve the device, the request is denied otherwise", func() {
+		dsl.Default(true)
+		dsl.Example(true)
+	})
+
+	dsl.Required("token", "userCode")
+})
+
+// VerifyDeviceResponse defines the response returned after a device authorization is approved or denied.
+var VerifyDeviceResponse = dsl.Type("VerifyDeviceResponse", func() {
+	dsl.Description("Response indicating that the device authorization was approved or denied.")
+	dsl.Extend(SuccessResponse)
+})
+
+// OAuthService defines the OAuth 2.0 authorization server endpoints.
+var _ = dsl.Service("oauth", func() {
+	dsl.Description("The oauth service implements an OAuth 2.0 authorization server.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the permission to manage clients")
+
+	// Base path for the oauth service.
+	dsl.HTTP(func() {
+		dsl.Path("/oauth")
+	})
+
+	// --- Method: registerClient ---
+	dsl.Method("registerClient", func() {
+		dsl.Description("Registers an OAuth client owned by the authenticated user, who must hold the permission to manage clients.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(RegisterClientRequest)
+		dsl.Result(RegisterClientResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("forbidden")
+		dsl.Error("invalid_scope", OAuthError, "A requested scope can't be granted to the client")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/clients")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(RegisterClientResponse)
+			})
+
+			dsl.Response("invalid_scope", dsl.StatusBadRequest)
+		})
+	})
+
+	// --- Method: authorize ---
+	dsl.Method("authorize", func() {
+		dsl.Description("Handles an authorization code request for the authenticated user and returns the redirect back to the client.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(AuthorizeRequest)
+		dsl.Result(AuthorizeResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.GET("/authorize")
+			dsl.Param("response_type")
+			dsl.Param("client_id")
+			dsl.Param("redirect_uri")
+			dsl.Param("scope")
+			dsl.Param("state")
+			dsl.Param("code_challenge")
+			dsl.Param("code_challenge_method")
+			dsl.Param("nonce")
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(AuthorizeResponse)
+			})
+		})
+	})
+
+	// --- Method: grantConsent ---
+	dsl.Method("grantConsent", func() {
+		dsl.Description("Records the authenticated user's consent for a client to access the given scopes.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(GrantConsentRequest)
+		dsl.Result(GrantConsentResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+
+		dsl.HTTP(func() {
+			dsl.POST("/consents")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(GrantConsentResponse)
+			})
+		})
+	})
+
+	// --- Method: token ---
+	dsl.Method("token", func() {
+		dsl.Description("Exchanges an authorization grant for an access token.")
+
+		dsl.Payload(TokenRequest)
+		dsl.Result(OAuthTokenResponse)
+
+		dsl.Error("invalid_request", OAuthError, "The request is missing a parameter or is malformed")
+		dsl.Error("invalid_client", OAuthError, "Client authentication failed")
+		dsl.Error("invalid_grant", OAuthError, "The grant is invalid, expired or revoked")
+		dsl.Error("unauthorized_client", OAuthError, "The client is not allowed to use this grant type")
+		dsl.Error("unsupported_grant_type", OAuthError, "The grant type is not supported")
+		dsl.Error("invalid_scope", OAuthError, "The requested scope is invalid")
+		dsl.Error("authorization_pending", OAuthError, "The user hasn't approved the device authorization yet")
+		dsl.Error("slow_down", OAuthError, "The client is polling too frequently")
+		dsl.Error("access_denied", OAuthError, "The user or authorization server denied the request")
+		dsl.Error("expired_token", OAuthError, "The device code has expired")
+		dsl.Error("invalid_target", OAuthError, "The requested audience is not allowed")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/token")
+			dsl.Header("authorization:Authorization")
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(OAuthTokenResponse)
+			})
+
+			dsl.Response("invalid_request", dsl.StatusBadRequest)
+			dsl.Response("invalid_client", dsl.StatusUnauthorized)
+			dsl.Response("invalid_grant", dsl.StatusBadRequest)
+			dsl.Response("unauthorized_client", dsl.StatusBadRequest)
+			dsl.Response("unsupported_grant_type", dsl.StatusBadRequest)
+			dsl.Response("invalid_scope", dsl.StatusBadRequest)
+			dsl.Response("authorization_pending", dsl.StatusBadRequest)
+			dsl.Response("slow_down", dsl.StatusBadRequest)
+			dsl.Response("access_denied", dsl.StatusBadRequest)
+			dsl.Response("expired_token", dsl.StatusBadRequest)
+			dsl.Response("invalid_target", dsl.StatusBadRequest)
+		})
+	})
+
+	// --- Method: introspect ---
+	dsl.Method("introspect", func() {
+		dsl.Description("Reports whether a token is active and returns its metadata (RFC 7662).")
+
+		dsl.Payload(IntrospectRequest)
+		dsl.Result(IntrospectResponse)
+
+		dsl.Error("invalid_request", OAuthError, "The request is missing a parameter or is malformed")
+		dsl.Error("invalid_client", OAuthError, "Client authentication failed")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/introspect")
+			dsl.Header("authorization:Authorization")
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(IntrospectResponse)
+			})
+
+			dsl.Response("invalid_request", dsl.StatusBadRequest)
+			dsl.Response("invalid_client", dsl.StatusUnauthorized)
+		})
+	})
+
+	// --- Method: revoke ---
+	dsl.Method("revoke", func() {
+		dsl.Description("Revokes an access or refresh token issued to the calling client (RFC 7009).")
+
+		dsl.Payload(RevokeRequest)
+
+		dsl.Error("invalid_request", OAuthError, "The request is missing a parameter or is malformed")
+		dsl.Error("invalid_client", OAuthError, "Client authentication failed")
+		dsl.Error("unsupported_token_type", OAuthError, "The token type can't be revoked")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/revoke")
+			dsl.Header("authorization:Authorization")
+
+			dsl.Response(dsl.StatusOK)
+
+			dsl.Response("invalid_request", dsl.StatusBadRequest)
+			dsl.Response("invalid_client", dsl.StatusUnauthorized)
+			dsl.Response("unsupported_token_type", dsl.StatusBadRequest)
+		})
+	})
+
+	// --- Method: userinfo ---
+	dsl.Method("userinfo", func() {
+		dsl.Description("Returns the standard claims of the user the access token was issued for, filtered by its scopes.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(UserInfoRequest)
+		dsl.Result(UserInfo)
+
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.GET("/userinfo")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(UserInfo)
+			})
+		})
+	})
+
+	// --- Method: jwks ---
+	dsl.Method("jwks", func() {
+		dsl.Description("Returns the public keys verifying ID token signatures.")
+
+		dsl.Result(JWKSResponse)
+
+		dsl.HTTP(func() {
+			dsl.GET("/jwks")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(JWKSResponse)
+			})
+		})
+	})
+
+	// --- Method: deviceAuthorization ---
+	dsl.Method("deviceAuthorization", func() {
+		dsl.Description("Starts a device authorization grant and returns the codes the device and user need (RFC 8628).")
+
+		dsl.Payload(DeviceAuthorizationRequest)
+		dsl.Result(DeviceAuthorizationResponse)
+
+		dsl.Error("invalid_request", OAuthError, "The request is missing a parameter or is malformed")
+		dsl.Error("invalid_client", OAuthError, "Client authentication failed")
+		dsl.Error("unauthorized_client", OAuthError, "The client is not allowed to use this grant type")
+		dsl.Error("invalid_scope", OAuthError, "The requested scope is invalid")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/device_authorization")
+			dsl.Header("authorization:Authorization")
+
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(DeviceAuthorizationResponse)
+			})
+
+			dsl.Response("invalid_request", dsl.StatusBadRequest)
+			dsl.Response("invalid_client", dsl.StatusUnauthorized)
+			dsl.Response("unauthorized_client", dsl.StatusBadRequest)
+			dsl.Response("invalid_scope", dsl.StatusBadRequest)
+		})
+	})
+
+	// --- Method: verifyDevice ---
+	dsl.Method("verifyDevice", func() {
+		dsl.Description("Approves or denies a pending device authorization on behalf of the authenticated user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(VerifyDeviceRequest)
+		dsl.Result(VerifyDeviceResponse)
+
+		dsl.Error("bad_request")
+		dsl.Error("not_found")
+		dsl.Error("unauthorized")
+		dsl.Error("invalid_token")
+		dsl.Error("session_expired")
+		dsl.Error("internal_server_error")
+
+		dsl.HTTP(func() {
+			dsl.POST("/device/verify")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(VerifyDeviceResponse)
+			})
+		})
+	})
+})
@@ -0,0 +1,202 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// Organization describes a tenant of the IAM system.
+var Organization = dsl.Type("Organization", func() {
+	dsl.Description("An organization. Users, groups and OAuth clients belong to exactly one organization.")
+
+	dsl.Attribute("id", dsl.String, "Organization's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11")
+	})
+
+	dsl.Attribute("slug", dsl.String, "Unique URL safe name routing requests to the organization", func() {
+		dsl.Description("Sent in the tenant header or used as the subdomain of the base domain.")
+		dsl.Example("acme")
+	})
+
+	dsl.Attribute("name", dsl.String, "Human readable name of the organization", func() {
+		dsl.Example("Acme Corporation")
+	})
+
+	dsl.Attribute("adminEmails", dsl.ArrayOf(dsl.String), "Email addresses granted the admin role once their owner proves owning them, by accepting an invitation sent to them or signing in through an identity provider that verified them", func() {
+		dsl.Example([]string{"it@acme.com"})
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the organization was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("updatedAt", dsl.String, "Timestamp when the organization was last updated", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-06-15T13:45:30Z")
+	})
+
+	dsl.Required("id", "slug", "name", "adminEmails", "createdAt", "updatedAt")
+})
+
+// ListOrganizationsRequest defines the payload for listing organizations.
+var ListOrganizationsRequest = dsl.Type("ListOrganizationsRequest", func() {
+	dsl.Description("Payload for listing every organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// OrganizationRequest defines the payload for operations on a single organization.
+var OrganizationRequest = dsl.Type("OrganizationRequest", func() {
+	dsl.Description("Payload identifying an organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Organization's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// CreateOrganizationRequest defines the payload for creating an organization.
+var CreateOrganizationRequest = dsl.Type("CreateOrganizationRequest", func() {
+	dsl.Description("Payload for creating an organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("slug", dsl.String, "Unique URL safe name routing requests to the organization", func() {
+		dsl.Pattern(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+		dsl.Example("acme")
+	})
+
+	dsl.Attribute("name", dsl.String, "Human readable name of the organization", func() {
+		dsl.MinLength(1)
+		dsl.MaxLength(100)
+		dsl.Example("Acme Corporation")
+	})
+
+	dsl.Attribute("adminEmails", dsl.ArrayOf(dsl.String, func() {
+		dsl.Format(dsl.FormatEmail)
+	}), "Email addresses granted the admin role once their owner proves owning them, by accepting an invitation sent to them or signing in through an identity provider that verified them", func() {
+		dsl.Example([]string{"it@acme.com"})
+	})
+
+	dsl.Required("token", "slug", "name")
+})
+
+// OrganizationResponse defines the response returning a single organization.
+var OrganizationResponse = dsl.Type("OrganizationResponse", func() {
+	dsl.Description("Response returning an organization.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", Organization, "The organization")
+
+	dsl.Required("success", "message", "data")
+})
+
+// ListOrganizationsResponse defines the response listing organizations.
+var ListOrganizationsResponse = dsl.Type("ListOrganizationsResponse", func() {
+	dsl.Description("Response returned when listing organizations.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(Organization), "Every organization, ordered by slug")
+
+	dsl.Required("success", "message", "data")
+})
+
+// organizationMethodErrors declares the errors every organization method may return.
+func organizationMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("internal_server_error")
+}
+
+// OrganizationService defines the organization management endpoints.
+var _ = dsl.Service("organization", func() {
+	dsl.Description("Organization management service for the platform admins of the default organization.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller isn't a platform admin")
+
+	// Base URL path for all HTTP endpoints in the organization service.
+	dsl.HTTP(func() {
+		dsl.Path("/organizations")
+	})
+
+	// --- Method: list ---
+	dsl.Method("list", func() {
+		dsl.Description("Lists every organization.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListOrganizationsRequest)
+		dsl.Result(ListOrganizationsResponse)
+		organizationMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListOrganizationsResponse)
+			})
+		})
+	})
+
+	// --- Method: get ---
+	dsl.Method("get", func() {
+		dsl.Description("Retrieves an organization.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(OrganizationRequest)
+		dsl.Result(OrganizationResponse)
+		organizationMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.GET("/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(OrganizationResponse)
+			})
+		})
+	})
+
+	// --- Method: create ---
+	dsl.Method("create", func() {
+		dsl.Description("Creates an organization. Its admins register through the organization's tenant header or subdomain.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(CreateOrganizationRequest)
+		dsl.Result(OrganizationResponse)
+		organizationMethodErrors()
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(OrganizationResponse)
+			})
+		})
+	})
+})
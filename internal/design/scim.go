@@ -0,0 +1,613 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// scimContentType is the media type of SCIM requests and responses (RFC 7644 section 3.1).
+const scimContentType = "application/scim+json"
+
+// ScimName defines the components of a user's name.
+var ScimName = dsl.Type("ScimName", func() {
+	dsl.Description("Components of the user's real name (RFC 7643 section 4.1.1).")
+
+	dsl.Attribute("formatted", dsl.String, "Full name formatted for display", func() {
+		dsl.Example("John Doe")
+	})
+
+	dsl.Attribute("givenName", dsl.String, "Given name of the user", func() {
+		dsl.Example("John")
+	})
+
+	dsl.Attribute("familyName", dsl.String, "Family name of the user", func() {
+		dsl.Example("Doe")
+	})
+})
+
+// ScimEmail defines an email address of a user.
+var ScimEmail = dsl.Type("ScimEmail", func() {
+	dsl.Description("Email address of the user (RFC 7643 section 4.1.2).")
+
+	dsl.Attribute("value", dsl.String, "Email address", func() {
+		dsl.Example("john@example.com")
+	})
+
+	dsl.Attribute("type", dsl.String, "Label of the address", func() {
+		dsl.Example("work")
+	})
+
+	dsl.Attribute("primary", dsl.Boolean, "Whether this is the user's primary address", func() {
+		dsl.Example(true)
+	})
+
+	dsl.Required("value")
+})
+
+// ScimMember defines a reference from a group to a member, or from a user to a group.
+var ScimMember = dsl.Type("ScimMember", func() {
+	dsl.Description("Reference to a group member or to a group a user belongs to.")
+
+	dsl.Attribute("value", dsl.String, "Identifier of the referenced resource", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("display", dsl.String, "Display name of the referenced resource", func() {
+		dsl.Example("John Doe")
+	})
+
+	dsl.Attribute("type", dsl.String, "Type of the referenced resource", func() {
+		dsl.Example("User")
+	})
+
+	dsl.Required("value")
+})
+
+// ScimMeta defines the resource metadata returned with every SCIM resource.
+var ScimMeta = dsl.Type("ScimMeta", func() {
+	dsl.Description("Resource metadata (RFC 7643 section 3.1).")
+
+	dsl.Attribute("resourceType", dsl.String, "Type of the resource", func() {
+		dsl.Example("User")
+	})
+
+	dsl.Attribute("created", dsl.String, "Timestamp when the resource was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("lastModified", dsl.String, "Timestamp when the resource was last modified", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("location", dsl.String, "URI of the resource", func() {
+		dsl.Example("http://localhost:8080/api/v1/scim/v2/Users/4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Required("resourceType")
+})
+
+// ScimUser defines a SCIM user resource mapped onto a system user. The user name is the
+// user's email address, which is the login identifier.
+var ScimUser = dsl.Type("ScimUser", func() {
+	dsl.Description("SCIM user resource (RFC 7643 section 4.1).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the resource conforms to", func() {
+		dsl.Example([]string{"urn:ietf:params:scim:schemas:core:2.0:User"})
+	})
+
+	dsl.Attribute("id", dsl.String, "Identifier of the user", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("externalId", dsl.String, "Identifier of the user in the provisioning client", func() {
+		dsl.Example("00u1abcd2EFGH3ijk4l5")
+	})
+
+	dsl.Attribute("userName", dsl.String, "Unique login identifier, the user's email address", func() {
+		dsl.Example("john@example.com")
+	})
+
+	dsl.Attribute("name", ScimName, "Components of the user's name")
+
+	dsl.Attribute("displayName", dsl.String, "Name of the user suitable for display", func() {
+		dsl.Example("John Doe")
+	})
+
+	dsl.Attribute("emails", dsl.ArrayOf(ScimEmail), "Email addresses of the user")
+
+	dsl.Attribute("active", dsl.Boolean, "Whether the user can sign in", func() {
+		dsl.Example(true)
+	})
+
+	dsl.Attribute("password", dsl.String, "Initial password of the user, never returned", func() {
+		dsl.Example("secure-password")
+	})
+
+	dsl.Attribute("groups", dsl.ArrayOf(ScimMember), "Groups the user belongs to, read only")
+
+	dsl.Attribute("meta", ScimMeta, "Resource metadata")
+})
+
+// ScimGroup defines a SCIM group resource.
+var ScimGroup = dsl.Type("ScimGroup", func() {
+	dsl.Description("SCIM group resource (RFC 7643 section 4.2).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the resource conforms to", func() {
+		dsl.Example([]string{"urn:ietf:params:scim:schemas:core:2.0:Group"})
+	})
+
+	dsl.Attribute("id", dsl.String, "Identifier of the group", func() {
+		dsl.Example("9f1c6b7e-2d4a-4f7e-8a53-0b1f0a6d2c11")
+	})
+
+	dsl.Attribute("externalId", dsl.String, "Identifier of the group in the provisioning client", func() {
+		dsl.Example("00g1abcd2EFGH3ijk4l5")
+	})
+
+	dsl.Attribute("displayName", dsl.String, "Unique name of the group", func() {
+		dsl.Example("Engineering")
+	})
+
+	dsl.Attribute("members", dsl.ArrayOf(ScimMember), "Members of the group")
+
+	dsl.Attribute("meta", ScimMeta, "Resource metadata")
+})
+
+// ScimUserList defines a page of users matching a query.
+var ScimUserList = dsl.Type("ScimUserList", func() {
+	dsl.Description("Page of users matching a query (RFC 7644 section 3.4.2).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the message conforms to")
+	dsl.Attribute("totalResults", dsl.Int, "Number of users matching the query")
+	dsl.Attribute("startIndex", dsl.Int, "1-based index of the first returned user")
+	dsl.Attribute("itemsPerPage", dsl.Int, "Number of users returned")
+	dsl.Attribute("Resources", dsl.ArrayOf(ScimUser), "Returned users")
+
+	dsl.Required("schemas", "totalResults", "startIndex", "itemsPerPage", "Resources")
+})
+
+// ScimGroupList defines a page of groups matching a query.
+var ScimGroupList = dsl.Type("ScimGroupList", func() {
+	dsl.Description("Page of groups matching a query (RFC 7644 section 3.4.2).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the message conforms to")
+	dsl.Attribute("totalResults", dsl.Int, "Number of groups matching the query")
+	dsl.Attribute("startIndex", dsl.Int, "1-based index of the first returned group")
+	dsl.Attribute("itemsPerPage", dsl.Int, "Number of groups returned")
+	dsl.Attribute("Resources", dsl.ArrayOf(ScimGroup), "Returned groups")
+
+	dsl.Required("schemas", "totalResults", "startIndex", "itemsPerPage", "Resources")
+})
+
+// ScimListRequest defines the query parameters of list requests.
+var ScimListRequest = dsl.Type("ScimListRequest", func() {
+	dsl.Description("Payload for querying resources (RFC 7644 section 3.4.2).")
+
+	dsl.Token("token", dsl.String, "Bearer access token of the provisioning client")
+
+	dsl.Attribute("filter", dsl.String, "Filter expression selecting resources", func() {
+		dsl.Example(`userName eq "john@example.com"`)
+	})
+
+	dsl.Attribute("startIndex", dsl.Int, "1-based index of the first resource to return", func() {
+		dsl.Default(1)
+	})
+
+	dsl.Attribute("count", dsl.Int, "Maximum number of resources to return")
+
+	dsl.Required("token")
+})
+
+// ScimResourceRequest defines the payload addressing a single resource.
+var ScimResourceRequest = dsl.Type("ScimResourceRequest", func() {
+	dsl.Description("Payload addressing a single SCIM resource.")
+
+	dsl.Token("token", dsl.String, "Bearer access token of the provisioning client")
+
+	dsl.Attribute("id", dsl.String, "Identifier of the resource", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// ScimUserRequest defines the payload for creating or replacing a user.
+var ScimUserRequest = dsl.Type("ScimUserRequest", func() {
+	dsl.Description("Payload for creating or replacing a SCIM user.")
+	dsl.Extend(ScimUser)
+
+	dsl.Token("token", dsl.String, "Bearer access token of the provisioning client")
+
+	dsl.Required("token", "userName")
+})
+
+// ScimGroupRequest defines the payload for creating or replacing a group.
+var ScimGroupRequest = dsl.Type("ScimGroupRequest", func() {
+	dsl.Description("Payload for creating or replacing a SCIM group.")
+	dsl.Extend(ScimGroup)
+
+	dsl.Token("token", dsl.String, "Bearer access token of the provisioning client")
+
+	dsl.Required("token", "displayName")
+})
+
+// ScimPatchOperation defines a single modification of a PATCH request.
+var ScimPatchOperation = dsl.Type("ScimPatchOperation", func() {
+	dsl.Description("Modification applied by a PATCH request (RFC 7644 section 3.5.2).")
+
+	dsl.Attribute("op", dsl.String, "Operation to perform: add, remove or replace", func() {
+		dsl.Example("replace")
+	})
+
+	dsl.Attribute("path", dsl.String, "Attribute path the operation targets", func() {
+		dsl.Example("active")
+	})
+
+	dsl.Attribute("value", dsl.Any, "Value to add or replace", func() {
+		dsl.Example(false)
+	})
+
+	dsl.Required("op")
+})
+
+// ScimPatchRequest defines the payload for modifying a resource.
+var ScimPatchRequest = dsl.Type("ScimPatchRequest", func() {
+	dsl.Description("Payload for modifying a SCIM resource (RFC 7644 section 3.5.2).")
+
+	dsl.Token("token", dsl.String, "Bearer access token of the provisioning client")
+
+	dsl.Attribute("id", dsl.String, "Identifier of the resource", func() {
+		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
+	})
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the message conforms to", func() {
+		dsl.Example([]string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"})
+	})
+
+	dsl.Attribute("Operations", dsl.ArrayOf(ScimPatchOperation), "Modifications to apply in order")
+
+	dsl.Required("token", "id", "Operations")
+})
+
+// ScimSupported defines a feature flag of the service provider configuration.
+var ScimSupported = dsl.Type("ScimSupported", func() {
+	dsl.Attribute("supported", dsl.Boolean, "Whether the feature is supported")
+	dsl.Required("supported")
+})
+
+// ScimBulk defines the bulk operation settings of the service provider configuration.
+var ScimBulk = dsl.Type("ScimBulk", func() {
+	dsl.Attribute("supported", dsl.Boolean, "Whether bulk operations are supported")
+	dsl.Attribute("maxOperations", dsl.Int, "Maximum number of operations per request")
+	dsl.Attribute("maxPayloadSize", dsl.Int, "Maximum payload size in bytes")
+	dsl.Required("supported", "maxOperations", "maxPayloadSize")
+})
+
+// ScimFilter defines the filter settings of the service provider configuration.
+var ScimFilter = dsl.Type("ScimFilter", func() {
+	dsl.Attribute("supported", dsl.Boolean, "Whether filtering is supported")
+	dsl.Attribute("maxResults", dsl.Int, "Maximum number of resources returned per page")
+	dsl.Required("supported", "maxResults")
+})
+
+// ScimAuthenticationScheme defines an authentication scheme accepted by the service provider.
+var ScimAuthenticationScheme = dsl.Type("ScimAuthenticationScheme", func() {
+	dsl.Attribute("type", dsl.String, "Type of the scheme", func() {
+		dsl.Example("oauthbearertoken")
+	})
+	dsl.Attribute("name", dsl.String, "Name of the scheme")
+	dsl.Attribute("description", dsl.String, "Description of the scheme")
+	dsl.Attribute("primary", dsl.Boolean, "Whether this is the preferred scheme")
+	dsl.Required("type", "name", "description")
+})
+
+// ScimServiceProviderConfig defines the SCIM features supported by the service provider.
+var ScimServiceProviderConfig = dsl.Type("ScimServiceProviderConfig", func() {
+	dsl.Description("SCIM features supported by the service provider (RFC 7643 section 5).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the resource conforms to")
+	dsl.Attribute("patch", ScimSupported, "PATCH support")
+	dsl.Attribute("bulk", ScimBulk, "Bulk operation support")
+	dsl.Attribute("filter", ScimFilter, "Filter support")
+	dsl.Attribute("changePassword", ScimSupported, "Password change support")
+	dsl.Attribute("sort", ScimSupported, "Sorting support")
+	dsl.Attribute("etag", ScimSupported, "ETag support")
+	dsl.Attribute("authenticationSchemes", dsl.ArrayOf(ScimAuthenticationScheme), "Accepted authentication schemes")
+	dsl.Attribute("meta", ScimMeta, "Resource metadata")
+
+	dsl.Required("schemas", "patch", "bulk", "filter", "changePassword", "sort", "etag", "authenticationSchemes")
+})
+
+// ScimSchema defines the description of a resource schema.
+var ScimSchema = dsl.Type("ScimSchema", func() {
+	dsl.Description("Definition of a resource schema (RFC 7643 section 7).")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the resource conforms to")
+	dsl.Attribute("id", dsl.String, "URI of the schema", func() {
+		dsl.Example("urn:ietf:params:scim:schemas:core:2.0:User")
+	})
+	dsl.Attribute("name", dsl.String, "Name of the schema", func() {
+		dsl.Example("User")
+	})
+	dsl.Attribute("description", dsl.String, "Description of the schema")
+	dsl.Attribute("attributes", dsl.ArrayOf(dsl.Any), "Attribute definitions of the schema")
+	dsl.Attribute("meta", ScimMeta, "Resource metadata")
+
+	dsl.Required("schemas", "id", "name", "attributes")
+})
+
+// ScimSchemaList defines the list of supported resource schemas.
+var ScimSchemaList = dsl.Type("ScimSchemaList", func() {
+	dsl.Description("Resource schemas supported by the service provider.")
+
+	dsl.Attribute("schemas", dsl.ArrayOf(dsl.String), "Schemas the message conforms to")
+	dsl.Attribute("totalResults", dsl.Int, "Number of schemas")
+	dsl.Attribute("startIndex", dsl.Int, "1-based index of the first returned schema")
+	dsl.Attribute("itemsPerPage", dsl.Int, "Number of schemas returned")
+	dsl.Attribute("Resources", dsl.ArrayOf(ScimSchema), "Supported schemas")
+
+	dsl.Required("schemas", "totalResults", "startIndex", "itemsPerPage", "Resources")
+})
+
+// ScimSchemaRequest defines the payload for retrieving a resource schema.
+var ScimSchemaRequest = dsl.Type("ScimSchemaRequest", func() {
+	dsl.Attribute("id", dsl.String, "URI of the schema", func() {
+		dsl.Example("urn:ietf:params:scim:schemas:core:2.0:User")
+	})
+	dsl.Required("id")
+})
+
+// scimResponse declares a successful SCIM response carrying the given body.
+func scimResponse(status int, body any) {
+	dsl.Response(status, func() {
+		dsl.ContentType(scimContentType)
+		dsl.Body(body)
+	})
+}
+
+var _ = dsl.Service("scim", func() {
+	dsl.Description("The scim service provisions users and groups for identity providers and HR systems (RFC 7644).")
+
+	// Errors are rendered as SCIM error messages, the scimType is derived from the error name.
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("not_found")
+	dsl.Error("uniqueness")
+	dsl.Error("invalid_filter")
+	dsl.Error("invalid_value")
+	dsl.Error("invalid_syntax")
+	dsl.Error("invalid_path")
+	dsl.Error("no_target")
+	dsl.Error("mutability")
+	dsl.Error("internal_server_error")
+
+	dsl.HTTP(func() {
+		dsl.Path("/scim/v2")
+
+		dsl.Response("unauthorized", dsl.StatusUnauthorized)
+		dsl.Response("invalid_token", dsl.StatusUnauthorized)
+		dsl.Response("session_expired", dsl.StatusUnauthorized)
+		dsl.Response("forbidden", dsl.StatusForbidden)
+		dsl.Response("not_found", dsl.StatusNotFound)
+		dsl.Response("uniqueness", dsl.StatusConflict)
+		dsl.Response("invalid_filter", dsl.StatusBadRequest)
+		dsl.Response("invalid_value", dsl.StatusBadRequest)
+		dsl.Response("invalid_syntax", dsl.StatusBadRequest)
+		dsl.Response("invalid_path", dsl.StatusBadRequest)
+		dsl.Response("no_target", dsl.StatusBadRequest)
+		dsl.Response("mutability", dsl.StatusBadRequest)
+		dsl.Response("internal_server_error", dsl.StatusInternalServerError)
+	})
+
+	// --- Method: listUsers ---
+	dsl.Method("listUsers", func() {
+		dsl.Description("Lists the users matching a filter, a page at a time.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimListRequest)
+		dsl.Result(ScimUserList)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Users")
+			dsl.Param("filter")
+			dsl.Param("startIndex")
+			dsl.Param("count")
+			scimResponse(dsl.StatusOK, ScimUserList)
+		})
+	})
+
+	// --- Method: getUser ---
+	dsl.Method("getUser", func() {
+		dsl.Description("Retrieves a user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimResourceRequest)
+		dsl.Result(ScimUser)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Users/{id}")
+			scimResponse(dsl.StatusOK, ScimUser)
+		})
+	})
+
+	// --- Method: createUser ---
+	dsl.Method("createUser", func() {
+		dsl.Description("Provisions a user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimUserRequest)
+		dsl.Result(ScimUser)
+
+		dsl.HTTP(func() {
+			dsl.POST("/Users")
+			scimResponse(dsl.StatusCreated, ScimUser)
+		})
+	})
+
+	// --- Method: replaceUser ---
+	dsl.Method("replaceUser", func() {
+		dsl.Description("Replaces the attributes of a user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimUserRequest)
+		dsl.Result(ScimUser)
+
+		dsl.HTTP(func() {
+			dsl.PUT("/Users/{id}")
+			scimResponse(dsl.StatusOK, ScimUser)
+		})
+	})
+
+	// --- Method: patchUser ---
+	dsl.Method("patchUser", func() {
+		dsl.Description("Modifies the attributes of a user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimPatchRequest)
+		dsl.Result(ScimUser)
+
+		dsl.HTTP(func() {
+			dsl.PATCH("/Users/{id}")
+			scimResponse(dsl.StatusOK, ScimUser)
+		})
+	})
+
+	// --- Method: deleteUser ---
+	dsl.Method("deleteUser", func() {
+		dsl.Description("Deprovisions a user.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimResourceRequest)
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/Users/{id}")
+			dsl.Response(dsl.StatusNoContent)
+		})
+	})
+
+	// --- Method: listGroups ---
+	dsl.Method("listGroups", func() {
+		dsl.Description("Lists the groups matching a filter, a page at a time.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimListRequest)
+		dsl.Result(ScimGroupList)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Groups")
+			dsl.Param("filter")
+			dsl.Param("startIndex")
+			dsl.Param("count")
+			scimResponse(dsl.StatusOK, ScimGroupList)
+		})
+	})
+
+	// --- Method: getGroup ---
+	dsl.Method("getGroup", func() {
+		dsl.Description("Retrieves a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimResourceRequest)
+		dsl.Result(ScimGroup)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Groups/{id}")
+			scimResponse(dsl.StatusOK, ScimGroup)
+		})
+	})
+
+	// --- Method: createGroup ---
+	dsl.Method("createGroup", func() {
+		dsl.Description("Provisions a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimGroupRequest)
+		dsl.Result(ScimGroup)
+
+		dsl.HTTP(func() {
+			dsl.POST("/Groups")
+			scimResponse(dsl.StatusCreated, ScimGroup)
+		})
+	})
+
+	// --- Method: replaceGroup ---
+	dsl.Method("replaceGroup", func() {
+		dsl.Description("Replaces the attributes and members of a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimGroupRequest)
+		dsl.Result(ScimGroup)
+
+		dsl.HTTP(func() {
+			dsl.PUT("/Groups/{id}")
+			scimResponse(dsl.StatusOK, ScimGroup)
+		})
+	})
+
+	// --- Method: patchGroup ---
+	dsl.Method("patchGroup", func() {
+		dsl.Description("Modifies the attributes and members of a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimPatchRequest)
+		dsl.Result(ScimGroup)
+
+		dsl.HTTP(func() {
+			dsl.PATCH("/Groups/{id}")
+			scimResponse(dsl.StatusOK, ScimGroup)
+		})
+	})
+
+	// --- Method: deleteGroup ---
+	dsl.Method("deleteGroup", func() {
+		dsl.Description("Deprovisions a group.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ScimResourceRequest)
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/Groups/{id}")
+			dsl.Response(dsl.StatusNoContent)
+		})
+	})
+
+	// --- Method: serviceProviderConfig ---
+	dsl.Method("serviceProviderConfig", func() {
+		dsl.Description("Describes the SCIM features supported by the service provider.")
+
+		dsl.Result(ScimServiceProviderConfig)
+
+		dsl.HTTP(func() {
+			dsl.GET("/ServiceProviderConfig")
+			scimResponse(dsl.StatusOK, ScimServiceProviderConfig)
+		})
+	})
+
+	// --- Method: listSchemas ---
+	dsl.Method("listSchemas", func() {
+		dsl.Description("Lists the resource schemas supported by the service provider.")
+
+		dsl.Result(ScimSchemaList)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Schemas")
+			scimResponse(dsl.StatusOK, ScimSchemaList)
+		})
+	})
+
+	// --- Method: getSchema ---
+	dsl.Method("getSchema", func() {
+		dsl.Description("Retrieves a resource schema.")
+
+		dsl.Payload(ScimSchemaRequest)
+		dsl.Result(ScimSchema)
+
+		dsl.HTTP(func() {
+			dsl.GET("/Schemas/{id}")
+			scimResponse(dsl.StatusOK, ScimSchema)
+		})
+	})
+})
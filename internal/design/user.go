@@ -13,6 +13,12 @@ var User = dsl.Type("User", func() {
 		dsl.Example("4d2efde6-448a-4c26-a69a-26c2f9a6de4a")
 	})
 
+	dsl.Attribute("tenantId", dsl.String, "ID of the organization the user belongs to", func() {
+		dsl.Description("Users, their roles and their email addresses are scoped to a single organization.")
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11")
+	})
+
 	dsl.Attribute("firstName", dsl.String, "User's first name", func() {
 		dsl.Example("John")
 	})
@@ -28,10 +34,25 @@ var User = dsl.Type("User", func() {
 		dsl.Example("personal", "john@gmail.com")
 	})
 
+	dsl.Attribute("emailVerified", dsl.Boolean, "Whether the user's email address has been verified", func() {
+		dsl.Default(false)
+		dsl.Example(false)
+	})
+
 	dsl.Attribute("status", dsl.String, "Indicates current status of the user", func() {
 		dsl.Example("active")
 	})
 
+	dsl.Attribute("roles", dsl.ArrayOf(dsl.String), "Roles granted to the user", func() {
+		dsl.Description("Roles determine the administrative permissions a user holds.")
+		dsl.Example([]string{"admin"})
+	})
+
+	dsl.Attribute("externalId", dsl.String, "Identifier of the user in the system that provisioned it", func() {
+		dsl.Description("Set by SCIM provisioning clients such as identity providers and HR systems.")
+		dsl.Example("00u1abcd2EFGH3ijk4l5")
+	})
+
 	dsl.Attribute("createdAt", dsl.String, "Timestamp when the user was created", func() {
 		dsl.Description("Timestamp representing when the user account was created.")
 		dsl.Format(dsl.FormatDateTime)
@@ -44,7 +65,7 @@ var User = dsl.Type("User", func() {
 		dsl.Example("2025-06-15T13:45:30Z")
 	})
 
-	dsl.Required("id", "firstName", "lastName", "email", "status", "createdAt", "updatedAt")
+	dsl.Required("id", "tenantId", "firstName", "lastName", "email", "emailVerified", "status", "createdAt", "updatedAt")
 })
 
 // ListUsersResponse represents the structure of a list of all users.
@@ -58,13 +79,15 @@ var ListUsersResponse = dsl.Type("ListUsersResponse", func() {
 	dsl.Attribute("data", dsl.ArrayOf(User), "List of users returned in the response", func() {
 		dsl.Example([]any{
 			map[string]any{
-				"id":        "4d2efde6-448a-4c26-a69a-26c2f9a6de4a",
-				"firstName": "John",
-				"lastName":  "Doe",
-				"email":     "john@gmail.com",
-				"status":    "active",
-				"createdAt": "2025-01-01T00:00:00Z",
-				"updatedAt": "2025-01-01T00:00:00Z",
+				"id":            "4d2efde6-448a-4c26-a69a-26c2f9a6de4a",
+				"tenantId":      "0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11",
+				"firstName":     "John",
+				"lastName":      "Doe",
+				"email":         "john@gmail.com",
+				"emailVerified": false,
+				"status":        "active",
+				"createdAt":     "2025-01-01T00:00:00Z",
+				"updatedAt":     "2025-01-01T00:00:00Z",
 			},
 		})
 	})
@@ -137,13 +160,15 @@ var CreateUserResponse = dsl.Type("CreateUserResponse", func() {
 	dsl.Attribute("message", dsl.String, "Human-readable message explaining the result.")
 	dsl.Attribute("data", User, "Details of the created user.", func() {
 		dsl.Example(map[string]any{
-			"id":        "4d2efde6-448a-4c26-a69a-26c2f9a6de4a",
-			"firstName": "John",
-			"lastName":  "Doe",
-			"email":     "john@gmail.com",
-			"status":    "active",
-			"createdAt": "2025-01-01T00:00:00Z",
-			"updatedAt": "2025-01-01T00:00:00Z",
+			"id":            "4d2efde6-448a-4c26-a69a-26c2f9a6de4a",
+			"tenantId":      "0b1f6d55-0e1c-4f5e-9a36-8f1f4d2d5a11",
+			"firstName":     "John",
+			"lastName":      "Doe",
+			"email":         "john@gmail.com",
+			"emailVerified": false,
+			"status":        "active",
+			"createdAt":     "2025-01-01T00:00:00Z",
+			"updatedAt":     "2025-01-01T00:00:00Z",
 		})
 	})
 })
@@ -201,6 +226,7 @@ var _ = dsl.Service("user", func() {
 		dsl.Description("Create a new user account in the system.")
 
 		dsl.Error("email_exists")
+		dsl.Error("internal_server_error")
 		dsl.Payload(CreateUserRequest)
 		dsl.Result(CreateUserResponse)
 
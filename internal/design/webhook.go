@@ -0,0 +1,376 @@
+package design
+
+import (
+	"goa.design/goa/v3/dsl"
+)
+
+// webhookEventTypes lists the domain event types webhooks can subscribe to.
+var webhookEventTypes = []any{"user.created", "user.suspended", "user.email_changed"}
+
+// WebhookSubscription describes an endpoint receiving domain events.
+var WebhookSubscription = dsl.Type("WebhookSubscription", func() {
+	dsl.Description("An endpoint of the organization receiving domain events of some types.")
+
+	dsl.Attribute("id", dsl.String, "Subscription's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("3f9c2b1a-7d4e-4f5a-8b6c-1d2e3f4a5b6c")
+	})
+
+	dsl.Attribute("url", dsl.String, "Endpoint events are posted to", func() {
+		dsl.Format(dsl.FormatURI)
+		dsl.Example("https://hooks.acme.com/iam")
+	})
+
+	dsl.Attribute("events", dsl.ArrayOf(dsl.String), "Types of the events delivered to the endpoint", func() {
+		dsl.Example([]string{"user.created", "user.suspended"})
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the subscription was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("id", "url", "events", "createdAt")
+})
+
+// WebhookAttempt describes one request made to deliver an event.
+var WebhookAttempt = dsl.Type("WebhookAttempt", func() {
+	dsl.Description("A request made to deliver an event to an endpoint.")
+
+	dsl.Attribute("attemptedAt", dsl.String, "Timestamp when the request was sent", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Attribute("statusCode", dsl.Int, "HTTP status of the endpoint's response", func() {
+		dsl.Example(503)
+	})
+
+	dsl.Attribute("error", dsl.String, "Why the attempt failed", func() {
+		dsl.Example("endpoint responded with status 503")
+	})
+
+	dsl.Attribute("durationMs", dsl.Int64, "Milliseconds the endpoint took to respond", func() {
+		dsl.Example(120)
+	})
+
+	dsl.Required("attemptedAt", "durationMs")
+})
+
+// WebhookDelivery describes the delivery of an event to a subscription.
+var WebhookDelivery = dsl.Type("WebhookDelivery", func() {
+	dsl.Description("The delivery of a domain event to a subscription, with the log of its attempts.")
+
+	dsl.Attribute("id", dsl.String, "Delivery's unique identifier, sent in the X-Webhook-Id header", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("8e7d6c5b-4a3f-4e2d-9c1b-0a9f8e7d6c5b")
+	})
+
+	dsl.Attribute("subscriptionId", dsl.String, "ID of the subscription the event is delivered to", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("3f9c2b1a-7d4e-4f5a-8b6c-1d2e3f4a5b6c")
+	})
+
+	dsl.Attribute("eventId", dsl.String, "ID of the event delivered", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+	})
+
+	dsl.Attribute("eventType", dsl.String, "Type of the event delivered", func() {
+		dsl.Example("user.created")
+	})
+
+	dsl.Attribute("status", dsl.String, "Lifecycle state of the delivery", func() {
+		dsl.Enum("pending", "succeeded", "dead")
+		dsl.Example("pending")
+	})
+
+	dsl.Attribute("attempts", dsl.ArrayOf(WebhookAttempt), "Requests made so far, oldest first")
+
+	dsl.Attribute("nextAttemptAt", dsl.String, "Timestamp when the next request is due", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:10Z")
+	})
+
+	dsl.Attribute("createdAt", dsl.String, "Timestamp when the delivery was created", func() {
+		dsl.Format(dsl.FormatDateTime)
+		dsl.Example("2025-01-01T00:00:00Z")
+	})
+
+	dsl.Required("id", "subscriptionId", "eventId", "eventType", "status", "attempts", "createdAt")
+})
+
+// ListWebhookSubscriptionsRequest defines the payload for listing webhook subscriptions.
+var ListWebhookSubscriptionsRequest = dsl.Type("ListWebhookSubscriptionsRequest", func() {
+	dsl.Description("Payload for listing the webhook subscriptions of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Required("token")
+})
+
+// CreateWebhookSubscriptionRequest defines the payload for subscribing an endpoint to events.
+var CreateWebhookSubscriptionRequest = dsl.Type("CreateWebhookSubscriptionRequest", func() {
+	dsl.Description("Payload for subscribing an endpoint to domain events.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("url", dsl.String, "HTTP or HTTPS endpoint to post events to, resolving to a public address. Redirects aren't followed", func() {
+		dsl.Format(dsl.FormatURI)
+		dsl.MaxLength(2048)
+		dsl.Example("https://hooks.acme.com/iam")
+	})
+
+	dsl.Attribute("events", dsl.ArrayOf(dsl.String, func() {
+		dsl.Enum(webhookEventTypes...)
+	}), "Types of the events to deliver", func() {
+		dsl.MinLength(1)
+		dsl.Example([]string{"user.created", "user.suspended"})
+	})
+
+	dsl.Required("token", "url", "events")
+})
+
+// WebhookSubscriptionRequest defines the payload for operations on a single subscription.
+var WebhookSubscriptionRequest = dsl.Type("WebhookSubscriptionRequest", func() {
+	dsl.Description("Payload identifying a webhook subscription.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Subscription's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("3f9c2b1a-7d4e-4f5a-8b6c-1d2e3f4a5b6c")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// ListWebhookDeliveriesRequest defines the payload for querying the delivery log.
+var ListWebhookDeliveriesRequest = dsl.Type("ListWebhookDeliveriesRequest", func() {
+	dsl.Description("Payload for querying the webhook delivery log of the organization.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("subscriptionId", dsl.String, "Only deliveries to this subscription", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("3f9c2b1a-7d4e-4f5a-8b6c-1d2e3f4a5b6c")
+	})
+
+	dsl.Attribute("status", dsl.String, "Only deliveries with this status, dead for the dead-letter queue", func() {
+		dsl.Enum("pending", "succeeded", "dead")
+		dsl.Example("dead")
+	})
+
+	dsl.Attribute("limit", dsl.Int, "Maximum number of deliveries to return", func() {
+		dsl.Minimum(1)
+		dsl.Maximum(1000)
+		dsl.Default(100)
+	})
+
+	dsl.Required("token")
+})
+
+// WebhookDeliveryRequest defines the payload for operations on a single delivery.
+var WebhookDeliveryRequest = dsl.Type("WebhookDeliveryRequest", func() {
+	dsl.Description("Payload identifying a webhook delivery.")
+
+	dsl.Token("token", dsl.String, "JWT token from Authorization header", func() {
+		dsl.Description("JWT access token extracted from Authorization: Bearer <token>")
+		dsl.Example("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...")
+	})
+
+	dsl.Attribute("id", dsl.String, "Delivery's unique identifier", func() {
+		dsl.Format(dsl.FormatUUID)
+		dsl.Example("8e7d6c5b-4a3f-4e2d-9c1b-0a9f8e7d6c5b")
+	})
+
+	dsl.Required("token", "id")
+})
+
+// CreatedWebhookSubscriptionResponse defines the response returned when an endpoint is subscribed.
+var CreatedWebhookSubscriptionResponse = dsl.Type("CreatedWebhookSubscriptionResponse", func() {
+	dsl.Description("Response returning a subscription and its signing secret, which is only returned in this response.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", WebhookSubscription, "The subscription")
+	dsl.Attribute("secret", dsl.String, "Key the X-Webhook-Signature header of each delivery is computed with", func() {
+		dsl.Example("whsec_Jq3vXk0mB1yS7cT9zR2eW4uN6pL8aD5fH0gK1jM3nQ")
+	})
+
+	dsl.Required("success", "message", "data", "secret")
+})
+
+// ListWebhookSubscriptionsResponse defines the response listing webhook subscriptions.
+var ListWebhookSubscriptionsResponse = dsl.Type("ListWebhookSubscriptionsResponse", func() {
+	dsl.Description("Response returned when listing webhook subscriptions.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(WebhookSubscription), "The subscriptions of the organization, oldest first")
+
+	dsl.Required("success", "message", "data")
+})
+
+// DeleteWebhookSubscriptionResponse defines the response returned after a subscription is removed.
+var DeleteWebhookSubscriptionResponse = dsl.Type("DeleteWebhookSubscriptionResponse", func() {
+	dsl.Description("Response returned when a webhook subscription is removed.")
+	dsl.Extend(SuccessResponse)
+})
+
+// ListWebhookDeliveriesResponse defines the response listing webhook deliveries.
+var ListWebhookDeliveriesResponse = dsl.Type("ListWebhookDeliveriesResponse", func() {
+	dsl.Description("Response returned when querying the webhook delivery log.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", dsl.ArrayOf(WebhookDelivery), "The matching deliveries, newest first")
+
+	dsl.Required("success", "message", "data")
+})
+
+// WebhookDeliveryResponse defines the response returning a single delivery.
+var WebhookDeliveryResponse = dsl.Type("WebhookDeliveryResponse", func() {
+	dsl.Description("Response returning a webhook delivery.")
+	dsl.Reference(SuccessResponse)
+
+	dsl.Attribute("success")
+	dsl.Attribute("message")
+	dsl.Attribute("data", WebhookDelivery, "The delivery")
+
+	dsl.Required("success", "message", "data")
+})
+
+// webhookMethodErrors declares the errors every webhook method may return.
+func webhookMethodErrors() {
+	dsl.Error("unauthorized")
+	dsl.Error("invalid_token")
+	dsl.Error("session_expired")
+	dsl.Error("forbidden")
+	dsl.Error("internal_server_error")
+}
+
+// WebhookService defines the webhook endpoints.
+var _ = dsl.Service("webhook", func() {
+	dsl.Description("Webhook service delivering domain events to endpoints of the organization.")
+
+	// Common domain level error types.
+	commonErrors()
+
+	// Specific service level errors.
+	dsl.Error("invalid_token", UnauthorizedError, "Invalid or expired token")
+	dsl.Error("session_expired", UnauthorizedError, "Session has expired")
+	dsl.Error("forbidden", UnauthorizedError, "Caller lacks the permission to manage webhooks")
+
+	// Base URL path for all HTTP endpoints in the webhook service.
+	dsl.HTTP(func() {
+		dsl.Path("/webhooks")
+	})
+
+	// --- Method: listSubscriptions ---
+	dsl.Method("listSubscriptions", func() {
+		dsl.Description("Lists the webhook subscriptions of the organization. Requires the webhooks:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListWebhookSubscriptionsRequest)
+		dsl.Result(ListWebhookSubscriptionsResponse)
+		webhookMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/subscriptions")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListWebhookSubscriptionsResponse)
+			})
+		})
+	})
+
+	// --- Method: createSubscription ---
+	dsl.Method("createSubscription", func() {
+		dsl.Description("Subscribes an endpoint to domain events. Each delivery is signed with the returned secret. Requires the webhooks:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(CreateWebhookSubscriptionRequest)
+		dsl.Result(CreatedWebhookSubscriptionResponse)
+		webhookMethodErrors()
+		dsl.Error("bad_request")
+
+		dsl.HTTP(func() {
+			dsl.POST("/subscriptions")
+			dsl.Response(dsl.StatusCreated, func() {
+				dsl.Body(CreatedWebhookSubscriptionResponse)
+			})
+		})
+	})
+
+	// --- Method: deleteSubscription ---
+	dsl.Method("deleteSubscription", func() {
+		dsl.Description("Removes a webhook subscription. Its pending deliveries are dropped. Requires the webhooks:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(WebhookSubscriptionRequest)
+		dsl.Result(DeleteWebhookSubscriptionResponse)
+		webhookMethodErrors()
+		dsl.Error("not_found")
+
+		dsl.HTTP(func() {
+			dsl.DELETE("/subscriptions/{id}")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(DeleteWebhookSubscriptionResponse)
+			})
+		})
+	})
+
+	// --- Method: listDeliveries ---
+	dsl.Method("listDeliveries", func() {
+		dsl.Description("Queries the webhook delivery log of the organization, newest deliveries first. Requires the webhooks:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(ListWebhookDeliveriesRequest)
+		dsl.Result(ListWebhookDeliveriesResponse)
+		webhookMethodErrors()
+
+		dsl.HTTP(func() {
+			dsl.GET("/deliveries")
+			dsl.Param("subscriptionId")
+			dsl.Param("status")
+			dsl.Param("limit")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(ListWebhookDeliveriesResponse)
+			})
+		})
+	})
+
+	// --- Method: retryDelivery ---
+	dsl.Method("retryDelivery", func() {
+		dsl.Description("Takes a dead-lettered delivery out of the dead-letter queue and attempts it once more. Requires the webhooks:manage permission.")
+		dsl.Security(JWTAuth)
+
+		dsl.Payload(WebhookDeliveryRequest)
+		dsl.Result(WebhookDeliveryResponse)
+		webhookMethodErrors()
+		dsl.Error("not_found")
+		dsl.Error("conflict")
+
+		dsl.HTTP(func() {
+			dsl.POST("/deliveries/{id}/retry")
+			dsl.Response(dsl.StatusOK, func() {
+				dsl.Body(WebhookDeliveryResponse)
+			})
+		})
+	})
+})
@@ -1,8 +1,67 @@
 package user
 
+import "slices"
+
 // Represents the possible lifecycle states of a user account.
 const (
 	UserStatusActive    string = "active"
 	UserStatusInactive  string = "inactive"
 	UserStatusSuspended string = "suspended"
 )
+
+// Roles that can be granted to a user.
+const (
+	RoleAdmin string = "admin"
+)
+
+// Permissions granted through roles.
+const (
+	PermissionImpersonate         string = "users:impersonate"
+	PermissionManageGroups        string = "groups:manage"
+	PermissionManageInvitations   string = "invitations:manage"
+	PermissionManageOrganizations string = "organizations:manage"
+	PermissionManageRelationships string = "relationships:manage"
+	PermissionCheckAccess         string = "access:check"
+	PermissionReadAudit           string = "audit:read"
+	PermissionManageWebhooks      string = "webhooks:manage"
+	PermissionManageLogging       string = "logging:manage"
+	PermissionManageClients       string = "clients:manage"
+	PermissionProvision           string = "scim:provision"
+)
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionImpersonate, PermissionManageGroups, PermissionManageInvitations,
+		PermissionManageOrganizations, PermissionManageRelationships, PermissionCheckAccess, PermissionReadAudit,
+		PermissionManageWebhooks, PermissionManageLogging, PermissionManageClients, PermissionProvision,
+	},
+}
+
+// IsRole reports whether the role exists.
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the sorted, deduplicated permissions granted by the given roles.
+func Permissions(roles []string) []string {
+	var permissions []string
+	for _, role := range roles {
+		permissions = append(permissions, rolePermissions[role]...)
+	}
+	slices.Sort(permissions)
+	return slices.Compact(permissions)
+}
+
+// HasPermission reports whether any of the given roles grants the permission.
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
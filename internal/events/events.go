@@ -0,0 +1,152 @@
+// Package events provides the domain events other systems react to. Stores record events in
+// an outbox together with the change they describe, and a relay publishes the recorded
+// events on a bus, so an event is never lost nor published for a change that didn't happen.
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Event types.
+const (
+	TypeUserCreated      = "user.created"
+	TypeUserSuspended    = "user.suspended"
+	TypeUserEmailChanged = "user.email_changed"
+)
+
+// Types lists every event type.
+var Types = []string{TypeUserCreated, TypeUserSuspended, TypeUserEmailChanged}
+
+// Event describes a change of the domain.
+type Event struct {
+	ID         string            `json:"id"`         // Unique identifier of the event
+	Type       string            `json:"type"`       // What happened, one of the event types
+	TenantID   string            `json:"tenantId"`   // ID of the organization the change happened in
+	SubjectID  string            `json:"subjectId"`  // ID of the entity that changed
+	Data       map[string]string `json:"data"`       // Attributes of the change
+	OccurredAt time.Time         `json:"occurredAt"` // Time the change happened
+}
+
+// New creates an event of the given type about a subject of the tenant.
+func New(eventType, tenantID, subjectID string, data map[string]string) *Event {
+	return &Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		TenantID:   tenantID,
+		SubjectID:  subjectID,
+		Data:       data,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// Outbox holds the events recorded by a store until they are published.
+type Outbox interface {
+	// Pending returns up to limit unpublished events, oldest first.
+	Pending(ctx context.Context, limit int) ([]*Event, error)
+
+	// MarkPublished removes the events with the given IDs from the outbox.
+	MarkPublished(ctx context.Context, eventIDs ...string) error
+}
+
+// Handler reacts to a published event. Returning an error keeps the event in the outbox to
+// be published again, so handlers must tolerate receiving an event more than once.
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus delivers published events to the handlers subscribed to them. Subscriptions are made
+// during startup, before events are published.
+type Bus struct {
+	handlers []Handler // Handlers in order of subscription
+}
+
+// NewBus creates a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for every published event.
+func (b *Bus) Subscribe(h Handler) {
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish passes the event to each handler in turn, stopping at the first one that fails.
+func (b *Bus) Publish(ctx context.Context, event *Event) error {
+	for _, h := range b.handlers {
+		if err := h(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Relay moves events from outboxes to the bus.
+type Relay struct {
+	log      *logger.Logger // Logger reporting events that couldn't be published
+	bus      *Bus           // Bus events are published on
+	outboxes []Outbox       // Outboxes events are taken from
+}
+
+// NewRelay creates a relay publishing the events of the outboxes on the bus.
+func NewRelay(log *logger.Logger, bus *Bus, outboxes ...Outbox) *Relay {
+	return &Relay{log: log, bus: bus, outboxes: outboxes}
+}
+
+// Flush publishes the pending events of every outbox and returns how many were published.
+// An event that fails to publish stays in its outbox, along with the events after it, so
+// events are published in the order they were recorded.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for _, outbox := range r.outboxes {
+		pending, err := outbox.Pending(ctx, 100)
+		if err != nil {
+			return published, err
+		}
+		for _, event := range pending {
+			if err := r.bus.Publish(ctx, event); err != nil {
+				return published, err
+			}
+			if err := outbox.MarkPublished(ctx, event.ID); err != nil {
+				return published, err
+			}
+			published++
+		}
+	}
+	return published, nil
+}
+
+// Lag returns how long the oldest unpublished event of the outboxes has been waiting, zero
+// when every event was published.
+func (r *Relay) Lag(ctx context.Context) (time.Duration, error) {
+	var lag time.Duration
+	for _, outbox := range r.outboxes {
+		pending, err := outbox.Pending(ctx, 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(pending) > 0 {
+			lag = max(lag, time.Since(pending[0].OccurredAt))
+		}
+	}
+	return lag, nil
+}
+
+// Run flushes the outboxes every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Flush(ctx); err != nil {
+				r.log.Errorw("publish domain events error", "error", err)
+			}
+		}
+	}
+}
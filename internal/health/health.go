@@ -0,0 +1,204 @@
+// Package health provides the probes orchestrators such as Kubernetes use to manage the
+// server. Liveness reports the process is able to serve requests, readiness that the
+// components it depends on work, and startup that they worked at least once since the
+// process started. Components report their health by registering a Checker.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of the probes and of the checks they run.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+	StatusFailing     = "failing"
+)
+
+// Checker reports whether a component the server depends on works, such as a store
+// answering pings. Checks must return once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// MaxLag returns a checker failing when the lag returned by lag exceeds max, such as the
+// age of the oldest domain event waiting to be published.
+func MaxLag(lag func(ctx context.Context) (time.Duration, error), max time.Duration) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		d, err := lag(ctx)
+		if err != nil {
+			return err
+		}
+		if d > max {
+			return fmt.Errorf("lag of %s exceeds %s", d.Round(time.Millisecond), max)
+		}
+		return nil
+	})
+}
+
+// Probes runs the checks of the registered components and serves the liveness, readiness
+// and startup probes.
+type Probes struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker // Registered checkers by name
+	timeout  time.Duration      // Time each check is given to complete
+	started  atomic.Bool        // Whether every check passed at least once
+	draining atomic.Bool        // Whether the server is shutting down
+}
+
+// CheckResult describes the outcome of a check.
+type CheckResult struct {
+	Status     string  `json:"status"`          // StatusOK or StatusFailing
+	Error      string  `json:"error,omitempty"` // Reason the check failed
+	DurationMs float64 `json:"durationMs"`      // Time the check took
+}
+
+// Report is the JSON body of the probes.
+type Report struct {
+	Status string                 `json:"status"`           // Overall status of the probe
+	Checks map[string]CheckResult `json:"checks,omitempty"` // Outcome of each check by name
+}
+
+// New creates probes giving each check the timeout to complete.
+func New(timeout time.Duration) *Probes {
+	return &Probes{checkers: make(map[string]Checker), timeout: timeout}
+}
+
+// Register adds a component's checker under the given name, replacing any checker
+// registered under the same name.
+func (p *Probes) Register(name string, c Checker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkers[name] = c
+}
+
+// Drain makes the server report itself not ready, so it stops receiving new requests
+// while it shuts down.
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+}
+
+// Check runs every registered check concurrently and reports whether all of them passed.
+func (p *Probes) Check(ctx context.Context) (Report, bool) {
+	p.mu.RLock()
+	checkers := maps.Clone(p.checkers)
+	p.mu.RUnlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		healthy = true
+		report  = Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checkers))}
+	)
+	for name, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := p.run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusOK {
+				healthy = false
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report, healthy
+}
+
+// run runs a check, failing it when it doesn't complete within the timeout.
+func (p *Probes) run(ctx context.Context, c Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check didn't complete within %s", p.timeout)
+	}
+
+	result := CheckResult{Status: StatusOK, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status, result.Error = StatusFailing, err.Error()
+	}
+	return result
+}
+
+// Liveness serves the liveness probe, which passes as long as the server answers requests.
+// Dependencies aren't checked, so a failing dependency doesn't get the process restarted.
+func (p *Probes) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// Readiness serves the readiness probe, which passes when every check passes and the
+// server isn't shutting down.
+func (p *Probes) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.draining.Load() {
+			writeReport(w, http.StatusServiceUnavailable, Report{Status: StatusDraining})
+			return
+		}
+
+		report, healthy := p.Check(r.Context())
+		if !healthy {
+			writeReport(w, http.StatusServiceUnavailable, report)
+			return
+		}
+		p.started.Store(true)
+		writeReport(w, http.StatusOK, report)
+	})
+}
+
+// Startup serves the startup probe, which passes once every check has passed, delaying
+// the liveness and readiness probes until the server has started.
+func (p *Probes) Startup() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.started.Load() {
+			writeReport(w, http.StatusOK, Report{Status: StatusOK})
+			return
+		}
+
+		report, healthy := p.Check(r.Context())
+		if !healthy {
+			writeReport(w, http.StatusServiceUnavailable, report)
+			return
+		}
+		p.started.Store(true)
+		writeReport(w, http.StatusOK, report)
+	})
+}
+
+// writeReport writes the report as the JSON body of a response with the given status code.
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
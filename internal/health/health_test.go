@@ -0,0 +1,118 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/iamBelugaa/goa-iam/internal/health"
+)
+
+func TestHealth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health Suite")
+}
+
+var _ = Describe("Probes", func() {
+	var (
+		probes  *health.Probes
+		storeUp bool
+	)
+
+	// probe serves a request to the handler and returns the status code and decoded report.
+	probe := func(h http.Handler) (int, health.Report) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var report health.Report
+		Expect(json.NewDecoder(rec.Body).Decode(&report)).To(Succeed())
+		return rec.Code, report
+	}
+
+	BeforeEach(func() {
+		storeUp = true
+		probes = health.New(50 * time.Millisecond)
+		probes.Register("store", health.CheckerFunc(func(ctx context.Context) error {
+			if !storeUp {
+				return errors.New("connection refused")
+			}
+			return nil
+		}))
+		probes.Register("outbox", health.MaxLag(func(ctx context.Context) (time.Duration, error) {
+			return time.Second, nil
+		}, time.Minute))
+	})
+
+	It("reports ready with the outcome of each check", func() {
+		code, report := probe(probes.Readiness())
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(report.Status).To(Equal(health.StatusOK))
+		Expect(report.Checks).To(HaveLen(2))
+		Expect(report.Checks["store"].Status).To(Equal(health.StatusOK))
+		Expect(report.Checks["outbox"].Status).To(Equal(health.StatusOK))
+	})
+
+	It("reports not ready while a check fails, without failing liveness", func() {
+		storeUp = false
+		probes.Register("slow", health.CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		}))
+
+		code, report := probe(probes.Readiness())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(report.Status).To(Equal(health.StatusUnavailable))
+		Expect(report.Checks["store"].Status).To(Equal(health.StatusFailing))
+		Expect(report.Checks["store"].Error).To(Equal("connection refused"))
+		Expect(report.Checks["slow"].Error).To(ContainSubstring("didn't complete"))
+		Expect(report.Checks["outbox"].Status).To(Equal(health.StatusOK))
+
+		code, report = probe(probes.Liveness())
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(report.Status).To(Equal(health.StatusOK))
+	})
+
+	It("fails a lag exceeding its maximum", func() {
+		probes.Register("outbox", health.MaxLag(func(ctx context.Context) (time.Duration, error) {
+			return 2 * time.Minute, nil
+		}, time.Minute))
+
+		code, report := probe(probes.Readiness())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(report.Checks["outbox"].Error).To(Equal("lag of 2m0s exceeds 1m0s"))
+	})
+
+	It("passes startup once every check passed and stays started", func() {
+		storeUp = false
+		code, _ := probe(probes.Startup())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+		storeUp = true
+		code, _ = probe(probes.Startup())
+		Expect(code).To(Equal(http.StatusOK))
+
+		storeUp = false
+		code, report := probe(probes.Startup())
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(report.Checks).To(BeEmpty())
+	})
+
+	It("reports not ready once draining", func() {
+		probes.Drain()
+
+		code, report := probe(probes.Readiness())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(report.Status).To(Equal(health.StatusDraining))
+
+		code, _ = probe(probes.Liveness())
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,184 @@
+// Package metrics exposes Prometheus metrics of the HTTP API and of the auth and user domain.
+// Collectors are registered on a dedicated registry served by Handler. Labels only take
+// values from small fixed sets, such as Goa service and method names, never user input or
+// identifiers, to keep the number of series bounded.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	goahttp "goa.design/goa/v3/http"
+)
+
+// Outcome label values.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// namespace prefixes the name of every metric.
+const namespace = "iam"
+
+// Registry holds every collector of the package along with the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by Goa service, method and response status code.",
+	}, []string{"service", "method", "code"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by Goa service and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "method"})
+
+	signins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signins_total",
+		Help:      "Signin attempts, by signin method and outcome.",
+	}, []string{"method", "outcome"})
+
+	tokensIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_issued_total",
+		Help:      "Tokens issued, by token type.",
+	}, []string{"token_type"})
+
+	tokenRevocations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_revocations_total",
+		Help:      "Tokens revoked before they expired, by token type.",
+	}, []string{"token_type"})
+
+	activeSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Sessions started at signin that haven't been revoked or found expired.",
+	})
+
+	userStoreDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "user_store_duration_seconds",
+		Help:      "Time taken by user store operations, by UserStorer method.",
+		Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpRequestDuration, signins, tokensIssued, tokenRevocations, activeSessions, userStoreDuration,
+	)
+}
+
+// Handler serves the metrics of the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Outcome returns the outcome label value of an operation that failed if err isn't nil.
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// ObserveSignin counts a signin attempt with the given method.
+func ObserveSignin(method string, err error) {
+	signins.WithLabelValues(method, Outcome(err)).Inc()
+}
+
+// ObserveTokenIssued counts an issued token of the given type, such as ACCESS_TOKEN.
+func ObserveTokenIssued(tokenType string) {
+	tokensIssued.WithLabelValues(strings.ToLower(tokenType)).Inc()
+}
+
+// ObserveTokenRevoked counts a revoked token of the given type.
+func ObserveTokenRevoked(tokenType string) {
+	tokenRevocations.WithLabelValues(strings.ToLower(tokenType)).Inc()
+}
+
+// SessionsStarted adds started sessions to the number of active sessions.
+func SessionsStarted(n int) {
+	activeSessions.Add(float64(n))
+}
+
+// SessionsEnded removes revoked or expired sessions from the number of active sessions.
+func SessionsEnded(n int) {
+	activeSessions.Sub(float64(n))
+}
+
+// ObserveUserStore records the time a user store operation took since start.
+func ObserveUserStore(operation string, start time.Time) {
+	userStoreDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// route identifies the Goa method an HTTP route is mounted for.
+type route struct {
+	service string
+	method  string
+}
+
+// HTTP measures the requests handled by the Goa endpoints of a muxer.
+type HTTP struct {
+	mux    goahttp.ResolverMuxer // Muxer resolving the pattern of the route a request matched
+	routes map[string]route      // Goa methods by HTTP verb and route pattern
+}
+
+// NewHTTP creates the measurements of the requests handled by the muxer. Its middleware must
+// be added to the muxer before any endpoint is mounted.
+func NewHTTP(mux goahttp.ResolverMuxer) *HTTP {
+	return &HTTP{mux: mux, routes: make(map[string]route)}
+}
+
+// Route labels requests of the verb and route pattern with the Goa service and method the
+// route is mounted for. Routes are added while mounting, before requests are served.
+func (h *HTTP) Route(service, method, verb, pattern string) {
+	h.routes[verb+" "+pattern] = route{service: service, method: method}
+}
+
+// Middleware counts and times each request by the Goa method its route is mounted for.
+// Requests not matching any route are labelled "unmatched".
+func (h *HTTP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		rt, ok := h.routes[r.Method+" "+h.mux.ResolvePattern(r)]
+		if !ok {
+			rt = route{service: "unmatched", method: "unmatched"}
+		}
+		httpRequests.WithLabelValues(rt.service, rt.method, strconv.Itoa(rw.status)).Inc()
+		httpRequestDuration.WithLabelValues(rt.service, rt.method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder captures the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goahttp "goa.design/goa/v3/http"
+
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
+
+// scrape returns the metrics served by the handler in the text exposition format.
+func scrape() string {
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	Expect(rec.Code).To(Equal(http.StatusOK))
+	return rec.Body.String()
+}
+
+var _ = Describe("Metrics", func() {
+	It("labels requests with the Goa service and method of their route", func() {
+		mux := goahttp.NewMuxer()
+		httpMetrics := metrics.NewHTTP(mux)
+		mux.Use(httpMetrics.Middleware)
+		mux.Handle(http.MethodGet, "/api/v1/groups/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		httpMetrics.Route("group", "get", http.MethodGet, "/api/v1/groups/{id}")
+
+		for _, path := range []string{"/api/v1/groups/eng", "/api/v1/groups/ops", "/api/v1/unknown"} {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`iam_http_requests_total{code="404",method="get",service="group"} 2`))
+		Expect(body).To(ContainSubstring(`iam_http_requests_total{code="404",method="unmatched",service="unmatched"} 1`))
+		Expect(body).To(ContainSubstring(`iam_http_request_duration_seconds_count{method="get",service="group"} 2`))
+		Expect(body).NotTo(ContainSubstring("eng"))
+	})
+
+	It("records domain metrics with low cardinality labels", func() {
+		metrics.ObserveSignin("password", nil)
+		metrics.ObserveSignin("password", errors.New("invalid credentials"))
+		metrics.ObserveTokenIssued("ACCESS_TOKEN")
+		metrics.ObserveTokenRevoked("REFRESH_TOKEN")
+		metrics.SessionsStarted(3)
+		metrics.SessionsEnded(1)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`iam_signins_total{method="password",outcome="success"} 1`))
+		Expect(body).To(ContainSubstring(`iam_signins_total{method="password",outcome="failure"} 1`))
+		Expect(body).To(ContainSubstring(`iam_tokens_issued_total{token_type="access_token"} 1`))
+		Expect(body).To(ContainSubstring(`iam_token_revocations_total{token_type="refresh_token"} 1`))
+		Expect(body).To(ContainSubstring("iam_active_sessions 2"))
+		Expect(strings.Count(body, "iam_signins_total{")).To(Equal(2))
+	})
+})
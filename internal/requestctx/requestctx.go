@@ -0,0 +1,131 @@
+// Package requestctx carries HTTP request metadata into the service layer,
+// where Goa generated handlers only expose a context.
+package requestctx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// HeaderRequestID carries the ID of a request, set by the client or its proxies, or else
+// generated, and echoed in the response.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// Metadata describes the client that issued the current request.
+type Metadata struct {
+	RequestID string // ID correlating the request with its log entries and response
+	IPAddress string // Client IP address, honouring X-Forwarded-For
+	UserAgent string // Client User-Agent header
+}
+
+// metadataContextKey is the context key under which request metadata is stored.
+type metadataContextKey struct{}
+
+// WithMetadata returns a copy of ctx carrying the given request metadata.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataContextKey{}, md)
+}
+
+// MetadataFromContext returns the request metadata attached to ctx, or the zero value.
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataContextKey{}).(Metadata)
+	return md
+}
+
+// caller holds the subject authenticated while a request is handled. Middleware wrapping
+// the handler only sees the context the request started with, so the subject is recorded in
+// this holder rather than in a context derived by the authenticator.
+type caller struct {
+	mu      sync.Mutex
+	subject string
+}
+
+// callerContextKey is the context key under which the caller holder is stored.
+type callerContextKey struct{}
+
+// SetSubject records the subject of the token the request was authenticated with.
+func SetSubject(ctx context.Context, subject string) {
+	if c, ok := ctx.Value(callerContextKey{}).(*caller); ok {
+		c.mu.Lock()
+		c.subject = subject
+		c.mu.Unlock()
+	}
+}
+
+// Subject returns the subject the request was authenticated as, or an empty string.
+func Subject(ctx context.Context) string {
+	if c, ok := ctx.Value(callerContextKey{}).(*caller); ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.subject
+	}
+	return ""
+}
+
+// RequestID returns the ID of the request ctx belongs to, or an empty string.
+func RequestID(ctx context.Context) string {
+	return MetadataFromContext(ctx).RequestID
+}
+
+// Middleware extracts client metadata from each request and attaches it to the request
+// context, along with a logger adding the request ID to every entry. The request ID is
+// taken from the X-Request-ID header when it holds a valid ID, generated otherwise, and
+// echoed in the response header of the same name.
+func Middleware(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			md := Metadata{
+				RequestID: requestID(r),
+				IPAddress: clientIP(r),
+				UserAgent: r.UserAgent(),
+			}
+			w.Header().Set(HeaderRequestID, md.RequestID)
+
+			ctx := context.WithValue(WithMetadata(r.Context(), md), callerContextKey{}, &caller{})
+			ctx = logger.WithContext(ctx, log)
+			ctx = logger.WithFields(ctx, "requestId", md.RequestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestID returns the request ID sent by the client, unless it is missing, too long or
+// holds characters other than printable ASCII, in which case a new one is generated.
+func requestID(r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(HeaderRequestID))
+	if id == "" || len(id) > maxRequestIDLength {
+		return uuid.New().String()
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return uuid.New().String()
+		}
+	}
+	return id
+}
+
+// clientIP returns the originating client address, preferring the first
+// X-Forwarded-For entry set by the ingress over the connection address.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		if ip, _, _ := strings.Cut(forwarded, ","); strings.TrimSpace(ip) != "" {
+			return strings.TrimSpace(ip)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	goahttp "goa.design/goa/v3/http"
+)
+
+// requestDecoder extends goahttp.RequestDecoder with support for
+// application/x-www-form-urlencoded bodies, which OAuth 2.0 clients
+// use when calling the token endpoint (RFC 6749 section 4.1.3) and
+// SAML identity providers use with the HTTP-POST binding, and for
+// structured JSON media types such as application/scim+json.
+func requestDecoder(r *http.Request) goahttp.Decoder {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case err != nil:
+		return goahttp.RequestDecoder(r)
+	case mediaType == "application/x-www-form-urlencoded":
+		return &formDecoder{r: r}
+	case strings.HasSuffix(mediaType, "+json"):
+		return json.NewDecoder(r.Body)
+	}
+	return goahttp.RequestDecoder(r)
+}
+
+// formDecoder decodes a form body by converting it into the equivalent JSON
+// object so the generated request body types can be reused unchanged.
+type formDecoder struct {
+	r *http.Request
+}
+
+// Decode parses the form body and decodes it into v.
+func (d *formDecoder) Decode(v any) error {
+	if err := d.r.ParseForm(); err != nil {
+		return err
+	}
+
+	fields := make(map[string]string, len(d.r.PostForm))
+	for key := range d.r.PostForm {
+		fields[key] = d.r.PostForm.Get(key)
+	}
+
+	// Let the generated decoder report missing bodies consistently with JSON requests.
+	if len(fields) == 0 {
+		return io.EOF
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return json.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+
+	goahttp "goa.design/goa/v3/http"
+
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+)
+
+// errorFormatter formats the errors Goa responds with that have no error type of their own,
+// identifying them with the ID of the request rather than a random one, so a client
+// reporting an error points at the log entries of the request that caused it.
+func errorFormatter(ctx context.Context, err error) goahttp.Statuser {
+	res := goahttp.NewErrorResponse(ctx, err)
+	if errRes, ok := res.(*goahttp.ErrorResponse); ok {
+		if id := requestctx.RequestID(ctx); id != "" {
+			errRes.ID = id
+		}
+	}
+	return res
+}
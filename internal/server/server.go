@@ -10,80 +10,390 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	goahttp "goa.design/goa/v3/http"
 
+	genaudit "github.com/iamBelugaa/goa-iam/gen/audit"
 	genauth "github.com/iamBelugaa/goa-iam/gen/auth"
+	genauthz "github.com/iamBelugaa/goa-iam/gen/authz"
+	gengroup "github.com/iamBelugaa/goa-iam/gen/group"
+	genauditserver "github.com/iamBelugaa/goa-iam/gen/http/audit/server"
 	genauthserver "github.com/iamBelugaa/goa-iam/gen/http/auth/server"
+	genauthzserver "github.com/iamBelugaa/goa-iam/gen/http/authz/server"
+	gengroupserver "github.com/iamBelugaa/goa-iam/gen/http/group/server"
+	geninvitationserver "github.com/iamBelugaa/goa-iam/gen/http/invitation/server"
+	genloggingserver "github.com/iamBelugaa/goa-iam/gen/http/logging/server"
+	genoauthserver "github.com/iamBelugaa/goa-iam/gen/http/oauth/server"
+	genorganizationserver "github.com/iamBelugaa/goa-iam/gen/http/organization/server"
+	genscimserver "github.com/iamBelugaa/goa-iam/gen/http/scim/server"
 	genuserserver "github.com/iamBelugaa/goa-iam/gen/http/user/server"
+	genwebhookserver "github.com/iamBelugaa/goa-iam/gen/http/webhook/server"
+	geninvitation "github.com/iamBelugaa/goa-iam/gen/invitation"
+	genlogging "github.com/iamBelugaa/goa-iam/gen/logging"
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+	genorganization "github.com/iamBelugaa/goa-iam/gen/organization"
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
 	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+	genwebhook "github.com/iamBelugaa/goa-iam/gen/webhook"
 
+	"github.com/iamBelugaa/goa-iam/internal/accesslog"
 	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/events"
+	"github.com/iamBelugaa/goa-iam/internal/health"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	auditfilestore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/file"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
 	"github.com/iamBelugaa/goa-iam/internal/services/authsvc"
+	linkmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/samlsp"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	credentialmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authzsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/authzsvc/policy"
+	tuplememorystore "github.com/iamBelugaa/goa-iam/internal/services/authzsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/invitesvc"
+	invitememorystore "github.com/iamBelugaa/goa-iam/internal/services/invitesvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/loggingsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/oauthsvc"
+	oauthmemorystore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/orgsvc"
+	orgmemorystore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/scimsvc"
 	"github.com/iamBelugaa/goa-iam/internal/services/usersvc"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
 	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/webhooksvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/dispatch"
+	webhookmemorystore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/internal/tracing"
 	"github.com/iamBelugaa/goa-iam/pkg/logger"
 )
 
-// server encapsulates the application configuration,
-// logger, HTTP server instance, and error channel.
+// server encapsulates the application configuration, logger, HTTP server instance,
+// error channel and the background workers delivering domain events.
 type server struct {
-	cfg         *config.Config // Application configuration
-	log         *logger.Logger // Application logger
-	httpServer  *http.Server   // Underlying HTTP server
-	serverError chan error     // Channel for capturing async server errors
+	cfg         *config.Config              // Application configuration
+	log         *logger.Logger              // Application logger
+	httpServer  *http.Server                // Underlying HTTP server
+	serverError chan error                  // Channel for capturing async server errors
+	probes      *health.Probes              // Health probes, reporting the server not ready once shutting down
+	relay       *events.Relay               // Publishes domain events recorded in store outboxes
+	dispatcher  *dispatch.Dispatcher        // Delivers published events to webhook subscriptions
+	stopWorkers context.CancelFunc          // Stops the relay and the dispatcher
+	stopTracing func(context.Context) error // Flushes pending spans and stops the tracer provider
 }
 
 // New creates and configures a new instance of the server.
 // It sets up user and auth services, mounts their HTTP handlers,
 // and initializes the HTTP server.
-func New(logger *logger.Logger, cfg *config.Config) *server {
-	// Initialize in-memory user store and user service.
-	userStore := usermemorystore.NewMemoryStore()
-	userSvc := usersvc.NewService(logger, userStore)
+func New(logger *logger.Logger, cfg *config.Config) (*server, error) {
+	// Install the trace context propagator and the tracer provider exporting spans, if any.
+	stopTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.Application.Service, cfg.Application.Version)
+	if err != nil {
+		return nil, fmt.Errorf("setup tracing: %w", err)
+	}
+
+	// Initialize the organization store and the default organization requests without a tenant are routed to.
+	orgStore := orgmemorystore.NewMemoryStore()
+	defaultOrg, err := orgsvc.Bootstrap(context.Background(), orgStore, cfg.Tenancy, cfg.Auth.AdminEmails)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap default organization: %w", err)
+	}
+	tenantRouter := tenancy.NewRouter(orgStore, cfg.Tenancy, defaultOrg.ID)
+
+	// Initialize the audit log, appended to a file when configured, and the recorder every service records security events with.
+	var auditStore auditstore.AuditStorer = auditmemorystore.NewMemoryStore()
+	if path := cfg.Audit.File; path != "" {
+		if auditStore, err = auditfilestore.NewFileStore(path); err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+	}
+	recorder := audit.NewRecorder(logger, auditStore)
+
+	// Initialize in-memory user store, timed for the store latency metrics and traced, and user service.
+	userMemoryStore := usermemorystore.NewMemoryStore()
+	userStore := userstore.WithTracing(userstore.WithMetrics(userMemoryStore))
+	userSvc := usersvc.NewService(userStore, recorder)
 	userEndpoints := genuser.NewEndpoints(userSvc)
 
-	// Initialize auth service using user store and configuration.
-	authsvc := authsvc.NewService(logger, userStore, cfg.Auth)
+	// Initialize the token and session managers and the authenticator shared by every service issuing or accepting tokens.
+	tokenManager := tokenmgr.NewJWTManager(cfg.Auth)
+	sessionManager := session.NewManager(sessionmemorystore.NewMemoryStore(), cfg.Auth)
+	authenticator := jwtauth.New(tokenManager, sessionManager, revocationmemorystore.NewMemoryStore())
+
+	// Initialize the OpenID Connect ID token signer.
+	idTokenSigner, err := idtoken.NewSigner(cfg.Auth, cfg.OIDC)
+	if err != nil {
+		return nil, fmt.Errorf("create id token signer: %w", err)
+	}
+	if cfg.OIDC.SigningKeyFile == "" {
+		logger.Warnw("no OIDC signing key configured, using an ephemeral key", "kid", idTokenSigner.JWKS()[0].Kid)
+	}
+
+	// Initialize the SAML service provider for enterprise identity providers.
+	samlSP, err := samlsp.NewServiceProvider(cfg.SAML)
+	if err != nil {
+		return nil, fmt.Errorf("create saml service provider: %w", err)
+	}
+	if samlSP.Ephemeral() {
+		logger.Warnw("no SAML certificate configured, using an ephemeral self-signed certificate")
+	}
+
+	// Initialize the group store and the resolver of roles held directly or through groups.
+	groupStore := groupmemorystore.NewMemoryStore()
+	accessResolver := membership.NewResolver(userStore, groupStore)
+
+	// Initialize auth service using user, passkey credential and identity link stores and configuration.
+	credentialStore := credentialmemorystore.NewMemoryStore()
+	linkStore := linkmemorystore.NewMemoryStore()
+	authsvc := authsvc.NewService(
+		userStore, orgStore, credentialStore, linkStore, tokenManager, idTokenSigner, sessionManager, accessResolver,
+		authenticator, samlSP, recorder, cfg.WebAuthn, cfg.Federation,
+	)
 	authEndPoints := genauth.NewEndpoints(authsvc)
 
-	// Create Goa HTTP multiplexer.
+	// Initialize OAuth authorization server backed by an in-memory client, code and consent store.
+	oauthSvc := oauthsvc.NewService(userStore, oauthmemorystore.NewMemoryStore(), tokenManager, idTokenSigner, sessionManager, accessResolver, authenticator, recorder, cfg.OAuth)
+	oauthEndpoints := genoauth.NewEndpoints(oauthSvc)
+
+	// Initialize the group service managing nested groups and the roles they grant.
+	groupSvc := groupsvc.NewService(logger.Named(gengroup.ServiceName), groupStore, userStore, accessResolver, authenticator, recorder)
+	groupEndpoints := gengroup.NewEndpoints(groupSvc)
+
+	// Initialize the SCIM provisioning service backed by the user and group stores.
+	scimSvc := scimsvc.NewService(userStore, groupStore, sessionManager, authenticator, recorder, cfg.SCIM)
+	scimEndpoints := genscim.NewEndpoints(scimSvc)
+
+	// Initialize the organization service managing the tenants of the system.
+	orgSvc := orgsvc.NewService(logger.Named(genorganization.ServiceName), orgStore, defaultOrg.ID, authenticator, recorder)
+	orgEndpoints := genorganization.NewEndpoints(orgSvc)
+
+	// Initialize the invitation service onboarding people into organizations.
+	// The owners of the admin emails are invited as admins, see invitesvc.BootstrapAdmins.
+	inviteStore := invitememorystore.NewMemoryStore()
+	if err := invitesvc.BootstrapAdmins(context.Background(), inviteStore, defaultOrg, cfg.Invitations); err != nil {
+		return nil, fmt.Errorf("invite admins: %w", err)
+	}
+	if cfg.Invitations.AdminTokensFile == "" && len(defaultOrg.AdminEmails) > 0 {
+		logger.Warnw("no admin invitation tokens file configured, admin emails are only granted through identity providers")
+	}
+	inviteSvc := invitesvc.NewService(inviteStore, userStore, orgStore, authenticator, recorder, cfg.Invitations)
+	inviteEndpoints := geninvitation.NewEndpoints(inviteSvc)
+
+	// Initialize the authorization service evaluating the configured policy against stored relationships.
+	authzPolicy := policy.Default()
+	if path := cfg.Authorization.PolicyFile; path != "" {
+		if authzPolicy, err = policy.LoadFile(path); err != nil {
+			return nil, fmt.Errorf("load authorization policy: %w", err)
+		}
+	}
+	authzSvc := authzsvc.NewService(logger.Named(genauthz.ServiceName), authzPolicy, tuplememorystore.NewMemoryStore(), userStore, accessResolver, authenticator, recorder)
+	authzEndpoints := genauthz.NewEndpoints(authzSvc)
+
+	// Initialize the audit service querying and verifying the audit log.
+	auditSvc := auditsvc.NewService(logger.Named(genaudit.ServiceName), auditStore, authenticator)
+	auditEndpoints := genaudit.NewEndpoints(auditSvc)
+
+	// Initialize the webhook service and the dispatcher delivering the domain events the relay
+	// publishes from the user store's outbox.
+	webhookStore := webhookmemorystore.NewMemoryStore()
+	dispatcher := dispatch.New(logger, webhookStore, cfg.Webhooks)
+	eventBus := events.NewBus()
+	eventBus.Subscribe(dispatcher.Handle)
+	relay := events.NewRelay(logger, eventBus, userMemoryStore)
+	webhookSvc := webhooksvc.NewService(logger.Named(genwebhook.ServiceName), webhookStore, authenticator, recorder, cfg.Webhooks)
+	webhookEndpoints := genwebhook.NewEndpoints(webhookSvc)
+
+	// Initialize the logging service controlling the levels of the application logger at runtime.
+	loggingSvc := loggingsvc.NewService(logger.Named(genlogging.ServiceName), defaultOrg.ID, authenticator, recorder)
+	loggingEndpoints := genlogging.NewEndpoints(loggingSvc)
+
+	// Trace the Goa method handling each request.
+	userEndpoints.Use(tracing.Endpoint)
+	authEndPoints.Use(tracing.Endpoint)
+	oauthEndpoints.Use(tracing.Endpoint)
+	groupEndpoints.Use(tracing.Endpoint)
+	scimEndpoints.Use(tracing.Endpoint)
+	orgEndpoints.Use(tracing.Endpoint)
+	inviteEndpoints.Use(tracing.Endpoint)
+	authzEndpoints.Use(tracing.Endpoint)
+	auditEndpoints.Use(tracing.Endpoint)
+	webhookEndpoints.Use(tracing.Endpoint)
+	loggingEndpoints.Use(tracing.Endpoint)
+
+	// Create Goa HTTP multiplexer, tracing requests, measuring them by the Goa method their
+	// route is mounted for and logging each of them.
 	mux := goahttp.NewMuxer()
+	mux.Use(tracing.Middleware(mux))
+	httpMetrics := metrics.NewHTTP(mux)
+	mux.Use(httpMetrics.Middleware)
+	mux.Use(accesslog.New(mux, cfg.AccessLog).Middleware)
 
 	// Setup and mount user HTTP handlers.
-	userHandlers := genuserserver.New(userEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, nil)
+	userHandlers := genuserserver.New(userEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
 	genuserserver.Mount(mux, userHandlers)
 
-	// Setup and mount auth HTTP handlers.
-	authHandlers := genauthserver.New(authEndPoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, nil)
+	// Setup and mount auth HTTP handlers; SAML identity providers post form encoded responses.
+	authHandlers := genauthserver.New(authEndPoints, mux, requestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
 	genauthserver.Mount(mux, authHandlers)
 
+	// Setup and mount OAuth HTTP handlers; the token endpoint accepts form encoded bodies.
+	oauthHandlers := genoauthserver.New(oauthEndpoints, mux, requestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genoauthserver.Mount(mux, oauthHandlers)
+
+	// Setup and mount group HTTP handlers.
+	groupHandlers := gengroupserver.New(groupEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	gengroupserver.Mount(mux, groupHandlers)
+
+	// Setup and mount SCIM HTTP handlers; requests and errors use SCIM media types and messages.
+	scimHandlers := genscimserver.New(scimEndpoints, mux, requestDecoder, scimsvc.ResponseEncoder, nil, scimsvc.FormatError)
+	genscimserver.Mount(mux, scimHandlers)
+
+	// Setup and mount organization HTTP handlers.
+	orgHandlers := genorganizationserver.New(orgEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genorganizationserver.Mount(mux, orgHandlers)
+
+	// Setup and mount invitation HTTP handlers.
+	inviteHandlers := geninvitationserver.New(inviteEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	geninvitationserver.Mount(mux, inviteHandlers)
+
+	// Setup and mount authorization HTTP handlers.
+	authzHandlers := genauthzserver.New(authzEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genauthzserver.Mount(mux, authzHandlers)
+
+	// Setup and mount audit HTTP handlers.
+	auditHandlers := genauditserver.New(auditEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genauditserver.Mount(mux, auditHandlers)
+
+	// Setup and mount webhook HTTP handlers.
+	webhookHandlers := genwebhookserver.New(webhookEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genwebhookserver.Mount(mux, webhookHandlers)
+
+	// Setup and mount logging HTTP handlers.
+	loggingHandlers := genloggingserver.New(loggingEndpoints, mux, goahttp.RequestDecoder, goahttp.ResponseEncoder, nil, errorFormatter)
+	genloggingserver.Mount(mux, loggingHandlers)
+
 	// Log mounted user endpoints.
 	for _, mount := range userHandlers.Mounts {
+		httpMetrics.Route(genuser.ServiceName, mount.Method, mount.Verb, mount.Pattern)
 		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
 	}
 
 	// Log mounted auth endpoints.
 	for _, mount := range authHandlers.Mounts {
+		httpMetrics.Route(genauth.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted OAuth endpoints.
+	for _, mount := range oauthHandlers.Mounts {
+		httpMetrics.Route(genoauth.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted group endpoints.
+	for _, mount := range groupHandlers.Mounts {
+		httpMetrics.Route(gengroup.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted SCIM endpoints.
+	for _, mount := range scimHandlers.Mounts {
+		httpMetrics.Route(genscim.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted organization endpoints.
+	for _, mount := range orgHandlers.Mounts {
+		httpMetrics.Route(genorganization.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted invitation endpoints.
+	for _, mount := range inviteHandlers.Mounts {
+		httpMetrics.Route(geninvitation.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted authorization endpoints.
+	for _, mount := range authzHandlers.Mounts {
+		httpMetrics.Route(genauthz.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted audit endpoints.
+	for _, mount := range auditHandlers.Mounts {
+		httpMetrics.Route(genaudit.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted webhook endpoints.
+	for _, mount := range webhookHandlers.Mounts {
+		httpMetrics.Route(genwebhook.ServiceName, mount.Method, mount.Verb, mount.Pattern)
+		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
+	}
+
+	// Log mounted logging endpoints.
+	for _, mount := range loggingHandlers.Mounts {
+		httpMetrics.Route(genlogging.ServiceName, mount.Method, mount.Verb, mount.Pattern)
 		log.Printf("%q mounted on %s %s", mount.Method, mount.Verb, mount.Pattern)
 	}
 
+	// Register the checks of the components the server needs to serve requests: the user
+	// store, the ID token signing key and the publication of domain events.
+	probes := health.New(cfg.Health.CheckTimeout)
+	probes.Register("userStore", health.CheckerFunc(userMemoryStore.Ping))
+	probes.Register("signingKey", idTokenSigner)
+	probes.Register("outbox", health.MaxLag(relay.Lag, cfg.Health.MaxOutboxLag))
+
+	// Serve the metrics and health probes next to the API, outside of tenant routing. API
+	// requests are given an ID and a logger carrying it before their tenant is resolved.
+	handler := http.NewServeMux()
+	handler.Handle("GET /metrics", metrics.Handler())
+	handler.Handle("GET /healthz", probes.Liveness())
+	handler.Handle("GET /readyz", probes.Readiness())
+	handler.Handle("GET /startupz", probes.Startup())
+	handler.Handle("/", requestctx.Middleware(logger)(tenantRouter.Middleware(mux)))
+
 	return &server{
 		cfg:         cfg,
 		log:         logger,
 		serverError: make(chan error, 1),
+		probes:      probes,
+		relay:       relay,
+		dispatcher:  dispatcher,
+		stopTracing: stopTracing,
 		httpServer: &http.Server{
-			Handler:      mux,
+			Handler:      handler,
 			IdleTimeout:  cfg.Server.IdleTimeout,
 			ReadTimeout:  cfg.Server.ReadTimeout,
 			WriteTimeout: cfg.Server.WriteTimeout,
 			Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
 		},
-	}
+	}, nil
 }
 
-// ListenAndServe starts the HTTP server.
+// ListenAndServe starts the HTTP server along with the workers delivering domain events.
 func (s *server) ListenAndServe() error {
+	workerCtx, cancel := context.WithCancel(context.Background())
+	s.stopWorkers = cancel
+	go s.relay.Run(workerCtx, s.cfg.Webhooks.PollInterval)
+	go s.dispatcher.Run(workerCtx)
+
 	go func() {
 		s.log.Infow("starting http server", "address", fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port))
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -96,6 +406,8 @@ func (s *server) ListenAndServe() error {
 // Shutdown listens for termination signals or server errors
 // and performs a graceful shutdown of the HTTP server.
 func (s *server) Shutdown() error {
+	defer s.stopWorkers()
+
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -110,6 +422,11 @@ func (s *server) Shutdown() error {
 		s.log.Infow("shutting down server signal received", "signal", sig)
 		s.log.Infow("initiating graceful shutdown", "service", s.cfg.Application.Service)
 
+		// Report the server not ready and give load balancers time to stop routing requests
+		// to it before draining connections.
+		s.probes.Drain()
+		time.Sleep(s.cfg.Health.ShutdownDelay)
+
 		// Create context with timeout for graceful shutdown.
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.Server.ShutdownTimeout)
 		defer cancel()
@@ -119,6 +436,11 @@ func (s *server) Shutdown() error {
 			return fmt.Errorf("could not stop server gracefully: %w", err)
 		}
 
+		// Export the spans of the last requests.
+		if err := s.stopTracing(shutdownCtx); err != nil {
+			s.log.Infow("tracing shutdown error", "error", err)
+		}
+
 		s.log.Infow("graceful shutdown completed successfully", "service", s.cfg.Application.Service)
 	}
 
@@ -0,0 +1,163 @@
+// Package auditsvc provides the query API of the audit log. Events are recorded by the other
+// services through package audit and stored in an append-only, hash chained log per tenant.
+package auditsvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"goa.design/goa/v3/security"
+
+	genaudit "github.com/iamBelugaa/goa-iam/gen/audit"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// service implements queries over the audit log.
+type service struct {
+	log   *logger.Logger         // Logger for structured logging
+	store auditstore.AuditStorer // Interface to the audit log
+	authn *jwtauth.Authenticator // Token authenticator for secured methods
+}
+
+// NewService initializes and returns a new audit service instance.
+func NewService(log *logger.Logger, store auditstore.AuditStorer, authn *jwtauth.Authenticator) *service {
+	return &service{log: log, store: store, authn: authn}
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// ListEvents returns the events of the tenant matching the filters, newest first.
+func (s *service) ListEvents(ctx context.Context, req *genaudit.ListAuditEventsRequest) (*genaudit.ListAuditEventsResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list audit events request received", "userId", claims.Subject, "actorId", req.ActorID, "types", req.Type)
+
+	filter := auditstore.Filter{TenantID: tenancy.FromContext(ctx), Types: req.Type, Limit: req.Limit}
+	if req.ActorID != nil {
+		filter.ActorID = *req.ActorID
+	}
+	if req.From != nil {
+		filter.From, _ = time.Parse(time.RFC3339, *req.From)
+	}
+	if req.To != nil {
+		filter.To, _ = time.Parse(time.RFC3339, *req.To)
+	}
+
+	events, err := s.store.Query(ctx, filter)
+	if err != nil {
+		s.log.Infow("list audit events error", "error", err)
+		return nil, genaudit.MakeInternalServerError(err)
+	}
+
+	data := make([]*genaudit.AuditEvent, 0, len(events))
+	for _, event := range events {
+		data = append(data, toAuditEvent(event))
+	}
+
+	s.log.Infow("list audit events request successful", "totalEvents", len(data))
+	return &genaudit.ListAuditEventsResponse{
+		Success: true,
+		Message: "Audit events fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// Verify checks the tenant's chain of events for events that were modified, removed or reordered.
+// The chain's head is returned so that callers can keep it outside the audit log and pass it
+// back as an anchor, which detects a truncated or entirely rewritten chain.
+func (s *service) Verify(ctx context.Context, req *genaudit.VerifyAuditLogRequest) (*genaudit.VerifyAuditLogResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("verify audit log request received", "userId", claims.Subject)
+
+	if (req.AnchorSequence == nil) != (req.AnchorHash == nil) {
+		s.log.Infow("verify audit log error", "error", "incomplete anchor")
+		return nil, genaudit.MakeBadRequest(fmt.Errorf("anchorSequence and anchorHash must be given together"))
+	}
+
+	events, err := s.store.Chain(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		s.log.Infow("verify audit log error", "error", err)
+		return nil, genaudit.MakeInternalServerError(err)
+	}
+
+	data := &genaudit.AuditVerification{Valid: true, TotalEvents: len(events)}
+	if len(events) > 0 {
+		head := events[len(events)-1]
+		data.HeadSequence, data.HeadHash = &head.Sequence, &head.Hash
+	}
+
+	err = auditstore.Verify(events)
+	if err == nil && req.AnchorSequence != nil {
+		err = auditstore.VerifyAnchor(events, *req.AnchorSequence, *req.AnchorHash)
+	}
+	if err != nil {
+		s.log.Warnw("audit log chain is broken", "error", err)
+		reason := err.Error()
+		data.Valid, data.Error = false, &reason
+	}
+
+	s.log.Infow("verify audit log request successful", "valid", data.Valid, "totalEvents", data.TotalEvents)
+	return &genaudit.VerifyAuditLogResponse{
+		Success: true,
+		Message: "Audit log verified successfully",
+		Data:    data,
+	}, nil
+}
+
+// authorize returns the caller's claims, requiring a user token holding the permission to read the audit log.
+func authorize(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, genaudit.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if !slices.Contains(claims.Permissions, userdomain.PermissionReadAudit) {
+		return tokenmgr.Claims{}, genaudit.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionReadAudit))
+	}
+	return claims, nil
+}
+
+// toAuditEvent converts a stored event into its API representation.
+func toAuditEvent(event *auditstore.Event) *genaudit.AuditEvent {
+	res := &genaudit.AuditEvent{
+		ID:         event.ID,
+		Sequence:   event.Sequence,
+		Type:       event.Type,
+		Outcome:    event.Outcome,
+		Details:    event.Details,
+		OccurredAt: event.OccurredAt.Format(time.RFC3339),
+		PrevHash:   event.PrevHash,
+		Hash:       event.Hash,
+	}
+	if event.ActorID != "" {
+		res.ActorID = &event.ActorID
+	}
+	if event.TargetID != "" {
+		res.TargetID = &event.TargetID
+	}
+	if event.IPAddress != "" {
+		res.IPAddress = &event.IPAddress
+	}
+	if event.UserAgent != "" {
+		res.UserAgent = &event.UserAgent
+	}
+	if event.Reason != "" {
+		res.Reason = &event.Reason
+	}
+	return res
+}
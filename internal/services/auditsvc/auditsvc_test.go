@@ -0,0 +1,231 @@
+package auditsvc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genaudit "github.com/iamBelugaa/goa-iam/gen/audit"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	auditfilestore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/file"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestAuditService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Audit service", func() {
+	const tenantID = "acme"
+
+	var (
+		ctx      context.Context
+		adminCtx context.Context
+		log      *logger.Logger
+		authn    *jwtauth.Authenticator
+		tm       *tokenmgr.JWTTokenManager
+		store    auditstore.AuditStorer
+		recorder *audit.Recorder
+		svc      genaudit.Service
+	)
+
+	userCtx := func(userID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+	}
+
+	verify := func() *genaudit.AuditVerification {
+		res, err := svc.Verify(adminCtx, &genaudit.VerifyAuditLogRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		return res.Data
+	}
+
+	BeforeEach(func() {
+		ctx = requestctx.WithMetadata(context.Background(), requestctx.Metadata{IPAddress: "203.0.113.7", UserAgent: "test"})
+
+		var err error
+		log, err = logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn = jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		store = auditmemorystore.NewMemoryStore()
+		recorder = audit.NewRecorder(log, store)
+		svc = auditsvc.NewService(log, store, authn)
+		adminCtx = userCtx("admin", userdomain.PermissionReadAudit)
+	})
+
+	It("records who did what from where", func() {
+		recorder.Record(userCtx("admin"), audit.Entry{Type: audit.EventGroupCreate, TargetID: "eng"})
+		recorder.Record(tenancy.WithTenant(ctx, tenantID), audit.Entry{
+			Type: audit.EventSignin, Err: fmt.Errorf("user doesn't exist"), Details: map[string]string{"method": "password"},
+		})
+
+		res, err := svc.ListEvents(adminCtx, &genaudit.ListAuditEventsRequest{Limit: 100})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data).To(HaveLen(2))
+
+		signin, create := res.Data[0], res.Data[1]
+		Expect(signin.Type).To(Equal(audit.EventSignin))
+		Expect(signin.Outcome).To(Equal(audit.OutcomeFailure))
+		Expect(signin.Reason).To(Equal(ptr("user doesn't exist")))
+		Expect(signin.ActorID).To(BeNil())
+		Expect(signin.PrevHash).To(Equal(create.Hash))
+
+		Expect(create.Outcome).To(Equal(audit.OutcomeSuccess))
+		Expect(create.ActorID).To(Equal(ptr("admin")))
+		Expect(create.TargetID).To(Equal(ptr("eng")))
+		Expect(create.IPAddress).To(Equal(ptr("203.0.113.7")))
+		Expect(create.Sequence).To(BeNumerically("==", 1))
+	})
+
+	It("filters events by time, actor and type within the tenant", func() {
+		recorder.Record(userCtx("admin"), audit.Entry{Type: audit.EventGroupCreate})
+		recorder.Record(userCtx("jane"), audit.Entry{Type: audit.EventSignout})
+		recorder.Record(userCtx("jane"), audit.Entry{Type: audit.EventTokenRefresh})
+		recorder.Record(tenancy.WithTenant(ctx, "globex"), audit.Entry{Type: audit.EventSignout, ActorID: "jane"})
+
+		res, err := svc.ListEvents(adminCtx, &genaudit.ListAuditEventsRequest{
+			ActorID: ptr("jane"), Type: []string{audit.EventSignout, audit.EventSignin}, Limit: 100,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data).To(HaveLen(1))
+		Expect(res.Data[0].Type).To(Equal(audit.EventSignout))
+
+		res, err = svc.ListEvents(adminCtx, &genaudit.ListAuditEventsRequest{Limit: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data).To(HaveLen(2))
+		Expect(res.Data[0].Type).To(Equal(audit.EventTokenRefresh))
+
+		future := time.Now().Add(time.Hour).Format(time.RFC3339)
+		res, err = svc.ListEvents(adminCtx, &genaudit.ListAuditEventsRequest{From: &future, Limit: 100})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data).To(BeEmpty())
+	})
+
+	It("requires the permission to read the audit log", func() {
+		_, err := svc.ListEvents(userCtx("jane"), &genaudit.ListAuditEventsRequest{Limit: 100})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		_, err = svc.Verify(userCtx("jane"), &genaudit.VerifyAuditLogRequest{})
+		Expect(errorName(err)).To(Equal("forbidden"))
+	})
+
+	It("persists events to a file and detects tampering with them", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+		fileStore, err := auditfilestore.NewFileStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		recorder = audit.NewRecorder(log, fileStore)
+		for _, userID := range []string{"jane", "john", "mary"} {
+			recorder.Record(userCtx(userID), audit.Entry{Type: audit.EventSignout})
+		}
+		Expect(fileStore.Close()).To(Succeed())
+
+		reopened, err := auditfilestore.NewFileStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		svc = auditsvc.NewService(log, reopened, authn)
+		Expect(verify().Valid).To(BeTrue())
+		Expect(verify().TotalEvents).To(Equal(3))
+		Expect(reopened.Close()).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, []byte(strings.Replace(string(data), `"john"`, `"mallory"`, 1)), 0o600)).To(Succeed())
+
+		tampered, err := auditfilestore.NewFileStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		svc = auditsvc.NewService(log, tampered, authn)
+		Expect(verify().Valid).To(BeFalse())
+		Expect(verify().Error).To(Equal(ptr("event 2 was modified")))
+		Expect(tampered.Close()).To(Succeed())
+	})
+
+	It("detects a truncated or resealed chain through the head of an earlier verification", func() {
+		for _, userID := range []string{"jane", "john", "mary"} {
+			recorder.Record(userCtx(userID), audit.Entry{Type: audit.EventSignout})
+		}
+		head := verify()
+		Expect(head.HeadSequence).To(Equal(ptr(int64(3))))
+		Expect(head.HeadHash).NotTo(BeNil())
+
+		verifyAnchor := func(store auditstore.AuditStorer) *genaudit.AuditVerification {
+			svc = auditsvc.NewService(log, store, authn)
+			res, err := svc.Verify(adminCtx, &genaudit.VerifyAuditLogRequest{AnchorSequence: head.HeadSequence, AnchorHash: head.HeadHash})
+			Expect(err).NotTo(HaveOccurred())
+			return res.Data
+		}
+		Expect(verifyAnchor(store).Valid).To(BeTrue())
+
+		// A chain rebuilt without its last event, or sealed again after changing an event,
+		// verifies on its own but no longer holds the anchor.
+		chain, err := store.Chain(ctx, tenantID)
+		Expect(err).NotTo(HaveOccurred())
+		truncated, resealed := auditmemorystore.NewMemoryStore(), auditmemorystore.NewMemoryStore()
+		for i, event := range chain {
+			if i < len(chain)-1 {
+				copied := *event
+				Expect(truncated.Append(ctx, &copied)).To(Succeed())
+			}
+			if i == 1 {
+				event.ActorID = "mallory"
+			}
+			Expect(resealed.Append(ctx, event)).To(Succeed())
+		}
+
+		res := verifyAnchor(truncated)
+		Expect(res.Valid).To(BeFalse())
+		Expect(res.Error).To(Equal(ptr("event 3 is missing")))
+
+		res = verifyAnchor(resealed)
+		Expect(res.Valid).To(BeFalse())
+		Expect(res.Error).To(Equal(ptr("event 3 doesn't match the anchor")))
+
+		_, err = svc.Verify(adminCtx, &genaudit.VerifyAuditLogRequest{AnchorSequence: head.HeadSequence})
+		Expect(errorName(err)).To(Equal("bad_request"))
+	})
+})
@@ -0,0 +1,62 @@
+// Package auditstore provides an in-memory implementation of the AuditStorer interface.
+package auditstore
+
+import (
+	"context"
+	"sync"
+
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+)
+
+// memory implements the AuditStorer interface using in-memory slices.
+type memory struct {
+	mu     sync.RWMutex                   // protects access to events
+	events map[string][]*auditstore.Event // stores the chain of events of each tenant
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory audit store.
+func NewMemoryStore() *memory {
+	return &memory{events: make(map[string][]*auditstore.Event)}
+}
+
+// Append seals the event and adds it to the end of its tenant's chain.
+func (m *memory) Append(ctx context.Context, event *auditstore.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var prev *auditstore.Event
+	if chain := m.events[event.TenantID]; len(chain) > 0 {
+		prev = chain[len(chain)-1]
+	}
+	if err := auditstore.Seal(event, prev); err != nil {
+		return err
+	}
+
+	stored := *event
+	m.events[event.TenantID] = append(m.events[event.TenantID], &stored)
+
+	return nil
+}
+
+// Query returns the events matching the filter, newest first.
+func (m *memory) Query(ctx context.Context, filter auditstore.Filter) ([]*auditstore.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return auditstore.Select(m.events[filter.TenantID], filter), nil
+}
+
+// Chain returns every event of the tenant in chain order.
+func (m *memory) Chain(ctx context.Context, tenantID string) ([]*auditstore.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chain := m.events[tenantID]
+	events := make([]*auditstore.Event, 0, len(chain))
+	for _, e := range chain {
+		event := *e
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,161 @@
+// Package auditstore defines the interface for interacting with the audit log storage layer.
+package auditstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Event is a security relevant action recorded in the audit log. Every tenant has its own
+// chain of events: each event carries the hash of the event before it, so changing or
+// removing an event breaks the chain from that event on.
+type Event struct {
+	ID         string            `json:"id"`                  // Unique identifier of the event
+	TenantID   string            `json:"tenantId"`            // ID of the organization the event happened in
+	Sequence   int64             `json:"sequence"`            // Position of the event in the tenant's chain, starting at 1
+	Type       string            `json:"type"`                // What happened, for example auth.signin
+	Outcome    string            `json:"outcome"`             // Whether the action succeeded or failed
+	ActorID    string            `json:"actorId,omitempty"`   // Principal that performed the action, if known
+	TargetID   string            `json:"targetId,omitempty"`  // Entity the action was performed on, if any
+	IPAddress  string            `json:"ipAddress,omitempty"` // IP address of the client that issued the request
+	UserAgent  string            `json:"userAgent,omitempty"` // User-Agent of the client that issued the request
+	Reason     string            `json:"reason,omitempty"`    // Why the action failed
+	Details    map[string]string `json:"details,omitempty"`   // Additional context of the action
+	OccurredAt time.Time         `json:"occurredAt"`          // Time the action happened
+	PrevHash   string            `json:"prevHash"`            // Hash of the previous event of the tenant, empty for the first
+	Hash       string            `json:"hash"`                // Hash of this event, including PrevHash
+}
+
+// Filter selects events of a tenant. Zero fields don't restrict the selection.
+type Filter struct {
+	TenantID string    // Tenant whose events are selected
+	From     time.Time // Earliest time an event occurred at, inclusive
+	To       time.Time // Latest time an event occurred at, exclusive
+	ActorID  string    // Principal that performed the action
+	Types    []string  // Event types to select
+	Limit    int       // Maximum number of events to return
+}
+
+// AuditStorer defines the contract for an append-only audit log. Events can't be updated
+// or deleted through it.
+type AuditStorer interface {
+	// Append links the event to the last event of its tenant, see Seal, and stores it.
+	Append(ctx context.Context, event *Event) error
+
+	// Query returns the events matching the filter, newest first.
+	Query(ctx context.Context, filter Filter) ([]*Event, error)
+
+	// Chain returns every event of the tenant in chain order, for verification.
+	Chain(ctx context.Context, tenantID string) ([]*Event, error)
+}
+
+// Seal sets the sequence, previous hash and hash of an event appended after prev, which is
+// nil for the first event of a tenant.
+func Seal(event *Event, prev *Event) error {
+	event.Sequence, event.PrevHash = 1, ""
+	if prev != nil {
+		event.Sequence, event.PrevHash = prev.Sequence+1, prev.Hash
+	}
+
+	hash, err := Hash(event)
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+	return nil
+}
+
+// Hash computes the SHA-256 hash of every field of the event except Hash itself.
+func Hash(event *Event) (string, error) {
+	unsealed := *event
+	unsealed.Hash = ""
+	unsealed.OccurredAt = unsealed.OccurredAt.UTC()
+
+	data, err := json.Marshal(&unsealed)
+	if err != nil {
+		return "", fmt.Errorf("encode audit event: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that the events form an unbroken chain starting at the first event of a
+// tenant, returning an error naming the first event that was changed, removed or reordered.
+func Verify(events []*Event) error {
+	var prev *Event
+	for _, event := range events {
+		want := int64(1)
+		prevHash := ""
+		if prev != nil {
+			want, prevHash = prev.Sequence+1, prev.Hash
+		}
+		if event.Sequence != want {
+			return fmt.Errorf("event %d is missing", want)
+		}
+		if event.PrevHash != prevHash {
+			return fmt.Errorf("event %d doesn't follow event %d", event.Sequence, want-1)
+		}
+
+		hash, err := Hash(event)
+		if err != nil {
+			return err
+		}
+		if hash != event.Hash {
+			return fmt.Errorf("event %d was modified", event.Sequence)
+		}
+		prev = event
+	}
+	return nil
+}
+
+// VerifyAnchor checks that a verified chain still holds the event an earlier verification
+// reported as its head. Verify can't tell a truncated chain, or one sealed again from its
+// first event on, from an intact one, but the head kept outside the audit log no longer
+// matches either.
+func VerifyAnchor(events []*Event, sequence int64, hash string) error {
+	if sequence < 1 || sequence > int64(len(events)) {
+		return fmt.Errorf("event %d is missing", sequence)
+	}
+	if events[sequence-1].Hash != hash {
+		return fmt.Errorf("event %d doesn't match the anchor", sequence)
+	}
+	return nil
+}
+
+// Matches reports whether the event is selected by the filter.
+func (f Filter) Matches(event *Event) bool {
+	if event.TenantID != f.TenantID {
+		return false
+	}
+	if !f.From.IsZero() && event.OccurredAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !event.OccurredAt.Before(f.To) {
+		return false
+	}
+	if f.ActorID != "" && event.ActorID != f.ActorID {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, event.Type)
+}
+
+// Select returns copies of the events of a tenant's chain matching the filter, newest
+// first, for stores keeping chains in memory.
+func Select(chain []*Event, filter Filter) []*Event {
+	found := make([]*Event, 0)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(found) == filter.Limit {
+			break
+		}
+		if filter.Matches(chain[i]) {
+			event := *chain[i]
+			found = append(found, &event)
+		}
+	}
+	return found
+}
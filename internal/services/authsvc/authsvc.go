@@ -3,7 +3,10 @@ package authsvc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"goa.design/goa/v3/security"
 
@@ -11,55 +14,108 @@ import (
 	genuser "github.com/iamBelugaa/goa-iam/gen/user"
 
 	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation"
+	linkstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/samlsp"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
 	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn"
+	credentialstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
 	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
 	"github.com/iamBelugaa/goa-iam/pkg/logger"
 	"github.com/iamBelugaa/goa-iam/pkg/redact"
 )
 
+// federationTimeout bounds each request made to an upstream identity provider.
+const federationTimeout = 10 * time.Second
+
+// Signin methods recorded in the audit log.
+const (
+	signinMethodPassword  = "password"
+	signinMethodPasskey   = "passkey"
+	signinMethodFederated = "federated"
+	signinMethodSAML      = "saml"
+)
+
 // service implements authentication operations such as signup, signin, signout,
 // and token-based authorization using a JWT token manager.
 type service struct {
-	log       *logger.Logger            // Logger for structured logging
-	userStore userstore.UserStorer      // Interface to the user data store
-	tm        *tokenmgr.JWTTokenManager // JWT manager for token generation and validation
+	userStore  userstore.UserStorer        // Interface to the user data store
+	orgStore   orgstore.OrganizationStorer // Interface to the organization data store
+	tm         *tokenmgr.JWTTokenManager   // JWT manager for token generation and validation
+	idTokens   *idtoken.Signer             // Signer for OpenID Connect ID tokens
+	rp         *webauthn.RelyingParty      // WebAuthn relying party for passkey ceremonies
+	federation *federation.Broker          // Broker for signins through upstream identity providers
+	saml       *samlsp.ServiceProvider     // SAML service provider for enterprise identity providers
+	sessions   *session.Manager            // Session manager tracking signed in clients
+	access     *membership.Resolver        // Resolver of the roles users hold directly or through groups
+	authn      *jwtauth.Authenticator      // Bearer token authenticator for secured methods
+	audit      *audit.Recorder             // Recorder of security events
 }
 
-// NewService initializes and returns a new auth service instance.
-func NewService(log *logger.Logger, userStore userstore.UserStorer, authCfg *config.Auth) *service {
+// NewService initializes and returns a new auth service instance. Operations log through
+// the logger of the request they serve.
+func NewService(
+	userStore userstore.UserStorer, orgStore orgstore.OrganizationStorer, credentialStore credentialstore.CredentialStorer,
+	linkStore linkstore.LinkStorer, tm *tokenmgr.JWTTokenManager, idTokens *idtoken.Signer, sessions *session.Manager,
+	access *membership.Resolver, authn *jwtauth.Authenticator, samlSP *samlsp.ServiceProvider,
+	recorder *audit.Recorder, webAuthnCfg *config.WebAuthn, federationCfg *config.Federation,
+) *service {
 	return &service{
-		log:       log,
-		userStore: userStore,
-		tm:        tokenmgr.NewJWTManager(authCfg),
+		userStore:  userStore,
+		orgStore:   orgStore,
+		tm:         tm,
+		idTokens:   idTokens,
+		sessions:   sessions,
+		access:     access,
+		rp:         webauthn.NewRelyingParty(webAuthnCfg, credentialStore),
+		federation: federation.NewBroker(federationCfg, linkStore, &http.Client{Timeout: federationTimeout}),
+		saml:       samlSP,
+		authn:      authn,
+		audit:      recorder,
 	}
 }
 
 // Signup creates a new user account after validating password confirmation.
 func (s *service) Signup(ctx context.Context, req *genauth.SignupRequest) (*genauth.SignupResponse, error) {
-	s.log.Infow(
+	logger.FromContext(ctx).Infow(
 		"signup request received",
-		"email", redact.RedactEmail(req.Email),
+		"email", req.Email,
 		"firstName", req.FirstName, "lastName", req.LastName,
-		"password", redact.RedactSensitiveData(req.Password),
-		"confirmPassword", redact.RedactSensitiveData(req.ConfirmPassword),
 	)
 
+	details := map[string]string{"email": redact.RedactEmail(req.Email)}
 	if req.Password != req.ConfirmPassword {
-		return nil, genauth.MakePasswordMismatch(fmt.Errorf("confirm password and password doesn't match"))
+		err := genauth.MakePasswordMismatch(fmt.Errorf("confirm password and password doesn't match"))
+		s.audit.Record(ctx, audit.Entry{Type: audit.EventSignup, Err: err, Details: details})
+		return nil, err
 	}
 
-	_, err := s.userStore.Create(ctx, &genuser.CreateUserRequest{
+	user, err := s.userStore.Create(ctx, tenancy.FromContext(ctx), &genuser.CreateUserRequest{
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
 		Password:  req.Password,
 	})
 	if err != nil {
-		s.log.Infow("create user error", "email", redact.RedactEmail(req.Email), "error", err)
+		logger.FromContext(ctx).Infow("create user error", "email", req.Email, "error", err)
+		s.audit.Record(ctx, audit.Entry{
+			Type: audit.EventSignup, Err: fmt.Errorf("user with email %s already exists", details["email"]), Details: details,
+		})
 		return nil, genauth.MakeEmailExists(err)
 	}
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventSignup, ActorID: user.ID, TargetID: user.ID, Details: details})
 
-	s.log.Infow("signup request successful", "email", redact.RedactEmail(req.Email))
+	logger.FromContext(ctx).Infow("signup request successful", "email", req.Email)
 	return &genauth.SignupResponse{
 		Success: true,
 		Message: "User signed up successfully",
@@ -68,61 +124,119 @@ func (s *service) Signup(ctx context.Context, req *genauth.SignupRequest) (*gena
 
 // Signin authenticates a user by email and password.
 func (s *service) Signin(ctx context.Context, req *genauth.SigninRequest) (*genauth.TokenResponse, error) {
-	s.log.Infow(
-		"signin request received",
-		"email", redact.RedactEmail(req.Email),
-		"password", redact.RedactSensitiveData(req.Password),
-	)
+	logger.FromContext(ctx).Infow("signin request received", "email", req.Email)
 
-	user, err := s.userStore.QueryByEmail(ctx, req.Email)
+	details := map[string]string{"method": signinMethodPassword, "email": redact.RedactEmail(req.Email)}
+	user, err := s.userStore.QueryByEmail(ctx, tenancy.FromContext(ctx), req.Email)
+	if err == nil && user == nil {
+		err = fmt.Errorf("user with email %s doesn't exist", details["email"])
+	}
 	if err != nil {
-		s.log.Infow("query user error", "email", redact.RedactEmail(req.Email), "error", err)
+		logger.FromContext(ctx).Infow("query user error", "email", req.Email, "error", err)
+		s.recordSignin(ctx, "", details, fmt.Errorf("no user with email %s", details["email"]))
 		return nil, genauth.MakeNotFound(err)
 	}
-	if user == nil {
-		s.log.Infow("query user error", "email", redact.RedactEmail(req.Email), "error", err)
-		return nil, genauth.MakeNotFound(fmt.Errorf("user with email %s doesn't exist", req.Email))
+
+	if err := s.userStore.VerifyPassword(ctx, user.ID, req.Password); err != nil {
+		logger.FromContext(ctx).Infow("verify password error", "userId", user.ID, "error", err)
+		s.recordSignin(ctx, user.ID, details, err)
+		if errors.Is(err, userdomain.ErrPasswordMismatch) {
+			return nil, genauth.MakeInvalidCredentials(fmt.Errorf("email or password is incorrect"))
+		}
+		return nil, genauth.MakeInternalServerError(err)
 	}
 
-	accessToken, err := s.tm.Generate(s.tm.StandardClaims(user.ID, tokenmgr.AccessToken))
+	tokens, err := s.issueTokens(ctx, user.ID)
+	s.recordSignin(ctx, user.ID, details, err)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.tm.Generate(s.tm.StandardClaims(user.ID, tokenmgr.RefreshToken))
+	logger.FromContext(ctx).Infow("signin request successful", "email", req.Email)
+	return &genauth.TokenResponse{
+		Success: true,
+		Message: "Signed in user successfully",
+		Data:    tokens,
+	}, nil
+}
+
+// Refresh exchanges a valid refresh token for a new token pair bound to the same session.
+// Refresh tokens are rotated, the one presented is revoked.
+func (s *service) Refresh(ctx context.Context, req *genauth.RefreshRequest) (*genauth.TokenResponse, error) {
+	logger.FromContext(ctx).Infow("refresh request received")
+
+	claims, err := s.authn.Validate(ctx, req.RefreshToken)
 	if err != nil {
+		logger.FromContext(ctx).Infow("refresh token validation error", "error", err)
+		s.audit.Record(ctx, audit.Entry{Type: audit.EventTokenRefresh, Err: err})
+		return nil, err
+	}
+
+	// Refresh tokens issued to OAuth clients are only redeemable at the token endpoint.
+	if claims.TokenType != tokenmgr.RefreshToken || claims.ClientID != "" {
+		logger.FromContext(ctx).Infow("invalid token used for refresh operation")
+		err := genauth.MakeInvalidToken(fmt.Errorf("invalid token used for refresh operation"))
+		s.audit.Record(ctx, audit.Entry{Type: audit.EventTokenRefresh, ActorID: claims.Subject, Err: err})
 		return nil, err
 	}
 
-	s.log.Infow("signin request successful", "email", redact.RedactEmail(req.Email))
+	tokens, err := s.generateTokens(ctx, claims.Subject, claims.SessionID)
+	if err == nil {
+		if redeemErr := s.authn.Redeem(ctx, claims); redeemErr != nil {
+			err = genauth.MakeInternalServerError(redeemErr)
+		}
+	}
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventTokenRefresh, ActorID: claims.Subject, Err: err,
+		Details: map[string]string{"sessionId": claims.SessionID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("refresh request successful", "userId", claims.Subject, "sessionId", claims.SessionID)
 	return &genauth.TokenResponse{
 		Success: true,
-		Message: "Signed in user successfully",
-		Data:    &genauth.TokenPayload{AccessToken: accessToken, RefreshToken: refreshToken},
+		Message: "Tokens refreshed successfully",
+		Data:    tokens,
 	}, nil
 }
 
 // Signout invalidates an access token by verifying its validity and user existence.
 func (s *service) Signout(ctx context.Context, req *genauth.SignoutRequest) (*genauth.SignoutResponse, error) {
-	s.log.Infow("signout request received", "token", redact.RedactSensitiveData(req.Token))
+	logger.FromContext(ctx).Infow("signout request received")
 
 	claims, err := s.tm.ParseWithClaims(req.Token)
 	if err != nil {
-		s.log.Infow("jwt parse error", "error", err)
+		logger.FromContext(ctx).Infow("jwt parse error", "error", err)
 		return nil, err
 	}
 
-	if claims.TokenType != tokenmgr.AccessToken {
-		s.log.Infow("invalid token used for signout operation")
+	if claims.TokenType != tokenmgr.AccessToken || claims.Principal() != tokenmgr.PrincipalUser {
+		logger.FromContext(ctx).Infow("invalid token used for signout operation")
 		return nil, genauth.MakeInvalidToken(fmt.Errorf("invalid token used for signout operation"))
 	}
+	if err := jwtauth.CheckTenant(ctx, claims); err != nil {
+		logger.FromContext(ctx).Infow("invalid token used for signout operation", "error", err)
+		return nil, err
+	}
 
-	if _, err := s.userStore.QueryById(ctx, claims.Subject); err != nil {
-		s.log.Infow("query user error", "error", err)
+	if _, err := s.queryUser(ctx, claims.Subject); err != nil {
+		logger.FromContext(ctx).Infow("query user error", "error", err)
 		return nil, genauth.MakeNotFound(err)
 	}
 
-	s.log.Infow("signout request successful")
+	err = s.sessions.Revoke(ctx, claims.Subject, claims.SessionID)
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventSignout, ActorID: claims.Subject, Err: err,
+		Details: map[string]string{"sessionId": claims.SessionID},
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("revoke session error", "sessionId", claims.SessionID, "error", err)
+		return nil, genauth.MakeInvalidToken(err)
+	}
+
+	logger.FromContext(ctx).Infow("signout request successful", "sessionId", claims.SessionID)
 	return &genauth.SignoutResponse{
 		Success: true,
 		Message: "Signed out successfully",
@@ -131,6 +245,118 @@ func (s *service) Signout(ctx context.Context, req *genauth.SignoutRequest) (*ge
 
 // JWTAuth validates a JWT and attaches the corresponding user context.
 func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
-	// TODO: Parse and validate the JWT token and attach user info to context.
-	return context.Background(), nil
+	return s.authn.Authenticate(ctx, token)
+}
+
+// recordSignin records a signin of the user in the audit log and the signin metrics, which
+// failed if err isn't nil.
+func (s *service) recordSignin(ctx context.Context, userID string, details map[string]string, err error) {
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventSignin, ActorID: userID, TargetID: userID, Err: err, Details: details})
+	metrics.ObserveSignin(details["method"], err)
+}
+
+// issueTokens starts a new session for the given user and generates an access
+// and refresh token pair bound to it. Only active users of the request's tenant sign in.
+func (s *service) issueTokens(ctx context.Context, userID string) (*genauth.TokenPayload, error) {
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessions.Create(ctx, userID, requestctx.MetadataFromContext(ctx))
+	if err != nil {
+		logger.FromContext(ctx).Infow("create session error", "userId", userID, "error", err)
+		return nil, genauth.MakeInternalServerError(err)
+	}
+	return s.generateTokens(ctx, userID, sess.ID)
+}
+
+// generateTokens generates an access and refresh token pair bound to an existing
+// session and the request's tenant, together with an ID token for the user. Users
+// that are no longer active get no new tokens.
+func (s *service) generateTokens(ctx context.Context, userID, sessionID string) (*genauth.TokenPayload, error) {
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	access, err := s.access.Resolve(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("resolve access error", "userId", userID, "error", err)
+		return nil, genauth.MakeNotFound(err)
+	}
+
+	accessClaims := s.tm.StandardClaims(userID, tokenmgr.AccessToken)
+	accessClaims.TenantID = tenancy.FromContext(ctx)
+	accessClaims.SessionID = sessionID
+	accessClaims.Roles = access.Roles
+	accessClaims.Permissions = access.Permissions
+
+	accessToken, err := s.tm.Generate(ctx, accessClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshClaims := s.tm.StandardClaims(userID, tokenmgr.RefreshToken)
+	refreshClaims.TenantID = tenancy.FromContext(ctx)
+	refreshClaims.SessionID = sessionID
+
+	refreshToken, err := s.tm.Generate(ctx, refreshClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := s.issueIDToken(ctx, userID, sessionID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &genauth.TokenPayload{AccessToken: accessToken, RefreshToken: refreshToken, IDToken: &idToken}, nil
+}
+
+// issueIDToken issues a first-party ID token carrying every standard user claim, with
+// the session start as the authentication time.
+func (s *service) issueIDToken(ctx context.Context, userID, sessionID, accessToken string) (string, error) {
+	user, err := s.queryUser(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query user error", "userId", userID, "error", err)
+		return "", genauth.MakeNotFound(err)
+	}
+
+	sess, err := s.sessions.Get(ctx, userID, sessionID)
+	if err != nil {
+		return "", genauth.MakeInvalidToken(err)
+	}
+
+	idToken, err := s.idTokens.Issue(idtoken.Params{
+		User:        user,
+		Scopes:      idtoken.AllScopes,
+		AuthTime:    sess.CreatedAt,
+		SessionID:   sessionID,
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("issue id token error", "userId", userID, "error", err)
+		return "", genauth.MakeInternalServerError(err)
+	}
+
+	return idToken, nil
+}
+
+// queryUser retrieves a user of the request's tenant by ID.
+func (s *service) queryUser(ctx context.Context, userID string) (*genuser.User, error) {
+	return userstore.QueryTenantUser(ctx, s.userStore, tenancy.FromContext(ctx), userID)
+}
+
+// activeUser retrieves a user of the request's tenant, failing unless the account is
+// active. Inactive and suspended users can't sign in or refresh their tokens.
+func (s *service) activeUser(ctx context.Context, userID string) (*genuser.User, error) {
+	user, err := s.queryUser(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query user error", "userId", userID, "error", err)
+		return nil, genauth.MakeNotFound(err)
+	}
+	if user.Status != userdomain.UserStatusActive {
+		logger.FromContext(ctx).Infow("user not active", "userId", userID, "status", user.Status)
+		return nil, genauth.MakeInvalidCredentials(fmt.Errorf("user account is %s", user.Status))
+	}
+	return user, nil
 }
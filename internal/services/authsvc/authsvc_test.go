@@ -0,0 +1,178 @@
+package authsvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genauth "github.com/iamBelugaa/goa-iam/gen/auth"
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc"
+	linkmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/samlsp"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	credentialmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	orgmemorystore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/scimsvc"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestAuthService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Auth Service Suite")
+}
+
+// authService is the generated service interface together with its JWTAuth handler.
+type authService interface {
+	genauth.Service
+	genauth.Auther
+}
+
+// Generating the RSA signing keys is slow, so they're shared by every spec.
+var (
+	idTokens *idtoken.Signer
+	samlSP   *samlsp.ServiceProvider
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Auth service", func() {
+	var (
+		ctx        context.Context
+		svc        authService
+		scim       genscim.Service
+		userStore  userstore.UserStorer
+		auditStore auditstore.AuditStorer
+	)
+
+	signup := func(email, password string) {
+		_, err := svc.Signup(ctx, &genauth.SignupRequest{
+			FirstName: "Ada", LastName: "Lovelace", Email: email, Password: password, ConfirmPassword: password,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	signins := func() []*auditstore.Event {
+		events, err := auditStore.Query(ctx, auditstore.Filter{Types: []string{audit.EventSignin}})
+		Expect(err).NotTo(HaveOccurred())
+		return events
+	}
+
+	BeforeEach(func() {
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+		ctx = logger.WithContext(context.Background(), log)
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm := tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		if idTokens == nil {
+			idTokens, err = idtoken.NewSigner(authCfg, &config.OIDC{IDTokenExpTime: time.Hour})
+			Expect(err).NotTo(HaveOccurred())
+			samlSP, err = samlsp.NewServiceProvider(&config.SAML{BaseURL: "https://iam.test/saml", RequestExpTime: time.Minute})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		userStore = usermemorystore.NewMemoryStore()
+		groupStore := groupmemorystore.NewMemoryStore()
+		auditStore = auditmemorystore.NewMemoryStore()
+		recorder := audit.NewRecorder(log, auditStore)
+		svc = authsvc.NewService(
+			userStore, orgmemorystore.NewMemoryStore(), credentialmemorystore.NewMemoryStore(),
+			linkmemorystore.NewMemoryStore(), tm, idTokens, sessions,
+			membership.NewResolver(userStore, groupStore), authn, samlSP,
+			recorder,
+			&config.WebAuthn{RPID: "localhost", RPDisplayName: "IAM", RPOrigins: []string{"http://localhost:8080"}, ChallengeTimeout: time.Minute},
+			&config.Federation{CallbackURL: "http://localhost:8080/api/v1/auth/federation/callback", StateExpTime: time.Minute},
+		)
+		scim = scimsvc.NewService(
+			userStore, groupStore, sessions, authn, recorder, &config.SCIM{BaseURL: "https://iam.test/scim/v2", MaxResults: 10},
+		)
+	})
+
+	Describe("Signin", func() {
+		It("issues tokens for the password the user signed up with", func() {
+			signup("ada@example.com", "Passw0rd!23")
+
+			res, err := svc.Signin(ctx, &genauth.SigninRequest{Email: "ada@example.com", Password: "Passw0rd!23"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Data.AccessToken).NotTo(BeEmpty())
+			Expect(res.Data.RefreshToken).NotTo(BeEmpty())
+
+			events := signins()
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Outcome).To(Equal(audit.OutcomeSuccess))
+		})
+
+		It("rejects a wrong password and audits the failed signin", func() {
+			signup("ada@example.com", "Passw0rd!23")
+			user, err := userStore.QueryByEmail(ctx, "", "ada@example.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Signin(ctx, &genauth.SigninRequest{Email: "ada@example.com", Password: "wrong-password"})
+			Expect(errorName(err)).To(Equal("invalid_credentials"))
+
+			events := signins()
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Outcome).To(Equal(audit.OutcomeFailure))
+			Expect(events[0].ActorID).To(Equal(user.ID))
+		})
+
+		It("rejects users deactivated through SCIM", func() {
+			signup("ada@example.com", "Passw0rd!23")
+			signin, err := svc.Signin(ctx, &genauth.SigninRequest{Email: "ada@example.com", Password: "Passw0rd!23"})
+			Expect(err).NotTo(HaveOccurred())
+			user, err := userStore.QueryByEmail(ctx, "", "ada@example.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = scim.PatchUser(ctx, &genscim.ScimPatchRequest{
+				ID:         user.ID,
+				Schemas:    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+				Operations: []*genscim.ScimPatchOperation{{Op: "replace", Path: ptr("active"), Value: false}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Signin(ctx, &genauth.SigninRequest{Email: "ada@example.com", Password: "Passw0rd!23"})
+			Expect(errorName(err)).To(Equal("invalid_credentials"))
+
+			_, err = svc.Refresh(ctx, &genauth.RefreshRequest{RefreshToken: signin.Data.RefreshToken})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
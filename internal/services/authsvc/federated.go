@@ -0,0 +1,324 @@
+package authsvc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	genauth "github.com/iamBelugaa/goa-iam/gen/auth"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/orgsvc"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// ListIdentityProviders returns the upstream identity providers users can sign in with.
+func (s *service) ListIdentityProviders(ctx context.Context) (*genauth.ListIdentityProvidersResponse, error) {
+	logger.FromContext(ctx).Infow("list identity providers request received")
+
+	providers := s.federation.Providers()
+	data := make([]*genauth.IdentityProvider, 0, len(providers))
+	for _, p := range providers {
+		data = append(data, &genauth.IdentityProvider{ID: p.ID, Name: p.Name})
+	}
+
+	logger.FromContext(ctx).Infow("list identity providers request successful", "totalProviders", len(data))
+	return &genauth.ListIdentityProvidersResponse{
+		Success: true,
+		Message: "Identity providers fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// BeginFederatedSignin starts a signin with an upstream identity provider. The signin is
+// bound to the client it's started in, see FederatedCallback.
+func (s *service) BeginFederatedSignin(ctx context.Context, req *genauth.FederatedSigninRequest) (*genauth.FederatedBeginResponse, error) {
+	logger.FromContext(ctx).Infow("begin federated signin request received", "provider", req.Provider)
+
+	authReq, binding, err := s.beginFederation(ctx, req.Provider, "")
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("begin federated signin request successful", "provider", req.Provider)
+	return &genauth.FederatedBeginResponse{
+		Success: true,
+		Message: "Federated signin started successfully",
+		Data:    authReq,
+		Binding: binding,
+	}, nil
+}
+
+// BeginIdentityLink starts linking an upstream identity to the authenticated user. The link
+// is bound to the client it's started in, so the user can't be tricked into completing it
+// with another person's identity.
+func (s *service) BeginIdentityLink(ctx context.Context, req *genauth.LinkIdentityRequest) (*genauth.FederatedBeginResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("begin identity link request received", "userId", claims.Subject, "provider", req.Provider)
+
+	authReq, binding, err := s.beginFederation(ctx, req.Provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("begin identity link request successful", "userId", claims.Subject, "provider", req.Provider)
+	return &genauth.FederatedBeginResponse{
+		Success: true,
+		Message: "Identity link started successfully",
+		Data:    authReq,
+		Binding: binding,
+	}, nil
+}
+
+// FederatedCallback completes a signin or link with an upstream identity provider, in the
+// client holding the binding issued when it started. Identities are resolved to local users
+// through an existing link, an explicit link request, or a local user with the same email
+// address when both the provider and the user verified it.
+func (s *service) FederatedCallback(ctx context.Context, req *genauth.FederatedCallbackRequest) (*genauth.TokenResponse, error) {
+	logger.FromContext(ctx).Infow("federated callback request received")
+
+	if req.Error != nil {
+		logger.FromContext(ctx).Infow("federated callback error", "error", *req.Error)
+		return nil, genauth.MakeInvalidCredentials(fmt.Errorf("identity provider returned %s", *req.Error))
+	}
+	if req.Code == nil || *req.Code == "" {
+		return nil, genauth.MakeBadRequest(fmt.Errorf("code is required"))
+	}
+
+	var binding string
+	if req.Binding != nil {
+		binding = *req.Binding
+	}
+	identity, err := s.federation.Finish(ctx, req.State, binding, *req.Code)
+	if err != nil {
+		logger.FromContext(ctx).Infow("finish federated signin error", "error", err)
+		s.recordSignin(ctx, "", map[string]string{"method": signinMethodFederated}, err)
+		if errors.Is(err, federation.ErrStateMismatch) || errors.Is(err, federation.ErrUnknownProvider) {
+			return nil, genauth.MakeBadRequest(err)
+		}
+		return nil, genauth.MakeInvalidCredentials(err)
+	}
+
+	// Providers redirect to a single callback URL, so the signin completes in the tenant it started in.
+	ctx = tenancy.WithTenant(ctx, identity.TenantID)
+
+	details := map[string]string{"method": signinMethodFederated, "provider": identity.ProviderID}
+	userID, err := s.resolveIdentity(ctx, identity, false)
+	if err != nil {
+		s.recordSignin(ctx, "", details, err)
+		return nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, userID)
+	s.recordSignin(ctx, userID, details, err)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("federated callback request successful", "userId", userID, "provider", identity.ProviderID)
+	return &genauth.TokenResponse{
+		Success: true,
+		Message: "Signed in user successfully",
+		Data:    tokens,
+	}, nil
+}
+
+// ListLinkedIdentities returns the upstream identities linked to the authenticated user.
+func (s *service) ListLinkedIdentities(ctx context.Context, req *genauth.ListLinkedIdentitiesRequest) (*genauth.ListLinkedIdentitiesResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("list linked identities request received", "userId", claims.Subject)
+
+	links, err := s.federation.Links(ctx, claims.Subject)
+	if err != nil {
+		logger.FromContext(ctx).Infow("list linked identities error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeInternalServerError(err)
+	}
+
+	data := make([]*genauth.LinkedIdentity, 0, len(links))
+	for _, link := range links {
+		data = append(data, &genauth.LinkedIdentity{
+			Provider: link.ProviderID,
+			Subject:  link.Subject,
+			Email:    link.Email,
+			LinkedAt: link.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	logger.FromContext(ctx).Infow("list linked identities request successful", "userId", claims.Subject, "totalIdentities", len(data))
+	return &genauth.ListLinkedIdentitiesResponse{
+		Success: true,
+		Message: "Linked identities fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// UnlinkIdentity removes the link between the authenticated user and an upstream identity provider.
+func (s *service) UnlinkIdentity(ctx context.Context, req *genauth.UnlinkIdentityRequest) (*genauth.UnlinkIdentityResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("unlink identity request received", "userId", claims.Subject, "provider", req.Provider)
+
+	if err := s.federation.Unlink(ctx, claims.Subject, req.Provider); err != nil {
+		logger.FromContext(ctx).Infow("unlink identity error", "userId", claims.Subject, "provider", req.Provider, "error", err)
+		return nil, genauth.MakeNotFound(err)
+	}
+
+	logger.FromContext(ctx).Infow("unlink identity request successful", "userId", claims.Subject, "provider", req.Provider)
+	return &genauth.UnlinkIdentityResponse{
+		Success: true,
+		Message: "Identity unlinked successfully",
+	}, nil
+}
+
+// beginFederation starts an authorization request with a provider, linking the returned
+// identity to linkUserID when it's set. It returns the request together with the binding
+// the client must present to complete it.
+func (s *service) beginFederation(ctx context.Context, providerID, linkUserID string) (*genauth.FederatedAuthorization, string, error) {
+	authReq, err := s.federation.Begin(ctx, providerID, linkUserID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("begin federation error", "provider", providerID, "error", err)
+		if errors.Is(err, federation.ErrUnknownProvider) {
+			return nil, "", genauth.MakeNotFound(err)
+		}
+		return nil, "", genauth.MakeInternalServerError(err)
+	}
+
+	return &genauth.FederatedAuthorization{
+		AuthorizationURL: authReq.URL,
+		State:            authReq.State,
+		ExpiresAt:        authReq.ExpiresAt.Format(time.RFC3339),
+	}, authReq.Binding, nil
+}
+
+// resolveIdentity returns the local user of the identity's tenant a verified identity signs
+// in as. When the provider verified the user's address, the address is marked verified and
+// the user is granted the admin role if it's one of the tenant's admin emails.
+func (s *service) resolveIdentity(ctx context.Context, identity *federation.Identity, provision bool) (string, error) {
+	userID, err := s.matchIdentity(ctx, identity, provision)
+	if err != nil {
+		return "", err
+	}
+	if !identity.EmailVerified {
+		return userID, nil
+	}
+
+	user, err := userstore.QueryTenantUser(ctx, s.userStore, identity.TenantID, userID)
+	if err != nil || !strings.EqualFold(user.Email, identity.Email) {
+		return userID, nil
+	}
+	if !user.EmailVerified {
+		if user, err = s.userStore.VerifyEmail(ctx, user.ID); err != nil {
+			logger.FromContext(ctx).Infow("verify email error", "userId", userID, "error", err)
+			return "", genauth.MakeInternalServerError(err)
+		}
+	}
+	if _, err := orgsvc.GrantAdminEmail(ctx, s.orgStore, s.userStore, user); err != nil {
+		logger.FromContext(ctx).Infow("grant admin role error", "userId", userID, "error", err)
+		return "", genauth.MakeInternalServerError(err)
+	}
+	return userID, nil
+}
+
+// matchIdentity returns the local user of the identity's tenant a verified identity signs
+// in as, linking the identity first when it was explicitly requested or matched by an email
+// both the provider and the local user verified. When provision is set, identities matching
+// no local user get a new account.
+func (s *service) matchIdentity(ctx context.Context, identity *federation.Identity, provision bool) (string, error) {
+	if identity.LinkUserID != "" {
+		if _, err := s.federation.Link(ctx, identity, identity.LinkUserID); err != nil {
+			logger.FromContext(ctx).Infow("link identity error", "userId", identity.LinkUserID, "provider", identity.ProviderID, "error", err)
+			return "", genauth.MakeConflict(err)
+		}
+		logger.FromContext(ctx).Infow("identity linked", "userId", identity.LinkUserID, "provider", identity.ProviderID)
+		return identity.LinkUserID, nil
+	}
+
+	if link, err := s.federation.Lookup(ctx, identity); err == nil {
+		return link.UserID, nil
+	}
+
+	// Unverified emails could be claimed by anyone at the provider, so they never match an account.
+	if !identity.EmailVerified || identity.Email == "" {
+		return "", genauth.MakeNotFound(fmt.Errorf("identity isn't linked to an account"))
+	}
+
+	user, err := s.userStore.QueryByEmail(ctx, identity.TenantID, identity.Email)
+	if err != nil && provision {
+		return s.provisionUser(ctx, identity)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Infow("query user error", "email", identity.Email, "error", err)
+		return "", genauth.MakeNotFound(fmt.Errorf("identity isn't linked to an account"))
+	}
+
+	// Anyone can register with any address, so only accounts that proved owning it are linked.
+	if !user.EmailVerified {
+		logger.FromContext(ctx).Infow("identity matches unverified email", "userId", user.ID, "provider", identity.ProviderID)
+		return "", genauth.MakeConflict(fmt.Errorf("account with the identity's email hasn't verified it, sign in and link the identity instead"))
+	}
+
+	if _, err := s.federation.Link(ctx, identity, user.ID); err != nil {
+		logger.FromContext(ctx).Infow("link identity error", "userId", user.ID, "provider", identity.ProviderID, "error", err)
+		return "", genauth.MakeConflict(err)
+	}
+
+	logger.FromContext(ctx).Infow("identity linked by verified email", "userId", user.ID, "provider", identity.ProviderID)
+	return user.ID, nil
+}
+
+// provisionUser creates a local user for a verified identity and links the identity to it.
+// Provisioned users sign in through their identity provider, so they get a random password.
+func (s *service) provisionUser(ctx context.Context, identity *federation.Identity) (string, error) {
+	password, err := randomPassword()
+	if err != nil {
+		logger.FromContext(ctx).Infow("generate password error", "provider", identity.ProviderID, "error", err)
+		return "", genauth.MakeInternalServerError(err)
+	}
+
+	user, err := s.userStore.Create(ctx, identity.TenantID, &genuser.CreateUserRequest{
+		FirstName: identity.GivenName,
+		LastName:  identity.FamilyName,
+		Email:     identity.Email,
+		Password:  password,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("provision user error", "email", identity.Email, "error", err)
+		return "", genauth.MakeConflict(err)
+	}
+
+	if _, err := s.federation.Link(ctx, identity, user.ID); err != nil {
+		logger.FromContext(ctx).Infow("link identity error", "userId", user.ID, "provider", identity.ProviderID, "error", err)
+		return "", genauth.MakeConflict(err)
+	}
+
+	logger.FromContext(ctx).Infow("user provisioned for identity", "userId", user.ID, "provider", identity.ProviderID)
+	return user.ID, nil
+}
+
+// randomPassword returns 32 random bytes encoded as unpadded base64url.
+func randomPassword() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
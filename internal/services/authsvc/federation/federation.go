@@ -0,0 +1,246 @@
+// Package federation implements the relying party side of the OpenID Connect
+// authorization code flow used to sign in through upstream identity providers.
+package federation
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	linkstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+)
+
+// Errors returned when a federated signin fails.
+var (
+	ErrUnknownProvider = errors.New("identity provider isn't configured")
+	ErrStateMismatch   = errors.New("state not issued to this client, expired or already used")
+	ErrProviderFailed  = errors.New("identity provider request failed")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+)
+
+// ProviderInfo describes a configured identity provider to clients.
+type ProviderInfo struct {
+	ID   string
+	Name string
+}
+
+// AuthorizationRequest holds the provider URL the user must be sent to. The binding is a
+// secret only handed to the client that started the request, such as in a cookie, which
+// it must present together with the state to complete it.
+type AuthorizationRequest struct {
+	ProviderID string
+	URL        string
+	State      string
+	Binding    string
+	ExpiresAt  time.Time
+}
+
+// Identity is the verified identity returned by a provider.
+type Identity struct {
+	ProviderID    string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	TenantID      string // Tenant the signin or link was started in
+	LinkUserID    string // Local user that asked to link this identity, empty for signins
+}
+
+// Broker sends users to upstream identity providers, verifies the identities they
+// return and manages the links between upstream subjects and local users.
+type Broker struct {
+	cfg       *config.Federation   // Callback URL and state lifetime
+	store     linkstore.LinkStorer // Storage for identity links
+	providers map[string]*provider // Configured providers by ID
+	order     []string             // Provider IDs in configuration order
+	states    *stateCache          // Outstanding authorization requests
+}
+
+// NewBroker creates a broker for the configured providers. The HTTP client is used for
+// every call to a provider, and http.DefaultClient is used when it's nil.
+func NewBroker(cfg *config.Federation, store linkstore.LinkStorer, client *http.Client) *Broker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	b := &Broker{
+		cfg:       cfg,
+		store:     store,
+		providers: make(map[string]*provider, len(cfg.Providers)),
+		states:    newStateCache(),
+	}
+	for _, p := range cfg.Providers {
+		b.providers[p.ID] = &provider{cfg: p, client: client}
+		b.order = append(b.order, p.ID)
+	}
+
+	return b
+}
+
+// Providers returns the configured identity providers.
+func (b *Broker) Providers() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(b.order))
+	for _, id := range b.order {
+		p := b.providers[id]
+		infos = append(infos, ProviderInfo{ID: p.cfg.ID, Name: p.cfg.Name})
+	}
+	return infos
+}
+
+// Begin starts an authorization code flow with the given provider. When linkUserID is set
+// the identity returned by the provider is linked to that user instead of signing in. The
+// flow completes in the tenant of ctx, whichever tenant the callback is routed to.
+func (b *Broker) Begin(ctx context.Context, providerID, linkUserID string) (*AuthorizationRequest, error) {
+	p, ok := b.providers[providerID]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderFailed, err)
+	}
+
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	binding, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(b.cfg.StateExpTime)
+	state, err := b.states.issue(pendingSignin{
+		providerID:   providerID,
+		nonce:        nonce,
+		codeVerifier: verifier,
+		binding:      binding,
+		tenantID:     tenancy.FromContext(ctx),
+		linkUserID:   linkUserID,
+		expiresAt:    expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate state : %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {b.cfg.CallbackURL},
+		"scope":                 {strings.Join(p.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	authURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderFailed, err)
+	}
+	existing := authURL.Query()
+	for key, values := range query {
+		existing[key] = values
+	}
+	authURL.RawQuery = existing.Encode()
+
+	return &AuthorizationRequest{
+		ProviderID: providerID,
+		URL:        authURL.String(),
+		State:      state,
+		Binding:    binding,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// Finish completes the authorization code flow for the given state, redeeming the code
+// at the provider and verifying the returned ID token. The binding must be the one issued
+// with the state, so a state can't complete a flow in another client than the one that
+// started it.
+func (b *Broker) Finish(ctx context.Context, state, binding, code string) (*Identity, error) {
+	pending, ok := b.states.consume(state)
+	if !ok || subtle.ConstantTimeCompare([]byte(pending.binding), []byte(binding)) != 1 {
+		return nil, ErrStateMismatch
+	}
+
+	p, ok := b.providers[pending.providerID]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderFailed, err)
+	}
+
+	idToken, err := p.exchange(ctx, meta, code, b.cfg.CallbackURL, pending.codeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderFailed, err)
+	}
+
+	claims, err := p.verify(ctx, meta, idToken, pending.nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	return &Identity{
+		ProviderID:    p.cfg.ID,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.emailVerified(),
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		TenantID:      pending.tenantID,
+		LinkUserID:    pending.linkUserID,
+	}, nil
+}
+
+// Lookup returns the link of a verified identity, if it has been linked to a local user of
+// the identity's tenant.
+func (b *Broker) Lookup(ctx context.Context, identity *Identity) (*linkstore.Link, error) {
+	return b.store.QueryBySubject(ctx, identity.TenantID, identity.ProviderID, identity.Subject)
+}
+
+// Link connects a verified identity to a local user of the identity's tenant.
+func (b *Broker) Link(ctx context.Context, identity *Identity, userID string) (*linkstore.Link, error) {
+	link := &linkstore.Link{
+		TenantID:   identity.TenantID,
+		ProviderID: identity.ProviderID,
+		Subject:    identity.Subject,
+		UserID:     userID,
+		Email:      identity.Email,
+		CreatedAt:  time.Now(),
+	}
+	if err := b.store.Create(ctx, link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Links returns the identities linked to a local user.
+func (b *Broker) Links(ctx context.Context, userID string) ([]*linkstore.Link, error) {
+	return b.store.QueryByUser(ctx, userID)
+}
+
+// Unlink removes the link between a local user and a provider.
+func (b *Broker) Unlink(ctx context.Context, userID, providerID string) error {
+	return b.store.Delete(ctx, userID, providerID)
+}
@@ -0,0 +1,345 @@
+package federation_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation"
+	linkmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store/memory"
+)
+
+func TestFederation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Federation Suite")
+}
+
+// stubGrant is an authorization code the stub provider will redeem.
+type stubGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// stubProvider is a minimal OpenID provider serving discovery, a key set and a
+// token endpoint that issues RS256 signed ID tokens for registered codes.
+type stubProvider struct {
+	server       *httptest.Server
+	clientID     string
+	clientSecret string
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey // published signing key
+	kid    string          // ID of the published key
+	signer *rsa.PrivateKey // key ID tokens are signed with, the published key when nil
+	grants map[string]stubGrant
+}
+
+func newStubProvider(clientID, clientSecret string) *stubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	p := &stubProvider{
+		key:          key,
+		kid:          "stub-key",
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		grants:       make(map[string]stubGrant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": p.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+// token redeems a registered code after checking client authentication and PKCE.
+func (p *stubProvider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != p.clientID || secret != p.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	grant, ok := p.grants[r.PostFormValue("code")]
+	delete(p.grants, r.PostFormValue("code"))
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	token.Header["kid"] = p.kid
+	signer := p.signer
+	if signer == nil {
+		signer = p.key
+	}
+	idToken, err := token.SignedString(signer)
+	Expect(err).NotTo(HaveOccurred())
+
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "upstream", "token_type": "Bearer", "id_token": idToken})
+}
+
+// authorize plays the part of the user authenticating at the provider: it registers a code
+// for the authorization request at authURL and returns it. Claims override the defaults.
+func (p *stubProvider) authorize(authURL string, claims jwt.MapClaims) string {
+	u, err := url.Parse(authURL)
+	Expect(err).NotTo(HaveOccurred())
+
+	query := u.Query()
+	Expect(query.Get("client_id")).To(Equal(p.clientID))
+	Expect(query.Get("code_challenge_method")).To(Equal("S256"))
+
+	defaults := jwt.MapClaims{
+		"iss":            p.server.URL,
+		"sub":            "upstream-subject",
+		"aud":            p.clientID,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          query.Get("nonce"),
+		"email":          "ada@example.com",
+		"email_verified": true,
+	}
+	for name, value := range claims {
+		defaults[name] = value
+	}
+
+	code := "code-" + query.Get("state")[:8]
+	p.mu.Lock()
+	p.grants[code] = stubGrant{challenge: query.Get("code_challenge"), claims: defaults}
+	p.mu.Unlock()
+
+	return code
+}
+
+// rotate replaces the published signing key.
+func (p *stubProvider) rotate(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key, p.kid = key, kid
+}
+
+// signWith makes the provider sign ID tokens with a key it doesn't publish.
+func (p *stubProvider) signWith(key *rsa.PrivateKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.signer = key
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	Expect(json.NewEncoder(w).Encode(v)).To(Succeed())
+}
+
+var _ = Describe("Broker", func() {
+	const (
+		userID      = "4d2efde6-448a-4c26-a69a-26c2f9a6de4a"
+		callbackURL = "http://localhost:8080/api/v1/auth/federation/callback"
+	)
+
+	var (
+		ctx      context.Context
+		stub     *stubProvider
+		broker   *federation.Broker
+		identity *federation.Identity
+	)
+
+	signin := func(linkUserID string, claims jwt.MapClaims) (*federation.Identity, error) {
+		req, err := broker.Begin(ctx, "stub", linkUserID)
+		Expect(err).NotTo(HaveOccurred())
+		return broker.Finish(ctx, req.State, req.Binding, stub.authorize(req.URL, claims))
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		stub = newStubProvider("iam-client", "s3cr3t")
+		DeferCleanup(stub.server.Close)
+
+		broker = federation.NewBroker(&config.Federation{
+			Providers: []config.IdentityProvider{{
+				ID:           "stub",
+				Name:         "Stub",
+				Issuer:       stub.server.URL,
+				ClientID:     "iam-client",
+				ClientSecret: "s3cr3t",
+			}},
+			CallbackURL:  callbackURL,
+			StateExpTime: time.Minute,
+		}, linkmemorystore.NewMemoryStore(), nil)
+	})
+
+	It("lists the configured providers", func() {
+		Expect(broker.Providers()).To(Equal([]federation.ProviderInfo{{ID: "stub", Name: "Stub"}}))
+	})
+
+	It("sends the user to the provider's authorization endpoint", func() {
+		req, err := broker.Begin(ctx, "stub", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		u, err := url.Parse(req.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Path).To(Equal("/authorize"))
+		Expect(u.Query().Get("redirect_uri")).To(Equal(callbackURL))
+		Expect(u.Query().Get("scope")).To(Equal("openid email profile"))
+		Expect(u.Query().Get("state")).To(Equal(req.State))
+		Expect(u.Query().Get("nonce")).NotTo(BeEmpty())
+	})
+
+	It("rejects unknown providers", func() {
+		_, err := broker.Begin(ctx, "unknown", "")
+		Expect(err).To(MatchError(federation.ErrUnknownProvider))
+	})
+
+	It("returns the verified identity from the provider", func() {
+		var err error
+		identity, err = signin(userID, jwt.MapClaims{"name": "Ada Lovelace"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.ProviderID).To(Equal("stub"))
+		Expect(identity.Subject).To(Equal("upstream-subject"))
+		Expect(identity.Email).To(Equal("ada@example.com"))
+		Expect(identity.EmailVerified).To(BeTrue())
+		Expect(identity.Name).To(Equal("Ada Lovelace"))
+		Expect(identity.LinkUserID).To(Equal(userID))
+	})
+
+	It("accepts each state only once", func() {
+		req, err := broker.Begin(ctx, "stub", "")
+		Expect(err).NotTo(HaveOccurred())
+		code := stub.authorize(req.URL, nil)
+
+		_, err = broker.Finish(ctx, req.State, req.Binding, code)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = broker.Finish(ctx, req.State, req.Binding, code)
+		Expect(err).To(MatchError(federation.ErrStateMismatch))
+	})
+
+	It("only completes a request in the client that started it", func() {
+		req, err := broker.Begin(ctx, "stub", userID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.Binding).NotTo(BeEmpty())
+		code := stub.authorize(req.URL, nil)
+
+		// A state lured into another browser arrives without the binding of the request.
+		_, err = broker.Finish(ctx, req.State, "", code)
+		Expect(err).To(MatchError(federation.ErrStateMismatch))
+
+		// The state is consumed by the failed attempt.
+		_, err = broker.Finish(ctx, req.State, req.Binding, code)
+		Expect(err).To(MatchError(federation.ErrStateMismatch))
+	})
+
+	It("rejects ID tokens with the wrong nonce, audience or issuer", func() {
+		for _, claims := range []jwt.MapClaims{
+			{"nonce": "replayed"},
+			{"aud": "another-client"},
+			{"iss": "https://evil.test"},
+			{"exp": time.Now().Add(-time.Minute).Unix()},
+		} {
+			_, err := signin("", claims)
+			Expect(err).To(MatchError(federation.ErrInvalidIDToken))
+		}
+	})
+
+	It("refetches the key set when the provider rotates its key", func() {
+		_, err := signin("", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		stub.rotate("rotated")
+		_, err = signin("", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects ID tokens signed with a key the provider doesn't publish", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		stub.signWith(key)
+
+		_, err = signin("", nil)
+		Expect(err).To(MatchError(federation.ErrInvalidIDToken))
+	})
+
+	It("fails when the provider rejects the code", func() {
+		req, err := broker.Begin(ctx, "stub", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = broker.Finish(ctx, req.State, req.Binding, "forged")
+		Expect(err).To(MatchError(federation.ErrProviderFailed))
+	})
+
+	Context("with a verified identity", func() {
+		BeforeEach(func() {
+			var err error
+			identity, err = signin("", nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("links the identity to a single user", func() {
+			_, err := broker.Lookup(ctx, identity)
+			Expect(err).To(HaveOccurred())
+
+			link, err := broker.Link(ctx, identity, userID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link.Email).To(Equal("ada@example.com"))
+
+			found, err := broker.Lookup(ctx, identity)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.UserID).To(Equal(userID))
+
+			_, err = broker.Link(ctx, identity, "another-user")
+			Expect(err).To(HaveOccurred())
+
+			links, err := broker.Links(ctx, userID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(links).To(HaveLen(1))
+
+			Expect(broker.Unlink(ctx, userID, "stub")).To(Succeed())
+			_, err = broker.Lookup(ctx, identity)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
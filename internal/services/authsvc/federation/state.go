@@ -0,0 +1,78 @@
+package federation
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// pendingSignin holds the server side state of an authorization request sent to a provider.
+type pendingSignin struct {
+	providerID   string    // Provider the user was sent to
+	nonce        string    // Nonce the ID token must carry
+	codeVerifier string    // PKCE verifier for the authorization code
+	binding      string    // Secret the client that started the flow must present
+	tenantID     string    // Tenant the flow was started in
+	linkUserID   string    // Local user to link the identity to, empty for signins
+	expiresAt    time.Time // Time after which the state is no longer accepted
+}
+
+// stateCache keeps outstanding authorization requests in memory until they are consumed or expire.
+type stateCache struct {
+	mu      sync.Mutex               // protects access to pending
+	pending map[string]pendingSignin // maps state values to their requests
+}
+
+// newStateCache creates an empty state cache.
+func newStateCache() *stateCache {
+	return &stateCache{pending: make(map[string]pendingSignin)}
+}
+
+// issue generates a new random state value and binds it to the given request.
+func (c *stateCache) issue(p pendingSignin) (string, error) {
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired requests so abandoned signins don't accumulate.
+	now := time.Now()
+	for key, existing := range c.pending {
+		if now.After(existing.expiresAt) {
+			delete(c.pending, key)
+		}
+	}
+
+	c.pending[state] = p
+	return state, nil
+}
+
+// consume removes the state from the cache and returns its request if it exists and has not expired.
+func (c *stateCache) consume(state string) (pendingSignin, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[state]
+	if !ok {
+		return pendingSignin{}, false
+	}
+	delete(c.pending, state)
+
+	if time.Now().After(p.expiresAt) {
+		return pendingSignin{}, false
+	}
+	return p, true
+}
+
+// randomString returns 32 random bytes encoded as unpadded base64url.
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
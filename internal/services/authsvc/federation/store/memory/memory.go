@@ -0,0 +1,92 @@
+// Package linkstore provides an in-memory implementation of the LinkStorer interface.
+package linkstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	linkstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/federation/store"
+)
+
+// memory implements the LinkStorer interface using an in-memory map.
+type memory struct {
+	mu    sync.RWMutex               // protects access to links
+	links map[string]*linkstore.Link // stores links by tenant, provider ID and subject
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory link store.
+func NewMemoryStore() *memory {
+	return &memory{links: make(map[string]*linkstore.Link)}
+}
+
+// Create adds a new link to the in-memory store.
+func (m *memory) Create(ctx context.Context, link *linkstore.Link) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := linkKey(link.TenantID, link.ProviderID, link.Subject)
+	if _, exists := m.links[key]; exists {
+		return fmt.Errorf("identity is already linked to a user")
+	}
+	for _, existing := range m.links {
+		if existing.UserID == link.UserID && existing.ProviderID == link.ProviderID {
+			return fmt.Errorf("user is already linked to provider %s", link.ProviderID)
+		}
+	}
+
+	stored := *link
+	m.links[key] = &stored
+
+	return nil
+}
+
+// QueryBySubject retrieves a link of a tenant from memory by provider ID and subject.
+func (m *memory) QueryBySubject(ctx context.Context, tenantID, providerID, subject string) (*linkstore.Link, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	link, ok := m.links[linkKey(tenantID, providerID, subject)]
+	if !ok {
+		return nil, fmt.Errorf("identity isn't linked to a user")
+	}
+
+	found := *link
+	return &found, nil
+}
+
+// QueryByUser returns all links of the given user currently stored in memory.
+func (m *memory) QueryByUser(ctx context.Context, userID string) ([]*linkstore.Link, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	links := make([]*linkstore.Link, 0)
+	for _, link := range m.links {
+		if link.UserID == userID {
+			found := *link
+			links = append(links, &found)
+		}
+	}
+
+	return links, nil
+}
+
+// Delete removes the link between a user and a provider from memory.
+func (m *memory) Delete(ctx context.Context, userID, providerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, link := range m.links {
+		if link.UserID == userID && link.ProviderID == providerID {
+			delete(m.links, key)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user isn't linked to provider %s", providerID)
+}
+
+// linkKey returns the map key for a provider subject within a tenant.
+func linkKey(tenantID, providerID, subject string) string {
+	return tenantID + "|" + providerID + "|" + subject
+}
@@ -0,0 +1,34 @@
+// Package linkstore defines the interface for interacting with the federated identity link storage layer.
+package linkstore
+
+import (
+	"context"
+	"time"
+)
+
+// Link connects the subject of an upstream identity provider to a local user. The same
+// subject can be linked to one user in each tenant.
+type Link struct {
+	TenantID   string    // ID of the tenant the local user belongs to
+	ProviderID string    // ID of the configured identity provider
+	Subject    string    // Subject identifier issued by the identity provider
+	UserID     string    // ID of the local user the identity is linked to
+	Email      string    // Email address reported by the identity provider when linked
+	CreatedAt  time.Time // Time the identity was linked
+}
+
+// LinkStorer defines the contract for managing federated identity links in a storage backend.
+type LinkStorer interface {
+	// Create stores a new link. A provider subject can only be linked to one user per
+	// tenant, and a user can only be linked to one subject of each provider.
+	Create(ctx context.Context, link *Link) error
+
+	// QueryBySubject retrieves the link for a subject of the given provider within a tenant.
+	QueryBySubject(ctx context.Context, tenantID, providerID, subject string) (*Link, error)
+
+	// QueryByUser returns all links of the given user.
+	QueryByUser(ctx context.Context, userID string) ([]*Link, error)
+
+	// Delete removes the link between a user and the given provider.
+	Delete(ctx context.Context, userID, providerID string) error
+}
@@ -0,0 +1,261 @@
+// Package idtoken issues OpenID Connect ID tokens and exposes the keys that verify them.
+package idtoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+)
+
+// Standard OpenID Connect scopes.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// AllScopes lists every standard scope, used for first-party tokens which aren't
+// restricted by a client's grant.
+var AllScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// UserClaims holds the standard claims describing a user (OpenID Connect Core section 5.1).
+type UserClaims struct {
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// UserClaimsFor returns the standard claims of the user that the given scopes allow
+// to be released: profile claims for the profile scope and email claims for the email scope.
+func UserClaimsFor(user *genuser.User, scopes []string) UserClaims {
+	var claims UserClaims
+
+	if slices.Contains(scopes, ScopeProfile) {
+		claims.GivenName = user.FirstName
+		claims.FamilyName = user.LastName
+		claims.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	if slices.Contains(scopes, ScopeEmail) {
+		verified := user.EmailVerified
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+
+	return claims
+}
+
+// Claims are the claims of an ID token (OpenID Connect Core section 2).
+type Claims struct {
+	jwt.RegisteredClaims
+	UserClaims
+	Nonce     string           `json:"nonce,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"`
+	AtHash    string           `json:"at_hash,omitempty"`
+	SessionID string           `json:"sid,omitempty"`
+}
+
+// Params describes the authentication an ID token is issued for.
+type Params struct {
+	User        *genuser.User // Authenticated user
+	Audience    string        // Client the token is issued to, the default audience when empty
+	Scopes      []string      // Granted scopes deciding which user claims are released
+	Nonce       string        // Nonce from the authentication request
+	AuthTime    time.Time     // Time the user authenticated
+	SessionID   string        // Session the authentication belongs to
+	AccessToken string        // Access token issued alongside, hashed into at_hash
+}
+
+// JWK is an RSA public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Signer signs ID tokens with an RSA key using RS256.
+type Signer struct {
+	issuer   string          // Issuer identifier placed in the iss claim
+	audience string          // Audience of first-party ID tokens
+	ttl      time.Duration   // Lifetime of issued ID tokens
+	key      *rsa.PrivateKey // Signing key
+	jwk      JWK             // Public key of the signing key
+}
+
+// NewSigner creates a signer using the key configured in cfg, or a freshly generated
+// key when none is configured. Tokens signed with a generated key can't be verified
+// after a restart, so generated keys are only suitable for development.
+func NewSigner(authCfg *config.Auth, cfg *config.OIDC) (*Signer, error) {
+	key, err := loadKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{
+		issuer:   authCfg.Issuer,
+		audience: authCfg.Audience,
+		ttl:      cfg.IDTokenExpTime,
+		key:      key,
+		jwk:      publicJWK(&key.PublicKey),
+	}, nil
+}
+
+// Check reports whether the signing key is loaded and valid, so ID tokens can be issued.
+func (s *Signer) Check(ctx context.Context) error {
+	if s.key == nil {
+		return fmt.Errorf("no signing key loaded")
+	}
+	if err := s.key.Validate(); err != nil {
+		return fmt.Errorf("invalid signing key: %w", err)
+	}
+	return nil
+}
+
+// Issue signs an ID token for the given authentication.
+func (s *Signer) Issue(params Params) (string, error) {
+	audience := params.Audience
+	if audience == "" {
+		audience = s.audience
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    s.issuer,
+			Subject:   params.User.ID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+		UserClaims: UserClaimsFor(params.User, params.Scopes),
+		Nonce:      params.Nonce,
+		SessionID:  params.SessionID,
+	}
+	if !params.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(params.AuthTime)
+	}
+	if params.AccessToken != "" {
+		claims.AtHash = AccessTokenHash(params.AccessToken)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.jwk.Kid
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("sign id token: %w", err)
+	}
+
+	metrics.ObserveTokenIssued("ID_TOKEN")
+	return signed, nil
+}
+
+// Parse verifies an ID token issued by this signer and returns its claims.
+func (s *Signer) Parse(token string) (Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	var claims Claims
+	if _, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return &s.key.PublicKey, nil
+	}); err != nil {
+		return Claims{}, fmt.Errorf("parse id token: %w", err)
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys verifying issued ID tokens.
+func (s *Signer) JWKS() []JWK {
+	return []JWK{s.jwk}
+}
+
+// AccessTokenHash computes the at_hash of an access token for RS256 ID tokens: the
+// base64url encoded left half of its SHA-256 hash (OpenID Connect Core section 3.1.3.6).
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// loadKey reads a PEM encoded RSA private key in PKCS#1 or PKCS#8 form, or generates
+// a new key when path is empty.
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	if path == "" {
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key must be an RSA key, got %T", parsed)
+	}
+	return key, nil
+}
+
+// publicJWK converts an RSA public key to a JWK identified by its RFC 7638 thumbprint.
+func publicJWK(key *rsa.PublicKey) JWK {
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+
+	// The thumbprint hashes the required members in lexicographic order.
+	thumbprint, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{E: e, Kty: "RSA", N: n})
+	sum := sha256.Sum256(thumbprint)
+
+	return JWK{
+		Kty: "RSA",
+		Kid: base64.RawURLEncoding.EncodeToString(sum[:]),
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Name,
+		N:   n,
+		E:   e,
+	}
+}
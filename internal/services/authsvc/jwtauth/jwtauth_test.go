@@ -0,0 +1,119 @@
+package jwtauth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestJWTAuth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "JWT Auth Suite")
+}
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Authenticator", func() {
+	var (
+		ctx      context.Context
+		now      time.Time
+		tm       *tokenmgr.JWTTokenManager
+		sessions *session.Manager
+		authn    *jwtauth.Authenticator
+	)
+
+	// accessToken issues an access token for the user's session, valid right away.
+	accessToken := func(userID, sessionID string) string {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.SessionID = sessionID
+		claims.NotBefore = jwt.NewNumericDate(time.Now().Add(-time.Second))
+
+		token, err := tm.Generate(ctx, claims)
+		Expect(err).NotTo(HaveOccurred())
+		return token
+	}
+
+	BeforeEach(func() {
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+		ctx = logger.WithContext(context.Background(), log)
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+			SessionIdleTimeout:  time.Hour,
+		}
+		now = time.Now()
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions = session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg).
+			WithClock(func() time.Time { return now })
+		authn = jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+	})
+
+	It("rejects the tokens of a revoked session", func() {
+		sess, err := sessions.Create(ctx, "alice", requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+		token := accessToken("alice", sess.ID)
+
+		authCtx, err := authn.Authenticate(ctx, token)
+		Expect(err).NotTo(HaveOccurred())
+		claims, ok := tokenmgr.UserClaimsFromContext(authCtx)
+		Expect(ok).To(BeTrue())
+		Expect(claims.SessionID).To(Equal(sess.ID))
+
+		Expect(sessions.Revoke(ctx, "alice", sess.ID)).To(Succeed())
+
+		_, err = authn.Authenticate(ctx, token)
+		Expect(errorName(err)).To(Equal("invalid_token"))
+	})
+
+	It("rejects the tokens of other sessions once the others are revoked", func() {
+		current, err := sessions.Create(ctx, "alice", requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+		other, err := sessions.Create(ctx, "alice", requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sessions.RevokeOthers(ctx, "alice", current.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = authn.Authenticate(ctx, accessToken("alice", current.ID))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = authn.Authenticate(ctx, accessToken("alice", other.ID))
+		Expect(errorName(err)).To(Equal("invalid_token"))
+	})
+
+	It("rejects the tokens of an expired session as session expired", func() {
+		sess, err := sessions.Create(ctx, "alice", requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+		token := accessToken("alice", sess.ID)
+
+		now = now.Add(2 * time.Hour)
+		_, err = authn.Authenticate(ctx, token)
+		Expect(errorName(err)).To(Equal("session_expired"))
+	})
+})
@@ -0,0 +1,44 @@
+// Package revocationstore provides an in-memory implementation of the RevocationStorer interface.
+package revocationstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memory implements the RevocationStorer interface using an in-memory map.
+type memory struct {
+	mu      sync.RWMutex         // protects access to revoked
+	revoked map[string]time.Time // stores token expiry times by token ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory revocation store.
+func NewMemoryStore() *memory {
+	return &memory{revoked: make(map[string]time.Time)}
+}
+
+// Revoke records a token ID as revoked, pruning entries for tokens that already expired.
+func (m *memory) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range m.revoked {
+		if now.After(exp) {
+			delete(m.revoked, id)
+		}
+	}
+
+	m.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked reports whether a token ID has been revoked.
+func (m *memory) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, revoked := m.revoked[tokenID]
+	return revoked, nil
+}
@@ -0,0 +1,17 @@
+// Package revocationstore defines the interface for interacting with the token revocation storage layer.
+package revocationstore
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationStorer defines the contract for tracking revoked tokens in a storage backend.
+// Tokens are identified by their jti claim and only need to be remembered until they expire.
+type RevocationStorer interface {
+	// Revoke records the token ID as revoked until the given expiry time.
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+
+	// IsRevoked reports whether the token ID has been revoked.
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
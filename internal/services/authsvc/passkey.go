@@ -0,0 +1,245 @@
+package authsvc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	genauth "github.com/iamBelugaa/goa-iam/gen/auth"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// publicKeyCredentialType is the only credential type defined by WebAuthn.
+const publicKeyCredentialType = "public-key"
+
+// BeginPasskeyRegistration issues creation options for registering a passkey
+// for the authenticated user.
+func (s *service) BeginPasskeyRegistration(ctx context.Context, req *genauth.PasskeyRegistrationOptionsRequest) (*genauth.PasskeyRegistrationOptionsResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("begin passkey registration request received", "userId", claims.Subject)
+
+	user, err := s.queryUser(ctx, claims.Subject)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query user error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeNotFound(err)
+	}
+
+	opts, err := s.rp.BeginRegistration(ctx, user.ID, user.Email, user.FirstName+" "+user.LastName)
+	if err != nil {
+		logger.FromContext(ctx).Infow("begin passkey registration error", "userId", user.ID, "error", err)
+		return nil, err
+	}
+
+	params := make([]*genauth.PasskeyCredentialParameter, 0, len(opts.Algorithms))
+	for _, alg := range opts.Algorithms {
+		params = append(params, &genauth.PasskeyCredentialParameter{Type: publicKeyCredentialType, Alg: alg})
+	}
+
+	logger.FromContext(ctx).Infow("begin passkey registration request successful", "userId", user.ID)
+	return &genauth.PasskeyRegistrationOptionsResponse{
+		Success: true,
+		Message: "Passkey registration options created successfully",
+		Data: &genauth.PasskeyCreationOptions{
+			Challenge: encodeBase64URL(opts.Challenge),
+			Rp:        &genauth.PasskeyRelyingParty{ID: opts.RPID, Name: opts.RPName},
+			User: &genauth.PasskeyUserEntity{
+				ID:          encodeBase64URL(opts.UserID),
+				Name:        opts.UserName,
+				DisplayName: opts.UserDisplayName,
+			},
+			PubKeyCredParams:   params,
+			ExcludeCredentials: credentialDescriptors(opts.ExcludeCredentials),
+			Timeout:            opts.Timeout.Milliseconds(),
+			Attestation:        "none",
+		},
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the attestation response and stores the
+// new passkey for the authenticated user.
+func (s *service) FinishPasskeyRegistration(ctx context.Context, req *genauth.PasskeyRegistrationRequest) (*genauth.PasskeyRegistrationResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("finish passkey registration request received", "userId", claims.Subject, "credentialId", req.ID)
+
+	resp, err := decodeAttestation(req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("decode passkey attestation error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeBadRequest(err)
+	}
+
+	if _, err := s.rp.FinishRegistration(ctx, claims.Subject, resp); err != nil {
+		logger.FromContext(ctx).Infow("finish passkey registration error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeBadRequest(err)
+	}
+
+	logger.FromContext(ctx).Infow("finish passkey registration request successful", "userId", claims.Subject, "credentialId", req.ID)
+	return &genauth.PasskeyRegistrationResponse{
+		Success: true,
+		Message: "Passkey registered successfully",
+	}, nil
+}
+
+// BeginPasskeySignin issues request options for a passkey signin. When an email
+// is given only that user's passkeys are allowed to answer the challenge. Unknown
+// emails get the options of a signin without email, which allow no credentials, so
+// the response doesn't tell whether an account exists.
+func (s *service) BeginPasskeySignin(ctx context.Context, req *genauth.PasskeySigninOptionsRequest) (*genauth.PasskeySigninOptionsResponse, error) {
+	var userID string
+	if req.Email != nil {
+		logger.FromContext(ctx).Infow("begin passkey signin request received", "email", *req.Email)
+
+		user, err := s.userStore.QueryByEmail(ctx, tenancy.FromContext(ctx), *req.Email)
+		if err == nil && user != nil {
+			userID = user.ID
+		} else {
+			logger.FromContext(ctx).Infow("query user error", "email", *req.Email, "error", err)
+		}
+	} else {
+		logger.FromContext(ctx).Infow("begin passkey signin request received")
+	}
+
+	opts, err := s.rp.BeginLogin(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("begin passkey signin error", "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("begin passkey signin request successful", "userId", userID)
+	return &genauth.PasskeySigninOptionsResponse{
+		Success: true,
+		Message: "Passkey signin options created successfully",
+		Data: &genauth.PasskeyRequestOptions{
+			Challenge:        encodeBase64URL(opts.Challenge),
+			RpID:             opts.RPID,
+			AllowCredentials: credentialDescriptors(opts.AllowCredentials),
+			Timeout:          opts.Timeout.Milliseconds(),
+			UserVerification: "preferred",
+		},
+	}, nil
+}
+
+// FinishPasskeySignin verifies the passkey assertion and issues the same access
+// and refresh token pair as a password signin.
+func (s *service) FinishPasskeySignin(ctx context.Context, req *genauth.PasskeySigninRequest) (*genauth.TokenResponse, error) {
+	logger.FromContext(ctx).Infow("finish passkey signin request received", "credentialId", req.ID)
+
+	resp, err := decodeAssertion(req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("decode passkey assertion error", "error", err)
+		return nil, genauth.MakeInvalidCredentials(err)
+	}
+
+	details := map[string]string{"method": signinMethodPasskey, "credentialId": req.ID}
+	cred, err := s.rp.FinishLogin(ctx, resp)
+	if err != nil {
+		logger.FromContext(ctx).Infow("finish passkey signin error", "credentialId", req.ID, "error", err)
+		s.recordSignin(ctx, "", details, err)
+		return nil, genauth.MakeInvalidCredentials(err)
+	}
+
+	if _, err := s.queryUser(ctx, cred.UserID); err != nil {
+		logger.FromContext(ctx).Infow("query user error", "userId", cred.UserID, "error", err)
+		s.recordSignin(ctx, cred.UserID, details, err)
+		return nil, genauth.MakeNotFound(err)
+	}
+
+	tokens, err := s.issueTokens(ctx, cred.UserID)
+	s.recordSignin(ctx, cred.UserID, details, err)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("finish passkey signin request successful", "userId", cred.UserID)
+	return &genauth.TokenResponse{
+		Success: true,
+		Message: "Signed in user successfully",
+		Data:    tokens,
+	}, nil
+}
+
+// decodeAttestation converts the base64url encoded registration payload into a webauthn response.
+func decodeAttestation(req *genauth.PasskeyRegistrationRequest) (*webauthn.AttestationResponse, error) {
+	var (
+		resp webauthn.AttestationResponse
+		err  error
+	)
+
+	if resp.CredentialID, err = decodeBase64URL("id", req.ID); err != nil {
+		return nil, err
+	}
+	if resp.ClientDataJSON, err = decodeBase64URL("clientDataJSON", req.Response.ClientDataJSON); err != nil {
+		return nil, err
+	}
+	if resp.AttestationObject, err = decodeBase64URL("attestationObject", req.Response.AttestationObject); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// decodeAssertion converts the base64url encoded signin payload into a webauthn response.
+func decodeAssertion(req *genauth.PasskeySigninRequest) (*webauthn.AssertionResponse, error) {
+	var (
+		resp webauthn.AssertionResponse
+		err  error
+	)
+
+	if resp.CredentialID, err = decodeBase64URL("id", req.ID); err != nil {
+		return nil, err
+	}
+	if resp.ClientDataJSON, err = decodeBase64URL("clientDataJSON", req.Response.ClientDataJSON); err != nil {
+		return nil, err
+	}
+	if resp.AuthenticatorData, err = decodeBase64URL("authenticatorData", req.Response.AuthenticatorData); err != nil {
+		return nil, err
+	}
+	if resp.Signature, err = decodeBase64URL("signature", req.Response.Signature); err != nil {
+		return nil, err
+	}
+	if req.Response.UserHandle != nil {
+		if resp.UserHandle, err = decodeBase64URL("userHandle", *req.Response.UserHandle); err != nil {
+			return nil, err
+		}
+	}
+
+	return &resp, nil
+}
+
+// credentialDescriptors converts raw credential IDs into descriptors for the client.
+func credentialDescriptors(ids [][]byte) []*genauth.PasskeyCredentialDescriptor {
+	descriptors := make([]*genauth.PasskeyCredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		descriptors = append(descriptors, &genauth.PasskeyCredentialDescriptor{
+			Type: publicKeyCredentialType,
+			ID:   encodeBase64URL(id),
+		})
+	}
+	return descriptors
+}
+
+// encodeBase64URL encodes binary WebAuthn values the way browsers expect them.
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeBase64URL decodes a base64url value, accepting both padded and unpadded input.
+func decodeBase64URL(field, value string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64url : %w", field, err)
+	}
+	return data, nil
+}
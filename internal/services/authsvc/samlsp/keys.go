@@ -0,0 +1,136 @@
+package samlsp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+)
+
+// ephemeralCertLifetime is the validity of certificates generated when none is configured.
+const ephemeralCertLifetime = 365 * 24 * time.Hour
+
+// loadKeyPair reads the configured PEM encoded certificate and RSA private key, or generates
+// a self-signed pair when neither is configured. Identity providers pin the certificate from
+// the metadata, so a generated pair must be re-registered after every restart.
+func loadKeyPair(cfg *config.SAML) (*rsa.PrivateKey, *x509.Certificate, error) {
+	if cfg.KeyFile == "" && cfg.CertificateFile == "" {
+		return generateKeyPair(cfg.BaseURL)
+	}
+	if cfg.KeyFile == "" || cfg.CertificateFile == "" {
+		return nil, nil, fmt.Errorf("saml certificate and key must be configured together")
+	}
+
+	key, err := readKey(cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := readCertificate(cfg.CertificateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		return nil, nil, fmt.Errorf("saml certificate doesn't match the key")
+	}
+	return key, cert, nil
+}
+
+// generateKeyPair creates an RSA key and a self-signed certificate named after the base URL's host.
+func generateKeyPair(baseURL string) (*rsa.PrivateKey, *x509.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate saml key: %w", err)
+	}
+
+	commonName := baseURL
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		commonName = u.Hostname()
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate saml certificate serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(ephemeralCertLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create saml certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse saml certificate: %w", err)
+	}
+	return key, cert, nil
+}
+
+// readKey reads a PEM encoded RSA private key in PKCS#1 or PKCS#8 form.
+func readKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse saml key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("saml key must be an RSA key, got %T", parsed)
+	}
+	return key, nil
+}
+
+// readCertificate reads a PEM encoded X.509 certificate.
+func readCertificate(path string) (*x509.Certificate, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse saml certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// readPEM reads the first PEM block of a file.
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,101 @@
+package samlsp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// pendingRequest holds the server side state of an authentication request sent to a provider.
+type pendingRequest struct {
+	providerID string    // Provider the user was sent to
+	tenantID   string    // Tenant the signin was started in
+	requestID  string    // ID the response must be in response to
+	expiresAt  time.Time // Time after which the relay state is no longer accepted
+}
+
+// requestCache keeps outstanding authentication requests in memory until they are consumed
+// or expire, along with the assertions already accepted.
+type requestCache struct {
+	mu       sync.Mutex                // protects access to pending and consumed
+	pending  map[string]pendingRequest // maps relay states to their requests
+	consumed map[string]time.Time      // maps accepted assertion IDs to when they expire
+}
+
+// newRequestCache creates an empty request cache.
+func newRequestCache() *requestCache {
+	return &requestCache{
+		pending:  make(map[string]pendingRequest),
+		consumed: make(map[string]time.Time),
+	}
+}
+
+// issue generates a new random relay state and binds it to the given request.
+func (c *requestCache) issue(p pendingRequest) (string, error) {
+	relayState, err := randomString()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(time.Now())
+	c.pending[relayState] = p
+	return relayState, nil
+}
+
+// consume removes the relay state from the cache and returns its request if it exists and has not expired.
+func (c *requestCache) consume(relayState string) (pendingRequest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[relayState]
+	if !ok {
+		return pendingRequest{}, false
+	}
+	delete(c.pending, relayState)
+
+	if time.Now().After(p.expiresAt) {
+		return pendingRequest{}, false
+	}
+	return p, true
+}
+
+// markConsumed records an accepted assertion until it expires, reporting false if it was already accepted.
+func (c *requestCache) markConsumed(assertionID string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(time.Now())
+	if _, ok := c.consumed[assertionID]; ok {
+		return false
+	}
+	c.consumed[assertionID] = expiresAt
+	return true
+}
+
+// prune drops expired requests and assertions so abandoned signins don't accumulate.
+// The caller must hold the lock.
+func (c *requestCache) prune(now time.Time) {
+	for key, p := range c.pending {
+		if now.After(p.expiresAt) {
+			delete(c.pending, key)
+		}
+	}
+	for id, expiresAt := range c.consumed {
+		if now.After(expiresAt) {
+			delete(c.consumed, id)
+		}
+	}
+}
+
+// randomString returns 32 random bytes encoded as unpadded base64url.
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
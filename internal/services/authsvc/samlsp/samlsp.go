@@ -0,0 +1,362 @@
+// Package samlsp implements the service provider side of SAML 2.0 web browser SSO used to
+// sign in through enterprise identity providers. Requests are sent with the HTTP-Redirect
+// binding and signed responses are accepted with the HTTP-POST binding.
+package samlsp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+)
+
+// Errors returned when a SAML signin fails.
+var (
+	ErrUnknownProvider = errors.New("saml identity provider isn't configured")
+	ErrRequestMismatch = errors.New("relay state not issued, expired or already used")
+	ErrInvalidResponse = errors.New("invalid saml response")
+)
+
+// Well known attribute names holding the user's profile, tried when a provider doesn't configure them.
+var (
+	emailAttributes = []string{
+		"email", "mail", "emailAddress",
+		"urn:oid:0.9.2342.19200300.100.1.3",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+	}
+	firstNameAttributes = []string{
+		"firstName", "givenName",
+		"urn:oid:2.5.4.42",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname",
+	}
+	lastNameAttributes = []string{
+		"lastName", "sn", "surname",
+		"urn:oid:2.5.4.4",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname",
+	}
+)
+
+// ProviderInfo describes a configured SAML identity provider to clients.
+type ProviderInfo struct {
+	ID   string
+	Name string
+}
+
+// AuthnRequest holds the identity provider URL the user must be sent to.
+type AuthnRequest struct {
+	ProviderID string
+	URL        string
+	RelayState string
+	ExpiresAt  time.Time
+}
+
+// Identity is the subject of a verified assertion.
+type Identity struct {
+	ProviderID      string
+	NameID          string
+	Email           string
+	FirstName       string
+	LastName        string
+	TenantID        string // Tenant the signin was started in, empty for identity provider initiated signins
+	JITProvisioning bool   // Whether a local user may be created for an unknown subject
+}
+
+// provider pairs a configured identity provider with the SAML service provider talking to it.
+type provider struct {
+	cfg config.SAMLProvider
+	sp  *saml.ServiceProvider
+}
+
+// ServiceProvider sends users to SAML identity providers and verifies the assertions they
+// post back. Each identity provider gets its own entity ID and assertion consumer service
+// URL below the configured base URL, so providers can't replay assertions across each other.
+type ServiceProvider struct {
+	cfg       *config.SAML         // Base URL and request lifetime
+	providers map[string]*provider // Configured providers by ID
+	order     []string             // Provider IDs in configuration order
+	requests  *requestCache        // Outstanding requests and consumed assertions
+	ephemeral bool                 // Whether the signing key was generated at startup
+}
+
+// NewServiceProvider creates a service provider for the configured identity providers,
+// reading each provider's metadata and the service provider's certificate and key.
+func NewServiceProvider(cfg *config.SAML) (*ServiceProvider, error) {
+	key, cert, err := loadKeyPair(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ServiceProvider{
+		cfg:       cfg,
+		providers: make(map[string]*provider, len(cfg.Providers)),
+		requests:  newRequestCache(),
+		ephemeral: cfg.KeyFile == "",
+	}
+
+	for _, p := range cfg.Providers {
+		if _, ok := s.providers[p.ID]; ok {
+			return nil, fmt.Errorf("duplicate saml provider %q", p.ID)
+		}
+
+		metadata, err := readMetadata(p.MetadataFile)
+		if err != nil {
+			return nil, fmt.Errorf("saml provider %s: %w", p.ID, err)
+		}
+
+		metadataURL, err := url.Parse(s.providerURL(p.ID, "metadata"))
+		if err != nil {
+			return nil, fmt.Errorf("saml provider %s: %w", p.ID, err)
+		}
+		acsURL, err := url.Parse(s.providerURL(p.ID, "acs"))
+		if err != nil {
+			return nil, fmt.Errorf("saml provider %s: %w", p.ID, err)
+		}
+
+		nameIDFormat := saml.PersistentNameIDFormat
+		if p.NameIDFormat != "" {
+			nameIDFormat = saml.NameIDFormat(p.NameIDFormat)
+		}
+
+		s.providers[p.ID] = &provider{
+			cfg: p,
+			sp: &saml.ServiceProvider{
+				EntityID:          metadataURL.String(),
+				Key:               key,
+				Certificate:       cert,
+				MetadataURL:       *metadataURL,
+				AcsURL:            *acsURL,
+				IDPMetadata:       metadata,
+				AuthnNameIDFormat: nameIDFormat,
+				AllowIDPInitiated: p.AllowIDPInitiated,
+			},
+		}
+		s.order = append(s.order, p.ID)
+	}
+
+	return s, nil
+}
+
+// Ephemeral reports whether the signing key was generated at startup rather than configured.
+func (s *ServiceProvider) Ephemeral() bool {
+	return s.ephemeral
+}
+
+// Providers returns the configured SAML identity providers.
+func (s *ServiceProvider) Providers() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(s.order))
+	for _, id := range s.order {
+		p := s.providers[id]
+		infos = append(infos, ProviderInfo{ID: p.cfg.ID, Name: p.cfg.Name})
+	}
+	return infos
+}
+
+// Metadata returns the service provider metadata document to register with an identity provider.
+func (s *ServiceProvider) Metadata(providerID string) ([]byte, error) {
+	p, ok := s.providers[providerID]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	data, err := xml.MarshalIndent(p.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal metadata: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Begin creates an authentication request for the given provider and returns the
+// HTTP-Redirect binding URL carrying it. The signin completes in the given tenant.
+func (s *ServiceProvider) Begin(providerID, tenantID string) (*AuthnRequest, error) {
+	p, ok := s.providers[providerID]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	ssoURL := p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if ssoURL == "" {
+		return nil, fmt.Errorf("saml provider %s has no HTTP-Redirect single sign on service", providerID)
+	}
+
+	req, err := p.sp.MakeAuthenticationRequest(ssoURL, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return nil, fmt.Errorf("create authentication request: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.cfg.RequestExpTime)
+	relayState, err := s.requests.issue(pendingRequest{
+		providerID: providerID,
+		tenantID:   tenantID,
+		requestID:  req.ID,
+		expiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate relay state: %w", err)
+	}
+
+	redirectURL, err := req.Redirect(relayState, p.sp)
+	if err != nil {
+		return nil, fmt.Errorf("encode authentication request: %w", err)
+	}
+
+	return &AuthnRequest{
+		ProviderID: providerID,
+		URL:        redirectURL.String(),
+		RelayState: relayState,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// Finish verifies a base64 encoded response posted to the provider's assertion consumer
+// service and returns the identity it asserts. The relay state must match a request made
+// by Begin unless the provider allows identity provider initiated signins.
+func (s *ServiceProvider) Finish(providerID, samlResponse, relayState string) (*Identity, error) {
+	p, ok := s.providers[providerID]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	var (
+		possibleRequestIDs []string
+		tenantID           string
+	)
+	if pending, ok := s.requests.consume(relayState); ok && pending.providerID == providerID {
+		possibleRequestIDs = []string{pending.requestID}
+		tenantID = pending.tenantID
+	} else if !p.cfg.AllowIDPInitiated {
+		return nil, ErrRequestMismatch
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(samlResponse))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	assertion, err := parseResponse(p.sp, raw, possibleRequestIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return nil, fmt.Errorf("%w: assertion has no subject", ErrInvalidResponse)
+	}
+
+	// Assertions are bearer credentials, so each one is only accepted once while it's valid.
+	expiresAt := assertion.IssueInstant.Add(saml.MaxIssueDelay)
+	if assertion.Conditions != nil && !assertion.Conditions.NotOnOrAfter.IsZero() {
+		expiresAt = assertion.Conditions.NotOnOrAfter.Add(saml.MaxClockSkew)
+	}
+	if !s.requests.markConsumed(providerID+"|"+assertion.ID, expiresAt) {
+		return nil, fmt.Errorf("%w: assertion already used", ErrInvalidResponse)
+	}
+
+	nameID := assertion.Subject.NameID
+	identity := &Identity{
+		ProviderID:      providerID,
+		NameID:          nameID.Value,
+		Email:           attributeValue(assertion, p.cfg.EmailAttribute, emailAttributes),
+		FirstName:       attributeValue(assertion, p.cfg.FirstNameAttribute, firstNameAttributes),
+		LastName:        attributeValue(assertion, p.cfg.LastNameAttribute, lastNameAttributes),
+		TenantID:        tenantID,
+		JITProvisioning: p.cfg.JITProvisioning,
+	}
+	if identity.Email == "" && nameID.Format == string(saml.EmailAddressNameIDFormat) {
+		identity.Email = nameID.Value
+	}
+
+	return identity, nil
+}
+
+// parseResponse verifies a response with the library, unwrapping the reason it hides behind
+// a generic message. The library dereferences optional assertion elements once the signature
+// has been verified, so a panic is reported as an invalid response too.
+func parseResponse(sp *saml.ServiceProvider, raw []byte, possibleRequestIDs []string) (assertion *saml.Assertion, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			assertion, err = nil, fmt.Errorf("malformed assertion: %v", r)
+		}
+	}()
+
+	assertion, err = sp.ParseXMLResponse(raw, possibleRequestIDs)
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) && invalid.PrivateErr != nil {
+			err = invalid.PrivateErr
+		}
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// providerURL returns the URL of an endpoint of the given provider below the base URL.
+func (s *ServiceProvider) providerURL(providerID, endpoint string) string {
+	return strings.TrimSuffix(s.cfg.BaseURL, "/") + "/" + url.PathEscape(providerID) + "/" + endpoint
+}
+
+// attributeValue returns the first value of the configured attribute, or of the first
+// well known attribute present when none is configured.
+func attributeValue(assertion *saml.Assertion, configured string, defaults []string) string {
+	names := defaults
+	if configured != "" {
+		names = []string{configured}
+	}
+
+	for _, name := range names {
+		for _, statement := range assertion.AttributeStatements {
+			for _, attr := range statement.Attributes {
+				if attr.Name != name && attr.FriendlyName != name {
+					continue
+				}
+				for _, value := range attr.Values {
+					if v := strings.TrimSpace(value.Value); v != "" {
+						return v
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// readMetadata parses an identity provider metadata file holding either a single entity
+// descriptor or an entities descriptor with exactly one identity provider.
+func readMetadata(path string) (*saml.EntityDescriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata: %w", err)
+	}
+
+	var entity saml.EntityDescriptor
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&entity); err == nil && len(entity.IDPSSODescriptors) > 0 {
+		return &entity, nil
+	}
+
+	var entities saml.EntitiesDescriptor
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&entities); err != nil {
+		return nil, fmt.Errorf("parse metadata: %w", err)
+	}
+
+	var found *saml.EntityDescriptor
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) == 0 {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("metadata describes more than one identity provider")
+		}
+		found = &entities.EntityDescriptors[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("metadata doesn't describe an identity provider")
+	}
+	return found, nil
+}
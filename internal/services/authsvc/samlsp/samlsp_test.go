@@ -0,0 +1,344 @@
+package samlsp_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/samlsp"
+)
+
+func TestSAMLSP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SAML Service Provider Suite")
+}
+
+const (
+	baseURL     = "http://localhost:8080/api/v1/auth/saml"
+	idpEntityID = "https://idp.example.com/metadata"
+	idpSSOURL   = "https://idp.example.com/sso"
+)
+
+// signer is an identity provider key pair signing assertion fixtures.
+type signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newSigner() *signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &signer{key: key, cert: cert}
+}
+
+// metadata returns identity provider metadata publishing the signer's certificate.
+func (s *signer) metadata() string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="%s"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, idpEntityID, base64.StdEncoding.EncodeToString(s.cert.Raw), idpSSOURL)
+}
+
+// assertion describes the response fixture to sign.
+type assertion struct {
+	providerID   string
+	inResponseTo string
+	audience     string
+	nameID       string
+	email        string
+	issueInstant time.Time
+}
+
+// response builds a base64 encoded response whose assertion is signed by the signer. The
+// tamper function, when set, runs on the signed assertion before it's encoded.
+func (s *signer) response(a assertion, tamper func(*etree.Element)) string {
+	now := a.issueInstant
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	acsURL := baseURL + "/" + a.providerID + "/acs"
+	audience := a.audience
+	if audience == "" {
+		audience = baseURL + "/" + a.providerID + "/metadata"
+	}
+	inResponseTo := ""
+	if a.inResponseTo != "" {
+		inResponseTo = fmt.Sprintf(` InResponseTo="%s"`, a.inResponseTo)
+	}
+	instant := now.Format(time.RFC3339)
+	notOnOrAfter := now.Add(5 * time.Minute).Format(time.RFC3339)
+
+	assertionXML := fmt.Sprintf(`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="id-assertion-%d" IssueInstant="%s" Version="2.0">
+  <saml:Issuer>%s</saml:Issuer>
+  <saml:Subject>
+    <saml:NameID Format="urn:oasis:names:tc:SAML:2.0:nameid-format:persistent">%s</saml:NameID>
+    <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+      <saml:SubjectConfirmationData%s NotOnOrAfter="%s" Recipient="%s"/>
+    </saml:SubjectConfirmation>
+  </saml:Subject>
+  <saml:Conditions NotBefore="%s" NotOnOrAfter="%s">
+    <saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction>
+  </saml:Conditions>
+  <saml:AuthnStatement AuthnInstant="%s">
+    <saml:AuthnContext><saml:AuthnContextClassRef>urn:oasis:names:tc:SAML:2.0:ac:classes:Password</saml:AuthnContextClassRef></saml:AuthnContext>
+  </saml:AuthnStatement>
+  <saml:AttributeStatement>
+    <saml:Attribute Name="email"><saml:AttributeValue>%s</saml:AttributeValue></saml:Attribute>
+    <saml:Attribute Name="http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname"><saml:AttributeValue>John</saml:AttributeValue></saml:Attribute>
+    <saml:Attribute Name="sn" FriendlyName="surname"><saml:AttributeValue>Doe</saml:AttributeValue></saml:Attribute>
+  </saml:AttributeStatement>
+</saml:Assertion>`,
+		time.Now().UnixNano(), instant, idpEntityID, a.nameID, inResponseTo, notOnOrAfter, acsURL,
+		now.Add(-time.Minute).Format(time.RFC3339), notOnOrAfter, audience, instant, a.email,
+	)
+
+	doc := etree.NewDocument()
+	Expect(doc.ReadFromString(assertionXML)).To(Succeed())
+
+	ctx := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(tls.Certificate{
+		Certificate: [][]byte{s.cert.Raw},
+		PrivateKey:  s.key,
+	}))
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signed, err := ctx.SignEnveloped(doc.Root())
+	Expect(err).NotTo(HaveOccurred())
+
+	if tamper != nil {
+		tamper(signed)
+	}
+
+	responseDoc := etree.NewDocument()
+	Expect(responseDoc.ReadFromString(fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="id-response-%d" Version="2.0" IssueInstant="%s" Destination="%s"%s>
+  <saml:Issuer>%s</saml:Issuer>
+  <samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+</samlp:Response>`, time.Now().UnixNano(), instant, acsURL, inResponseTo, idpEntityID))).To(Succeed())
+	responseDoc.Root().AddChild(signed)
+
+	raw, err := responseDoc.WriteToBytes()
+	Expect(err).NotTo(HaveOccurred())
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// requestID decodes the authentication request carried by an HTTP-Redirect binding URL and returns its ID.
+func requestID(redirectURL string) string {
+	u, err := url.Parse(redirectURL)
+	Expect(err).NotTo(HaveOccurred())
+
+	deflated, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	Expect(err).NotTo(HaveOccurred())
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	Expect(err).NotTo(HaveOccurred())
+
+	var req saml.AuthnRequest
+	Expect(xml.Unmarshal(inflated, &req)).To(Succeed())
+	return req.ID
+}
+
+var _ = Describe("Service provider", func() {
+	var (
+		idp *signer
+		sp  *samlsp.ServiceProvider
+	)
+
+	BeforeEach(func() {
+		idp = newSigner()
+
+		metadataFile := filepath.Join(GinkgoT().TempDir(), "idp.xml")
+		Expect(os.WriteFile(metadataFile, []byte(idp.metadata()), 0o600)).To(Succeed())
+
+		var err error
+		sp, err = samlsp.NewServiceProvider(&config.SAML{
+			BaseURL:        baseURL,
+			RequestExpTime: time.Minute,
+			Providers: []config.SAMLProvider{
+				{ID: "okta", Name: "Okta", MetadataFile: metadataFile, JITProvisioning: true},
+				{ID: "adfs", Name: "ADFS", MetadataFile: metadataFile, AllowIDPInitiated: true},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	begin := func(providerID string) (string, string) {
+		req, err := sp.Begin(providerID, "")
+		Expect(err).NotTo(HaveOccurred())
+		return req.RelayState, requestID(req.URL)
+	}
+
+	It("lists providers and generates metadata for each", func() {
+		Expect(sp.Ephemeral()).To(BeTrue())
+		Expect(sp.Providers()).To(Equal([]samlsp.ProviderInfo{{ID: "okta", Name: "Okta"}, {ID: "adfs", Name: "ADFS"}}))
+
+		metadata, err := sp.Metadata("okta")
+		Expect(err).NotTo(HaveOccurred())
+
+		var entity saml.EntityDescriptor
+		Expect(xml.Unmarshal(metadata, &entity)).To(Succeed())
+		Expect(entity.EntityID).To(Equal(baseURL + "/okta/metadata"))
+		Expect(entity.SPSSODescriptors).To(HaveLen(1))
+		Expect(entity.SPSSODescriptors[0].AssertionConsumerServices[0].Location).To(Equal(baseURL + "/okta/acs"))
+
+		_, err = sp.Metadata("unknown")
+		Expect(err).To(MatchError(samlsp.ErrUnknownProvider))
+	})
+
+	It("redirects to the identity provider with an authentication request", func() {
+		req, err := sp.Begin("okta", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.URL).To(HavePrefix(idpSSOURL + "?SAMLRequest="))
+		Expect(req.URL).To(ContainSubstring("RelayState=" + req.RelayState))
+		Expect(requestID(req.URL)).NotTo(BeEmpty())
+
+		_, err = sp.Begin("unknown", "")
+		Expect(err).To(MatchError(samlsp.ErrUnknownProvider))
+	})
+
+	It("accepts a signed assertion in response to the request", func() {
+		relayState, id := begin("okta")
+
+		identity, err := sp.Finish("okta", idp.response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+		}, nil), relayState)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*identity).To(Equal(samlsp.Identity{
+			ProviderID:      "okta",
+			NameID:          "00u1abcd",
+			Email:           "john@example.com",
+			FirstName:       "John",
+			LastName:        "Doe",
+			JITProvisioning: true,
+		}))
+	})
+
+	It("rejects an assertion modified after signing", func() {
+		relayState, id := begin("okta")
+
+		_, err := sp.Finish("okta", idp.response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+		}, func(el *etree.Element) {
+			value := el.FindElement("./AttributeStatement/Attribute[@Name='email']/AttributeValue")
+			value.SetText("admin@example.com")
+		}), relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+
+	It("rejects an assertion signed by another key", func() {
+		relayState, id := begin("okta")
+
+		_, err := sp.Finish("okta", newSigner().response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+		}, nil), relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+
+	It("rejects an assertion for another audience", func() {
+		relayState, id := begin("okta")
+
+		_, err := sp.Finish("okta", idp.response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+			audience: baseURL + "/adfs/metadata",
+		}, nil), relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+
+	It("rejects an expired assertion", func() {
+		relayState, id := begin("okta")
+
+		_, err := sp.Finish("okta", idp.response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+			issueInstant: time.Now().UTC().Add(-time.Hour),
+		}, nil), relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+
+	It("rejects a response to another request", func() {
+		relayState, _ := begin("okta")
+		_, otherID := begin("okta")
+
+		_, err := sp.Finish("okta", idp.response(assertion{
+			providerID: "okta", inResponseTo: otherID, nameID: "00u1abcd", email: "john@example.com",
+		}, nil), relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+
+	It("rejects unknown and reused relay states", func() {
+		relayState, id := begin("okta")
+		response := idp.response(assertion{
+			providerID: "okta", inResponseTo: id, nameID: "00u1abcd", email: "john@example.com",
+		}, nil)
+
+		_, err := sp.Finish("okta", response, "unknown")
+		Expect(err).To(MatchError(samlsp.ErrRequestMismatch))
+
+		_, err = sp.Finish("okta", response, relayState)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sp.Finish("okta", response, relayState)
+		Expect(err).To(MatchError(samlsp.ErrRequestMismatch))
+	})
+
+	It("accepts identity provider initiated assertions only once when allowed", func() {
+		response := idp.response(assertion{
+			providerID: "adfs", nameID: "S-1-5-21", email: "jane@example.com",
+		}, nil)
+
+		identity, err := sp.Finish("adfs", response, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.NameID).To(Equal("S-1-5-21"))
+		Expect(identity.JITProvisioning).To(BeFalse())
+
+		_, err = sp.Finish("adfs", response, "")
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+		Expect(err.Error()).To(ContainSubstring("already used"))
+	})
+
+	It("rejects malformed responses", func() {
+		relayState, _ := begin("okta")
+
+		_, err := sp.Finish("okta", "not base64!", relayState)
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+
+		_, err = sp.Finish("adfs", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("<", 3))), "")
+		Expect(err).To(MatchError(samlsp.ErrInvalidResponse))
+	})
+})
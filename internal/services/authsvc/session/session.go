@@ -0,0 +1,163 @@
+// Package session manages the lifecycle of user sessions created at signin.
+package session
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	sessionstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store"
+)
+
+// Errors returned when a session can no longer be used.
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExpired  = errors.New("session has expired")
+)
+
+// Manager creates, validates and revokes sessions backed by a session store.
+type Manager struct {
+	store           sessionstore.SessionStorer // Storage for session records
+	idleTimeout     time.Duration              // Maximum time between uses of a session
+	absoluteTimeout time.Duration              // Maximum lifetime of a session since signin
+	now             func() time.Time           // Clock sessions are timed with
+}
+
+// NewManager creates a session manager using the given store and the session limits from the auth config.
+func NewManager(store sessionstore.SessionStorer, cfg *config.Auth) *Manager {
+	return &Manager{
+		store:           store,
+		idleTimeout:     cfg.SessionIdleTimeout,
+		absoluteTimeout: cfg.SessionAbsoluteTimeout,
+		now:             time.Now,
+	}
+}
+
+// WithClock makes the manager time sessions with the given clock instead of the system
+// clock, so tests can move time forward.
+func (m *Manager) WithClock(now func() time.Time) *Manager {
+	m.now = now
+	return m
+}
+
+// Create starts a new session for the user, recording the client that signed in.
+func (m *Manager) Create(ctx context.Context, userID string, md requestctx.Metadata) (*sessionstore.Session, error) {
+	now := m.now()
+	sess := &sessionstore.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		IPAddress:  md.IPAddress,
+		UserAgent:  md.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+
+	if err := m.store.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	metrics.SessionsStarted(1)
+	return sess, nil
+}
+
+// Validate checks that the session still exists for the user and is within its
+// idle and absolute limits, then records its use. Expired sessions are removed.
+func (m *Manager) Validate(ctx context.Context, userID, sessionID string) (*sessionstore.Session, error) {
+	sess, err := m.store.QueryByID(ctx, sessionID)
+	if err != nil || sess.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+
+	now := m.now()
+	if m.expired(sess, now) {
+		if err := m.store.Delete(ctx, sess.ID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		metrics.SessionsEnded(1)
+		return nil, ErrSessionExpired
+	}
+
+	sess.LastUsedAt = now
+	if err := m.store.Touch(ctx, sess.ID, sess.LastUsedAt); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	return sess, nil
+}
+
+// Get returns the user's session without recording its use.
+func (m *Manager) Get(ctx context.Context, userID, sessionID string) (*sessionstore.Session, error) {
+	sess, err := m.store.QueryByID(ctx, sessionID)
+	if err != nil || sess.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+// List returns the user's sessions that have not expired, most recently used first.
+func (m *Manager) List(ctx context.Context, userID string) ([]*sessionstore.Session, error) {
+	all, err := m.store.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	sessions := make([]*sessionstore.Session, 0, len(all))
+	for _, sess := range all {
+		if !m.expired(sess, now) {
+			sessions = append(sessions, sess)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+// Revoke ends a single session owned by the user.
+func (m *Manager) Revoke(ctx context.Context, userID, sessionID string) error {
+	sess, err := m.store.QueryByID(ctx, sessionID)
+	if err != nil || sess.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if err := m.store.Delete(ctx, sessionID); err != nil {
+		return err
+	}
+
+	metrics.SessionsEnded(1)
+	return nil
+}
+
+// RevokeOthers ends every session of the user except the current one and
+// returns the number of sessions revoked.
+func (m *Manager) RevokeOthers(ctx context.Context, userID, currentID string) (int, error) {
+	n, err := m.store.DeleteByUser(ctx, userID, currentID)
+	metrics.SessionsEnded(n)
+	return n, err
+}
+
+// RevokeAll ends every session of the user, signing it out everywhere, and returns
+// the number of sessions revoked.
+func (m *Manager) RevokeAll(ctx context.Context, userID string) (int, error) {
+	n, err := m.store.DeleteByUser(ctx, userID, "")
+	metrics.SessionsEnded(n)
+	return n, err
+}
+
+// expired reports whether the session exceeded its idle timeout or absolute lifetime at the given time.
+func (m *Manager) expired(sess *sessionstore.Session, now time.Time) bool {
+	if m.idleTimeout > 0 && now.Sub(sess.LastUsedAt) > m.idleTimeout {
+		return true
+	}
+	if m.absoluteTimeout > 0 && now.Sub(sess.CreatedAt) > m.absoluteTimeout {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+)
+
+func TestSession(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Session Suite")
+}
+
+// ids returns the IDs of the sessions, in order.
+func ids(sessions []*sessionstore.Session) []string {
+	out := make([]string, len(sessions))
+	for i, sess := range sessions {
+		out[i] = sess.ID
+	}
+	return out
+}
+
+var _ = Describe("Session manager", func() {
+	var (
+		ctx      context.Context
+		now      time.Time
+		sessions *session.Manager
+	)
+
+	create := func(userID, userAgent string) *sessionstore.Session {
+		sess, err := sessions.Create(ctx, userID, requestctx.Metadata{IPAddress: "10.0.0.1", UserAgent: userAgent})
+		Expect(err).NotTo(HaveOccurred())
+		return sess
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+		sessions = session.NewManager(sessionmemorystore.NewMemoryStore(), &config.Auth{
+			SessionIdleTimeout:     time.Hour,
+			SessionAbsoluteTimeout: 24 * time.Hour,
+		}).WithClock(func() time.Time { return now })
+	})
+
+	Describe("validating sessions", func() {
+		var sess *sessionstore.Session
+
+		BeforeEach(func() {
+			sess = create("alice", "laptop")
+		})
+
+		It("expires a session left idle for longer than the idle timeout", func() {
+			now = now.Add(59 * time.Minute)
+			_, err := sessions.Validate(ctx, "alice", sess.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			now = now.Add(61 * time.Minute)
+			_, err = sessions.Validate(ctx, "alice", sess.ID)
+			Expect(err).To(MatchError(session.ErrSessionExpired))
+
+			// Expired sessions are removed.
+			_, err = sessions.Validate(ctx, "alice", sess.ID)
+			Expect(err).To(MatchError(session.ErrSessionNotFound))
+		})
+
+		It("slides the idle timeout forward each time the session is used", func() {
+			for range 20 {
+				now = now.Add(50 * time.Minute)
+				validated, err := sessions.Validate(ctx, "alice", sess.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(validated.LastUsedAt).To(Equal(now))
+			}
+			Expect(now.Sub(sess.CreatedAt)).To(BeNumerically(">", time.Hour))
+		})
+
+		It("expires a session older than the absolute timeout, even when in use", func() {
+			for range 28 {
+				now = now.Add(50 * time.Minute)
+				_, err := sessions.Validate(ctx, "alice", sess.ID)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			now = now.Add(50 * time.Minute)
+			_, err := sessions.Validate(ctx, "alice", sess.ID)
+			Expect(err).To(MatchError(session.ErrSessionExpired))
+		})
+
+		It("doesn't list expired sessions", func() {
+			fresh := create("alice", "phone")
+			now = now.Add(30 * time.Minute)
+			_, err := sessions.Validate(ctx, "alice", fresh.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			now = now.Add(45 * time.Minute)
+			list, err := sessions.List(ctx, "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ids(list)).To(ConsistOf(fresh.ID))
+		})
+	})
+
+	Describe("listing and revoking sessions", func() {
+		var laptop, phone, tablet *sessionstore.Session
+
+		BeforeEach(func() {
+			laptop = create("alice", "laptop")
+			phone = create("alice", "phone")
+			tablet = create("alice", "tablet")
+			create("bob", "laptop")
+
+			// Use the phone last, so it's listed first.
+			now = now.Add(time.Minute)
+			_, err := sessions.Validate(ctx, "alice", phone.ID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("lists the caller's sessions only, most recently used first", func() {
+			list, err := sessions.List(ctx, "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ids(list)).To(ConsistOf(laptop.ID, phone.ID, tablet.ID))
+			Expect(list[0].ID).To(Equal(phone.ID))
+			Expect(list[0].UserAgent).To(Equal("phone"))
+			Expect(list[0].IPAddress).To(Equal("10.0.0.1"))
+		})
+
+		It("revokes one of the caller's sessions", func() {
+			Expect(sessions.Revoke(ctx, "alice", tablet.ID)).To(Succeed())
+
+			list, err := sessions.List(ctx, "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ids(list)).To(ConsistOf(laptop.ID, phone.ID))
+
+			_, err = sessions.Validate(ctx, "alice", tablet.ID)
+			Expect(err).To(MatchError(session.ErrSessionNotFound))
+			Expect(sessions.Revoke(ctx, "alice", tablet.ID)).To(MatchError(session.ErrSessionNotFound))
+		})
+
+		It("doesn't revoke the sessions of other users", func() {
+			Expect(sessions.Revoke(ctx, "bob", laptop.ID)).To(MatchError(session.ErrSessionNotFound))
+
+			_, err := sessions.Validate(ctx, "alice", laptop.ID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("revokes every other session of the caller, keeping the current one", func() {
+			revoked, err := sessions.RevokeOthers(ctx, "alice", laptop.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revoked).To(Equal(2))
+
+			list, err := sessions.List(ctx, "alice")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ids(list)).To(ConsistOf(laptop.ID))
+
+			bobs, err := sessions.List(ctx, "bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bobs).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+// Package sessionstore provides an in-memory implementation of the SessionStorer interface.
+package sessionstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sessionstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store"
+)
+
+// memory implements the SessionStorer interface using in-memory maps.
+type memory struct {
+	mu       sync.RWMutex                     // protects access to sessions
+	sessions map[string]*sessionstore.Session // stores sessions by ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory session store.
+func NewMemoryStore() *memory {
+	return &memory{sessions: make(map[string]*sessionstore.Session)}
+}
+
+// Create adds a new session to the in-memory store.
+func (m *memory) Create(ctx context.Context, sess *sessionstore.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[sess.ID]; exists {
+		return fmt.Errorf("session with id %s already exists", sess.ID)
+	}
+
+	stored := *sess
+	m.sessions[sess.ID] = &stored
+
+	return nil
+}
+
+// QueryByID retrieves a session from memory by its ID.
+func (m *memory) QueryByID(ctx context.Context, sessionID string) (*sessionstore.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sess, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("session with id %s doesn't exist", sessionID)
+	}
+
+	found := *sess
+	return &found, nil
+}
+
+// QueryByUser returns all sessions in memory belonging to the given user.
+func (m *memory) QueryByUser(ctx context.Context, userID string) ([]*sessionstore.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var sessions []*sessionstore.Session
+	for _, sess := range m.sessions {
+		if sess.UserID == userID {
+			found := *sess
+			sessions = append(sessions, &found)
+		}
+	}
+
+	return sessions, nil
+}
+
+// Touch records the time a session was last used.
+func (m *memory) Touch(ctx context.Context, sessionID string, lastUsedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess, ok := m.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("session with id %s doesn't exist", sessionID)
+	}
+
+	sess.LastUsedAt = lastUsedAt
+	return nil
+}
+
+// Delete removes a session from memory.
+func (m *memory) Delete(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[sessionID]; !ok {
+		return fmt.Errorf("session with id %s doesn't exist", sessionID)
+	}
+
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+// DeleteByUser removes all sessions of a user except the one with exceptID.
+func (m *memory) DeleteByUser(ctx context.Context, userID, exceptID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int
+	for id, sess := range m.sessions {
+		if sess.UserID == userID && id != exceptID {
+			delete(m.sessions, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
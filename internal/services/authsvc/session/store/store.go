@@ -0,0 +1,40 @@
+// Package sessionstore defines the interface for interacting with the session storage layer.
+package sessionstore
+
+import (
+	"context"
+	"time"
+)
+
+// Session represents a signed in device or client of a user. Every token pair
+// issued at signin references its session through the sid claim.
+type Session struct {
+	ID         string    // Unique session identifier
+	UserID     string    // ID of the user who owns the session
+	IPAddress  string    // Client IP address at signin
+	UserAgent  string    // Client User-Agent at signin
+	CreatedAt  time.Time // Time the session was created
+	LastUsedAt time.Time // Time the session was last used to authenticate a request
+}
+
+// SessionStorer defines the contract for managing sessions in a storage backend.
+type SessionStorer interface {
+	// Create stores a new session.
+	Create(ctx context.Context, sess *Session) error
+
+	// QueryByID retrieves a session by its unique ID.
+	QueryByID(ctx context.Context, sessionID string) (*Session, error)
+
+	// QueryByUser returns all sessions belonging to the given user.
+	QueryByUser(ctx context.Context, userID string) ([]*Session, error)
+
+	// Touch records the time a session was last used.
+	Touch(ctx context.Context, sessionID string, lastUsedAt time.Time) error
+
+	// Delete removes a session so that tokens referencing it are no longer accepted.
+	Delete(ctx context.Context, sessionID string) error
+
+	// DeleteByUser removes all sessions of a user except the one with exceptID
+	// and returns the number of sessions removed.
+	DeleteByUser(ctx context.Context, userID, exceptID string) (int, error)
+}
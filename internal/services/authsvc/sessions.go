@@ -0,0 +1,100 @@
+package authsvc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	genauth "github.com/iamBelugaa/goa-iam/gen/auth"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// ListSessions returns the active sessions of the authenticated user.
+func (s *service) ListSessions(ctx context.Context, req *genauth.ListSessionsRequest) (*genauth.ListSessionsResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("list sessions request received", "userId", claims.Subject)
+
+	sessions, err := s.sessions.List(ctx, claims.Subject)
+	if err != nil {
+		logger.FromContext(ctx).Infow("list sessions error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeInternalServerError(err)
+	}
+
+	data := make([]*genauth.Session, 0, len(sessions))
+	for _, sess := range sessions {
+		data = append(data, &genauth.Session{
+			ID:         sess.ID,
+			IPAddress:  sess.IPAddress,
+			UserAgent:  sess.UserAgent,
+			CreatedAt:  sess.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: sess.LastUsedAt.Format(time.RFC3339),
+			Current:    sess.ID == claims.SessionID,
+		})
+	}
+
+	logger.FromContext(ctx).Infow("list sessions request successful", "userId", claims.Subject, "totalSessions", len(data))
+	return &genauth.ListSessionsResponse{
+		Success: true,
+		Message: "Sessions fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// RevokeSession revokes one of the authenticated user's sessions.
+func (s *service) RevokeSession(ctx context.Context, req *genauth.RevokeSessionRequest) (*genauth.RevokeSessionResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("revoke session request received", "userId", claims.Subject, "sessionId", req.ID)
+
+	err := s.sessions.Revoke(ctx, claims.Subject, req.ID)
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventSessionRevoke, ActorID: claims.Subject, TargetID: req.ID, Err: err,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("revoke session error", "userId", claims.Subject, "sessionId", req.ID, "error", err)
+		return nil, genauth.MakeNotFound(err)
+	}
+
+	logger.FromContext(ctx).Infow("revoke session request successful", "userId", claims.Subject, "sessionId", req.ID)
+	return &genauth.RevokeSessionResponse{
+		Success: true,
+		Message: "Session revoked successfully",
+	}, nil
+}
+
+// RevokeOtherSessions revokes every session of the authenticated user except the current one.
+func (s *service) RevokeOtherSessions(ctx context.Context, req *genauth.RevokeOtherSessionsRequest) (*genauth.RevokeSessionResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("revoke other sessions request received", "userId", claims.Subject, "sessionId", claims.SessionID)
+
+	revoked, err := s.sessions.RevokeOthers(ctx, claims.Subject, claims.SessionID)
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventSessionRevoke, ActorID: claims.Subject, Err: err,
+		Details: map[string]string{"keptSessionId": claims.SessionID, "revoked": strconv.Itoa(revoked)},
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("revoke other sessions error", "userId", claims.Subject, "error", err)
+		return nil, genauth.MakeInternalServerError(err)
+	}
+
+	logger.FromContext(ctx).Infow("revoke other sessions request successful", "userId", claims.Subject, "totalRevoked", revoked)
+	return &genauth.RevokeSessionResponse{
+		Success: true,
+		Message: fmt.Sprintf("Revoked %d other session(s) successfully", revoked),
+	}, nil
+}
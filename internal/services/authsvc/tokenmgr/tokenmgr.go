@@ -2,15 +2,19 @@
 package tokenmgr
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/iamBelugaa/goa-iam/gen/auth"
 	"github.com/iamBelugaa/goa-iam/internal/config"
 	"github.com/iamBelugaa/goa-iam/internal/domain/codes"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+	"github.com/iamBelugaa/goa-iam/internal/tracing"
 )
 
 // tokenType defines a custom string type used to distinguish token purposes.
@@ -22,10 +26,72 @@ var (
 	RefreshToken tokenType = "REFRESH_TOKEN"
 )
 
-// Claims wraps jwt.RegisteredClaims and adds a custom TokenType field.
+// principalType distinguishes who a token was issued to.
+type principalType string
+
+// Supported principal types. Tokens without a principal claim were issued to users.
+var (
+	PrincipalUser   principalType = "user"
+	PrincipalClient principalType = "client"
+)
+
+// Actor identifies the party acting on behalf of a token's subject (RFC 8693 section 4.1).
+// A nested actor identifies whoever the actor was in turn acting for, forming a chain
+// from the current actor back to the first.
+type Actor struct {
+	Subject       string        `json:"sub"`
+	PrincipalType principalType `json:"principal,omitempty"`
+	Actor         *Actor        `json:"act,omitempty"`
+}
+
+// Claims wraps jwt.RegisteredClaims and adds the token type, the tenant the token was
+// issued in, the session the token belongs to and, for tokens issued through OAuth, the client, granted scope, the
+// time the user authenticated and the actor the token was delegated to. Access tokens
+// issued to users also carry the roles and permissions the user effectively holds.
 type Claims struct {
 	jwt.RegisteredClaims
-	TokenType tokenType `json:"tokenType"`
+	TokenType     tokenType        `json:"tokenType"`
+	PrincipalType principalType    `json:"principal,omitempty"`
+	TenantID      string           `json:"tid,omitempty"`
+	SessionID     string           `json:"sid,omitempty"`
+	ClientID      string           `json:"client_id,omitempty"`
+	Scope         string           `json:"scope,omitempty"`
+	AuthTime      *jwt.NumericDate `json:"auth_time,omitempty"`
+	Actor         *Actor           `json:"act,omitempty"`
+	Roles         []string         `json:"roles,omitempty"`
+	Permissions   []string         `json:"permissions,omitempty"`
+}
+
+// Principal returns the type of principal identified by the token subject.
+func (c Claims) Principal() principalType {
+	if c.PrincipalType == "" {
+		return PrincipalUser
+	}
+	return c.PrincipalType
+}
+
+// claimsContextKey is the context key under which validated token claims are stored.
+type claimsContextKey struct{}
+
+// WithClaims returns a copy of ctx carrying the given validated claims.
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims attached to ctx by the JWT auth handler, if any.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(Claims)
+	return claims, ok
+}
+
+// UserClaimsFromContext returns the claims attached to ctx only when the token was
+// issued to a user, for operations acting on the caller's own account.
+func UserClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.Principal() != PrincipalUser {
+		return Claims{}, false
+	}
+	return claims, true
 }
 
 // JWTTokenManager is responsible for creating and validating JWT tokens
@@ -55,10 +121,7 @@ func NewJWTManager(cfg *config.Auth) *JWTTokenManager {
 // StandardClaims creates a JWT Claims object for the given subject and token type.
 // It sets fields like issuer, audience, issue time, expiration, etc.
 func (tm *JWTTokenManager) StandardClaims(sub string, tokenType tokenType) Claims {
-	expiration := tm.cfg.AccessTokenExpTime
-	if tokenType == RefreshToken {
-		expiration = tm.cfg.RefreshTokenExpTime
-	}
+	expiration := tm.TTL(tokenType)
 
 	return Claims{
 		TokenType: tokenType,
@@ -74,11 +137,21 @@ func (tm *JWTTokenManager) StandardClaims(sub string, tokenType tokenType) Claim
 	}
 }
 
+// TTL returns the configured lifetime of tokens of the given type.
+func (tm *JWTTokenManager) TTL(tokenType tokenType) time.Duration {
+	if tokenType == RefreshToken {
+		return tm.cfg.RefreshTokenExpTime
+	}
+	return tm.cfg.AccessTokenExpTime
+}
+
 // Generate signs the given claims and returns the corresponding JWT as a string.
-func (tm *JWTTokenManager) Generate(claims Claims) (string, error) {
+func (tm *JWTTokenManager) Generate(ctx context.Context, claims Claims) (string, error) {
+	_, span := tracing.Start(ctx, "JWTTokenManager.Generate", attribute.String("token.type", string(claims.TokenType)))
 	token := jwt.NewWithClaims(tm.method, claims)
 
 	signedToken, err := token.SignedString([]byte(tm.cfg.Secret))
+	tracing.End(span, err)
 	if err != nil {
 		return "", &auth.InternalServerError{
 			Message: "Failed to sign token string",
@@ -86,6 +159,7 @@ func (tm *JWTTokenManager) Generate(claims Claims) (string, error) {
 		}
 	}
 
+	metrics.ObserveTokenIssued(string(claims.TokenType))
 	return signedToken, nil
 }
 
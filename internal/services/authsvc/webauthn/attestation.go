@@ -0,0 +1,188 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Supported attestation statement formats.
+const (
+	AttestationFormatNone   = "none"
+	AttestationFormatPacked = "packed"
+)
+
+// Authenticator data flags as defined by the WebAuthn specification.
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+	flagExtensions   byte = 0x80
+)
+
+// Fixed sizes of the authenticator data layout.
+const (
+	rpIDHashSize     = 32
+	authDataMinSize  = rpIDHashSize + 1 + 4
+	aaguidSize       = 16
+	credIDLengthSize = 2
+)
+
+// oidFIDOAAGUID identifies the certificate extension carrying the authenticator AAGUID.
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// authenticatorData is the parsed binary structure signed by the authenticator.
+type authenticatorData struct {
+	rpIDHash            []byte
+	flags               byte
+	signCount           uint32
+	aaguid              []byte
+	credentialID        []byte
+	credentialPublicKey []byte
+}
+
+// attestationObject is the CBOR structure returned by the authenticator on registration.
+type attestationObject struct {
+	Format    string          `cbor:"fmt"`
+	Statement cbor.RawMessage `cbor:"attStmt"`
+	AuthData  []byte          `cbor:"authData"`
+}
+
+// packedStatement is the attestation statement of the "packed" format.
+type packedStatement struct {
+	Alg int64    `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// parseAuthenticatorData decodes the binary authenticator data structure.
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < authDataMinSize {
+		return nil, fmt.Errorf("authenticator data too short")
+	}
+
+	data := &authenticatorData{
+		rpIDHash:  raw[:rpIDHashSize],
+		flags:     raw[rpIDHashSize],
+		signCount: binary.BigEndian.Uint32(raw[rpIDHashSize+1 : authDataMinSize]),
+	}
+
+	rest := raw[authDataMinSize:]
+	if data.flags&flagAttestedData != 0 {
+		if len(rest) < aaguidSize+credIDLengthSize {
+			return nil, fmt.Errorf("attested credential data too short")
+		}
+
+		data.aaguid = rest[:aaguidSize]
+		idLength := int(binary.BigEndian.Uint16(rest[aaguidSize : aaguidSize+credIDLengthSize]))
+		rest = rest[aaguidSize+credIDLengthSize:]
+
+		if len(rest) < idLength {
+			return nil, fmt.Errorf("credential id exceeds authenticator data")
+		}
+		data.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		// The public key is a CBOR item of unknown length followed by optional extensions.
+		var key cbor.RawMessage
+		decoder := cbor.NewDecoder(bytes.NewReader(rest))
+		if err := decoder.Decode(&key); err != nil {
+			return nil, fmt.Errorf("decode credential public key : %w", err)
+		}
+		data.credentialPublicKey = key
+		rest = rest[decoder.NumBytesRead():]
+	}
+
+	if data.flags&flagExtensions != 0 {
+		var extensions map[string]any
+		decoder := cbor.NewDecoder(bytes.NewReader(rest))
+		if err := decoder.Decode(&extensions); err != nil {
+			return nil, fmt.Errorf("decode authenticator extensions : %w", err)
+		}
+		rest = rest[decoder.NumBytesRead():]
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("authenticator data has %d trailing bytes", len(rest))
+	}
+
+	return data, nil
+}
+
+// verifyAttestation validates the attestation statement against the authenticator data
+// and the hash of the client data. Only self and basic attestation are supported; the
+// attestation certificate chain is not validated against a trust anchor.
+func verifyAttestation(obj *attestationObject, authData *authenticatorData, credKey *publicKey, clientDataHash []byte) error {
+	switch obj.Format {
+	case AttestationFormatNone:
+		var statement map[string]any
+		if err := cbor.Unmarshal(obj.Statement, &statement); err != nil || len(statement) != 0 {
+			return fmt.Errorf("%w : none attestation must have an empty statement", ErrInvalidAttestation)
+		}
+		return nil
+
+	case AttestationFormatPacked:
+		var statement packedStatement
+		if err := cbor.Unmarshal(obj.Statement, &statement); err != nil {
+			return fmt.Errorf("%w : decode packed statement : %v", ErrInvalidAttestation, err)
+		}
+
+		signed := append(append([]byte{}, obj.AuthData...), clientDataHash...)
+
+		// Self attestation is signed by the credential private key itself.
+		if len(statement.X5C) == 0 {
+			if statement.Alg != credKey.alg {
+				return fmt.Errorf("%w : self attestation algorithm mismatch", ErrInvalidAttestation)
+			}
+			if err := credKey.verify(signed, statement.Sig); err != nil {
+				return fmt.Errorf("%w : self attestation signature", ErrInvalidAttestation)
+			}
+			return nil
+		}
+
+		return verifyPackedCertificate(statement, authData, signed)
+	}
+
+	return fmt.Errorf("%w : format %q", ErrUnsupportedAttestation, obj.Format)
+}
+
+// verifyPackedCertificate validates a packed attestation signed by an attestation certificate.
+func verifyPackedCertificate(statement packedStatement, authData *authenticatorData, signed []byte) error {
+	cert, err := x509.ParseCertificate(statement.X5C[0])
+	if err != nil {
+		return fmt.Errorf("%w : parse attestation certificate : %v", ErrInvalidAttestation, err)
+	}
+
+	sigAlg, err := x509SignatureAlgorithm(statement.Alg)
+	if err != nil {
+		return fmt.Errorf("%w : %v", ErrInvalidAttestation, err)
+	}
+	if err := cert.CheckSignature(sigAlg, signed, statement.Sig); err != nil {
+		return fmt.Errorf("%w : attestation signature : %v", ErrInvalidAttestation, err)
+	}
+
+	// Certificate requirements from the packed attestation statement format.
+	if cert.Version != 3 || cert.IsCA {
+		return fmt.Errorf("%w : attestation certificate must be a v3 leaf", ErrInvalidAttestation)
+	}
+	if len(cert.Subject.OrganizationalUnit) != 1 || cert.Subject.OrganizationalUnit[0] != "Authenticator Attestation" {
+		return fmt.Errorf("%w : attestation certificate subject", ErrInvalidAttestation)
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+
+		var aaguid []byte
+		if _, err := asn1.Unmarshal(ext.Value, &aaguid); err != nil || !bytes.Equal(aaguid, authData.aaguid) {
+			return fmt.Errorf("%w : attestation certificate aaguid mismatch", ErrInvalidAttestation)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// challengeSize is the number of random bytes used for each ceremony challenge.
+const challengeSize = 32
+
+// session holds the server side state bound to an issued challenge.
+type session struct {
+	ceremony  string    // Ceremony the challenge was issued for
+	userID    string    // User the challenge was issued to, empty for discoverable logins
+	expiresAt time.Time // Time after which the challenge is no longer accepted
+}
+
+// challengeCache keeps outstanding challenges in memory until they are consumed or expire.
+type challengeCache struct {
+	mu       sync.Mutex         // protects access to sessions
+	sessions map[string]session // maps encoded challenges to their sessions
+}
+
+// newChallengeCache creates an empty challenge cache.
+func newChallengeCache() *challengeCache {
+	return &challengeCache{sessions: make(map[string]session)}
+}
+
+// issue generates a new random challenge and binds it to the given session.
+func (c *challengeCache) issue(s session) ([]byte, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired challenges so abandoned ceremonies don't accumulate.
+	now := time.Now()
+	for key, existing := range c.sessions {
+		if now.After(existing.expiresAt) {
+			delete(c.sessions, key)
+		}
+	}
+
+	c.sessions[base64.RawURLEncoding.EncodeToString(challenge)] = s
+	return challenge, nil
+}
+
+// consume removes the challenge from the cache and returns its session
+// if it exists, has not expired and was issued for the given ceremony.
+func (c *challengeCache) consume(challenge []byte, ceremony string) (session, bool) {
+	key := base64.RawURLEncoding.EncodeToString(challenge)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sessions[key]
+	if !ok {
+		return session{}, false
+	}
+	delete(c.sessions, key)
+
+	if time.Now().After(s.expiresAt) || s.ceremony != ceremony {
+		return session{}, false
+	}
+	return s, true
+}
@@ -0,0 +1,170 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE algorithm identifiers supported for credential public keys.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the COSE algorithms advertised during registration, in order of preference.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key types and parameters as defined in RFC 9053.
+const (
+	coseKeyTypeOKP int64 = 1
+	coseKeyTypeEC2 int64 = 2
+	coseKeyTypeRSA int64 = 3
+
+	coseCurveP256    int64 = 1
+	coseCurveEd25519 int64 = 6
+
+	coseLabelKeyType int64 = 1
+	coseLabelAlg     int64 = 3
+	coseLabelCrv     int64 = -1 // EC2 and OKP curve, RSA modulus
+	coseLabelX       int64 = -2 // EC2 and OKP x coordinate, RSA exponent
+	coseLabelY       int64 = -3 // EC2 y coordinate
+
+	minRSAKeyBits = 2048 // Smallest RSA modulus accepted for credential keys
+)
+
+// publicKey is a parsed COSE credential public key.
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key structure into a usable public key.
+func parsePublicKey(raw []byte) (*publicKey, error) {
+	var params map[int64]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("decode cose key : %w", err)
+	}
+
+	var kty, alg int64
+	if err := decodeParam(params, coseLabelKeyType, &kty); err != nil {
+		return nil, err
+	}
+	if err := decodeParam(params, coseLabelAlg, &alg); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		var crv int64
+		var x, y []byte
+		if err := decodeParams(params, map[int64]any{coseLabelCrv: &crv, coseLabelX: &x, coseLabelY: &y}); err != nil {
+			return nil, err
+		}
+		if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("unsupported ec2 key curve %d", crv)
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("ec2 key point is not on curve")
+		}
+		return &publicKey{alg: alg, key: key}, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		var crv int64
+		var x []byte
+		if err := decodeParams(params, map[int64]any{coseLabelCrv: &crv, coseLabelX: &x}); err != nil {
+			return nil, err
+		}
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported okp key curve %d", crv)
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		var n, e []byte
+		if err := decodeParams(params, map[int64]any{coseLabelCrv: &n, coseLabelX: &e}); err != nil {
+			return nil, err
+		}
+
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key of %d bits is shorter than %d bits", modulus.BitLen(), minRSAKeyBits)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("invalid rsa public exponent")
+		}
+		return &publicKey{alg: alg, key: &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported cose key type %d with algorithm %d", kty, alg)
+}
+
+// verify checks the signature over data using the algorithm bound to the key.
+func (k *publicKey) verify(data, sig []byte) error {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return ErrInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return ErrInvalidSignature
+		}
+	default:
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// x509SignatureAlgorithm maps a COSE algorithm to its x509 counterpart for certificate based attestation.
+func x509SignatureAlgorithm(alg int64) (x509.SignatureAlgorithm, error) {
+	switch alg {
+	case AlgES256:
+		return x509.ECDSAWithSHA256, nil
+	case AlgEdDSA:
+		return x509.PureEd25519, nil
+	case AlgRS256:
+		return x509.SHA256WithRSA, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported attestation algorithm %d", alg)
+}
+
+// decodeParams decodes each requested COSE key parameter into its destination.
+func decodeParams(params map[int64]cbor.RawMessage, dst map[int64]any) error {
+	for label, v := range dst {
+		if err := decodeParam(params, label, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeParam decodes a single required COSE key parameter.
+func decodeParam(params map[int64]cbor.RawMessage, label int64, v any) error {
+	raw, ok := params[label]
+	if !ok {
+		return fmt.Errorf("cose key parameter %d is missing", label)
+	}
+	if err := cbor.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("decode cose key parameter %d : %w", label, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package credentialstore provides an in-memory implementation of the CredentialStorer interface.
+package credentialstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	credentialstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store"
+)
+
+// memory implements the CredentialStorer interface using in-memory maps.
+type memory struct {
+	mu          sync.RWMutex                           // protects access to credentials and userToIds
+	userToIds   map[string][]string                    // maps user IDs to their encoded credential IDs
+	credentials map[string]*credentialstore.Credential // stores credentials by encoded credential ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory credential store.
+func NewMemoryStore() *memory {
+	return &memory{
+		userToIds:   make(map[string][]string),
+		credentials: make(map[string]*credentialstore.Credential),
+	}
+}
+
+// Create adds a new credential to the in-memory store.
+func (m *memory) Create(ctx context.Context, cred *credentialstore.Credential) error {
+	key := encodeID(cred.ID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.credentials[key]; exists {
+		return fmt.Errorf("credential %s already exists", key)
+	}
+
+	stored := *cred
+	m.credentials[key] = &stored
+	m.userToIds[cred.UserID] = append(m.userToIds[cred.UserID], key)
+
+	return nil
+}
+
+// QueryByID retrieves a credential from memory by its credential ID.
+func (m *memory) QueryByID(ctx context.Context, credentialID []byte) (*credentialstore.Credential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cred, ok := m.credentials[encodeID(credentialID)]
+	if !ok {
+		return nil, fmt.Errorf("credential %s doesn't exist", encodeID(credentialID))
+	}
+
+	found := *cred
+	return &found, nil
+}
+
+// QueryByUser returns all credentials registered by the given user.
+func (m *memory) QueryByUser(ctx context.Context, userID string) ([]*credentialstore.Credential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := m.userToIds[userID]
+	creds := make([]*credentialstore.Credential, 0, len(keys))
+
+	for _, key := range keys {
+		cred := *m.credentials[key]
+		creds = append(creds, &cred)
+	}
+
+	return creds, nil
+}
+
+// UpdateSignCount records the latest signature counter and usage time for a credential.
+func (m *memory) UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, lastUsedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cred, ok := m.credentials[encodeID(credentialID)]
+	if !ok {
+		return fmt.Errorf("credential %s doesn't exist", encodeID(credentialID))
+	}
+
+	cred.SignCount = signCount
+	cred.LastUsedAt = lastUsedAt
+
+	return nil
+}
+
+// encodeID converts a raw credential ID into a map key.
+func encodeID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
@@ -0,0 +1,34 @@
+// Package credentialstore defines the interface for interacting with the passkey credential storage layer.
+package credentialstore
+
+import (
+	"context"
+	"time"
+)
+
+// Credential represents a WebAuthn public key credential registered by a user.
+type Credential struct {
+	ID                []byte    // Credential ID assigned by the authenticator
+	UserID            string    // ID of the user who owns the credential
+	PublicKey         []byte    // COSE encoded credential public key
+	AttestationFormat string    // Attestation statement format used at registration
+	AAGUID            []byte    // Authenticator attestation GUID
+	SignCount         uint32    // Last signature counter reported by the authenticator
+	CreatedAt         time.Time // Time the credential was registered
+	LastUsedAt        time.Time // Time the credential was last used for an assertion
+}
+
+// CredentialStorer defines the contract for managing passkey credentials in a storage backend.
+type CredentialStorer interface {
+	// Create stores a newly registered credential.
+	Create(ctx context.Context, cred *Credential) error
+
+	// QueryByID retrieves a credential by its credential ID.
+	QueryByID(ctx context.Context, credentialID []byte) (*Credential, error)
+
+	// QueryByUser returns all credentials registered by the given user.
+	QueryByUser(ctx context.Context, userID string) ([]*Credential, error)
+
+	// UpdateSignCount records the latest signature counter and usage time for a credential.
+	UpdateSignCount(ctx context.Context, credentialID []byte, signCount uint32, lastUsedAt time.Time) error
+}
@@ -0,0 +1,315 @@
+// Package webauthn implements the relying party side of the WebAuthn registration
+// and assertion ceremonies used for passkey login.
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	credentialstore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store"
+)
+
+// Ceremony types reported by the client in the collected client data.
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// Errors returned when a ceremony fails verification.
+var (
+	ErrChallengeMismatch      = errors.New("challenge not issued, expired or already used")
+	ErrInvalidClientData      = errors.New("invalid client data")
+	ErrOriginMismatch         = errors.New("origin not allowed")
+	ErrRPIDMismatch           = errors.New("relying party id hash mismatch")
+	ErrUserNotPresent         = errors.New("user presence flag not set")
+	ErrInvalidSignature       = errors.New("invalid assertion signature")
+	ErrInvalidAttestation     = errors.New("invalid attestation statement")
+	ErrUnsupportedAttestation = errors.New("unsupported attestation format")
+	ErrCredentialNotAllowed   = errors.New("credential not allowed for this ceremony")
+	ErrSignCountRegression    = errors.New("signature counter did not increase, possible cloned authenticator")
+)
+
+// CreationOptions holds the parameters a client needs to create a new credential.
+type CreationOptions struct {
+	Challenge          []byte
+	RPID               string
+	RPName             string
+	UserID             []byte
+	UserName           string
+	UserDisplayName    string
+	Algorithms         []int64
+	ExcludeCredentials [][]byte
+	Timeout            time.Duration
+}
+
+// RequestOptions holds the parameters a client needs to produce an assertion.
+type RequestOptions struct {
+	Challenge        []byte
+	RPID             string
+	AllowCredentials [][]byte
+	Timeout          time.Duration
+}
+
+// AttestationResponse is the authenticator response to a registration ceremony.
+type AttestationResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// AssertionResponse is the authenticator response to a login ceremony.
+type AssertionResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// clientData is the JSON structure collected by the client during a ceremony.
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// RelyingParty verifies WebAuthn ceremonies and persists the resulting credentials.
+type RelyingParty struct {
+	cfg        *config.WebAuthn                 // Relying party identity and allowed origins
+	store      credentialstore.CredentialStorer // Storage for registered credentials
+	challenges *challengeCache                  // Outstanding ceremony challenges
+}
+
+// NewRelyingParty creates a relying party for the given configuration and credential store.
+func NewRelyingParty(cfg *config.WebAuthn, store credentialstore.CredentialStorer) *RelyingParty {
+	return &RelyingParty{
+		cfg:        cfg,
+		store:      store,
+		challenges: newChallengeCache(),
+	}
+}
+
+// BeginRegistration issues a challenge for registering a new passkey for the given user.
+func (rp *RelyingParty) BeginRegistration(ctx context.Context, userID, userName, displayName string) (*CreationOptions, error) {
+	existing, err := rp.store.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := rp.challenges.issue(session{
+		ceremony:  ceremonyCreate,
+		userID:    userID,
+		expiresAt: time.Now().Add(rp.cfg.ChallengeTimeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate challenge : %w", err)
+	}
+
+	exclude := make([][]byte, 0, len(existing))
+	for _, cred := range existing {
+		exclude = append(exclude, cred.ID)
+	}
+
+	return &CreationOptions{
+		Challenge:          challenge,
+		RPID:               rp.cfg.RPID,
+		RPName:             rp.cfg.RPDisplayName,
+		UserID:             []byte(userID),
+		UserName:           userName,
+		UserDisplayName:    displayName,
+		Algorithms:         SupportedAlgorithms,
+		ExcludeCredentials: exclude,
+		Timeout:            rp.cfg.ChallengeTimeout,
+	}, nil
+}
+
+// FinishRegistration verifies the attestation produced for a registration challenge
+// and stores the new credential for the given user.
+func (rp *RelyingParty) FinishRegistration(ctx context.Context, userID string, resp *AttestationResponse) (*credentialstore.Credential, error) {
+	sess, err := rp.verifyClientData(resp.ClientDataJSON, ceremonyCreate)
+	if err != nil {
+		return nil, err
+	}
+	if sess.userID != userID {
+		return nil, ErrChallengeMismatch
+	}
+
+	var obj attestationObject
+	if err := cbor.Unmarshal(resp.AttestationObject, &obj); err != nil {
+		return nil, fmt.Errorf("%w : decode attestation object : %v", ErrInvalidAttestation, err)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(obj.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 {
+		return nil, fmt.Errorf("%w : attested credential data missing", ErrInvalidAttestation)
+	}
+	if len(resp.CredentialID) > 0 && !bytes.Equal(resp.CredentialID, authData.credentialID) {
+		return nil, fmt.Errorf("%w : credential id mismatch", ErrInvalidAttestation)
+	}
+
+	credKey, err := parsePublicKey(authData.credentialPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidAttestation, err)
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	if err := verifyAttestation(&obj, authData, credKey, clientDataHash[:]); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cred := &credentialstore.Credential{
+		ID:                authData.credentialID,
+		UserID:            userID,
+		PublicKey:         authData.credentialPublicKey,
+		AttestationFormat: obj.Format,
+		AAGUID:            authData.aaguid,
+		SignCount:         authData.signCount,
+		CreatedAt:         now,
+		LastUsedAt:        now,
+	}
+
+	if err := rp.store.Create(ctx, cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// BeginLogin issues a challenge for a passkey login. When userID is empty the
+// challenge can be answered by any discoverable credential.
+func (rp *RelyingParty) BeginLogin(ctx context.Context, userID string) (*RequestOptions, error) {
+	var allow [][]byte
+	if userID != "" {
+		creds, err := rp.store.QueryByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, cred := range creds {
+			allow = append(allow, cred.ID)
+		}
+	}
+
+	challenge, err := rp.challenges.issue(session{
+		ceremony:  ceremonyGet,
+		userID:    userID,
+		expiresAt: time.Now().Add(rp.cfg.ChallengeTimeout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate challenge : %w", err)
+	}
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: allow,
+		Timeout:          rp.cfg.ChallengeTimeout,
+	}, nil
+}
+
+// FinishLogin verifies an assertion against the stored credential and returns the
+// credential with its updated signature counter.
+func (rp *RelyingParty) FinishLogin(ctx context.Context, resp *AssertionResponse) (*credentialstore.Credential, error) {
+	sess, err := rp.verifyClientData(resp.ClientDataJSON, ceremonyGet)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := rp.store.QueryByID(ctx, resp.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrCredentialNotAllowed, err)
+	}
+	if sess.userID != "" && sess.userID != cred.UserID {
+		return nil, ErrCredentialNotAllowed
+	}
+	if len(resp.UserHandle) > 0 && string(resp.UserHandle) != cred.UserID {
+		return nil, ErrCredentialNotAllowed
+	}
+
+	authData, err := rp.verifyAuthenticatorData(resp.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	credKey, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.ClientDataJSON)
+	signed := append(append([]byte{}, resp.AuthenticatorData...), clientDataHash[:]...)
+	if err := credKey.verify(signed, resp.Signature); err != nil {
+		return nil, err
+	}
+
+	// A counter that fails to increase indicates the private key may have been cloned.
+	// Authenticators that don't implement counters always report zero.
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		return nil, ErrSignCountRegression
+	}
+
+	cred.SignCount = authData.signCount
+	cred.LastUsedAt = time.Now()
+	if err := rp.store.UpdateSignCount(ctx, cred.ID, cred.SignCount, cred.LastUsedAt); err != nil {
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// verifyClientData checks the collected client data and consumes the challenge it references.
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony string) (session, error) {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return session{}, fmt.Errorf("%w : %v", ErrInvalidClientData, err)
+	}
+	if data.Type != ceremony {
+		return session{}, fmt.Errorf("%w : unexpected type %q", ErrInvalidClientData, data.Type)
+	}
+	if !slices.Contains(rp.cfg.RPOrigins, data.Origin) {
+		return session{}, fmt.Errorf("%w : %s", ErrOriginMismatch, data.Origin)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil {
+		return session{}, fmt.Errorf("%w : challenge encoding", ErrInvalidClientData)
+	}
+
+	sess, ok := rp.challenges.consume(challenge, ceremony)
+	if !ok {
+		return session{}, ErrChallengeMismatch
+	}
+	return sess, nil
+}
+
+// verifyAuthenticatorData parses the authenticator data and checks the relying party
+// binding and user presence.
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %v", ErrInvalidAttestation, err)
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return nil, ErrRPIDMismatch
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return nil, ErrUserNotPresent
+	}
+
+	return authData, nil
+}
@@ -0,0 +1,402 @@
+package webauthn_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn"
+	credentialmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/webauthn/store/memory"
+)
+
+func TestWebAuthn(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WebAuthn Suite")
+}
+
+// softAuthenticator is a minimal software authenticator producing
+// attestations and assertions with an ES256 key.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	aaguid       []byte
+	signCount    uint32
+	rpID         string
+	origin       string
+	coseKey      []byte // Credential public key registered instead of the ES256 key, if set
+}
+
+func newSoftAuthenticator(rpID, origin string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &softAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+		aaguid:       make([]byte, 16),
+		rpID:         rpID,
+		origin:       origin,
+	}
+}
+
+func (a *softAuthenticator) cosePublicKey() []byte {
+	if a.coseKey != nil {
+		return a.coseKey
+	}
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+
+	key, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+func (a *softAuthenticator) authenticatorData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(0x01 | 0x04)
+	if attested {
+		flags |= 0x40
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.cosePublicKey()...)
+	}
+	return data
+}
+
+func (a *softAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.origin,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+func (a *softAuthenticator) sign(authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	Expect(err).NotTo(HaveOccurred())
+	return sig
+}
+
+func (a *softAuthenticator) create(challenge []byte, format string, statement map[string]any) *webauthn.AttestationResponse {
+	clientDataJSON := a.clientData("webauthn.create", challenge)
+	authData := a.authenticatorData(true)
+
+	if statement == nil {
+		statement = map[string]any{}
+		if format == webauthn.AttestationFormatPacked {
+			statement = map[string]any{"alg": webauthn.AlgES256, "sig": a.sign(authData, clientDataJSON)}
+		}
+	}
+
+	attestationObject, err := cbor.Marshal(map[string]any{"fmt": format, "attStmt": statement, "authData": authData})
+	Expect(err).NotTo(HaveOccurred())
+
+	return &webauthn.AttestationResponse{
+		CredentialID:      a.credentialID,
+		ClientDataJSON:    clientDataJSON,
+		AttestationObject: attestationObject,
+	}
+}
+
+func (a *softAuthenticator) get(challenge []byte, userHandle []byte) *webauthn.AssertionResponse {
+	a.signCount++
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	authData := a.authenticatorData(false)
+
+	return &webauthn.AssertionResponse{
+		CredentialID:      a.credentialID,
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         a.sign(authData, clientDataJSON),
+		UserHandle:        userHandle,
+	}
+}
+
+// attestationCertificate issues a self-signed packed attestation certificate for the authenticator.
+func (a *softAuthenticator) attestationCertificate(attestationKey *ecdsa.PrivateKey) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"Soft Authenticator"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Soft Authenticator Attestation",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &attestationKey.PublicKey, attestationKey)
+	Expect(err).NotTo(HaveOccurred())
+	return der
+}
+
+var _ = Describe("RelyingParty", func() {
+	const (
+		userID = "4d2efde6-448a-4c26-a69a-26c2f9a6de4a"
+		rpID   = "localhost"
+		origin = "http://localhost:8080"
+	)
+
+	var (
+		ctx           context.Context
+		rp            *webauthn.RelyingParty
+		authenticator *softAuthenticator
+	)
+
+	register := func(format string) {
+		opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+		Expect(err).NotTo(HaveOccurred())
+
+		cred, err := rp.FinishRegistration(ctx, userID, authenticator.create(opts.Challenge, format, nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cred.UserID).To(Equal(userID))
+		Expect(cred.AttestationFormat).To(Equal(format))
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		rp = webauthn.NewRelyingParty(&config.WebAuthn{
+			RPID:             rpID,
+			RPDisplayName:    "IAM Platform",
+			RPOrigins:        []string{origin},
+			ChallengeTimeout: time.Minute,
+		}, credentialmemorystore.NewMemoryStore())
+		authenticator = newSoftAuthenticator(rpID, origin)
+	})
+
+	Describe("registration", func() {
+		It("should issue creation options for the user", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.Challenge).To(HaveLen(32))
+			Expect(opts.RPID).To(Equal(rpID))
+			Expect(opts.UserID).To(Equal([]byte(userID)))
+			Expect(opts.Algorithms).To(ContainElement(webauthn.AlgES256))
+		})
+
+		It("should register a credential with none attestation", func() {
+			register(webauthn.AttestationFormatNone)
+		})
+
+		It("should register a credential with packed self attestation", func() {
+			register(webauthn.AttestationFormatPacked)
+		})
+
+		It("should register a credential with packed certificate attestation", func() {
+			attestationKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			clientDataJSON := authenticator.clientData("webauthn.create", opts.Challenge)
+			authData := authenticator.authenticatorData(true)
+			clientDataHash := sha256.Sum256(clientDataJSON)
+			digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+			sig, err := ecdsa.SignASN1(rand.Reader, attestationKey, digest[:])
+			Expect(err).NotTo(HaveOccurred())
+
+			attestationObject, err := cbor.Marshal(map[string]any{
+				"fmt":      webauthn.AttestationFormatPacked,
+				"authData": authData,
+				"attStmt": map[string]any{
+					"alg": webauthn.AlgES256,
+					"sig": sig,
+					"x5c": [][]byte{authenticator.attestationCertificate(attestationKey)},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishRegistration(ctx, userID, &webauthn.AttestationResponse{
+				CredentialID:      authenticator.credentialID,
+				ClientDataJSON:    clientDataJSON,
+				AttestationObject: attestationObject,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a packed attestation with an invalid signature", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			resp := authenticator.create(opts.Challenge, webauthn.AttestationFormatPacked, map[string]any{
+				"alg": webauthn.AlgES256,
+				"sig": []byte("not-a-signature"),
+			})
+
+			_, err = rp.FinishRegistration(ctx, userID, resp)
+			Expect(err).To(MatchError(webauthn.ErrInvalidAttestation))
+		})
+
+		It("should reject a challenge that was already used", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			resp := authenticator.create(opts.Challenge, webauthn.AttestationFormatNone, nil)
+			_, err = rp.FinishRegistration(ctx, userID, resp)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishRegistration(ctx, userID, resp)
+			Expect(err).To(MatchError(webauthn.ErrChallengeMismatch))
+		})
+
+		It("should reject a challenge issued to another user", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishRegistration(ctx, "another-user", authenticator.create(opts.Challenge, webauthn.AttestationFormatNone, nil))
+			Expect(err).To(MatchError(webauthn.ErrChallengeMismatch))
+		})
+
+		It("should reject an origin that is not allowed", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			authenticator.origin = "https://evil.example.com"
+			_, err = rp.FinishRegistration(ctx, userID, authenticator.create(opts.Challenge, webauthn.AttestationFormatNone, nil))
+			Expect(err).To(MatchError(webauthn.ErrOriginMismatch))
+		})
+
+		It("should reject authenticator data scoped to another relying party", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			authenticator.rpID = "evil.example.com"
+			_, err = rp.FinishRegistration(ctx, userID, authenticator.create(opts.Challenge, webauthn.AttestationFormatNone, nil))
+			Expect(err).To(MatchError(webauthn.ErrRPIDMismatch))
+		})
+
+		It("should reject rsa keys shorter than 2048 bits", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 1024)
+			Expect(err).NotTo(HaveOccurred())
+			authenticator.coseKey, err = cbor.Marshal(map[int]any{
+				1: 3, 3: webauthn.AlgRS256, -1: key.N.Bytes(), -2: big.NewInt(int64(key.E)).Bytes(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishRegistration(ctx, userID, authenticator.create(opts.Challenge, webauthn.AttestationFormatNone, nil))
+			Expect(err).To(MatchError(webauthn.ErrInvalidAttestation))
+			Expect(err).To(MatchError(ContainSubstring("shorter than 2048 bits")))
+		})
+
+		It("should exclude already registered credentials", func() {
+			register(webauthn.AttestationFormatNone)
+
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.ExcludeCredentials).To(Equal([][]byte{authenticator.credentialID}))
+		})
+	})
+
+	Describe("login", func() {
+		BeforeEach(func() {
+			register(webauthn.AttestationFormatNone)
+		})
+
+		It("should verify an assertion for a user scoped challenge", func() {
+			opts, err := rp.BeginLogin(ctx, userID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.AllowCredentials).To(Equal([][]byte{authenticator.credentialID}))
+
+			cred, err := rp.FinishLogin(ctx, authenticator.get(opts.Challenge, nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cred.UserID).To(Equal(userID))
+			Expect(cred.SignCount).To(Equal(uint32(1)))
+		})
+
+		It("should verify an assertion from a discoverable credential", func() {
+			opts, err := rp.BeginLogin(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.AllowCredentials).To(BeEmpty())
+
+			cred, err := rp.FinishLogin(ctx, authenticator.get(opts.Challenge, []byte(userID)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cred.UserID).To(Equal(userID))
+		})
+
+		It("should reject a user handle that doesn't own the credential", func() {
+			opts, err := rp.BeginLogin(ctx, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishLogin(ctx, authenticator.get(opts.Challenge, []byte("another-user")))
+			Expect(err).To(MatchError(webauthn.ErrCredentialNotAllowed))
+		})
+
+		It("should reject an assertion signed by another key", func() {
+			opts, err := rp.BeginLogin(ctx, userID)
+			Expect(err).NotTo(HaveOccurred())
+
+			impostor := newSoftAuthenticator(rpID, origin)
+			impostor.credentialID = authenticator.credentialID
+
+			_, err = rp.FinishLogin(ctx, impostor.get(opts.Challenge, nil))
+			Expect(err).To(MatchError(webauthn.ErrInvalidSignature))
+		})
+
+		It("should reject a signature counter that did not increase", func() {
+			for range 2 {
+				opts, err := rp.BeginLogin(ctx, userID)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = rp.FinishLogin(ctx, authenticator.get(opts.Challenge, nil))
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			opts, err := rp.BeginLogin(ctx, userID)
+			Expect(err).NotTo(HaveOccurred())
+
+			authenticator.signCount = 0
+			_, err = rp.FinishLogin(ctx, authenticator.get(opts.Challenge, nil))
+			Expect(err).To(MatchError(webauthn.ErrSignCountRegression))
+		})
+
+		It("should reject a registration challenge used for login", func() {
+			opts, err := rp.BeginRegistration(ctx, userID, "john@gmail.com", "John Doe")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = rp.FinishLogin(ctx, authenticator.get(opts.Challenge, nil))
+			Expect(err).To(MatchError(webauthn.ErrChallengeMismatch))
+		})
+	})
+})
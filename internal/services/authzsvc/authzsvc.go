@@ -0,0 +1,363 @@
+// Package authzsvc provides the authorization API other services call to find out whether a
+// subject may perform an action on a resource. Decisions come from a policy of attribute
+// conditions and relationship tuples, see package policy, and relationships are managed per
+// tenant through the same API.
+package authzsvc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"goa.design/goa/v3/security"
+
+	genauthz "github.com/iamBelugaa/goa-iam/gen/authz"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/authzsvc/policy"
+	tuplestore "github.com/iamBelugaa/goa-iam/internal/services/authzsvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// subjectTypeUser is the subject type whose attributes are looked up instead of trusted.
+const subjectTypeUser = "user"
+
+// service implements authorization checks and relationship management.
+type service struct {
+	log        *logger.Logger         // Logger for structured logging
+	engine     *policy.Engine         // Engine evaluating checks against the policy
+	tupleStore tuplestore.TupleStorer // Interface to the relationship data store
+	userStore  userstore.UserStorer   // Interface to the user data store
+	access     *membership.Resolver   // Resolver of the groups, roles and permissions of users
+	authn      *jwtauth.Authenticator // Token authenticator for secured methods
+	audit      *audit.Recorder        // Recorder of security events
+}
+
+// NewService initializes and returns a new authorization service instance.
+func NewService(
+	log *logger.Logger, p *policy.Policy, tupleStore tuplestore.TupleStorer,
+	userStore userstore.UserStorer, access *membership.Resolver, authn *jwtauth.Authenticator,
+	recorder *audit.Recorder,
+) *service {
+	return &service{
+		log:        log,
+		engine:     policy.NewEngine(p, tupleStore),
+		tupleStore: tupleStore,
+		userStore:  userStore,
+		access:     access,
+		authn:      authn,
+		audit:      recorder,
+	}
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// Check decides whether a subject may perform an action on a resource.
+func (s *service) Check(ctx context.Context, req *genauthz.CheckRequest) (*genauthz.CheckResponse, error) {
+	check := &genauthz.AuthzCheck{Subject: req.Subject, Action: req.Action, Resource: req.Resource, Context: req.Context}
+
+	claims, err := authorizeCheck(ctx, check)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("check request received", "principal", claims.Subject, "subject", entityRef(req.Subject), "action", req.Action)
+
+	decision, err := s.check(ctx, check, req.Explain)
+	if err != nil {
+		s.log.Infow("check error", "subject", entityRef(req.Subject), "action", req.Action, "error", err)
+		return nil, genauthz.MakeInternalServerError(err)
+	}
+
+	s.log.Infow("check request successful", "subject", entityRef(req.Subject), "action", req.Action, "allowed", decision.Allowed)
+	return &genauthz.CheckResponse{
+		Success: true,
+		Message: "Check evaluated successfully",
+		Data:    decision,
+	}, nil
+}
+
+// BatchCheck decides several checks, returning the decisions in the order of the checks.
+func (s *service) BatchCheck(ctx context.Context, req *genauthz.BatchCheckRequest) (*genauthz.BatchCheckResponse, error) {
+	var claims tokenmgr.Claims
+	for _, check := range req.Checks {
+		var err error
+		if claims, err = authorizeCheck(ctx, check); err != nil {
+			return nil, err
+		}
+	}
+	s.log.Infow("batch check request received", "principal", claims.Subject, "totalChecks", len(req.Checks))
+
+	data := make([]*genauthz.AuthzDecision, 0, len(req.Checks))
+	for _, check := range req.Checks {
+		decision, err := s.check(ctx, check, req.Explain)
+		if err != nil {
+			s.log.Infow("batch check error", "subject", entityRef(check.Subject), "action", check.Action, "error", err)
+			return nil, genauthz.MakeInternalServerError(err)
+		}
+		data = append(data, decision)
+	}
+
+	s.log.Infow("batch check request successful", "totalChecks", len(data))
+	return &genauthz.BatchCheckResponse{
+		Success: true,
+		Message: "Checks evaluated successfully",
+		Data:    data,
+	}, nil
+}
+
+// ListRelationships returns the relationships of the tenant ordered by object, relation and subject.
+func (s *service) ListRelationships(ctx context.Context, req *genauthz.ListRelationshipsRequest) (*genauthz.ListRelationshipsResponse, error) {
+	claims, err := authorizeRelationships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list relationships request received", "principal", claims.Subject, "object", req.Object)
+
+	var object string
+	if req.Object != nil {
+		object = *req.Object
+	}
+
+	tuples, err := s.tupleStore.List(ctx, tenancy.FromContext(ctx), object)
+	if err != nil {
+		s.log.Infow("list relationships error", "error", err)
+		return nil, genauthz.MakeInternalServerError(err)
+	}
+	slices.SortFunc(tuples, func(a, b *tuplestore.Tuple) int {
+		return strings.Compare(tupleKey(a), tupleKey(b))
+	})
+
+	data := make([]*genauthz.Relationship, 0, len(tuples))
+	for _, tuple := range tuples {
+		data = append(data, toRelationship(tuple))
+	}
+
+	s.log.Infow("list relationships request successful", "totalRelationships", len(data))
+	return &genauthz.ListRelationshipsResponse{
+		Success: true,
+		Message: "Relationships fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// WriteRelationship stores a relationship of the tenant.
+func (s *service) WriteRelationship(ctx context.Context, req *genauthz.RelationshipRequest) (*genauthz.RelationshipResponse, error) {
+	claims, err := authorizeRelationships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("write relationship request received", "principal", claims.Subject, "object", req.Object, "relation", req.Relation, "subject", req.Subject)
+
+	tuple := &tuplestore.Tuple{
+		TenantID:  tenancy.FromContext(ctx),
+		Object:    req.Object,
+		Relation:  req.Relation,
+		Subject:   req.Subject,
+		CreatedAt: time.Now(),
+	}
+	if err := s.tupleStore.Write(ctx, tuple); err != nil {
+		s.log.Infow("write relationship error", "relationship", tupleKey(tuple), "error", err)
+		return nil, genauthz.MakeConflict(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventRelationshipWrite, TargetID: tupleKey(tuple)})
+
+	s.log.Infow("write relationship request successful", "relationship", tupleKey(tuple))
+	return &genauthz.RelationshipResponse{
+		Success: true,
+		Message: "Relationship written successfully",
+		Data:    toRelationship(tuple),
+	}, nil
+}
+
+// DeleteRelationship removes a relationship of the tenant.
+func (s *service) DeleteRelationship(ctx context.Context, req *genauthz.RelationshipRequest) (*genauthz.DeleteRelationshipResponse, error) {
+	claims, err := authorizeRelationships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("delete relationship request received", "principal", claims.Subject, "object", req.Object, "relation", req.Relation, "subject", req.Subject)
+
+	if err := s.tupleStore.Delete(ctx, tenancy.FromContext(ctx), req.Object, req.Relation, req.Subject); err != nil {
+		s.log.Infow("delete relationship error", "object", req.Object, "relation", req.Relation, "subject", req.Subject, "error", err)
+		return nil, genauthz.MakeNotFound(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventRelationshipDelete, TargetID: req.Object + "#" + req.Relation + "@" + req.Subject,
+	})
+
+	s.log.Infow("delete relationship request successful", "object", req.Object, "relation", req.Relation, "subject", req.Subject)
+	return &genauthz.DeleteRelationshipResponse{
+		Success: true,
+		Message: "Relationship deleted successfully",
+	}, nil
+}
+
+// check evaluates a single check. Users are looked up in the tenant of ctx, and a user who
+// doesn't exist there is denied without evaluating the policy.
+func (s *service) check(ctx context.Context, check *genauthz.AuthzCheck, explain bool) (*genauthz.AuthzDecision, error) {
+	req := &policy.Request{
+		Principal: toEntity(check.Subject),
+		Action:    check.Action,
+		Resource:  toEntity(check.Resource),
+		Context:   check.Context,
+	}
+
+	if req.Principal.Type == subjectTypeUser {
+		ok, err := s.enrichUser(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return &genauthz.AuthzDecision{
+				Allowed: false,
+				Reason:  fmt.Sprintf("%s doesn't exist", req.Principal.Ref()),
+			}, nil
+		}
+	}
+
+	decision, err := s.engine.Check(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return toDecision(decision, explain), nil
+}
+
+// enrichUser overwrites the attributes of a user principal with the ones stored for the
+// user, so callers can't claim roles or groups the user doesn't hold, and adds the user's
+// group memberships. It reports false if the user doesn't exist in the tenant of ctx.
+func (s *service) enrichUser(ctx context.Context, req *policy.Request) (bool, error) {
+	if req.Principal.ID == "" {
+		return false, nil
+	}
+
+	user, err := userstore.QueryTenantUser(ctx, s.userStore, tenancy.FromContext(ctx), req.Principal.ID)
+	if err != nil {
+		return false, nil
+	}
+	access, err := s.access.Resolve(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	attributes := maps.Clone(req.Principal.Attributes)
+	if attributes == nil {
+		attributes = make(map[string]any)
+	}
+	attributes["email"] = user.Email
+	attributes["status"] = user.Status
+	attributes["tenantId"] = user.TenantID
+	attributes["roles"] = access.Roles
+	attributes["permissions"] = access.Permissions
+	attributes["groups"] = access.Groups
+	req.Principal.Attributes = attributes
+
+	for _, groupID := range access.Groups {
+		req.Memberships = append(req.Memberships, "group:"+groupID+"#member")
+	}
+	return true, nil
+}
+
+// authorizeCheck returns the caller's claims. Users may check their own access, while checking
+// anyone else's requires the permission to check access, held through a role by users and
+// granted at registration to clients.
+func authorizeCheck(ctx context.Context, check *genauthz.AuthzCheck) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.ClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, genauthz.MakeUnauthorized(fmt.Errorf("access token required"))
+	}
+	if granted(claims, userdomain.PermissionCheckAccess) {
+		return claims, nil
+	}
+
+	self := claims.Principal() == tokenmgr.PrincipalUser &&
+		check.Subject.Type == subjectTypeUser && check.Subject.ID != nil && *check.Subject.ID == claims.Subject
+	if !self {
+		return tokenmgr.Claims{}, genauthz.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionCheckAccess))
+	}
+	return claims, nil
+}
+
+// authorizeRelationships returns the caller's claims, requiring the permission to manage relationships.
+func authorizeRelationships(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.ClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, genauthz.MakeUnauthorized(fmt.Errorf("access token required"))
+	}
+	if !granted(claims, userdomain.PermissionManageRelationships) {
+		return tokenmgr.Claims{}, genauthz.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageRelationships))
+	}
+	return claims, nil
+}
+
+// granted reports whether the caller holds the permission, through its roles for users and
+// through the grant the admin who registered it recorded on the client for clients. Scopes
+// never grant permissions by themselves.
+func granted(claims tokenmgr.Claims, permission string) bool {
+	return slices.Contains(claims.Permissions, permission)
+}
+
+// toEntity converts an API entity into a policy entity.
+func toEntity(entity *genauthz.AuthzEntity) policy.Entity {
+	res := policy.Entity{Type: entity.Type, Attributes: entity.Attributes}
+	if entity.ID != nil {
+		res.ID = *entity.ID
+	}
+	return res
+}
+
+// entityRef returns the entity in type:id form for logging.
+func entityRef(entity *genauthz.AuthzEntity) string {
+	return toEntity(entity).Ref()
+}
+
+// toDecision converts a policy decision into its API representation, including the outcome
+// of every rule when requested.
+func toDecision(decision *policy.Decision, explain bool) *genauthz.AuthzDecision {
+	res := &genauthz.AuthzDecision{Allowed: decision.Allowed, Reason: decision.Reason}
+	if decision.RuleID != "" {
+		res.RuleID = &decision.RuleID
+	}
+	if !explain {
+		return res
+	}
+
+	res.Explanation = make([]*genauthz.RuleEvaluation, 0, len(decision.Evaluations))
+	for _, evaluation := range decision.Evaluations {
+		res.Explanation = append(res.Explanation, &genauthz.RuleEvaluation{
+			RuleID:  evaluation.RuleID,
+			Effect:  evaluation.Effect,
+			Matched: evaluation.Matched,
+			Reason:  evaluation.Reason,
+		})
+	}
+	return res
+}
+
+// toRelationship converts a stored tuple into its API representation.
+func toRelationship(tuple *tuplestore.Tuple) *genauthz.Relationship {
+	return &genauthz.Relationship{
+		Object:    tuple.Object,
+		Relation:  tuple.Relation,
+		Subject:   tuple.Subject,
+		CreatedAt: tuple.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// tupleKey returns the tuple in object#relation@subject form.
+func tupleKey(tuple *tuplestore.Tuple) string {
+	return tuple.Object + "#" + tuple.Relation + "@" + tuple.Subject
+}
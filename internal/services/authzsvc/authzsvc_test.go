@@ -0,0 +1,277 @@
+package authzsvc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genauthz "github.com/iamBelugaa/goa-iam/gen/authz"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/authzsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/authzsvc/policy"
+	tuplememorystore "github.com/iamBelugaa/goa-iam/internal/services/authzsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestAuthzService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Authz Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+// testPolicy lets managers read users of their org unit, lets viewers read documents,
+// which editors are as well, and denies everything to suspended users.
+const testPolicy = `{
+	"rules": [
+		{
+			"id": "suspended-users",
+			"effect": "deny",
+			"actions": ["*"],
+			"conditions": [{"attribute": "principal.status", "operator": "equals", "value": "suspended"}]
+		},
+		{
+			"id": "managers-read-org-unit",
+			"effect": "allow",
+			"actions": ["users:read"],
+			"resourceTypes": ["user"],
+			"conditions": [
+				{"attribute": "principal.title", "operator": "equals", "value": "manager"},
+				{"attribute": "resource.orgUnit", "operator": "equals", "ref": "principal.orgUnit"}
+			]
+		},
+		{
+			"id": "document-viewers",
+			"effect": "allow",
+			"actions": ["documents:read"],
+			"resourceTypes": ["document"],
+			"relation": "viewer"
+		}
+	],
+	"relations": {"document": {"viewer": ["editor"]}}
+}`
+
+var _ = Describe("Authz service", func() {
+	const tenantID = "acme"
+
+	var (
+		ctx        context.Context
+		adminCtx   context.Context
+		svc        genauthz.Service
+		userStore  userstore.UserStorer
+		groupStore groupstore.GroupStorer
+		tm         *tokenmgr.JWTTokenManager
+		alice      *genuser.User
+		bob        *genuser.User
+	)
+
+	userCtx := func(userID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+	}
+
+	createUser := func(email string) *genuser.User {
+		u, err := userStore.Create(ctx, tenantID, &genuser.CreateUserRequest{
+			FirstName: "Test", LastName: "User", Email: email, Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return u
+	}
+
+	user := func(u *genuser.User, attributes map[string]any) *genauthz.AuthzEntity {
+		return &genauthz.AuthzEntity{Type: "user", ID: &u.ID, Attributes: attributes}
+	}
+
+	document := func(id string) *genauthz.AuthzEntity {
+		return &genauthz.AuthzEntity{Type: "document", ID: &id}
+	}
+
+	check := func(callerCtx context.Context, subject *genauthz.AuthzEntity, action string, resource *genauthz.AuthzEntity) *genauthz.AuthzDecision {
+		res, err := svc.Check(callerCtx, &genauthz.CheckRequest{Subject: subject, Action: action, Resource: resource, Explain: true})
+		Expect(err).NotTo(HaveOccurred())
+		return res.Data
+	}
+
+	write := func(object, relation, subject string) {
+		_, err := svc.WriteRelationship(adminCtx, &genauthz.RelationshipRequest{Object: object, Relation: relation, Subject: subject})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		path := filepath.Join(GinkgoT().TempDir(), "policy.json")
+		Expect(os.WriteFile(path, []byte(testPolicy), 0o600)).To(Succeed())
+		p, err := policy.LoadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		userStore = usermemorystore.NewMemoryStore()
+		groupStore = groupmemorystore.NewMemoryStore()
+		svc = authzsvc.NewService(
+			log, p, tuplememorystore.NewMemoryStore(), userStore, membership.NewResolver(userStore, groupStore), authn,
+			audit.NewRecorder(log, auditmemorystore.NewMemoryStore()),
+		)
+
+		alice = createUser("alice@acme.com")
+		bob = createUser("bob@acme.com")
+		adminCtx = userCtx("admin", userdomain.PermissionCheckAccess, userdomain.PermissionManageRelationships)
+	})
+
+	It("allows managers to read users of their org unit and explains the decision", func() {
+		manager := user(alice, map[string]any{"title": "manager", "orgUnit": "sales"})
+
+		decision := check(adminCtx, manager, "users:read", user(bob, map[string]any{"orgUnit": "sales"}))
+		Expect(decision.Allowed).To(BeTrue())
+		Expect(decision.RuleID).To(Equal(ptr("managers-read-org-unit")))
+		Expect(decision.Explanation).To(HaveLen(3))
+		Expect(decision.Explanation[1].Matched).To(BeTrue())
+		Expect(decision.Explanation[1].Reason).To(ContainSubstring("resource.orgUnit equals principal.orgUnit"))
+
+		decision = check(adminCtx, manager, "users:read", user(bob, map[string]any{"orgUnit": "support"}))
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.RuleID).To(BeNil())
+		Expect(decision.Explanation[1].Reason).To(ContainSubstring("doesn't satisfy equals principal.orgUnit"))
+	})
+
+	It("resolves relations through implied relations and group memberships", func() {
+		Expect(groupStore.Create(ctx, &groupstore.Group{
+			ID: "eng", TenantID: tenantID, DisplayName: "Engineering", Members: []string{bob.ID},
+		})).To(Succeed())
+		write("document:readme", "editor", "group:eng#member")
+
+		decision := check(adminCtx, user(bob, nil), "documents:read", document("readme"))
+		Expect(decision.Allowed).To(BeTrue())
+		Expect(decision.Reason).To(ContainSubstring("document:readme#editor@group:eng#member"))
+
+		Expect(check(adminCtx, user(alice, nil), "documents:read", document("readme")).Allowed).To(BeFalse())
+	})
+
+	It("lets deny rules override allow rules using stored attributes", func() {
+		write("document:readme", "viewer", "user:"+alice.ID)
+		alice.Status = userdomain.UserStatusSuspended
+		_, err := userStore.Update(ctx, alice)
+		Expect(err).NotTo(HaveOccurred())
+
+		decision := check(adminCtx, user(alice, map[string]any{"status": "active"}), "documents:read", document("readme"))
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.RuleID).To(Equal(ptr("suspended-users")))
+	})
+
+	It("denies users of other tenants", func() {
+		other, err := userStore.Create(ctx, "globex", &genuser.CreateUserRequest{
+			FirstName: "Test", LastName: "User", Email: "carol@globex.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		decision := check(adminCtx, user(other, map[string]any{"title": "manager", "orgUnit": "sales"}), "users:read", user(bob, map[string]any{"orgUnit": "sales"}))
+		Expect(decision.Allowed).To(BeFalse())
+		Expect(decision.Reason).To(ContainSubstring("doesn't exist"))
+	})
+
+	It("lets users check themselves but requires a permission or scope to check others", func() {
+		_, err := svc.Check(userCtx(alice.ID), &genauthz.CheckRequest{Subject: user(alice, nil), Action: "documents:read", Resource: document("readme")})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = svc.BatchCheck(userCtx(alice.ID), &genauthz.BatchCheckRequest{Checks: []*genauthz.AuthzCheck{
+			{Subject: user(alice, nil), Action: "documents:read", Resource: document("readme")},
+			{Subject: user(bob, nil), Action: "documents:read", Resource: document("readme")},
+		}})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		claims := tm.StandardClaims("reporting", tokenmgr.AccessToken)
+		claims.PrincipalType = tokenmgr.PrincipalClient
+		claims.TenantID = tenantID
+		claims.Scope = "openid " + userdomain.PermissionCheckAccess
+		clientCtx := tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+
+		// The scope alone doesn't grant the permission.
+		_, err = svc.BatchCheck(clientCtx, &genauthz.BatchCheckRequest{Checks: []*genauthz.AuthzCheck{
+			{Subject: user(bob, nil), Action: "documents:read", Resource: document("readme")},
+		}})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		claims.Permissions = []string{userdomain.PermissionCheckAccess}
+		clientCtx = tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+
+		res, err := svc.BatchCheck(clientCtx, &genauthz.BatchCheckRequest{Checks: []*genauthz.AuthzCheck{
+			{Subject: user(bob, nil), Action: "documents:read", Resource: document("readme")},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data).To(HaveLen(1))
+		Expect(res.Data[0].Explanation).To(BeNil())
+	})
+
+	It("manages relationships per tenant", func() {
+		write("document:readme", "viewer", "user:"+alice.ID)
+
+		_, err := svc.WriteRelationship(adminCtx, &genauthz.RelationshipRequest{Object: "document:readme", Relation: "viewer", Subject: "user:" + alice.ID})
+		Expect(errorName(err)).To(Equal("conflict"))
+
+		_, err = svc.WriteRelationship(userCtx(alice.ID), &genauthz.RelationshipRequest{Object: "document:readme", Relation: "viewer", Subject: "user:" + bob.ID})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		list, err := svc.ListRelationships(adminCtx, &genauthz.ListRelationshipsRequest{Object: ptr("document:readme")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(HaveLen(1))
+
+		otherCtx := tenancy.WithTenant(adminCtx, "globex")
+		list, err = svc.ListRelationships(otherCtx, &genauthz.ListRelationshipsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(BeEmpty())
+
+		_, err = svc.DeleteRelationship(otherCtx, &genauthz.RelationshipRequest{Object: "document:readme", Relation: "viewer", Subject: "user:" + alice.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+
+		_, err = svc.DeleteRelationship(adminCtx, &genauthz.RelationshipRequest{Object: "document:readme", Relation: "viewer", Subject: "user:" + alice.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check(adminCtx, user(alice, nil), "documents:read", document("readme")).Allowed).To(BeFalse())
+	})
+})
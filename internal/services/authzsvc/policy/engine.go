@@ -0,0 +1,344 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	tuplestore "github.com/iamBelugaa/goa-iam/internal/services/authzsvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+)
+
+// maxRelationDepth bounds how many sets are followed when resolving a relation, which
+// also stops relationships that refer to each other from looping.
+const maxRelationDepth = 8
+
+// Entity is a principal or resource taking part in a check.
+type Entity struct {
+	Type       string         // Type of the entity, for example user or document
+	ID         string         // Identifier of the entity within its type
+	Attributes map[string]any // Attributes conditions can refer to
+}
+
+// Ref returns the entity in the type:id form used by relationships.
+func (e Entity) Ref() string {
+	return e.Type + ":" + e.ID
+}
+
+// Request asks whether a principal may perform an action on a resource.
+type Request struct {
+	Principal   Entity         // Who performs the action
+	Memberships []string       // Sets the principal belongs to, for example group:eng#member
+	Action      string         // What the principal wants to do, for example users:read
+	Resource    Entity         // What the action is performed on
+	Context     map[string]any // Attributes of the circumstances, for example the client's IP
+}
+
+// Decision is the outcome of a check.
+type Decision struct {
+	Allowed     bool         // Whether the action is allowed
+	RuleID      string       // Rule that decided the check, empty when no rule matched
+	Reason      string       // Human readable explanation of the decision
+	Evaluations []Evaluation // Outcome of every rule, in policy order
+}
+
+// Evaluation explains why a rule did or didn't match a request.
+type Evaluation struct {
+	RuleID  string // Rule that was evaluated
+	Effect  string // Effect of the rule
+	Matched bool   // Whether the rule matched the request
+	Reason  string // Why the rule matched or the first reason it didn't
+}
+
+// Engine evaluates requests against a policy and the relationships of each tenant.
+type Engine struct {
+	policy *Policy                // Rules and implied relations
+	tuples tuplestore.TupleStorer // Store of the relationships relations are resolved from
+}
+
+// NewEngine creates an engine evaluating the policy against the relationships in the store.
+func NewEngine(policy *Policy, tuples tuplestore.TupleStorer) *Engine {
+	return &Engine{policy: policy, tuples: tuples}
+}
+
+// Check evaluates every rule against the request. The first matching deny rule denies the
+// request, otherwise the first matching allow rule allows it, and when no rule matches it is
+// denied. Relations are resolved in the tenant of ctx.
+func (e *Engine) Check(ctx context.Context, req *Request) (*Decision, error) {
+	decision := &Decision{Evaluations: make([]Evaluation, 0, len(e.policy.Rules))}
+
+	var allow, deny *Evaluation
+	for _, rule := range e.policy.Rules {
+		matched, reason, err := e.evaluate(ctx, rule, req)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate rule %s: %w", rule.ID, err)
+		}
+
+		decision.Evaluations = append(decision.Evaluations, Evaluation{
+			RuleID: rule.ID, Effect: rule.Effect, Matched: matched, Reason: reason,
+		})
+		if !matched {
+			continue
+		}
+
+		last := &decision.Evaluations[len(decision.Evaluations)-1]
+		if rule.Effect == EffectDeny && deny == nil {
+			deny = last
+		}
+		if rule.Effect == EffectAllow && allow == nil {
+			allow = last
+		}
+	}
+
+	switch {
+	case deny != nil:
+		decision.RuleID = deny.RuleID
+		decision.Reason = fmt.Sprintf("denied by rule %s: %s", deny.RuleID, deny.Reason)
+	case allow != nil:
+		decision.Allowed = true
+		decision.RuleID = allow.RuleID
+		decision.Reason = fmt.Sprintf("allowed by rule %s: %s", allow.RuleID, allow.Reason)
+	default:
+		decision.Reason = "no rule allows the action"
+	}
+	return decision, nil
+}
+
+// evaluate reports whether the rule matches the request, and why.
+func (e *Engine) evaluate(ctx context.Context, rule Rule, req *Request) (bool, string, error) {
+	if !slices.ContainsFunc(rule.Actions, func(pattern string) bool { return matchAction(pattern, req.Action) }) {
+		return false, fmt.Sprintf("doesn't apply to action %s", req.Action), nil
+	}
+	if len(rule.ResourceTypes) > 0 && !slices.Contains(rule.ResourceTypes, req.Resource.Type) {
+		return false, fmt.Sprintf("doesn't apply to resources of type %s", req.Resource.Type), nil
+	}
+
+	reasons := make([]string, 0, len(rule.Conditions)+1)
+	for _, c := range rule.Conditions {
+		holds, reason := evaluateCondition(c, req)
+		if !holds {
+			return false, reason, nil
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if rule.Relation != "" {
+		if req.Resource.ID == "" {
+			return false, "resource has no id to resolve the relation on", nil
+		}
+
+		subjects := map[string]bool{req.Principal.Ref(): true}
+		for _, membership := range req.Memberships {
+			subjects[membership] = true
+		}
+
+		via, err := e.holds(ctx, req.Resource.Ref(), rule.Relation, subjects, 0, map[string]bool{})
+		if err != nil {
+			return false, "", err
+		}
+		if via == "" {
+			return false, fmt.Sprintf("%s isn't %s of %s", req.Principal.Ref(), rule.Relation, req.Resource.Ref()), nil
+		}
+		reasons = append(reasons, via)
+	}
+
+	if len(reasons) == 0 {
+		return true, "applies unconditionally", nil
+	}
+	return true, strings.Join(reasons, ", "), nil
+}
+
+// holds returns how one of the subjects holds the relation on the object, directly, through
+// a relation implying it or through a set of subjects, or the empty string if it doesn't.
+func (e *Engine) holds(ctx context.Context, object, relation string, subjects map[string]bool, depth int, visited map[string]bool) (string, error) {
+	key := object + "#" + relation
+	if depth > maxRelationDepth || visited[key] {
+		return "", nil
+	}
+	visited[key] = true
+
+	objectType, _, _ := strings.Cut(object, ":")
+	for _, r := range e.impliedBy(objectType, relation) {
+		tuples, err := e.tuples.QuerySubjects(ctx, tenancy.FromContext(ctx), object, r)
+		if err != nil {
+			return "", err
+		}
+		slices.SortFunc(tuples, func(a, b *tuplestore.Tuple) int { return strings.Compare(a.Subject, b.Subject) })
+
+		for _, t := range tuples {
+			if subjects[t.Subject] {
+				return fmt.Sprintf("%s#%s@%s", object, r, t.Subject), nil
+			}
+
+			setObject, setRelation, isSet := strings.Cut(t.Subject, "#")
+			if !isSet {
+				continue
+			}
+			via, err := e.holds(ctx, setObject, setRelation, subjects, depth+1, visited)
+			if err != nil {
+				return "", err
+			}
+			if via != "" {
+				return fmt.Sprintf("%s#%s@%s via %s", object, r, t.Subject, via), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// impliedBy returns the relation followed by every relation implying it on objects of the type.
+func (e *Engine) impliedBy(objectType, relation string) []string {
+	relations := []string{relation}
+	for i := 0; i < len(relations); i++ {
+		for _, implying := range e.policy.Relations[objectType][relations[i]] {
+			if !slices.Contains(relations, implying) {
+				relations = append(relations, implying)
+			}
+		}
+	}
+	return relations
+}
+
+// matchAction reports whether an action matches a rule's action pattern.
+func matchAction(pattern, action string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(action, prefix)
+	}
+	return pattern == action
+}
+
+// evaluateCondition reports whether the condition holds for the request, and why.
+func evaluateCondition(c Condition, req *Request) (bool, string) {
+	actual, found := lookup(req, c.Attribute)
+	if c.Operator == OperatorExists {
+		if !found {
+			return false, fmt.Sprintf("%s is missing", c.Attribute)
+		}
+		return true, fmt.Sprintf("%s exists", c.Attribute)
+	}
+
+	expected, operand := normalize(c.Value), fmt.Sprintf("%v", c.Value)
+	if c.Ref != "" {
+		var ok bool
+		if expected, ok = lookup(req, c.Ref); !ok {
+			return false, fmt.Sprintf("%s is missing", c.Ref)
+		}
+		operand = c.Ref
+	}
+
+	if !found {
+		if c.Operator == OperatorNotEquals {
+			return true, fmt.Sprintf("%s is missing", c.Attribute)
+		}
+		return false, fmt.Sprintf("%s is missing", c.Attribute)
+	}
+
+	var holds bool
+	switch c.Operator {
+	case OperatorEquals:
+		holds = reflect.DeepEqual(actual, expected)
+	case OperatorNotEquals:
+		holds = !reflect.DeepEqual(actual, expected)
+	case OperatorIn:
+		holds = containsValue(expected, actual)
+	case OperatorContains:
+		holds = containsValue(actual, expected)
+	case OperatorGreaterThan, OperatorLessThan:
+		a, aok := actual.(float64)
+		b, bok := expected.(float64)
+		holds = aok && bok && ((c.Operator == OperatorGreaterThan && a > b) || (c.Operator == OperatorLessThan && a < b))
+	}
+
+	if !holds {
+		return false, fmt.Sprintf("%s doesn't satisfy %s %s", c.Attribute, c.Operator, operand)
+	}
+	return true, fmt.Sprintf("%s %s %s", c.Attribute, c.Operator, operand)
+}
+
+// lookup resolves an attribute path against the request.
+func lookup(req *Request, path string) (any, bool) {
+	root, rest, _ := strings.Cut(path, ".")
+
+	var entity *Entity
+	switch root {
+	case RootAction:
+		return req.Action, rest == ""
+	case RootContext:
+		return lookupIn(req.Context, rest)
+	case RootPrincipal:
+		entity = &req.Principal
+	case RootResource:
+		entity = &req.Resource
+	default:
+		return nil, false
+	}
+
+	switch rest {
+	case "type":
+		return entity.Type, true
+	case "id":
+		return entity.ID, entity.ID != ""
+	}
+	return lookupIn(entity.Attributes, rest)
+}
+
+// lookupIn resolves a dotted path through nested attribute maps.
+func lookupIn(attributes map[string]any, path string) (any, bool) {
+	var value any = attributes
+	for _, key := range strings.Split(path, ".") {
+		m, ok := normalize(value).(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return normalize(value), value != nil
+}
+
+// containsValue reports whether list is a list holding value.
+func containsValue(list, value any) bool {
+	values, ok := list.([]any)
+	return ok && slices.ContainsFunc(values, func(v any) bool { return reflect.DeepEqual(v, value) })
+}
+
+// normalize converts attribute values to the types JSON decodes to, so that values set by
+// the server compare equal to values from a policy file or a request: numbers become
+// float64, slices []any and maps map[string]any.
+func normalize(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = normalize(v.Index(i).Interface())
+		}
+		return values
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		m := make(map[string]any, v.Len())
+		for _, key := range v.MapKeys() {
+			m[key.String()] = v.MapIndex(key).Interface()
+		}
+		return m
+	}
+	return value
+}
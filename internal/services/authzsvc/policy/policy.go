@@ -0,0 +1,171 @@
+// Package policy implements an attribute and relationship based authorization engine.
+//
+// A policy is a list of rules. A rule applies to some actions on some resource types and
+// matches when all of its conditions over the attributes of the principal, the resource,
+// the action and the request context hold, and, when it names a relation, the principal
+// holds that relation on the resource. Relations are Zanzibar-style tuples such as
+// document:readme#viewer@user:ada, which can grant a relation to the members of a set,
+// as in document:readme#viewer@group:eng#member, and can be implied by other relations.
+//
+// Deny rules override allow rules, and nothing is allowed unless a rule allows it.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Rule effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Condition operators.
+const (
+	OperatorEquals      = "equals"
+	OperatorNotEquals   = "notEquals"
+	OperatorIn          = "in"
+	OperatorContains    = "contains"
+	OperatorExists      = "exists"
+	OperatorGreaterThan = "greaterThan"
+	OperatorLessThan    = "lessThan"
+)
+
+// Roots of attribute paths. Paths name an attribute below a root, as in resource.orgUnit,
+// except for the action, which is a path of its own.
+const (
+	RootPrincipal = "principal"
+	RootResource  = "resource"
+	RootContext   = "context"
+	RootAction    = "action"
+)
+
+// Policy is a set of authorization rules and the relations implied by other relations.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+
+	// Relations maps object types to relations and the relations implying them. For
+	// {"document": {"viewer": ["editor"]}} every editor of a document is a viewer as well.
+	Relations map[string]map[string][]string `json:"relations"`
+}
+
+// Rule allows or denies actions on resources.
+type Rule struct {
+	ID            string      `json:"id"`            // Unique identifier reported when the rule decides a check
+	Description   string      `json:"description"`   // Human readable explanation of the rule
+	Effect        string      `json:"effect"`        // Allow or deny
+	Actions       []string    `json:"actions"`       // Actions the rule applies to, "*" and "prefix:*" match several
+	ResourceTypes []string    `json:"resourceTypes"` // Resource types the rule applies to, every type when empty
+	Conditions    []Condition `json:"conditions"`    // Conditions that must all hold
+	Relation      string      `json:"relation"`      // Relation the principal must hold on the resource, if any
+}
+
+// Condition compares an attribute with a literal value or with another attribute.
+type Condition struct {
+	Attribute string `json:"attribute"` // Path of the attribute, for example resource.orgUnit
+	Operator  string `json:"operator"`  // How the attribute is compared
+	Value     any    `json:"value"`     // Literal value to compare with
+	Ref       string `json:"ref"`       // Path of the attribute to compare with, instead of a literal value
+}
+
+// Default returns the policy used when none is configured. It allows every action named
+// by a permission the principal holds through its roles, so checks agree with role checks.
+func Default() *Policy {
+	return &Policy{
+		Rules: []Rule{
+			{
+				ID:          "role-permissions",
+				Description: "Principals may perform the actions named by their permissions",
+				Effect:      EffectAllow,
+				Actions:     []string{"*"},
+				Conditions: []Condition{
+					{Attribute: "principal.permissions", Operator: OperatorContains, Ref: RootAction},
+				},
+			},
+		},
+	}
+}
+
+// LoadFile reads and validates a policy from a JSON file.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decode policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate reports the first problem that would keep the policy from being evaluated.
+func (p *Policy) Validate() error {
+	ids := make(map[string]bool, len(p.Rules))
+	for i, rule := range p.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d has no id", i)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("rule id %s isn't unique", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return fmt.Errorf("rule %s: effect must be %s or %s", rule.ID, EffectAllow, EffectDeny)
+		}
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("rule %s applies to no action", rule.ID)
+		}
+
+		for _, c := range rule.Conditions {
+			if err := c.validate(); err != nil {
+				return fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the operator and attribute paths of the condition.
+func (c Condition) validate() error {
+	switch c.Operator {
+	case OperatorEquals, OperatorNotEquals, OperatorIn, OperatorContains, OperatorExists, OperatorGreaterThan, OperatorLessThan:
+	default:
+		return fmt.Errorf("unknown operator %q", c.Operator)
+	}
+
+	if err := validatePath(c.Attribute); err != nil {
+		return err
+	}
+	if c.Ref != "" {
+		if c.Value != nil {
+			return fmt.Errorf("condition on %s has both a value and a ref", c.Attribute)
+		}
+		return validatePath(c.Ref)
+	}
+	return nil
+}
+
+// validatePath checks that an attribute path starts at a known root.
+func validatePath(path string) error {
+	root, rest, _ := strings.Cut(path, ".")
+	switch root {
+	case RootAction:
+		if rest == "" {
+			return nil
+		}
+	case RootPrincipal, RootResource, RootContext:
+		if rest != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid attribute path %q", path)
+}
@@ -0,0 +1,101 @@
+// Package tuplestore provides an in-memory implementation of the TupleStorer interface.
+package tuplestore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	tuplestore "github.com/iamBelugaa/goa-iam/internal/services/authzsvc/store"
+)
+
+// objectRelation identifies the subjects holding a relation on an object of a tenant.
+type objectRelation struct {
+	tenantID string
+	object   string
+	relation string
+}
+
+// memory implements the TupleStorer interface using in-memory maps.
+type memory struct {
+	mu     sync.RWMutex                                    // protects access to tuples
+	tuples map[objectRelation]map[string]*tuplestore.Tuple // stores tuples by object relation and subject
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory tuple store.
+func NewMemoryStore() *memory {
+	return &memory{tuples: make(map[objectRelation]map[string]*tuplestore.Tuple)}
+}
+
+// Write adds a new relationship to the in-memory store.
+func (m *memory) Write(ctx context.Context, tuple *tuplestore.Tuple) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := objectRelation{tenantID: tuple.TenantID, object: tuple.Object, relation: tuple.Relation}
+	subjects, ok := m.tuples[key]
+	if !ok {
+		subjects = make(map[string]*tuplestore.Tuple)
+		m.tuples[key] = subjects
+	}
+	if _, exists := subjects[tuple.Subject]; exists {
+		return fmt.Errorf("relationship %s#%s@%s already exists", tuple.Object, tuple.Relation, tuple.Subject)
+	}
+
+	stored := *tuple
+	subjects[tuple.Subject] = &stored
+
+	return nil
+}
+
+// Delete removes a relationship from the in-memory store.
+func (m *memory) Delete(ctx context.Context, tenantID, object, relation, subject string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := objectRelation{tenantID: tenantID, object: object, relation: relation}
+	if _, exists := m.tuples[key][subject]; !exists {
+		return fmt.Errorf("relationship %s#%s@%s doesn't exist", object, relation, subject)
+	}
+
+	delete(m.tuples[key], subject)
+	if len(m.tuples[key]) == 0 {
+		delete(m.tuples, key)
+	}
+
+	return nil
+}
+
+// QuerySubjects returns the relationships granting the relation on the object.
+func (m *memory) QuerySubjects(ctx context.Context, tenantID, object, relation string) ([]*tuplestore.Tuple, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subjects := m.tuples[objectRelation{tenantID: tenantID, object: object, relation: relation}]
+	tuples := make([]*tuplestore.Tuple, 0, len(subjects))
+	for _, tuple := range subjects {
+		found := *tuple
+		tuples = append(tuples, &found)
+	}
+
+	return tuples, nil
+}
+
+// List returns the relationships of the tenant currently stored in memory.
+func (m *memory) List(ctx context.Context, tenantID, object string) ([]*tuplestore.Tuple, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tuples := make([]*tuplestore.Tuple, 0)
+	for key, subjects := range m.tuples {
+		if key.tenantID != tenantID || (object != "" && key.object != object) {
+			continue
+		}
+		for _, tuple := range subjects {
+			found := *tuple
+			tuples = append(tuples, &found)
+		}
+	}
+
+	return tuples, nil
+}
@@ -0,0 +1,35 @@
+// Package tuplestore defines the interface for interacting with the relationship tuple storage layer.
+package tuplestore
+
+import (
+	"context"
+	"time"
+)
+
+// Tuple is a relationship between a subject and an object, written as
+// object#relation@subject, for example document:readme#viewer@user:ada. The subject is
+// either an entity or a set of entities holding a relation on another object, for example
+// group:eng#member.
+type Tuple struct {
+	TenantID  string    // ID of the organization the relationship belongs to
+	Object    string    // Object in type:id form
+	Relation  string    // Relation the subject holds on the object
+	Subject   string    // Subject in type:id or type:id#relation form
+	CreatedAt time.Time // Time the relationship was written
+}
+
+// TupleStorer defines the contract for managing relationship tuples in a storage backend.
+type TupleStorer interface {
+	// Write stores a new relationship. Writing an existing relationship fails.
+	Write(ctx context.Context, tuple *Tuple) error
+
+	// Delete removes a relationship of the given tenant.
+	Delete(ctx context.Context, tenantID, object, relation, subject string) error
+
+	// QuerySubjects returns the relationships of the given tenant granting the relation on the object.
+	QuerySubjects(ctx context.Context, tenantID, object, relation string) ([]*Tuple, error)
+
+	// List returns the relationships of the given tenant, limited to those on the object
+	// unless it's empty.
+	List(ctx context.Context, tenantID, object string) ([]*Tuple, error)
+}
@@ -0,0 +1,468 @@
+// Package groupsvc provides group management business logic for the IAM system. Groups
+// grant roles to their members and can be nested, in which case the members of a nested
+// group are members of its parent groups as well. Groups, their members and their nested
+// groups all belong to the same tenant.
+package groupsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"goa.design/goa/v3/security"
+
+	gengroup "github.com/iamBelugaa/goa-iam/gen/group"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// service implements group management on top of the group and user stores.
+type service struct {
+	log        *logger.Logger         // Logger for structured logging
+	groupStore groupstore.GroupStorer // Interface to the group data store
+	userStore  userstore.UserStorer   // Interface to the user data store
+	access     *membership.Resolver   // Resolver of nested membership and inherited roles
+	authn      *jwtauth.Authenticator // Token authenticator for secured methods
+	audit      *audit.Recorder        // Recorder of security events
+}
+
+// NewService initializes and returns a new group service instance.
+func NewService(
+	log *logger.Logger, groupStore groupstore.GroupStorer, userStore userstore.UserStorer,
+	access *membership.Resolver, authn *jwtauth.Authenticator, recorder *audit.Recorder,
+) *service {
+	return &service{
+		log:        log,
+		groupStore: groupStore,
+		userStore:  userStore,
+		access:     access,
+		authn:      authn,
+		audit:      recorder,
+	}
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// List returns every group ordered by name.
+func (s *service) List(ctx context.Context, req *gengroup.ListGroupsRequest) (*gengroup.ListGroupsResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list groups request received", "userId", claims.Subject)
+
+	groups, err := s.groupStore.List(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		s.log.Infow("list groups error", "error", err)
+		return nil, gengroup.MakeInternalServerError(err)
+	}
+	slices.SortFunc(groups, func(a, b *groupstore.Group) int {
+		return strings.Compare(strings.ToLower(a.DisplayName), strings.ToLower(b.DisplayName))
+	})
+
+	data := make([]*gengroup.Group, 0, len(groups))
+	for _, group := range groups {
+		data = append(data, toGroup(group))
+	}
+
+	s.log.Infow("list groups request successful", "totalGroups", len(data))
+	return &gengroup.ListGroupsResponse{
+		Success: true,
+		Message: "Groups fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// Get retrieves a group by its ID.
+func (s *service) Get(ctx context.Context, req *gengroup.GroupRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("get group request received", "userId", claims.Subject, "groupId", req.ID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("get group error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	s.log.Infow("get group request successful", "groupId", req.ID)
+	return groupResponse(group, "Group fetched successfully"), nil
+}
+
+// Create creates a group granting the given roles to its members.
+func (s *service) Create(ctx context.Context, req *gengroup.CreateGroupRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("create group request received", "userId", claims.Subject, "displayName", req.DisplayName, "roles", req.Roles)
+
+	if err := validateRoles(req.Roles); err != nil {
+		s.log.Infow("create group error", "displayName", req.DisplayName, "error", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	group := &groupstore.Group{
+		ID:          uuid.New().String(),
+		TenantID:    tenancy.FromContext(ctx),
+		DisplayName: req.DisplayName,
+		Roles:       slices.Compact(slices.Sorted(slices.Values(req.Roles))),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+
+	if err := s.groupStore.Create(ctx, group); err != nil {
+		s.log.Infow("create group error", "displayName", req.DisplayName, "error", err)
+		return nil, gengroup.MakeConflict(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupCreate, TargetID: group.ID,
+		Details: map[string]string{"displayName": group.DisplayName, "roles": strings.Join(group.Roles, ",")},
+	})
+
+	s.log.Infow("create group request successful", "groupId", group.ID)
+	return groupResponse(group, "Group created successfully"), nil
+}
+
+// Update changes the name, description or roles of a group. Members pick up changed roles
+// the next time they are issued an access token.
+func (s *service) Update(ctx context.Context, req *gengroup.UpdateGroupRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("update group request received", "userId", claims.Subject, "groupId", req.ID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("update group error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	if req.DisplayName != nil {
+		group.DisplayName = *req.DisplayName
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if req.Roles != nil {
+		if err := validateRoles(req.Roles); err != nil {
+			s.log.Infow("update group error", "groupId", req.ID, "error", err)
+			return nil, err
+		}
+		group.Roles = slices.Compact(slices.Sorted(slices.Values(req.Roles)))
+	}
+
+	if err := s.groupStore.Update(ctx, group); err != nil {
+		s.log.Infow("update group error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeConflict(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupUpdate, TargetID: group.ID,
+		Details: map[string]string{"displayName": group.DisplayName, "roles": strings.Join(group.Roles, ",")},
+	})
+
+	return s.respond(ctx, "update group", group.ID, "Group updated successfully")
+}
+
+// Delete deletes a group. Groups nested in it are kept, but their members no longer
+// inherit the roles of the deleted group's ancestors through it.
+func (s *service) Delete(ctx context.Context, req *gengroup.GroupRequest) (*gengroup.DeleteGroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("delete group request received", "userId", claims.Subject, "groupId", req.ID)
+
+	if _, err := s.queryGroup(ctx, req.ID); err != nil {
+		s.log.Infow("delete group error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+	if err := s.groupStore.Delete(ctx, req.ID); err != nil {
+		s.log.Infow("delete group error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventGroupDelete, TargetID: req.ID})
+
+	s.log.Infow("delete group request successful", "groupId", req.ID)
+	return &gengroup.DeleteGroupResponse{
+		Success: true,
+		Message: "Group deleted successfully",
+	}, nil
+}
+
+// AddMember adds a user to a group. Adding an existing member has no effect.
+func (s *service) AddMember(ctx context.Context, req *gengroup.GroupMemberRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("add group member request received", "userId", claims.Subject, "groupId", req.ID, "memberId", req.UserID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("add group member error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+	if _, err := userstore.QueryTenantUser(ctx, s.userStore, tenancy.FromContext(ctx), req.UserID); err != nil {
+		s.log.Infow("add group member error", "groupId", req.ID, "memberId", req.UserID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	if !slices.Contains(group.Members, req.UserID) {
+		group.Members = append(group.Members, req.UserID)
+		if err := s.groupStore.Update(ctx, group); err != nil {
+			s.log.Infow("add group member error", "groupId", req.ID, "error", err)
+			return nil, gengroup.MakeInternalServerError(err)
+		}
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupMemberAdd, TargetID: group.ID, Details: map[string]string{"userId": req.UserID},
+	})
+
+	return s.respond(ctx, "add group member", group.ID, "Member added successfully")
+}
+
+// RemoveMember removes a user from a group.
+func (s *service) RemoveMember(ctx context.Context, req *gengroup.GroupMemberRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("remove group member request received", "userId", claims.Subject, "groupId", req.ID, "memberId", req.UserID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("remove group member error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+	if !slices.Contains(group.Members, req.UserID) {
+		err := fmt.Errorf("user with id %s isn't a member of the group", req.UserID)
+		s.log.Infow("remove group member error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	group.Members = slices.DeleteFunc(group.Members, func(id string) bool { return id == req.UserID })
+	if err := s.groupStore.Update(ctx, group); err != nil {
+		s.log.Infow("remove group member error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupMemberRemove, TargetID: group.ID, Details: map[string]string{"userId": req.UserID},
+	})
+
+	return s.respond(ctx, "remove group member", group.ID, "Member removed successfully")
+}
+
+// AddSubgroup nests a group in another. A group can't be nested in itself or in any group
+// nested in it, as its members would then inherit from themselves.
+func (s *service) AddSubgroup(ctx context.Context, req *gengroup.SubgroupRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("add subgroup request received", "userId", claims.Subject, "groupId", req.ID, "subgroupId", req.SubgroupID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("add subgroup error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+	if _, err := s.queryGroup(ctx, req.SubgroupID); err != nil {
+		s.log.Infow("add subgroup error", "groupId", req.ID, "subgroupId", req.SubgroupID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	if err := s.access.CheckNesting(ctx, req.ID, req.SubgroupID); err != nil {
+		s.log.Infow("add subgroup error", "groupId", req.ID, "subgroupId", req.SubgroupID, "error", err)
+		if errors.Is(err, membership.ErrCycle) {
+			return nil, gengroup.MakeNestingCycle(err)
+		}
+		return nil, gengroup.MakeInternalServerError(err)
+	}
+
+	if !slices.Contains(group.Subgroups, req.SubgroupID) {
+		group.Subgroups = append(group.Subgroups, req.SubgroupID)
+		if err := s.groupStore.Update(ctx, group); err != nil {
+			s.log.Infow("add subgroup error", "groupId", req.ID, "error", err)
+			return nil, gengroup.MakeInternalServerError(err)
+		}
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupSubgroupAdd, TargetID: group.ID, Details: map[string]string{"subgroupId": req.SubgroupID},
+	})
+
+	return s.respond(ctx, "add subgroup", group.ID, "Subgroup added successfully")
+}
+
+// RemoveSubgroup removes a nested group from its parent group.
+func (s *service) RemoveSubgroup(ctx context.Context, req *gengroup.SubgroupRequest) (*gengroup.GroupResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("remove subgroup request received", "userId", claims.Subject, "groupId", req.ID, "subgroupId", req.SubgroupID)
+
+	group, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("remove subgroup error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+	if !slices.Contains(group.Subgroups, req.SubgroupID) {
+		err := fmt.Errorf("group with id %s isn't nested in the group", req.SubgroupID)
+		s.log.Infow("remove subgroup error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	group.Subgroups = slices.DeleteFunc(group.Subgroups, func(id string) bool { return id == req.SubgroupID })
+	if err := s.groupStore.Update(ctx, group); err != nil {
+		s.log.Infow("remove subgroup error", "groupId", req.ID, "error", err)
+		return nil, gengroup.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventGroupSubgroupRemove, TargetID: group.ID, Details: map[string]string{"subgroupId": req.SubgroupID},
+	})
+
+	return s.respond(ctx, "remove subgroup", group.ID, "Subgroup removed successfully")
+}
+
+// GetUserAccess resolves the groups, roles and permissions a user holds. Users may resolve
+// their own access, while resolving anyone else's requires the permission to manage groups.
+func (s *service) GetUserAccess(ctx context.Context, req *gengroup.UserAccessRequest) (*gengroup.UserAccessResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, gengroup.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	s.log.Infow("get user access request received", "userId", claims.Subject, "targetUserId", req.UserID)
+
+	if req.UserID != claims.Subject && !slices.Contains(claims.Permissions, userdomain.PermissionManageGroups) {
+		s.log.Infow("get user access denied", "userId", claims.Subject, "targetUserId", req.UserID)
+		return nil, gengroup.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageGroups))
+	}
+
+	access, err := s.access.Resolve(ctx, req.UserID)
+	if err != nil {
+		s.log.Infow("get user access error", "targetUserId", req.UserID, "error", err)
+		return nil, gengroup.MakeNotFound(err)
+	}
+
+	s.log.Infow("get user access request successful", "targetUserId", req.UserID, "roles", access.Roles)
+	return &gengroup.UserAccessResponse{
+		Success: true,
+		Message: "User access resolved successfully",
+		Data: &gengroup.UserAccess{
+			UserID:      req.UserID,
+			Groups:      access.Groups,
+			Roles:       access.Roles,
+			Permissions: access.Permissions,
+		},
+	}, nil
+}
+
+// queryGroup retrieves a group of the request's tenant. Groups of other tenants are
+// reported as missing.
+func (s *service) queryGroup(ctx context.Context, groupID string) (*groupstore.Group, error) {
+	group, err := s.groupStore.QueryByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.TenantID != tenancy.FromContext(ctx) {
+		return nil, fmt.Errorf("group with id %s doesn't exist", groupID)
+	}
+	return group, nil
+}
+
+// respond reloads a modified group and returns it with the given message.
+func (s *service) respond(ctx context.Context, operation, groupID, message string) (*gengroup.GroupResponse, error) {
+	group, err := s.groupStore.QueryByID(ctx, groupID)
+	if err != nil {
+		s.log.Infow(operation+" error", "groupId", groupID, "error", err)
+		return nil, gengroup.MakeInternalServerError(err)
+	}
+
+	s.log.Infow(operation+" request successful", "groupId", groupID)
+	return groupResponse(group, message), nil
+}
+
+// authorize returns the caller's claims, requiring a user token holding the permission to manage groups.
+func authorize(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, gengroup.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if !slices.Contains(claims.Permissions, userdomain.PermissionManageGroups) {
+		return tokenmgr.Claims{}, gengroup.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageGroups))
+	}
+	return claims, nil
+}
+
+// validateRoles fails with bad_request if any of the roles doesn't exist.
+func validateRoles(roles []string) error {
+	for _, role := range roles {
+		if !userdomain.IsRole(role) {
+			return gengroup.MakeBadRequest(fmt.Errorf("role %q doesn't exist", role))
+		}
+	}
+	return nil
+}
+
+// groupResponse wraps a group in a response with the given message.
+func groupResponse(group *groupstore.Group, message string) *gengroup.GroupResponse {
+	return &gengroup.GroupResponse{Success: true, Message: message, Data: toGroup(group)}
+}
+
+// toGroup converts a stored group into its API representation.
+func toGroup(group *groupstore.Group) *gengroup.Group {
+	res := &gengroup.Group{
+		ID:          group.ID,
+		DisplayName: group.DisplayName,
+		Roles:       nonNil(group.Roles),
+		Members:     nonNil(group.Members),
+		Subgroups:   nonNil(group.Subgroups),
+		CreatedAt:   group.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   group.UpdatedAt.Format(time.RFC3339),
+	}
+	if group.Description != "" {
+		res.Description = &group.Description
+	}
+	if group.ExternalID != "" {
+		res.ExternalID = &group.ExternalID
+	}
+	return res
+}
+
+// nonNil returns the slice, or an empty slice when it's nil so it's rendered as an empty list.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,211 @@
+package groupsvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	gengroup "github.com/iamBelugaa/goa-iam/gen/group"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestGroupService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Group Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Group service", func() {
+	var (
+		ctx       context.Context
+		adminCtx  context.Context
+		svc       gengroup.Service
+		userStore userstore.UserStorer
+		tm        *tokenmgr.JWTTokenManager
+		member    *genuser.User
+	)
+
+	userCtx := func(userID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(ctx, claims)
+	}
+
+	createGroup := func(name string, roles ...string) *gengroup.Group {
+		res, err := svc.Create(adminCtx, &gengroup.CreateGroupRequest{DisplayName: name, Roles: roles})
+		Expect(err).NotTo(HaveOccurred())
+		return res.Data
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		userStore = usermemorystore.NewMemoryStore()
+		member, err = userStore.Create(ctx, "", &genuser.CreateUserRequest{
+			FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		groupStore := groupmemorystore.NewMemoryStore()
+		svc = groupsvc.NewService(log, groupStore, userStore, membership.NewResolver(userStore, groupStore), authn,
+			audit.NewRecorder(log, auditmemorystore.NewMemoryStore()),
+		)
+		adminCtx = userCtx("admin", userdomain.PermissionManageGroups)
+	})
+
+	It("requires the permission to manage groups", func() {
+		_, err := svc.List(userCtx(member.ID), &gengroup.ListGroupsRequest{})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		_, err = svc.List(ctx, &gengroup.ListGroupsRequest{})
+		Expect(errorName(err)).To(Equal("unauthorized"))
+	})
+
+	It("creates, updates, lists and deletes groups", func() {
+		group := createGroup("Engineering")
+		Expect(group.Roles).To(BeEmpty())
+		createGroup("Admins", userdomain.RoleAdmin)
+
+		_, err := svc.Create(adminCtx, &gengroup.CreateGroupRequest{DisplayName: "Engineering"})
+		Expect(errorName(err)).To(Equal("conflict"))
+		_, err = svc.Create(adminCtx, &gengroup.CreateGroupRequest{DisplayName: "Owners", Roles: []string{"owner"}})
+		Expect(errorName(err)).To(Equal("bad_request"))
+
+		updated, err := svc.Update(adminCtx, &gengroup.UpdateGroupRequest{ID: group.ID, Description: ptr("Builds things")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Data.DisplayName).To(Equal("Engineering"))
+		Expect(*updated.Data.Description).To(Equal("Builds things"))
+
+		list, err := svc.List(adminCtx, &gengroup.ListGroupsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(HaveLen(2))
+		Expect(list.Data[0].DisplayName).To(Equal("Admins"))
+
+		_, err = svc.Delete(adminCtx, &gengroup.GroupRequest{ID: group.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.Get(adminCtx, &gengroup.GroupRequest{ID: group.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+	})
+
+	It("grants members the roles of their groups and of every group they are nested in", func() {
+		admins := createGroup("Admins", userdomain.RoleAdmin)
+		platform := createGroup("Platform")
+		oncall := createGroup("On-call")
+
+		_, err := svc.AddMember(adminCtx, &gengroup.GroupMemberRequest{ID: oncall.ID, UserID: member.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.AddMember(adminCtx, &gengroup.GroupMemberRequest{ID: oncall.ID, UserID: "missing"})
+		Expect(errorName(err)).To(Equal("not_found"))
+
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: platform.ID, SubgroupID: oncall.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: admins.ID, SubgroupID: platform.ID})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Members may resolve their own access without any permission.
+		res, err := svc.GetUserAccess(userCtx(member.ID), &gengroup.UserAccessRequest{UserID: member.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Groups).To(ConsistOf(admins.ID, platform.ID, oncall.ID))
+		Expect(res.Data.Roles).To(Equal([]string{userdomain.RoleAdmin}))
+		Expect(res.Data.Permissions).To(ContainElements(userdomain.PermissionImpersonate, userdomain.PermissionManageGroups))
+
+		_, err = svc.GetUserAccess(userCtx("someone-else"), &gengroup.UserAccessRequest{UserID: member.ID})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		// Deleting a group in the chain cuts off the roles inherited through it.
+		_, err = svc.Delete(adminCtx, &gengroup.GroupRequest{ID: platform.ID})
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err = svc.GetUserAccess(adminCtx, &gengroup.UserAccessRequest{UserID: member.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Groups).To(Equal([]string{oncall.ID}))
+		Expect(res.Data.Roles).To(BeEmpty())
+	})
+
+	It("rejects nesting that would create a cycle", func() {
+		parent := createGroup("Parent")
+		child := createGroup("Child")
+		grandchild := createGroup("Grandchild")
+
+		_, err := svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: parent.ID, SubgroupID: child.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: child.ID, SubgroupID: grandchild.ID})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: grandchild.ID, SubgroupID: parent.ID})
+		Expect(errorName(err)).To(Equal("nesting_cycle"))
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: parent.ID, SubgroupID: parent.ID})
+		Expect(errorName(err)).To(Equal("nesting_cycle"))
+
+		_, err = svc.RemoveSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: child.ID, SubgroupID: grandchild.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.AddSubgroup(adminCtx, &gengroup.SubgroupRequest{ID: grandchild.ID, SubgroupID: parent.ID})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps the groups and users of each tenant apart", func() {
+		group := createGroup("Engineering")
+
+		// The admin of another tenant neither sees the group nor can add its users to groups.
+		otherCtx := tenancy.WithTenant(adminCtx, "other-tenant")
+		list, err := svc.List(otherCtx, &gengroup.ListGroupsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(BeEmpty())
+		_, err = svc.Get(otherCtx, &gengroup.GroupRequest{ID: group.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+		_, err = svc.Delete(otherCtx, &gengroup.GroupRequest{ID: group.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+
+		// Display names are only unique within a tenant.
+		other, err := svc.Create(otherCtx, &gengroup.CreateGroupRequest{DisplayName: "Engineering"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.AddMember(otherCtx, &gengroup.GroupMemberRequest{ID: other.Data.ID, UserID: member.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+	})
+})
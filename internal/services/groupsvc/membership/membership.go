@@ -0,0 +1,122 @@
+// Package membership resolves the groups a user belongs to through nested groups, and the
+// roles and permissions the user holds directly and through those groups.
+package membership
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+)
+
+// ErrCycle is returned when nesting a group would make it a member of itself.
+var ErrCycle = errors.New("nesting the group would create a cycle")
+
+// Access is what a user effectively holds. Every list is sorted and free of duplicates.
+type Access struct {
+	Groups      []string // IDs of the groups the user belongs to, directly or through nesting
+	Roles       []string // Roles granted to the user or to any of its groups
+	Permissions []string // Permissions granted by the roles
+}
+
+// Resolver resolves group membership and the access it grants.
+type Resolver struct {
+	users  userstore.UserStorer   // Store of the users whose directly granted roles are resolved
+	groups groupstore.GroupStorer // Store of the groups roles are inherited from
+}
+
+// NewResolver creates a resolver reading from the given user and group stores.
+func NewResolver(users userstore.UserStorer, groups groupstore.GroupStorer) *Resolver {
+	return &Resolver{users: users, groups: groups}
+}
+
+// Resolve returns the access a user of the tenant of ctx holds. Members of a group nested in
+// another belong to both, so roles are inherited from every group reachable upwards from the
+// user's groups.
+func (r *Resolver) Resolve(ctx context.Context, userID string) (*Access, error) {
+	user, err := userstore.QueryTenantUser(ctx, r.users, tenancy.FromContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	direct, err := r.groups.QueryByMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := r.ancestors(ctx, direct)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &Access{Groups: []string{}, Roles: slices.Clone(user.Roles)}
+	for _, group := range groups {
+		access.Groups = append(access.Groups, group.ID)
+		access.Roles = append(access.Roles, group.Roles...)
+	}
+
+	slices.Sort(access.Groups)
+	slices.Sort(access.Roles)
+	access.Roles = slices.Compact(access.Roles)
+	if access.Roles == nil {
+		access.Roles = []string{}
+	}
+
+	access.Permissions = userdomain.Permissions(access.Roles)
+	if access.Permissions == nil {
+		access.Permissions = []string{}
+	}
+	return access, nil
+}
+
+// CheckNesting returns ErrCycle if nesting the subgroup in the parent would make a group a
+// member of itself, which is the case when the subgroup is the parent or one of its ancestors.
+func (r *Resolver) CheckNesting(ctx context.Context, parentID, subgroupID string) error {
+	if parentID == subgroupID {
+		return ErrCycle
+	}
+
+	parent, err := r.groups.QueryByID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	ancestors, err := r.ancestors(ctx, []*groupstore.Group{parent})
+	if err != nil {
+		return err
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor.ID == subgroupID {
+			return ErrCycle
+		}
+	}
+	return nil
+}
+
+// ancestors returns the given groups together with every group they are nested in, directly
+// or transitively. Each group is visited once, so existing cycles can't loop forever.
+func (r *Resolver) ancestors(ctx context.Context, groups []*groupstore.Group) ([]*groupstore.Group, error) {
+	seen := make(map[string]bool, len(groups))
+	var result []*groupstore.Group
+
+	queue := slices.Clone(groups)
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+		if seen[group.ID] {
+			continue
+		}
+		seen[group.ID] = true
+		result = append(result, group)
+
+		parents, err := r.groups.QueryBySubgroup(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, parents...)
+	}
+	return result, nil
+}
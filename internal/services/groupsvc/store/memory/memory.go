@@ -0,0 +1,179 @@
+// Package groupstore provides an in-memory implementation of the GroupStorer interface.
+package groupstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+)
+
+// memory implements the GroupStorer interface using an in-memory map.
+type memory struct {
+	mu     sync.RWMutex                 // protects access to groups
+	groups map[string]*groupstore.Group // stores groups by ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory group store.
+func NewMemoryStore() *memory {
+	return &memory{groups: make(map[string]*groupstore.Group)}
+}
+
+// Create adds a new group to the in-memory store.
+func (m *memory) Create(ctx context.Context, group *groupstore.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.groups[group.ID]; exists {
+		return fmt.Errorf("group with id %s already exists", group.ID)
+	}
+	if err := m.checkDisplayName(group); err != nil {
+		return err
+	}
+
+	m.groups[group.ID] = clone(group)
+	return nil
+}
+
+// QueryByID retrieves a group from memory by its ID.
+func (m *memory) QueryByID(ctx context.Context, groupID string) (*groupstore.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	group, ok := m.groups[groupID]
+	if !ok {
+		return nil, fmt.Errorf("group with id %s doesn't exist", groupID)
+	}
+	return clone(group), nil
+}
+
+// QueryByMember returns the groups in memory the given user directly belongs to.
+func (m *memory) QueryByMember(ctx context.Context, userID string) ([]*groupstore.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var groups []*groupstore.Group
+	for _, group := range m.groups {
+		if slices.Contains(group.Members, userID) {
+			groups = append(groups, clone(group))
+		}
+	}
+	return groups, nil
+}
+
+// QueryBySubgroup returns the groups in memory the given group is directly nested in.
+func (m *memory) QueryBySubgroup(ctx context.Context, groupID string) ([]*groupstore.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var groups []*groupstore.Group
+	for _, group := range m.groups {
+		if slices.Contains(group.Subgroups, groupID) {
+			groups = append(groups, clone(group))
+		}
+	}
+	return groups, nil
+}
+
+// Update replaces the attributes, roles, members and subgroups of a group in memory.
+func (m *memory) Update(ctx context.Context, group *groupstore.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.groups[group.ID]
+	if !ok {
+		return fmt.Errorf("group with id %s doesn't exist", group.ID)
+	}
+
+	updated := clone(group)
+	updated.TenantID = existing.TenantID
+	if err := m.checkDisplayName(updated); err != nil {
+		return err
+	}
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = time.Now()
+	m.groups[group.ID] = updated
+
+	return nil
+}
+
+// Delete removes a group from memory and from the groups it is nested in.
+func (m *memory) Delete(ctx context.Context, groupID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[groupID]; !ok {
+		return fmt.Errorf("group with id %s doesn't exist", groupID)
+	}
+	delete(m.groups, groupID)
+
+	for id, group := range m.groups {
+		if !slices.Contains(group.Subgroups, groupID) {
+			continue
+		}
+
+		// Replace the stored group so previously returned values aren't modified.
+		updated := clone(group)
+		updated.Subgroups = slices.DeleteFunc(updated.Subgroups, func(subgroup string) bool { return subgroup == groupID })
+		updated.UpdatedAt = time.Now()
+		m.groups[id] = updated
+	}
+	return nil
+}
+
+// RemoveMember removes a user from every group in memory.
+func (m *memory) RemoveMember(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, group := range m.groups {
+		if !slices.Contains(group.Members, userID) {
+			continue
+		}
+
+		// Replace the stored group so previously returned values aren't modified.
+		updated := clone(group)
+		updated.Members = slices.DeleteFunc(updated.Members, func(member string) bool { return member == userID })
+		updated.UpdatedAt = time.Now()
+		m.groups[id] = updated
+	}
+	return nil
+}
+
+// List returns all groups of a tenant currently stored in memory.
+func (m *memory) List(ctx context.Context, tenantID string) ([]*groupstore.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make([]*groupstore.Group, 0)
+	for _, group := range m.groups {
+		if group.TenantID == tenantID {
+			groups = append(groups, clone(group))
+		}
+	}
+	return groups, nil
+}
+
+// checkDisplayName reports an error if another group of the same tenant already uses the
+// group's display name. Names are compared case insensitively. The caller must hold the lock.
+func (m *memory) checkDisplayName(group *groupstore.Group) error {
+	for _, existing := range m.groups {
+		if existing.ID != group.ID && existing.TenantID == group.TenantID && strings.EqualFold(existing.DisplayName, group.DisplayName) {
+			return fmt.Errorf("group with display name %s already exists", group.DisplayName)
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of the group that doesn't share its roles, members or subgroups slices.
+func clone(group *groupstore.Group) *groupstore.Group {
+	copied := *group
+	copied.Roles = slices.Clone(group.Roles)
+	copied.Members = slices.Clone(group.Members)
+	copied.Subgroups = slices.Clone(group.Subgroups)
+	return &copied
+}
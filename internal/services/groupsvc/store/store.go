@@ -0,0 +1,50 @@
+// Package groupstore defines the interface for interacting with the group data storage layer.
+package groupstore
+
+import (
+	"context"
+	"time"
+)
+
+// Group is a named collection of users of a tenant. Members of a group's subgroups are
+// members of the group as well, and every member holds the roles granted to the group.
+type Group struct {
+	ID          string    // Unique identifier of the group
+	TenantID    string    // ID of the tenant the group belongs to
+	DisplayName string    // Human readable name of the group, unique within its tenant
+	Description string    // Purpose of the group
+	ExternalID  string    // Identifier of the group in the system that provisioned it
+	Roles       []string  // Roles granted to the members of the group
+	Members     []string  // IDs of the users directly belonging to the group
+	Subgroups   []string  // IDs of the groups nested in the group
+	CreatedAt   time.Time // Time the group was created
+	UpdatedAt   time.Time // Time the group was last modified
+}
+
+// GroupStorer defines the contract for managing groups in a storage backend.
+type GroupStorer interface {
+	// Create stores a new group. Display names are unique within a tenant.
+	Create(ctx context.Context, group *Group) error
+
+	// QueryByID retrieves a group by its unique ID.
+	QueryByID(ctx context.Context, groupID string) (*Group, error)
+
+	// QueryByMember returns the groups the given user directly belongs to.
+	QueryByMember(ctx context.Context, userID string) ([]*Group, error)
+
+	// QueryBySubgroup returns the groups the given group is directly nested in.
+	QueryBySubgroup(ctx context.Context, groupID string) ([]*Group, error)
+
+	// Update replaces the attributes, roles, members and subgroups of an existing group.
+	// The tenant of a group never changes.
+	Update(ctx context.Context, group *Group) error
+
+	// Delete removes a group from the storage backend and from the groups it is nested in.
+	Delete(ctx context.Context, groupID string) error
+
+	// RemoveMember removes a user from every group it belongs to.
+	RemoveMember(ctx context.Context, userID string) error
+
+	// List returns all groups of the given tenant.
+	List(ctx context.Context, tenantID string) ([]*Group, error)
+}
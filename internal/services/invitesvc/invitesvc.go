@@ -0,0 +1,489 @@
+// Package invitesvc provides invitation business logic for the IAM system. Admins invite
+// people into their organization, optionally with a role, and hand them a secret token.
+// Accepting the invitation grants the role to the organization's user with the invited
+// email, signing that user up first when the organization doesn't have one yet.
+package invitesvc
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"goa.design/goa/v3/security"
+
+	geninvitation "github.com/iamBelugaa/goa-iam/gen/invitation"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	invitestore "github.com/iamBelugaa/goa-iam/internal/services/invitesvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/services/orgsvc"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
+)
+
+// service implements invitation management on top of the invitation and user stores.
+type service struct {
+	store     invitestore.InvitationStorer // Interface to the invitation data store
+	userStore userstore.UserStorer         // Interface to the user data store
+	orgStore  orgstore.OrganizationStorer  // Interface to the organization data store
+	authn     *jwtauth.Authenticator       // Token authenticator for secured methods
+	audit     *audit.Recorder              // Recorder of security events
+	cfg       *config.Invitations          // Invitation settings
+}
+
+// NewService initializes and returns a new invitation service instance, logging through
+// the logger of each request.
+func NewService(
+	store invitestore.InvitationStorer, userStore userstore.UserStorer,
+	orgStore orgstore.OrganizationStorer, authn *jwtauth.Authenticator, recorder *audit.Recorder,
+	cfg *config.Invitations,
+) *service {
+	return &service{
+		store:     store,
+		userStore: userStore,
+		orgStore:  orgStore,
+		authn:     authn,
+		audit:     recorder,
+		cfg:       cfg,
+	}
+}
+
+// BootstrapAdmins invites the owners of the organization's admin emails as admins, writing
+// the invitation tokens to the configured file, readable by its owner only, for the
+// operator to hand out. Accepting an invitation proves owning its email, which is how the
+// admin emails are granted without an identity provider. Nothing is invited when no file
+// is configured.
+func BootstrapAdmins(ctx context.Context, store invitestore.InvitationStorer, org *orgstore.Organization, cfg *config.Invitations) error {
+	if cfg.AdminTokensFile == "" {
+		return nil
+	}
+
+	var tokens strings.Builder
+	now := time.Now()
+	for _, email := range org.AdminEmails {
+		token, err := randomToken()
+		if err != nil {
+			return err
+		}
+
+		inv := &invitestore.Invitation{
+			ID:        uuid.New().String(),
+			TenantID:  org.ID,
+			Email:     email,
+			Role:      userdomain.RoleAdmin,
+			TokenHash: hashToken(token),
+			Status:    invitestore.StatusPending,
+			ExpiresAt: now.Add(cfg.ExpTime),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := store.Create(ctx, inv); err != nil {
+			return fmt.Errorf("invite admin %s: %w", redact.RedactEmail(email), err)
+		}
+		fmt.Fprintf(&tokens, "%s %s\n", email, token)
+	}
+
+	if err := os.WriteFile(cfg.AdminTokensFile, []byte(tokens.String()), 0o600); err != nil {
+		return fmt.Errorf("write admin invitation tokens: %w", err)
+	}
+	return nil
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// List returns the invitations of the tenant, newest first. Expired invitations are removed
+// beforehand.
+func (s *service) List(ctx context.Context, req *geninvitation.ListInvitationsRequest) (*geninvitation.ListInvitationsResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infow("list invitations request received", "userId", claims.Subject)
+
+	s.deleteExpired(ctx)
+
+	invitations, err := s.store.List(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		logger.FromContext(ctx).Infow("list invitations error", "error", err)
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+	slices.SortFunc(invitations, func(a, b *invitestore.Invitation) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	data := make([]*geninvitation.Invitation, 0, len(invitations))
+	for _, inv := range invitations {
+		data = append(data, toInvitation(inv))
+	}
+
+	logger.FromContext(ctx).Infow("list invitations request successful", "totalInvitations", len(data))
+	return &geninvitation.ListInvitationsResponse{
+		Success: true,
+		Message: "Invitations fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// Invite invites a person to the tenant. The invitation token is only returned in the
+// response and when the invitation is resent.
+func (s *service) Invite(ctx context.Context, req *geninvitation.CreateInvitationRequest) (*geninvitation.IssuedInvitationResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infow("invite request received", "userId", claims.Subject, "email", req.Email, "role", req.Role)
+
+	var role string
+	if req.Role != nil {
+		role = *req.Role
+		if !userdomain.IsRole(role) {
+			logger.FromContext(ctx).Infow("invite error", "email", req.Email, "error", "unknown role")
+			return nil, geninvitation.MakeBadRequest(fmt.Errorf("role %q doesn't exist", role))
+		}
+	}
+
+	// Expired invitations no longer block inviting the same email address again.
+	s.deleteExpired(ctx)
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	now := time.Now()
+	inv := &invitestore.Invitation{
+		ID:        uuid.New().String(),
+		TenantID:  tenancy.FromContext(ctx),
+		Email:     req.Email,
+		Role:      role,
+		TokenHash: hashToken(token),
+		Status:    invitestore.StatusPending,
+		InviterID: claims.Subject,
+		ExpiresAt: now.Add(s.cfg.ExpTime),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.store.Create(ctx, inv); err != nil {
+		logger.FromContext(ctx).Infow("invite error", "email", req.Email, "error", err)
+		return nil, geninvitation.MakeConflict(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventInvitationCreate, TargetID: inv.ID,
+		Details: map[string]string{"email": redact.RedactEmail(inv.Email), "role": inv.Role},
+	})
+
+	logger.FromContext(ctx).Infow("invite request successful", "invitationId", inv.ID)
+	return &geninvitation.IssuedInvitationResponse{
+		Success:     true,
+		Message:     "Invitation created successfully",
+		Data:        toInvitation(inv),
+		InviteToken: token,
+	}, nil
+}
+
+// Resend issues a new token for a pending invitation and restarts its expiry. The previous
+// token can no longer be used.
+func (s *service) Resend(ctx context.Context, req *geninvitation.InvitationRequest) (*geninvitation.IssuedInvitationResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infow("resend invitation request received", "userId", claims.Subject, "invitationId", req.ID)
+
+	inv, err := s.queryPending(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("resend invitation error", "invitationId", req.ID, "error", err)
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	now := time.Now()
+	inv.TokenHash = hashToken(token)
+	inv.ExpiresAt = now.Add(s.cfg.ExpTime)
+	inv.UpdatedAt = now
+	if err := s.store.Update(ctx, inv); err != nil {
+		logger.FromContext(ctx).Infow("resend invitation error", "invitationId", req.ID, "error", err)
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventInvitationResend, TargetID: inv.ID})
+
+	logger.FromContext(ctx).Infow("resend invitation request successful", "invitationId", req.ID)
+	return &geninvitation.IssuedInvitationResponse{
+		Success:     true,
+		Message:     "Invitation resent successfully",
+		Data:        toInvitation(inv),
+		InviteToken: token,
+	}, nil
+}
+
+// Revoke revokes a pending invitation so it can no longer be accepted.
+func (s *service) Revoke(ctx context.Context, req *geninvitation.InvitationRequest) (*geninvitation.InvitationResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infow("revoke invitation request received", "userId", claims.Subject, "invitationId", req.ID)
+
+	inv, err := s.queryPending(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("revoke invitation error", "invitationId", req.ID, "error", err)
+		return nil, err
+	}
+
+	inv.Status = invitestore.StatusRevoked
+	inv.UpdatedAt = time.Now()
+	if err := s.store.Update(ctx, inv); err != nil {
+		logger.FromContext(ctx).Infow("revoke invitation error", "invitationId", req.ID, "error", err)
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventInvitationRevoke, TargetID: inv.ID})
+
+	logger.FromContext(ctx).Infow("revoke invitation request successful", "invitationId", req.ID)
+	return &geninvitation.InvitationResponse{
+		Success: true,
+		Message: "Invitation revoked successfully",
+		Data:    toInvitation(inv),
+	}, nil
+}
+
+// Accept accepts an invitation in the tenant it was issued for. The tenant's user with the
+// invited email, who must authenticate with their access token, is granted the
+// invitation's role; when there's no such user, one is signed up with the name and password
+// in the request. Accepting proves owning the invited email, so the email is marked verified
+// and the user is also granted the admin role when it's one of the tenant's admin emails.
+func (s *service) Accept(ctx context.Context, req *geninvitation.AcceptInvitationRequest) (*geninvitation.AcceptInvitationResponse, error) {
+	logger.FromContext(ctx).Infow("accept invitation request received")
+
+	inv, err := s.store.QueryByTokenHash(ctx, hashToken(req.InviteToken))
+	if err != nil {
+		logger.FromContext(ctx).Infow("accept invitation error", "error", err)
+		return nil, geninvitation.MakeNotFound(fmt.Errorf("invitation token is invalid"))
+	}
+	if inv.Status != invitestore.StatusPending {
+		logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "status", inv.Status)
+		return nil, geninvitation.MakeBadRequest(fmt.Errorf("invitation has already been %s", inv.Status))
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", "invitation expired")
+		return nil, geninvitation.MakeInvitationExpired(fmt.Errorf("invitation expired at %s", inv.ExpiresAt.Format(time.RFC3339)))
+	}
+
+	// The invitation decides the tenant, whichever tenant the request was routed to.
+	ctx = tenancy.WithTenant(ctx, inv.TenantID)
+
+	user, err := s.userStore.QueryByEmail(ctx, inv.TenantID, inv.Email)
+	if err != nil || user == nil {
+		if user, err = s.signup(ctx, inv, req); err != nil {
+			logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+			return nil, err
+		}
+	} else if err := s.authenticateInvitee(ctx, user, req.Authorization); err != nil {
+		logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+		return nil, err
+	}
+
+	if inv.Role != "" && !slices.Contains(user.Roles, inv.Role) {
+		if user, err = s.userStore.UpdateRoles(ctx, user.ID, append(slices.Clone(user.Roles), inv.Role)); err != nil {
+			logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+			return nil, geninvitation.MakeInternalServerError(err)
+		}
+	}
+	if !user.EmailVerified {
+		if user, err = s.userStore.VerifyEmail(ctx, user.ID); err != nil {
+			logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+			return nil, geninvitation.MakeInternalServerError(err)
+		}
+	}
+	if user, err = orgsvc.GrantAdminEmail(ctx, s.orgStore, s.userStore, user); err != nil {
+		logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	now := time.Now()
+	inv.Status = invitestore.StatusAccepted
+	inv.UserID = user.ID
+	inv.AcceptedAt = now
+	inv.UpdatedAt = now
+	if err := s.store.Update(ctx, inv); err != nil {
+		logger.FromContext(ctx).Infow("accept invitation error", "invitationId", inv.ID, "error", err)
+		return nil, geninvitation.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventInvitationAccept, ActorID: user.ID, TargetID: inv.ID, Details: map[string]string{"role": inv.Role},
+	})
+
+	logger.FromContext(ctx).Infow("accept invitation request successful", "invitationId", inv.ID, "userId", user.ID)
+	return &geninvitation.AcceptInvitationResponse{
+		Success: true,
+		Message: "Invitation accepted successfully",
+		Data:    toUser(user),
+	}, nil
+}
+
+// signup creates the invited user with the name and password in the request, applying the
+// same checks as signing up.
+func (s *service) signup(ctx context.Context, inv *invitestore.Invitation, req *geninvitation.AcceptInvitationRequest) (*genuser.User, error) {
+	if req.FirstName == nil || req.LastName == nil || req.Password == nil || req.ConfirmPassword == nil {
+		return nil, geninvitation.MakeBadRequest(fmt.Errorf("firstName, lastName, password and confirmPassword are required to sign up"))
+	}
+	if *req.Password != *req.ConfirmPassword {
+		return nil, geninvitation.MakePasswordMismatch(fmt.Errorf("confirm password and password doesn't match"))
+	}
+
+	user, err := s.userStore.Create(ctx, inv.TenantID, &genuser.CreateUserRequest{
+		FirstName: *req.FirstName,
+		LastName:  *req.LastName,
+		Email:     inv.Email,
+		Password:  *req.Password,
+	})
+	if err != nil {
+		return nil, geninvitation.MakeConflict(err)
+	}
+	return user, nil
+}
+
+// authenticateInvitee checks that the caller is the existing user the invitation was sent
+// to, so an invitation token alone never grants roles to an account.
+func (s *service) authenticateInvitee(ctx context.Context, user *genuser.User, authorization *string) error {
+	var token string
+	if authorization != nil {
+		token, _ = strings.CutPrefix(*authorization, "Bearer ")
+	}
+	if token == "" {
+		return geninvitation.MakeUnauthorized(fmt.Errorf("access token of the invited user required"))
+	}
+
+	ctx, err := s.authn.Authenticate(ctx, token)
+	if err != nil {
+		return err
+	}
+	if claims, ok := tokenmgr.UserClaimsFromContext(ctx); !ok || claims.Subject != user.ID {
+		return geninvitation.MakeForbidden(fmt.Errorf("invitation was sent to another user"))
+	}
+	return nil
+}
+
+// queryPending retrieves a pending invitation of the request's tenant. Invitations of other
+// tenants are reported as missing.
+func (s *service) queryPending(ctx context.Context, invitationID string) (*invitestore.Invitation, error) {
+	inv, err := s.store.QueryByID(ctx, invitationID)
+	if err != nil {
+		return nil, geninvitation.MakeNotFound(err)
+	}
+	if inv.TenantID != tenancy.FromContext(ctx) {
+		return nil, geninvitation.MakeNotFound(fmt.Errorf("invitation with id %s doesn't exist", invitationID))
+	}
+	if inv.Status != invitestore.StatusPending {
+		return nil, geninvitation.MakeConflict(fmt.Errorf("invitation has already been %s", inv.Status))
+	}
+	return inv, nil
+}
+
+// deleteExpired removes the expired invitations of every tenant. Failures are only logged,
+// since expired invitations can't be accepted anyway.
+func (s *service) deleteExpired(ctx context.Context) {
+	deleted, err := s.store.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		logger.FromContext(ctx).Infow("delete expired invitations error", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger.FromContext(ctx).Infow("deleted expired invitations", "count", deleted)
+	}
+}
+
+// authorize returns the claims of the caller, failing unless it's a user holding the
+// invitations:manage permission.
+func authorize(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, geninvitation.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if !slices.Contains(claims.Permissions, userdomain.PermissionManageInvitations) {
+		return tokenmgr.Claims{}, geninvitation.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageInvitations))
+	}
+	return claims, nil
+}
+
+// randomToken returns a URL safe, 256-bit random invitation token.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate invitation token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the SHA-256 hash invitations are looked up by, so stored invitations
+// don't reveal their tokens.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// toInvitation converts a stored invitation into its API representation.
+func toInvitation(inv *invitestore.Invitation) *geninvitation.Invitation {
+	res := &geninvitation.Invitation{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Status:    inv.Status,
+		InviterID: inv.InviterID,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: inv.UpdatedAt.Format(time.RFC3339),
+	}
+	if inv.Role != "" {
+		res.Role = &inv.Role
+	}
+	if inv.UserID != "" {
+		res.UserID = &inv.UserID
+	}
+	if !inv.AcceptedAt.IsZero() {
+		acceptedAt := inv.AcceptedAt.Format(time.RFC3339)
+		res.AcceptedAt = &acceptedAt
+	}
+	return res
+}
+
+// toUser converts a user into the representation returned by the invitation service.
+func toUser(u *genuser.User) *geninvitation.User {
+	return &geninvitation.User{
+		ID:            u.ID,
+		TenantID:      u.TenantID,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Status:        u.Status,
+		Roles:         u.Roles,
+		ExternalID:    u.ExternalID,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
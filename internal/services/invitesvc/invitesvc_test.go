@@ -0,0 +1,292 @@
+package invitesvc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	geninvitation "github.com/iamBelugaa/goa-iam/gen/invitation"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/invitesvc"
+	invitestore "github.com/iamBelugaa/goa-iam/internal/services/invitesvc/store"
+	invitememorystore "github.com/iamBelugaa/goa-iam/internal/services/invitesvc/store/memory"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+	orgmemorystore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store/memory"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestInvitationService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Invitation Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Invitation service", func() {
+	const tenantID = "acme"
+
+	var (
+		ctx         context.Context
+		adminCtx    context.Context
+		svc         geninvitation.Service
+		userStore   userstore.UserStorer
+		inviteStore invitestore.InvitationStorer
+		tm          *tokenmgr.JWTTokenManager
+		sessions    *session.Manager
+		newSvc      func(cfg *config.Invitations) geninvitation.Service
+	)
+
+	userCtx := func(userID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+	}
+
+	invite := func(email string, role *string) *geninvitation.IssuedInvitationResponse {
+		res, err := svc.Invite(adminCtx, &geninvitation.CreateInvitationRequest{Email: email, Role: role})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	// bearer returns the Authorization header of a signed in user of the tenant. Issued
+	// tokens only become valid one second after issuance, so callers wait before using it.
+	bearer := func(userID string) *string {
+		sess, err := sessions.Create(ctx, userID, requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.SessionID = sess.ID
+		token, err := tm.Generate(context.Background(), claims)
+		Expect(err).NotTo(HaveOccurred())
+		return ptr("Bearer " + token)
+	}
+
+	signupRequest := func(token string) *geninvitation.AcceptInvitationRequest {
+		return &geninvitation.AcceptInvitationRequest{
+			InviteToken:     token,
+			FirstName:       ptr("Jane"),
+			LastName:        ptr("Doe"),
+			Password:        ptr("Passw0rd!23"),
+			ConfirmPassword: ptr("Passw0rd!23"),
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions = session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		orgStore := orgmemorystore.NewMemoryStore()
+		Expect(orgStore.Create(ctx, &orgstore.Organization{ID: tenantID, Slug: tenantID, Name: "Acme", AdminEmails: []string{"it@acme.com"}})).To(Succeed())
+
+		userStore = usermemorystore.NewMemoryStore()
+		inviteStore = invitememorystore.NewMemoryStore()
+		newSvc = func(cfg *config.Invitations) geninvitation.Service {
+			return invitesvc.NewService(inviteStore, userStore, orgStore, authn, audit.NewRecorder(log, auditmemorystore.NewMemoryStore()), cfg)
+		}
+		svc = newSvc(&config.Invitations{ExpTime: time.Hour})
+		adminCtx = userCtx("admin", userdomain.PermissionManageInvitations)
+	})
+
+	It("requires the permission to manage invitations", func() {
+		_, err := svc.Invite(userCtx("member"), &geninvitation.CreateInvitationRequest{Email: "jane@acme.com"})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		_, err = svc.List(ctx, &geninvitation.ListInvitationsRequest{})
+		Expect(errorName(err)).To(Equal("unauthorized"))
+	})
+
+	It("signs up invited people and grants them the invitation's role", func() {
+		issued := invite("jane@acme.com", ptr(userdomain.RoleAdmin))
+		Expect(issued.Data.Status).To(Equal("pending"))
+		Expect(issued.InviteToken).NotTo(BeEmpty())
+
+		_, err := svc.Invite(adminCtx, &geninvitation.CreateInvitationRequest{Email: "jane@acme.com"})
+		Expect(errorName(err)).To(Equal("conflict"))
+		_, err = svc.Invite(adminCtx, &geninvitation.CreateInvitationRequest{Email: "joe@acme.com", Role: ptr("owner")})
+		Expect(errorName(err)).To(Equal("bad_request"))
+
+		req := signupRequest(issued.InviteToken)
+		req.ConfirmPassword = ptr("something-else")
+		_, err = svc.Accept(ctx, req)
+		Expect(errorName(err)).To(Equal("password_mismatch"))
+
+		// The invitation decides the tenant, not the tenant the request was routed to.
+		res, err := svc.Accept(ctx, signupRequest(issued.InviteToken))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.TenantID).To(Equal(tenantID))
+		Expect(res.Data.Email).To(Equal("jane@acme.com"))
+		Expect(res.Data.Roles).To(Equal([]string{userdomain.RoleAdmin}))
+
+		_, err = svc.Accept(ctx, signupRequest(issued.InviteToken))
+		Expect(errorName(err)).To(Equal("bad_request"))
+
+		list, err := svc.List(adminCtx, &geninvitation.ListInvitationsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(HaveLen(1))
+		Expect(list.Data[0].Status).To(Equal("accepted"))
+		Expect(*list.Data[0].UserID).To(Equal(res.Data.ID))
+	})
+
+	It("links invitations to the tenant's existing user with the invited email", func() {
+		existing, err := userStore.Create(ctx, tenantID, &genuser.CreateUserRequest{
+			FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = userStore.Create(ctx, "other-tenant", &genuser.CreateUserRequest{
+			FirstName: "Joe", LastName: "Doe", Email: "joe@acme.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		other, err := userStore.Create(ctx, tenantID, &genuser.CreateUserRequest{
+			FirstName: "Mallory", LastName: "Doe", Email: "mallory@acme.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		janeAuth, otherAuth := bearer(existing.ID), bearer(other.ID)
+		time.Sleep(time.Second)
+
+		// The invitation token alone doesn't attach the invitation to an existing account.
+		inviteToken := invite("jane@acme.com", ptr(userdomain.RoleAdmin)).InviteToken
+		_, err = svc.Accept(ctx, &geninvitation.AcceptInvitationRequest{InviteToken: inviteToken})
+		Expect(errorName(err)).To(Equal("unauthorized"))
+		_, err = svc.Accept(ctx, &geninvitation.AcceptInvitationRequest{InviteToken: inviteToken, Authorization: otherAuth})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		res, err := svc.Accept(ctx, &geninvitation.AcceptInvitationRequest{InviteToken: inviteToken, Authorization: janeAuth})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.ID).To(Equal(existing.ID))
+		Expect(res.Data.Roles).To(Equal([]string{userdomain.RoleAdmin}))
+		Expect(res.Data.EmailVerified).To(BeTrue())
+
+		// Users of other tenants aren't linked, so the invited person has to sign up.
+		_, err = svc.Accept(ctx, &geninvitation.AcceptInvitationRequest{InviteToken: invite("joe@acme.com", nil).InviteToken})
+		Expect(errorName(err)).To(Equal("bad_request"))
+	})
+
+	It("grants the admin role to the tenant's admin emails once they accept an invitation", func() {
+		// Registering with an admin email doesn't prove owning it, so it grants no role.
+		existing, err := userStore.Create(ctx, tenantID, &genuser.CreateUserRequest{
+			FirstName: "Ian", LastName: "Tech", Email: "it@acme.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(existing.Roles).To(BeEmpty())
+		auth := bearer(existing.ID)
+		time.Sleep(time.Second)
+
+		res, err := svc.Accept(ctx, &geninvitation.AcceptInvitationRequest{InviteToken: invite("it@acme.com", nil).InviteToken, Authorization: auth})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.ID).To(Equal(existing.ID))
+		Expect(res.Data.Roles).To(Equal([]string{userdomain.RoleAdmin}))
+
+		res, err = svc.Accept(ctx, signupRequest(invite("jane@acme.com", nil).InviteToken))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Roles).To(BeEmpty())
+	})
+
+	It("invites the admin emails at startup, writing the tokens to the configured file", func() {
+		org := &orgstore.Organization{ID: tenantID, AdminEmails: []string{"it@acme.com"}}
+		Expect(invitesvc.BootstrapAdmins(ctx, inviteStore, org, &config.Invitations{ExpTime: time.Hour})).To(Succeed())
+		invitations, err := inviteStore.List(ctx, tenantID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(invitations).To(BeEmpty())
+
+		path := filepath.Join(GinkgoT().TempDir(), "admin-tokens")
+		Expect(invitesvc.BootstrapAdmins(ctx, inviteStore, org, &config.Invitations{ExpTime: time.Hour, AdminTokensFile: path})).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		email, token, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+		Expect(ok).To(BeTrue())
+		Expect(email).To(Equal("it@acme.com"))
+
+		res, err := svc.Accept(ctx, signupRequest(token))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Email).To(Equal("it@acme.com"))
+		Expect(res.Data.Roles).To(Equal([]string{userdomain.RoleAdmin}))
+	})
+
+	It("resends and revokes pending invitations", func() {
+		issued := invite("jane@acme.com", nil)
+
+		resent, err := svc.Resend(adminCtx, &geninvitation.InvitationRequest{ID: issued.Data.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resent.InviteToken).NotTo(Equal(issued.InviteToken))
+		_, err = svc.Accept(ctx, signupRequest(issued.InviteToken))
+		Expect(errorName(err)).To(Equal("not_found"))
+
+		// Invitations of other tenants are invisible.
+		otherCtx := tenancy.WithTenant(adminCtx, "other-tenant")
+		_, err = svc.Revoke(otherCtx, &geninvitation.InvitationRequest{ID: issued.Data.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+
+		revoked, err := svc.Revoke(adminCtx, &geninvitation.InvitationRequest{ID: issued.Data.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revoked.Data.Status).To(Equal("revoked"))
+		_, err = svc.Accept(ctx, signupRequest(resent.InviteToken))
+		Expect(errorName(err)).To(Equal("bad_request"))
+		_, err = svc.Resend(adminCtx, &geninvitation.InvitationRequest{ID: issued.Data.ID})
+		Expect(errorName(err)).To(Equal("conflict"))
+	})
+
+	It("rejects and removes expired invitations", func() {
+		svc = newSvc(&config.Invitations{ExpTime: -time.Minute})
+		issued := invite("jane@acme.com", nil)
+
+		_, err := svc.Accept(ctx, signupRequest(issued.InviteToken))
+		Expect(errorName(err)).To(Equal("invitation_expired"))
+
+		list, err := svc.List(adminCtx, &geninvitation.ListInvitationsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(BeEmpty())
+
+		// The expired invitation no longer blocks inviting the person again.
+		invite("jane@acme.com", nil)
+	})
+})
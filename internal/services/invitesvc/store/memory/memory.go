@@ -0,0 +1,129 @@
+// Package invitestore provides an in-memory implementation of the InvitationStorer interface.
+package invitestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	invitestore "github.com/iamBelugaa/goa-iam/internal/services/invitesvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
+)
+
+// memory implements the InvitationStorer interface using in-memory maps.
+type memory struct {
+	mu           sync.RWMutex                       // protects access to invitations and tokenToIdMap
+	tokenToIdMap map[string]string                  // maps token hashes to invitation IDs
+	invitations  map[string]*invitestore.Invitation // stores invitations by ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory invitation store.
+func NewMemoryStore() *memory {
+	return &memory{
+		tokenToIdMap: make(map[string]string),
+		invitations:  make(map[string]*invitestore.Invitation),
+	}
+}
+
+// Create adds a new invitation to the in-memory store.
+func (m *memory) Create(ctx context.Context, inv *invitestore.Invitation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.invitations[inv.ID]; exists {
+		return fmt.Errorf("invitation with id %s already exists", inv.ID)
+	}
+	for _, existing := range m.invitations {
+		if existing.TenantID == inv.TenantID && existing.Status == invitestore.StatusPending && strings.EqualFold(existing.Email, inv.Email) {
+			return fmt.Errorf("a pending invitation for %s already exists", redact.RedactEmail(inv.Email))
+		}
+	}
+
+	stored := *inv
+	m.tokenToIdMap[string(inv.TokenHash)] = inv.ID
+	m.invitations[inv.ID] = &stored
+
+	return nil
+}
+
+// QueryByID retrieves an invitation from memory by its ID.
+func (m *memory) QueryByID(ctx context.Context, invitationID string) (*invitestore.Invitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inv, ok := m.invitations[invitationID]
+	if !ok {
+		return nil, fmt.Errorf("invitation with id %s doesn't exist", invitationID)
+	}
+
+	found := *inv
+	return &found, nil
+}
+
+// QueryByTokenHash retrieves an invitation from memory by the hash of its token.
+func (m *memory) QueryByTokenHash(ctx context.Context, tokenHash []byte) (*invitestore.Invitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inv, ok := m.invitations[m.tokenToIdMap[string(tokenHash)]]
+	if !ok {
+		return nil, fmt.Errorf("invitation doesn't exist")
+	}
+
+	found := *inv
+	return &found, nil
+}
+
+// Update replaces an existing invitation in memory, re-indexing it when its token changed.
+func (m *memory) Update(ctx context.Context, inv *invitestore.Invitation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.invitations[inv.ID]
+	if !ok {
+		return fmt.Errorf("invitation with id %s doesn't exist", inv.ID)
+	}
+
+	stored := *inv
+	delete(m.tokenToIdMap, string(existing.TokenHash))
+	m.tokenToIdMap[string(inv.TokenHash)] = inv.ID
+	m.invitations[inv.ID] = &stored
+
+	return nil
+}
+
+// List returns all invitations of the given tenant currently stored in memory.
+func (m *memory) List(ctx context.Context, tenantID string) ([]*invitestore.Invitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	invitations := make([]*invitestore.Invitation, 0)
+	for _, inv := range m.invitations {
+		if inv.TenantID != tenantID {
+			continue
+		}
+		found := *inv
+		invitations = append(invitations, &found)
+	}
+
+	return invitations, nil
+}
+
+// DeleteExpired removes the pending invitations that expired before the given time.
+func (m *memory) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for id, inv := range m.invitations {
+		if inv.Status == invitestore.StatusPending && inv.ExpiresAt.Before(before) {
+			delete(m.tokenToIdMap, string(inv.TokenHash))
+			delete(m.invitations, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,53 @@
+// Package invitestore defines the interface for interacting with the invitation data storage layer.
+package invitestore
+
+import (
+	"context"
+	"time"
+)
+
+// Invitation statuses. Pending invitations that expire are removed rather than kept.
+const (
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+)
+
+// Invitation represents an invitation for a person to join an organization.
+type Invitation struct {
+	ID         string    // Unique identifier of the invitation
+	TenantID   string    // ID of the organization the person is invited to
+	Email      string    // Email address of the invited person
+	Role       string    // Role granted on acceptance, empty for none
+	TokenHash  []byte    // SHA-256 hash of the secret token the invitation is accepted with
+	Status     string    // Pending, accepted or revoked
+	InviterID  string    // ID of the user who sent the invitation, empty for admins invited at startup
+	UserID     string    // ID of the user who accepted the invitation
+	ExpiresAt  time.Time // Time after which the invitation can no longer be accepted
+	AcceptedAt time.Time // Time the invitation was accepted
+	CreatedAt  time.Time // Time the invitation was created
+	UpdatedAt  time.Time // Time the invitation was last modified
+}
+
+// InvitationStorer defines the contract for managing invitations in a storage backend.
+type InvitationStorer interface {
+	// Create stores a new invitation. An email address has at most one pending invitation
+	// per tenant.
+	Create(ctx context.Context, inv *Invitation) error
+
+	// QueryByID retrieves an invitation by its unique ID.
+	QueryByID(ctx context.Context, invitationID string) (*Invitation, error)
+
+	// QueryByTokenHash retrieves an invitation by the hash of its token.
+	QueryByTokenHash(ctx context.Context, tokenHash []byte) (*Invitation, error)
+
+	// Update replaces an existing invitation.
+	Update(ctx context.Context, inv *Invitation) error
+
+	// List returns all invitations of the given tenant.
+	List(ctx context.Context, tenantID string) ([]*Invitation, error)
+
+	// DeleteExpired removes the pending invitations that expired before the given time and
+	// returns how many were removed.
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
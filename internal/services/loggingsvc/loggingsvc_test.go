@@ -0,0 +1,144 @@
+package loggingsvc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zapcore"
+	goa "goa.design/goa/v3/pkg"
+
+	genlogging "github.com/iamBelugaa/goa-iam/gen/logging"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/loggingsvc"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestLoggingService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logging Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("Logging service", func() {
+	const defaultOrgID = "default"
+
+	var (
+		ctx context.Context
+		tm  *tokenmgr.JWTTokenManager
+		log *logger.Logger
+		svc genlogging.Service
+	)
+
+	userCtx := func(tenantID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims("admin", tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		log, err = logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+		recorder := audit.NewRecorder(log, auditmemorystore.NewMemoryStore())
+
+		svc = loggingsvc.NewService(log, defaultOrgID, authn, recorder)
+	})
+
+	It("is restricted to platform admins holding the logging permission", func() {
+		_, err := svc.GetLevels(ctx, &genlogging.GetLogLevelsRequest{})
+		Expect(errorName(err)).To(Equal("unauthorized"))
+
+		_, err = svc.GetLevels(userCtx(defaultOrgID), &genlogging.GetLogLevelsRequest{})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		_, err = svc.SetLevel(userCtx("acme", userdomain.PermissionManageLogging), &genlogging.SetLogLevelRequest{Level: "debug"})
+		Expect(errorName(err)).To(Equal("forbidden"))
+		Expect(log.Levels().Global().Level).To(Equal(zapcore.ErrorLevel))
+	})
+
+	It("changes the global level and named logger overrides", func() {
+		adminCtx := userCtx(defaultOrgID, userdomain.PermissionManageLogging)
+
+		res, err := svc.SetLevel(adminCtx, &genlogging.SetLogLevelRequest{Level: "warn"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Level).To(Equal("warn"))
+		Expect(res.Data.RevertAt).To(BeNil())
+
+		res, err = svc.SetOverride(adminCtx, &genlogging.SetLogLevelOverrideRequest{Name: "auth", Level: "debug", TTL: ptr("15m")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Overrides).To(HaveLen(1))
+		Expect(res.Data.Overrides[0].Name).To(Equal("auth"))
+		Expect(res.Data.Overrides[0].Level).To(Equal("debug"))
+		Expect(res.Data.Overrides[0].RevertAt).NotTo(BeNil())
+
+		Expect(log.Named("auth").Desugar().Core().Enabled(zapcore.DebugLevel)).To(BeTrue())
+		Expect(log.Named("auth").Desugar().Check(zapcore.DebugLevel, "debug")).NotTo(BeNil())
+		Expect(log.Named("auth.passkey").Desugar().Check(zapcore.DebugLevel, "debug")).NotTo(BeNil())
+		Expect(log.Named("user").Desugar().Check(zapcore.DebugLevel, "debug")).To(BeNil())
+		Expect(log.Named("user").Desugar().Check(zapcore.WarnLevel, "warn")).NotTo(BeNil())
+
+		res, err = svc.DeleteOverride(adminCtx, &genlogging.DeleteLogLevelOverrideRequest{Name: "auth"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Overrides).To(BeEmpty())
+		Expect(log.Named("auth").Desugar().Check(zapcore.DebugLevel, "debug")).To(BeNil())
+
+		_, err = svc.DeleteOverride(adminCtx, &genlogging.DeleteLogLevelOverrideRequest{Name: "auth"})
+		Expect(errorName(err)).To(Equal("not_found"))
+	})
+
+	It("reverts timed changes once their TTL elapses", func() {
+		adminCtx := userCtx(defaultOrgID, userdomain.PermissionManageLogging)
+
+		_, err := svc.SetLevel(adminCtx, &genlogging.SetLogLevelRequest{Level: "warn"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.SetLevel(adminCtx, &genlogging.SetLogLevelRequest{Level: "debug", TTL: ptr("20ms")})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = svc.SetOverride(adminCtx, &genlogging.SetLogLevelOverrideRequest{Name: "auth", Level: "info", TTL: ptr("20ms")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log.Levels().Global().Level).To(Equal(zapcore.DebugLevel))
+
+		Eventually(func() zapcore.Level { return log.Levels().Global().Level }).Should(Equal(zapcore.WarnLevel))
+		Eventually(func() int { return len(log.Levels().Overrides()) }).Should(BeZero())
+
+		_, err = svc.SetLevel(adminCtx, &genlogging.SetLogLevelRequest{Level: "debug", TTL: ptr("soon")})
+		Expect(errorName(err)).To(Equal("bad_request"))
+	})
+})
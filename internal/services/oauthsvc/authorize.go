@@ -0,0 +1,226 @@
+package oauthsvc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Authorization endpoint error codes from RFC 6749 section 4.1.2.1 and OpenID Connect Core.
+const (
+	errInvalidRequest          = "invalid_request"
+	errUnsupportedResponseType = "unsupported_response_type"
+	errInvalidScope            = "invalid_scope"
+	errConsentRequired         = "consent_required"
+	errServerError             = "server_error"
+)
+
+// Authorize handles an authorization code request on behalf of the authenticated user.
+// Requests that can't be tied to a registered redirect URI fail directly; every other
+// outcome is returned as a redirect back to the client carrying a code or an error.
+func (s *service) Authorize(ctx context.Context, req *genoauth.AuthorizeRequest) (*genoauth.AuthorizeResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genoauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("authorize request received", "userId", claims.Subject, "clientId", req.ClientID)
+
+	client, err := s.queryClient(ctx, req.ClientID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query client error", "clientId", req.ClientID, "error", err)
+		return nil, genoauth.MakeBadRequest(err)
+	}
+
+	redirectURI, err := resolveRedirectURI(client, req.RedirectURI)
+	if err != nil {
+		logger.FromContext(ctx).Infow("resolve redirect uri error", "clientId", client.ID, "error", err)
+		return nil, genoauth.MakeBadRequest(err)
+	}
+
+	if req.ResponseType != "code" {
+		return authorizeRedirect(redirectURI, req.State, errUnsupportedResponseType, "only the code response type is supported"), nil
+	}
+	if !slices.Contains(client.GrantTypes, grantTypeAuthorizationCode) {
+		return authorizeRedirect(redirectURI, req.State, errUnauthorizedClient, "client may not use the authorization code grant"), nil
+	}
+
+	scopes, err := resolveScopes(client, req.Scope)
+	if err != nil {
+		return authorizeRedirect(redirectURI, req.State, errInvalidScope, err.Error()), nil
+	}
+
+	challenge, method, err := resolveCodeChallenge(client, req.CodeChallenge, req.CodeChallengeMethod)
+	if err != nil {
+		return authorizeRedirect(redirectURI, req.State, errInvalidRequest, err.Error()), nil
+	}
+
+	consent, err := s.store.QueryConsent(ctx, claims.Subject, client.ID)
+	if err != nil || !containsAll(consent.Scopes, scopes) {
+		logger.FromContext(ctx).Infow("authorize consent required", "userId", claims.Subject, "clientId", client.ID)
+		return authorizeRedirect(redirectURI, req.State, errConsentRequired, "user has not granted the requested scopes"), nil
+	}
+
+	// The user authenticated when the session behind their access token was created.
+	sess, err := s.sessions.Get(ctx, claims.Subject, claims.SessionID)
+	if err != nil {
+		return authorizeRedirect(redirectURI, req.State, errServerError, "failed to resolve the user's session"), nil
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return authorizeRedirect(redirectURI, req.State, errServerError, "failed to generate authorization code"), nil
+	}
+
+	if err := s.store.CreateCode(ctx, &oauthstore.AuthorizationCode{
+		Code:                code,
+		ClientID:            client.ID,
+		UserID:              claims.Subject,
+		RedirectURI:         redirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: method,
+		Nonce:               stringValue(req.Nonce),
+		AuthTime:            sess.CreatedAt,
+		ExpiresAt:           time.Now().Add(s.cfg.AuthorizationCodeExpTime),
+	}); err != nil {
+		logger.FromContext(ctx).Infow("create authorization code error", "clientId", client.ID, "error", err)
+		return authorizeRedirect(redirectURI, req.State, errServerError, "failed to store authorization code"), nil
+	}
+
+	logger.FromContext(ctx).Infow("authorize request successful", "userId", claims.Subject, "clientId", client.ID)
+	return &genoauth.AuthorizeResponse{
+		Success: true,
+		Message: "Authorization granted successfully",
+		Data:    &genoauth.AuthorizeRedirect{RedirectTo: appendQuery(redirectURI, url.Values{"code": {code}}, req.State)},
+	}, nil
+}
+
+// GrantConsent records the authenticated user's consent for a client to access the given scopes.
+func (s *service) GrantConsent(ctx context.Context, req *genoauth.GrantConsentRequest) (*genoauth.GrantConsentResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genoauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("grant consent request received", "userId", claims.Subject, "clientId", req.ClientID, "scope", req.Scope)
+
+	client, err := s.queryClient(ctx, req.ClientID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query client error", "clientId", req.ClientID, "error", err)
+		return nil, genoauth.MakeNotFound(err)
+	}
+
+	scopes, err := resolveScopes(client, &req.Scope)
+	if err != nil {
+		logger.FromContext(ctx).Infow("grant consent error", "clientId", client.ID, "error", err)
+		return nil, genoauth.MakeBadRequest(err)
+	}
+
+	if err := s.store.SaveConsent(ctx, &oauthstore.Consent{
+		UserID:    claims.Subject,
+		ClientID:  client.ID,
+		Scopes:    scopes,
+		GrantedAt: time.Now(),
+	}); err != nil {
+		logger.FromContext(ctx).Infow("save consent error", "clientId", client.ID, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	logger.FromContext(ctx).Infow("grant consent request successful", "userId", claims.Subject, "clientId", client.ID)
+	return &genoauth.GrantConsentResponse{
+		Success: true,
+		Message: "Consent granted successfully",
+	}, nil
+}
+
+// resolveRedirectURI returns the redirect URI to use for the request. A given URI must
+// exactly match a registered one; it may only be omitted when exactly one is registered.
+func resolveRedirectURI(client *oauthstore.Client, requested *string) (string, error) {
+	if requested == nil {
+		if len(client.RedirectURIs) != 1 {
+			return "", fmt.Errorf("redirect_uri is required")
+		}
+		return client.RedirectURIs[0], nil
+	}
+
+	if !slices.Contains(client.RedirectURIs, *requested) {
+		return "", fmt.Errorf("redirect_uri is not registered for client")
+	}
+	return *requested, nil
+}
+
+// resolveScopes parses a space separated scope parameter and checks it against the
+// scopes the client may request. An empty parameter requests all client scopes.
+func resolveScopes(client *oauthstore.Client, scope *string) ([]string, error) {
+	if scope == nil || strings.TrimSpace(*scope) == "" {
+		return client.Scopes, nil
+	}
+
+	scopes := strings.Fields(*scope)
+	for _, requested := range scopes {
+		if !slices.Contains(client.Scopes, requested) {
+			return nil, fmt.Errorf("scope %q is not allowed for client", requested)
+		}
+	}
+	return scopes, nil
+}
+
+// stringValue returns the value of an optional string, or the empty string when unset.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// containsAll reports whether every requested value, such as a scope or an audience, is in the granted set.
+func containsAll(granted, requested []string) bool {
+	for _, value := range requested {
+		if !slices.Contains(granted, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeRedirect builds an error redirect as defined in RFC 6749 section 4.1.2.1.
+func authorizeRedirect(redirectURI string, state *string, code, description string) *genoauth.AuthorizeResponse {
+	params := url.Values{"error": {code}, "error_description": {description}}
+
+	return &genoauth.AuthorizeResponse{
+		Success: false,
+		Message: "Authorization request was rejected",
+		Data:    &genoauth.AuthorizeRedirect{RedirectTo: appendQuery(redirectURI, params, state)},
+	}
+}
+
+// appendQuery adds the response parameters and state to the query of the redirect URI.
+func appendQuery(redirectURI string, params url.Values, state *string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		for _, v := range values {
+			query.Add(key, v)
+		}
+	}
+	if state != nil && *state != "" {
+		query.Set("state", *state)
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
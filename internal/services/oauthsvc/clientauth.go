@@ -0,0 +1,198 @@
+package oauthsvc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+)
+
+// clientAssertionTypeJWTBearer is the only client assertion type accepted (RFC 7523 section 2.2).
+const clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// maxAssertionLifetime bounds how far in the future a client assertion may expire,
+// which also bounds how long its jti must be remembered.
+const maxAssertionLifetime = 5 * time.Minute
+
+// assertionSigningMethods lists the algorithms accepted for client assertions.
+var assertionSigningMethods = []string{
+	jwt.SigningMethodRS256.Name,
+	jwt.SigningMethodES256.Name,
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// clientCredentials holds the client authentication parameters shared by the token,
+// introspection and revocation endpoints.
+type clientCredentials struct {
+	Authorization       *string // HTTP Authorization header
+	ClientID            *string // client_id parameter
+	ClientSecret        *string // client_secret parameter
+	ClientAssertionType *string // client_assertion_type parameter
+	ClientAssertion     *string // client_assertion parameter
+}
+
+// authenticateClient identifies the client making a request (RFC 6749 section 2.3).
+// Clients authenticate with their secret using HTTP Basic or body parameters, with a
+// signed JWT assertion (private_key_jwt) or, for public clients, only identify themselves.
+func (s *service) authenticateClient(ctx context.Context, req clientCredentials) (*oauthstore.Client, error) {
+	if req.ClientAssertion != nil || req.ClientAssertionType != nil {
+		return s.authenticateClientAssertion(ctx, req)
+	}
+
+	clientID, secret, hasBasic, err := parseBasicAuth(req.Authorization)
+	if err != nil {
+		return nil, oauthError(errInvalidClient, err.Error())
+	}
+
+	if !hasBasic {
+		if req.ClientID == nil || *req.ClientID == "" {
+			return nil, oauthError(errInvalidClient, "client authentication is required")
+		}
+		clientID = *req.ClientID
+		if req.ClientSecret != nil {
+			secret = *req.ClientSecret
+		}
+	}
+
+	client, err := s.queryClient(ctx, clientID)
+	if err != nil {
+		return nil, oauthError(errInvalidClient, "client authentication failed")
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case oauthstore.AuthMethodClientSecretBasic, oauthstore.AuthMethodClientSecretPost:
+		if secret == "" || subtle.ConstantTimeCompare(hashSecret(secret), client.SecretHash) != 1 {
+			return nil, oauthError(errInvalidClient, "client authentication failed")
+		}
+	case oauthstore.AuthMethodNone:
+		if secret != "" {
+			return nil, oauthError(errInvalidClient, "public clients must not use a client secret")
+		}
+	default:
+		return nil, oauthError(errInvalidClient, fmt.Sprintf("client must authenticate with %s", client.TokenEndpointAuthMethod))
+	}
+
+	return client, nil
+}
+
+// authenticateClientAssertion authenticates a private_key_jwt client (RFC 7523 section 3).
+// The assertion must be signed with the client's registered key, name the client as
+// issuer and subject, target the token endpoint and not have been used before.
+func (s *service) authenticateClientAssertion(ctx context.Context, req clientCredentials) (*oauthstore.Client, error) {
+	if req.ClientAssertionType == nil || *req.ClientAssertionType != clientAssertionTypeJWTBearer {
+		return nil, oauthError(errInvalidClient, "unsupported client_assertion_type")
+	}
+	if req.ClientAssertion == nil || *req.ClientAssertion == "" {
+		return nil, oauthError(errInvalidClient, "client_assertion is required")
+	}
+
+	// The issuer identifies the client whose key verifies the signature.
+	var unverified jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(*req.ClientAssertion, &unverified); err != nil {
+		return nil, oauthError(errInvalidClient, "client_assertion is malformed")
+	}
+	if req.ClientID != nil && *req.ClientID != unverified.Issuer {
+		return nil, oauthError(errInvalidClient, "client_id does not match the client assertion")
+	}
+
+	client, err := s.queryClient(ctx, unverified.Issuer)
+	if err != nil || client.TokenEndpointAuthMethod != oauthstore.AuthMethodPrivateKeyJWT {
+		return nil, oauthError(errInvalidClient, "client authentication failed")
+	}
+
+	key, err := parsePublicKey(client.PublicKeyPEM)
+	if err != nil {
+		return nil, oauthError(errInvalidClient, "client authentication failed")
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(assertionSigningMethods),
+		jwt.WithIssuer(client.ID),
+		jwt.WithSubject(client.ID),
+		jwt.WithAudience(s.cfg.TokenEndpointURL),
+		jwt.WithExpirationRequired(),
+	)
+
+	var claims jwt.RegisteredClaims
+	if _, err := parser.ParseWithClaims(*req.ClientAssertion, &claims, func(*jwt.Token) (any, error) {
+		return key, nil
+	}); err != nil {
+		return nil, oauthError(errInvalidClient, "client assertion is invalid")
+	}
+
+	if claims.ID == "" {
+		return nil, oauthError(errInvalidClient, "client assertion must have a jti")
+	}
+	if time.Until(claims.ExpiresAt.Time) > maxAssertionLifetime {
+		return nil, oauthError(errInvalidClient, "client assertion lifetime is too long")
+	}
+	if err := s.store.UseAssertion(ctx, client.ID, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, oauthError(errInvalidClient, err.Error())
+	}
+
+	return client, nil
+}
+
+// parsePublicKey decodes a PEM encoded PKIX public key usable for client assertions.
+func parsePublicKey(publicKeyPEM string) (any, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("public key must be PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// parseBasicAuth decodes client credentials sent with the HTTP Basic scheme, where the
+// client ID and secret are form-urlencoded before being joined.
+func parseBasicAuth(header *string) (clientID, secret string, ok bool, err error) {
+	if header == nil || *header == "" {
+		return "", "", false, nil
+	}
+
+	scheme, encoded, found := strings.Cut(*header, " ")
+	if !found || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false, fmt.Errorf("malformed basic credentials")
+	}
+
+	rawID, rawSecret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false, fmt.Errorf("malformed basic credentials")
+	}
+
+	if clientID, err = url.QueryUnescape(rawID); err != nil {
+		return "", "", false, fmt.Errorf("malformed basic credentials")
+	}
+	if secret, err = url.QueryUnescape(rawSecret); err != nil {
+		return "", "", false, fmt.Errorf("malformed basic credentials")
+	}
+
+	return clientID, secret, true, nil
+}
@@ -0,0 +1,271 @@
+package oauthsvc
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Device grant polling error codes from RFC 8628 section 3.5. access_denied is also
+// returned when the server refuses a token exchange.
+const (
+	errAuthorizationPending = "authorization_pending"
+	errSlowDown             = "slow_down"
+	errAccessDenied         = "access_denied"
+	errExpiredToken         = "expired_token"
+)
+
+// userCodeAlphabet holds the characters user codes are drawn from. Vowels are left out
+// so codes never spell words, and the remaining consonants are hard to confuse when typed.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters in a user code, excluding the separator.
+const userCodeLength = 8
+
+// slowDownIncrement is added to the polling interval whenever a device polls too fast.
+const slowDownIncrement = 5 * time.Second
+
+// DeviceAuthorization starts a device authorization grant for a client (RFC 8628 section 3.1).
+func (s *service) DeviceAuthorization(ctx context.Context, req *genoauth.DeviceAuthorizationRequest) (*genoauth.DeviceAuthorizationResponse, error) {
+	logger.FromContext(ctx).Infow("device authorization request received")
+
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		Authorization:       req.Authorization,
+		ClientID:            req.ClientID,
+		ClientSecret:        req.ClientSecret,
+		ClientAssertionType: req.ClientAssertionType,
+		ClientAssertion:     req.ClientAssertion,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("client authentication error", "error", err)
+		return nil, err
+	}
+
+	if !slices.Contains(client.GrantTypes, grantTypeDeviceCode) {
+		return nil, oauthError(errUnauthorizedClient, "client may not use the device authorization grant")
+	}
+
+	scopes, err := resolveScopes(client, req.Scope)
+	if err != nil {
+		return nil, oauthError(errInvalidScope, err.Error())
+	}
+
+	deviceCode, err := randomToken()
+	if err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+	userCode, err := randomUserCode()
+	if err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	auth := &oauthstore.DeviceAuthorization{
+		DeviceCode: deviceCode,
+		UserCode:   userCode,
+		ClientID:   client.ID,
+		Scopes:     scopes,
+		Status:     oauthstore.DeviceStatusPending,
+		Interval:   s.cfg.DevicePollInterval,
+		ExpiresAt:  time.Now().Add(s.cfg.DeviceCodeExpTime),
+	}
+	if err := s.store.CreateDeviceAuthorization(ctx, auth); err != nil {
+		logger.FromContext(ctx).Infow("create device authorization error", "clientId", client.ID, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	displayCode := formatUserCode(userCode)
+	logger.FromContext(ctx).Infow("device authorization request successful", "clientId", client.ID)
+
+	return &genoauth.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                displayCode,
+		VerificationURI:         s.cfg.DeviceVerificationURI,
+		VerificationURIComplete: appendQuery(s.cfg.DeviceVerificationURI, url.Values{"user_code": {displayCode}}, nil),
+		ExpiresIn:               int64(s.cfg.DeviceCodeExpTime.Seconds()),
+		Interval:                int64(s.cfg.DevicePollInterval.Seconds()),
+	}, nil
+}
+
+// VerifyDevice approves or denies a pending device authorization on behalf of the authenticated user.
+// Approving also records the user's consent for the requested scopes.
+func (s *service) VerifyDevice(ctx context.Context, req *genoauth.VerifyDeviceRequest) (*genoauth.VerifyDeviceResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genoauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("verify device request received", "userId", claims.Subject, "approve", req.Approve)
+
+	auth, err := s.store.QueryDeviceByUserCode(ctx, normalizeUserCode(req.UserCode))
+	if err != nil || time.Now().After(auth.ExpiresAt) {
+		logger.FromContext(ctx).Infow("query device authorization error", "userId", claims.Subject, "error", err)
+		return nil, genoauth.MakeNotFound(fmt.Errorf("user code is invalid or has expired"))
+	}
+	if _, err := s.queryClient(ctx, auth.ClientID); err != nil {
+		logger.FromContext(ctx).Infow("query client error", "clientId", auth.ClientID, "error", err)
+		return nil, genoauth.MakeNotFound(fmt.Errorf("user code is invalid or has expired"))
+	}
+	if auth.Status != oauthstore.DeviceStatusPending {
+		return nil, genoauth.MakeBadRequest(fmt.Errorf("device authorization has already been %s", auth.Status))
+	}
+
+	// The user authenticated when the session behind their access token was created.
+	sess, err := s.sessions.Get(ctx, claims.Subject, claims.SessionID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("get session error", "userId", claims.Subject, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	auth.UserID = claims.Subject
+	auth.AuthTime = sess.CreatedAt
+	auth.Status = oauthstore.DeviceStatusDenied
+	if req.Approve {
+		auth.Status = oauthstore.DeviceStatusApproved
+
+		if err := s.store.SaveConsent(ctx, &oauthstore.Consent{
+			UserID:    claims.Subject,
+			ClientID:  auth.ClientID,
+			Scopes:    auth.Scopes,
+			GrantedAt: time.Now(),
+		}); err != nil {
+			logger.FromContext(ctx).Infow("save consent error", "clientId", auth.ClientID, "error", err)
+			return nil, genoauth.MakeInternalServerError(err)
+		}
+	}
+
+	if err := s.store.UpdateDeviceAuthorization(ctx, auth); err != nil {
+		logger.FromContext(ctx).Infow("update device authorization error", "clientId", auth.ClientID, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	logger.FromContext(ctx).Infow("verify device request successful", "userId", claims.Subject, "clientId", auth.ClientID, "status", auth.Status)
+
+	message := "Device authorization denied"
+	if req.Approve {
+		message = "Device authorization approved"
+	}
+	return &genoauth.VerifyDeviceResponse{
+		Success: true,
+		Message: message,
+	}, nil
+}
+
+// exchangeDeviceCode redeems an approved device code (RFC 8628 section 3.4). Until the user
+// acts on the request the device receives authorization_pending, and polling faster than the
+// agreed interval returns slow_down and permanently widens the interval.
+func (s *service) exchangeDeviceCode(ctx context.Context, client *oauthstore.Client, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	if req.DeviceCode == nil || *req.DeviceCode == "" {
+		return nil, oauthError(errInvalidRequest, "device_code is required")
+	}
+
+	auth, err := s.store.QueryDeviceByDeviceCode(ctx, *req.DeviceCode)
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "device code is invalid")
+	}
+	if auth.ClientID != client.ID {
+		return nil, oauthError(errInvalidGrant, "device code was issued to another client")
+	}
+
+	now := time.Now()
+	if now.After(auth.ExpiresAt) {
+		_ = s.store.DeleteDeviceAuthorization(ctx, auth.DeviceCode)
+		return nil, oauthError(errExpiredToken, "device code has expired")
+	}
+
+	switch auth.Status {
+	case oauthstore.DeviceStatusDenied:
+		_ = s.store.DeleteDeviceAuthorization(ctx, auth.DeviceCode)
+		return nil, oauthError(errAccessDenied, "user denied the device authorization")
+
+	case oauthstore.DeviceStatusPending:
+		tooFast := !auth.LastPolledAt.IsZero() && now.Sub(auth.LastPolledAt) < auth.Interval
+		if tooFast {
+			auth.Interval += slowDownIncrement
+		}
+		auth.LastPolledAt = now
+
+		if err := s.store.UpdateDeviceAuthorization(ctx, auth); err != nil {
+			return nil, genoauth.MakeInternalServerError(err)
+		}
+		if tooFast {
+			return nil, oauthError(errSlowDown, fmt.Sprintf("poll at most every %d seconds", int64(auth.Interval.Seconds())))
+		}
+		return nil, oauthError(errAuthorizationPending, "user hasn't approved the device yet")
+	}
+
+	// Device codes are single use, so remove the grant before issuing tokens.
+	if err := s.store.DeleteDeviceAuthorization(ctx, auth.DeviceCode); err != nil {
+		return nil, oauthError(errInvalidGrant, "device code is invalid")
+	}
+
+	user, err := s.resourceOwner(ctx, client, auth.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessions.Create(ctx, auth.UserID, requestctx.MetadataFromContext(ctx))
+	if err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	return s.issueTokens(ctx, userGrant{
+		GrantType: grantTypeDeviceCode,
+		User:      user,
+		SessionID: sess.ID,
+		ClientID:  client.ID,
+		Scopes:    auth.Scopes,
+		AuthTime:  auth.AuthTime,
+	})
+}
+
+// randomUserCode generates a user code from userCodeAlphabet without modulo bias.
+func randomUserCode() (string, error) {
+	code := make([]byte, 0, userCodeLength)
+	buf := make([]byte, userCodeLength*2)
+
+	// 240 is the largest multiple of the alphabet size that fits in a byte.
+	limit := byte(256 - 256%len(userCodeAlphabet))
+	for len(code) < userCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(code) < userCodeLength {
+				code = append(code, userCodeAlphabet[int(b)%len(userCodeAlphabet)])
+			}
+		}
+	}
+
+	return string(code), nil
+}
+
+// formatUserCode splits a user code into two halves for display, e.g. WDJBMJHT becomes WDJB-MJHT.
+func formatUserCode(code string) string {
+	half := len(code) / 2
+	return code[:half] + "-" + code[half:]
+}
+
+// normalizeUserCode strips separators and whitespace and upper cases a user entered code
+// so it can be compared with the stored form (RFC 8628 section 6.1).
+func normalizeUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ' || r == '\t':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,288 @@
+package oauthsvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// tokenTypeAccessToken is the only token type accepted and issued by token exchange (RFC 8693 section 3).
+const tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+// errInvalidTarget is returned when a token exchange requests an audience it may not use (RFC 8693 section 2.2.2).
+const errInvalidTarget = "invalid_target"
+
+// exchangeToken issues a new access token derived from a subject token (RFC 8693). Without
+// an actor token the subject's token is downscoped. With an actor token the new token is
+// delegated to the actor, who is recorded in the act claim. With a requested subject the
+// caller identified by the subject token impersonates that user, which requires the
+// users:impersonate permission. The issued token never carries more scopes, permissions or
+// audiences, nor lives longer, than the token it was derived from; its permissions are
+// limited to the granted scopes whoever the principal is. Every exchange is recorded in the
+// audit trail.
+func (s *service) exchangeToken(ctx context.Context, client *oauthstore.Client, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	if client.Type != oauthstore.ClientTypeConfidential {
+		return nil, oauthError(errUnauthorizedClient, "token exchange requires a confidential client")
+	}
+	if req.SubjectToken == nil || *req.SubjectToken == "" {
+		return nil, oauthError(errInvalidRequest, "subject_token is required")
+	}
+	if stringValue(req.SubjectTokenType) != tokenTypeAccessToken {
+		return nil, oauthError(errInvalidRequest, "subject_token_type must be "+tokenTypeAccessToken)
+	}
+	if req.RequestedTokenType != nil && *req.RequestedTokenType != tokenTypeAccessToken {
+		return nil, oauthError(errInvalidRequest, "only access tokens can be requested")
+	}
+	if req.ActorToken != nil && req.RequestedSubject != nil {
+		return nil, oauthError(errInvalidRequest, "actor_token can't be combined with requested_subject")
+	}
+
+	subject, err := s.validateExchangeToken(ctx, *req.SubjectToken)
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "subject token is invalid, expired or revoked")
+	}
+
+	var (
+		kind   string
+		claims tokenmgr.Claims
+	)
+	switch {
+	case req.RequestedSubject != nil:
+		kind = oauthstore.ExchangeKindImpersonation
+		if claims, err = s.impersonationClaims(ctx, client, subject, *req.RequestedSubject); err != nil {
+			return nil, err
+		}
+
+	case req.ActorToken != nil:
+		if stringValue(req.ActorTokenType) != tokenTypeAccessToken {
+			return nil, oauthError(errInvalidRequest, "actor_token_type must be "+tokenTypeAccessToken)
+		}
+
+		actor, err := s.validateExchangeToken(ctx, *req.ActorToken)
+		if err != nil {
+			return nil, oauthError(errInvalidGrant, "actor token is invalid, expired or revoked")
+		}
+
+		kind = oauthstore.ExchangeKindDelegation
+		claims = s.derivedClaims(client, subject)
+		claims.Actor = &tokenmgr.Actor{
+			Subject:       actor.Subject,
+			PrincipalType: actor.Principal(),
+			Actor:         subject.Actor,
+		}
+
+	default:
+		kind = oauthstore.ExchangeKindDownscope
+		claims = s.derivedClaims(client, subject)
+	}
+
+	scopes, err := exchangeScopes(client, subject, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	claims.Scope = strings.Join(scopes, " ")
+	claims.Permissions = grantedPermissions(claims.Permissions, scopes)
+
+	if req.Audience != nil && strings.TrimSpace(*req.Audience) != "" {
+		audience := strings.Fields(*req.Audience)
+		if !containsAll(claims.Audience, audience) {
+			return nil, oauthError(errInvalidTarget, "requested audience exceeds the subject token's audience")
+		}
+		claims.Audience = jwt.ClaimStrings(audience)
+	}
+
+	accessToken, err := s.tm.Generate(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &oauthstore.TokenExchange{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		ClientID:  client.ID,
+		Subject:   claims.Subject,
+		Actors:    actorChain(claims.Actor),
+		Scopes:    scopes,
+		Audience:  claims.Audience,
+		TokenID:   claims.ID,
+		SourceID:  subject.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.RecordExchange(ctx, exchange); err != nil {
+		logger.FromContext(ctx).Infow("record token exchange error", "clientId", client.ID, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	// Impersonations are audited as they're authorized, see impersonationClaims.
+	if kind != oauthstore.ExchangeKindImpersonation {
+		actorID := claims.Subject
+		if claims.Actor != nil {
+			actorID = claims.Actor.Subject
+		}
+		s.audit.Record(ctx, audit.Entry{
+			Type: audit.EventTokenExchange, ActorID: actorID, TargetID: claims.Subject,
+			Details: map[string]string{"kind": kind, "clientId": client.ID, "scope": claims.Scope, "exchangeId": exchange.ID},
+		})
+	}
+
+	logger.FromContext(ctx).Infow("token exchange recorded", "kind", kind, "clientId", client.ID, "subject", exchange.Subject, "actors", exchange.Actors)
+
+	issuedTokenType := tokenTypeAccessToken
+	return &genoauth.OAuthTokenResponse{
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(time.Until(claims.ExpiresAt.Time).Seconds()),
+		Scope:           &claims.Scope,
+		IssuedTokenType: &issuedTokenType,
+	}, nil
+}
+
+// validateExchangeToken validates a subject or actor token, which must be an access token.
+func (s *service) validateExchangeToken(ctx context.Context, token string) (tokenmgr.Claims, error) {
+	claims, err := s.authn.Validate(ctx, token)
+	if err != nil {
+		return tokenmgr.Claims{}, err
+	}
+	if claims.TokenType != tokenmgr.AccessToken {
+		return tokenmgr.Claims{}, oauthError(errInvalidGrant, "token is not an access token")
+	}
+	return claims, nil
+}
+
+// derivedClaims returns access token claims for the subject of an existing token. The new
+// token stays bound to the subject's session and actor chain and expires no later than the
+// subject token. Client principals keep their own client ID so the token stays valid.
+func (s *service) derivedClaims(client *oauthstore.Client, subject tokenmgr.Claims) tokenmgr.Claims {
+	claims := s.tm.StandardClaims(subject.Subject, tokenmgr.AccessToken)
+	claims.PrincipalType = subject.PrincipalType
+	claims.SessionID = subject.SessionID
+	claims.AuthTime = subject.AuthTime
+	claims.Actor = subject.Actor
+	claims.Audience = subject.Audience
+	claims.Roles = subject.Roles
+	claims.Permissions = subject.Permissions
+	claims.TenantID = subject.TenantID
+
+	claims.ClientID = client.ID
+	if subject.Principal() == tokenmgr.PrincipalClient {
+		claims.ClientID = subject.ClientID
+	}
+
+	if subject.ExpiresAt.Before(claims.ExpiresAt.Time) {
+		claims.ExpiresAt = subject.ExpiresAt
+	}
+
+	return claims
+}
+
+// impersonationClaims returns access token claims for the requested user, acted on by the
+// caller identified by the subject token. The caller must be a user holding the
+// users:impersonate permission, directly or through a group. Impersonated tokens get their own session in the target
+// user's account so the user can see and revoke them.
+func (s *service) impersonationClaims(ctx context.Context, client *oauthstore.Client, caller tokenmgr.Claims, userID string) (tokenmgr.Claims, error) {
+	if caller.Principal() != tokenmgr.PrincipalUser {
+		return tokenmgr.Claims{}, oauthError(errAccessDenied, "only users may impersonate other users")
+	}
+
+	entry := audit.Entry{
+		Type: audit.EventUserImpersonate, ActorID: caller.Subject, TargetID: userID,
+		Details: map[string]string{"clientId": client.ID},
+	}
+
+	staff, err := s.access.Resolve(ctx, caller.Subject)
+	if err != nil || !slices.Contains(staff.Permissions, userdomain.PermissionImpersonate) {
+		logger.FromContext(ctx).Infow("impersonation denied", "userId", caller.Subject, "requestedSubject", userID)
+		entry.Err = fmt.Errorf("caller lacks the %s permission", userdomain.PermissionImpersonate)
+		s.audit.Record(ctx, entry)
+		return tokenmgr.Claims{}, oauthError(errAccessDenied, "caller lacks the "+userdomain.PermissionImpersonate+" permission")
+	}
+
+	if userID == caller.Subject {
+		return tokenmgr.Claims{}, oauthError(errInvalidRequest, "requested_subject must be another user")
+	}
+	target, err := userstore.QueryTenantUser(ctx, s.userStore, caller.TenantID, userID)
+	if err != nil {
+		return tokenmgr.Claims{}, oauthError(errInvalidRequest, "requested_subject doesn't exist")
+	}
+
+	access, err := s.access.Resolve(ctx, target.ID)
+	if err != nil {
+		return tokenmgr.Claims{}, genoauth.MakeInternalServerError(err)
+	}
+
+	sess, err := s.sessions.Create(ctx, target.ID, requestctx.MetadataFromContext(ctx))
+	if err != nil {
+		return tokenmgr.Claims{}, genoauth.MakeInternalServerError(err)
+	}
+
+	claims := s.tm.StandardClaims(target.ID, tokenmgr.AccessToken)
+	claims.SessionID = sess.ID
+	claims.Roles = access.Roles
+	claims.Permissions = access.Permissions
+	claims.TenantID = target.TenantID
+	claims.ClientID = client.ID
+	claims.Actor = &tokenmgr.Actor{
+		Subject:       caller.Subject,
+		PrincipalType: tokenmgr.PrincipalUser,
+		Actor:         caller.Actor,
+	}
+
+	// An impersonated token never outlives the token of the user acting through it.
+	if caller.ExpiresAt.Before(claims.ExpiresAt.Time) {
+		claims.ExpiresAt = caller.ExpiresAt
+	}
+
+	entry.Details["sessionId"] = sess.ID
+	s.audit.Record(ctx, entry)
+
+	return claims, nil
+}
+
+// exchangeScopes resolves the scopes of an exchanged token. They're limited to the client's
+// registered scopes and, when the subject token is scoped, to the subject token's scopes.
+// Exchanged tokens are always scoped, since an empty scope denotes a first-party token.
+func exchangeScopes(client *oauthstore.Client, subject tokenmgr.Claims, scope *string) ([]string, error) {
+	allowed := client.Scopes
+	if granted := strings.Fields(subject.Scope); len(granted) > 0 {
+		allowed = slices.DeleteFunc(slices.Clone(allowed), func(s string) bool {
+			return !slices.Contains(granted, s)
+		})
+	}
+
+	scopes := allowed
+	if scope != nil && strings.TrimSpace(*scope) != "" {
+		scopes = strings.Fields(*scope)
+		if !containsAll(allowed, scopes) {
+			return nil, oauthError(errInvalidScope, "requested scope exceeds the subject token's scope")
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, oauthError(errInvalidScope, "no scope can be granted for the exchanged token")
+	}
+	return scopes, nil
+}
+
+// actorChain flattens an act claim into the list of actor subjects, current actor first.
+func actorChain(actor *tokenmgr.Actor) []string {
+	var chain []string
+	for ; actor != nil; actor = actor.Actor {
+		chain = append(chain, actor.Subject)
+	}
+	return chain
+}
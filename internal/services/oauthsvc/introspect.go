@@ -0,0 +1,132 @@
+package oauthsvc
+
+import (
+	"context"
+	"fmt"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Token type hints defined by RFC 7009 section 2.1.
+const (
+	tokenTypeHintAccessToken  = "access_token"
+	tokenTypeHintRefreshToken = "refresh_token"
+)
+
+// errUnsupportedTokenType is returned for unknown token type hints (RFC 7009 section 2.2.1).
+const errUnsupportedTokenType = "unsupported_token_type"
+
+// Introspect reports whether a token is active (RFC 7662). Only confidential clients,
+// typically resource servers, may introspect tokens. Tokens that are malformed, expired,
+// revoked or whose session ended are reported as inactive without further detail.
+func (s *service) Introspect(ctx context.Context, req *genoauth.IntrospectRequest) (*genoauth.IntrospectResponse, error) {
+	logger.FromContext(ctx).Infow("introspect request received")
+
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		Authorization:       req.Authorization,
+		ClientID:            req.ClientID,
+		ClientSecret:        req.ClientSecret,
+		ClientAssertionType: req.ClientAssertionType,
+		ClientAssertion:     req.ClientAssertion,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("client authentication error", "error", err)
+		return nil, err
+	}
+	if client.Type != oauthstore.ClientTypeConfidential {
+		return nil, oauthError(errInvalidClient, "only confidential clients may introspect tokens")
+	}
+
+	// Validation also records the use of the session, as the resource server is
+	// introspecting the token in order to serve a request made with it.
+	claims, err := s.authn.Validate(ctx, req.Token)
+	if err != nil {
+		logger.FromContext(ctx).Infow("introspect request successful", "clientId", client.ID, "active", false)
+		return &genoauth.IntrospectResponse{Active: false}, nil
+	}
+
+	tokenType := "Bearer"
+	if claims.TokenType == tokenmgr.RefreshToken {
+		tokenType = tokenTypeHintRefreshToken
+	}
+
+	res := &genoauth.IntrospectResponse{
+		Active:    true,
+		Sub:       &claims.Subject,
+		TokenType: &tokenType,
+		Aud:       claims.Audience,
+		Iss:       &claims.Issuer,
+		Jti:       &claims.ID,
+	}
+	if claims.Scope != "" {
+		res.Scope = &claims.Scope
+	}
+	if claims.ClientID != "" {
+		res.ClientID = &claims.ClientID
+	}
+	if claims.ExpiresAt != nil {
+		exp := claims.ExpiresAt.Unix()
+		res.Exp = &exp
+	}
+	if claims.IssuedAt != nil {
+		iat := claims.IssuedAt.Unix()
+		res.Iat = &iat
+	}
+	if claims.NotBefore != nil {
+		nbf := claims.NotBefore.Unix()
+		res.Nbf = &nbf
+	}
+
+	logger.FromContext(ctx).Infow("introspect request successful", "clientId", client.ID, "active", true)
+	return res, nil
+}
+
+// Revoke revokes a token issued to the calling client (RFC 7009). Revoking a refresh
+// token also ends the session it belongs to. Invalid tokens are not an error, since
+// the client's goal of the token no longer being usable is already met.
+func (s *service) Revoke(ctx context.Context, req *genoauth.RevokeRequest) error {
+	logger.FromContext(ctx).Infow("revoke request received")
+
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		Authorization:       req.Authorization,
+		ClientID:            req.ClientID,
+		ClientSecret:        req.ClientSecret,
+		ClientAssertionType: req.ClientAssertionType,
+		ClientAssertion:     req.ClientAssertion,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("client authentication error", "error", err)
+		return err
+	}
+
+	if req.TokenTypeHint != nil {
+		switch *req.TokenTypeHint {
+		case tokenTypeHintAccessToken, tokenTypeHintRefreshToken:
+		default:
+			return oauthError(errUnsupportedTokenType, fmt.Sprintf("token type %q is not supported", *req.TokenTypeHint))
+		}
+	}
+
+	claims, err := s.authn.Validate(ctx, req.Token)
+	if err != nil {
+		logger.FromContext(ctx).Infow("revoke request successful", "clientId", client.ID, "revoked", false)
+		return nil
+	}
+
+	if claims.ClientID != client.ID {
+		logger.FromContext(ctx).Infow("revoke request error", "clientId", client.ID, "tokenClientId", claims.ClientID)
+		return oauthError(errInvalidRequest, "token was not issued to this client")
+	}
+
+	if err := s.authn.Revoke(ctx, claims); err != nil {
+		logger.FromContext(ctx).Infow("revoke token error", "clientId", client.ID, "error", err)
+		return genoauth.MakeInternalServerError(err)
+	}
+
+	logger.FromContext(ctx).Infow("revoke request successful", "clientId", client.ID, "revoked", true)
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package oauthsvc provides OAuth 2.0 authorization server business logic for the IAM system.
+package oauthsvc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"goa.design/goa/v3/security"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// defaultScopes are the OpenID Connect scopes, granted to clients registered without an
+// explicit scope list.
+var defaultScopes = []string{idtoken.ScopeOpenID, idtoken.ScopeProfile, idtoken.ScopeEmail}
+
+// permissionScopes are the scopes naming a permission that clients may be granted. Clients
+// hold them when acting on their own behalf, so only owners holding the permission may
+// register a client with them.
+var permissionScopes = []string{
+	userdomain.PermissionCheckAccess, userdomain.PermissionManageRelationships, userdomain.PermissionProvision,
+}
+
+// defaultGrantTypes are allowed for clients registered without explicit grant types.
+var defaultGrantTypes = []string{grantTypeAuthorizationCode, grantTypeRefreshToken}
+
+// service implements the OAuth 2.0 authorization server endpoints.
+type service struct {
+	cfg       *config.OAuth             // Authorization server settings
+	userStore userstore.UserStorer      // Interface to the user data store
+	store     oauthstore.OAuthStorer    // Interface to the OAuth data store
+	tm        *tokenmgr.JWTTokenManager // JWT manager for token generation and validation
+	idTokens  *idtoken.Signer           // Signer for OpenID Connect ID tokens
+	sessions  *session.Manager          // Session manager for sessions created by grants
+	access    *membership.Resolver      // Resolver of the roles users hold directly or through groups
+	authn     *jwtauth.Authenticator    // Token authenticator for secured methods, introspection and revocation
+	audit     *audit.Recorder           // Recorder of security events
+}
+
+// NewService initializes and returns a new oauth service instance. Requests, including
+// those of the token endpoint, log through the logger carried by their context.
+func NewService(
+	userStore userstore.UserStorer, store oauthstore.OAuthStorer,
+	tm *tokenmgr.JWTTokenManager, idTokens *idtoken.Signer, sessions *session.Manager, access *membership.Resolver,
+	authn *jwtauth.Authenticator, recorder *audit.Recorder, cfg *config.OAuth,
+) *service {
+	return &service{
+		cfg:       cfg,
+		userStore: userStore,
+		store:     store,
+		tm:        tm,
+		idTokens:  idTokens,
+		sessions:  sessions,
+		access:    access,
+		authn:     authn,
+		audit:     recorder,
+	}
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// RegisterClient registers a new OAuth client owned by the authenticated user, who must
+// hold the permission to manage clients. The client secret of confidential clients is only
+// returned in this response.
+func (s *service) RegisterClient(ctx context.Context, req *genoauth.RegisterClientRequest) (*genoauth.RegisterClientResponse, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genoauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if !slices.Contains(claims.Permissions, userdomain.PermissionManageClients) {
+		return nil, genoauth.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageClients))
+	}
+
+	logger.FromContext(ctx).Infow("register client request received", "userId", claims.Subject, "name", req.Name, "type", req.Type)
+
+	client := &oauthstore.Client{
+		ID:                      uuid.New().String(),
+		Name:                    req.Name,
+		Type:                    req.Type,
+		TokenEndpointAuthMethod: defaultAuthMethod(req.Type, req.TokenEndpointAuthMethod),
+		GrantTypes:              req.GrantTypes,
+		RedirectURIs:            req.RedirectUris,
+		Scopes:                  req.Scopes,
+		Audience:                req.Audience,
+		OwnerID:                 claims.Subject,
+		TenantID:                claims.TenantID,
+		CreatedAt:               time.Now(),
+	}
+	if len(client.GrantTypes) == 0 {
+		client.GrantTypes = defaultGrantTypes
+	}
+	actsForUsers := slices.Contains(client.GrantTypes, grantTypeAuthorizationCode) || slices.Contains(client.GrantTypes, grantTypeDeviceCode)
+	if len(client.Scopes) == 0 && actsForUsers {
+		client.Scopes = defaultScopes
+	}
+	if req.PublicKey != nil {
+		client.PublicKeyPEM = *req.PublicKey
+	}
+
+	if err := validateClient(client); err != nil {
+		logger.FromContext(ctx).Infow("register client error", "userId", claims.Subject, "error", err)
+		return nil, genoauth.MakeBadRequest(err)
+	}
+	if err := validateScopes(client.Scopes, claims.Permissions); err != nil {
+		logger.FromContext(ctx).Infow("register client error", "userId", claims.Subject, "error", err)
+		return nil, oauthError(errInvalidScope, err.Error())
+	}
+	client.Permissions = grantedPermissions(permissionScopes, client.Scopes)
+
+	var secret string
+	if usesClientSecret(client) {
+		var err error
+		if secret, err = randomToken(); err != nil {
+			return nil, genoauth.MakeInternalServerError(err)
+		}
+		client.SecretHash = hashSecret(secret)
+	}
+
+	if err := s.store.CreateClient(ctx, client); err != nil {
+		logger.FromContext(ctx).Infow("create client error", "userId", claims.Subject, "error", err)
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventClientRegister, TargetID: client.ID,
+		Details: map[string]string{
+			"name":       client.Name,
+			"type":       client.Type,
+			"grantTypes": strings.Join(client.GrantTypes, " "),
+			"scope":      strings.Join(client.Scopes, " "),
+		},
+	})
+
+	data := &genoauth.OAuthClient{
+		ClientID:                client.ID,
+		Name:                    client.Name,
+		Type:                    client.Type,
+		TokenEndpointAuthMethod: client.TokenEndpointAuthMethod,
+		GrantTypes:              client.GrantTypes,
+		RedirectUris:            client.RedirectURIs,
+		Scopes:                  client.Scopes,
+		Audience:                client.Audience,
+		CreatedAt:               client.CreatedAt.Format(time.RFC3339),
+	}
+	if secret != "" {
+		data.ClientSecret = &secret
+	}
+
+	logger.FromContext(ctx).Infow("register client request successful", "userId", claims.Subject, "clientId", client.ID)
+	return &genoauth.RegisterClientResponse{
+		Success: true,
+		Message: "Client registered successfully",
+		Data:    data,
+	}, nil
+}
+
+// queryClient retrieves a client of the request's tenant. Clients of other tenants are
+// reported as missing.
+func (s *service) queryClient(ctx context.Context, clientID string) (*oauthstore.Client, error) {
+	client, err := s.store.QueryClientByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.TenantID != tenancy.FromContext(ctx) {
+		return nil, fmt.Errorf("client with id %s doesn't exist", clientID)
+	}
+	return client, nil
+}
+
+// defaultAuthMethod returns the requested token endpoint authentication method, or the
+// default for the client type when none was requested.
+func defaultAuthMethod(clientType string, method *string) string {
+	if method != nil {
+		return *method
+	}
+	if clientType == oauthstore.ClientTypePublic {
+		return oauthstore.AuthMethodNone
+	}
+	return oauthstore.AuthMethodClientSecretBasic
+}
+
+// usesClientSecret reports whether the client authenticates with a shared secret.
+func usesClientSecret(client *oauthstore.Client) bool {
+	return client.TokenEndpointAuthMethod == oauthstore.AuthMethodClientSecretBasic ||
+		client.TokenEndpointAuthMethod == oauthstore.AuthMethodClientSecretPost
+}
+
+// validateClient checks that the registered grant types, authentication method and
+// redirect URIs of a client are consistent with each other.
+func validateClient(client *oauthstore.Client) error {
+	public := client.Type == oauthstore.ClientTypePublic
+	if public != (client.TokenEndpointAuthMethod == oauthstore.AuthMethodNone) {
+		return fmt.Errorf("token endpoint auth method %q is not allowed for %s clients", client.TokenEndpointAuthMethod, client.Type)
+	}
+
+	if public && slices.Contains(client.GrantTypes, grantTypeClientCredentials) {
+		return fmt.Errorf("client credentials grant requires a confidential client")
+	}
+	if public && slices.Contains(client.GrantTypes, grantTypeTokenExchange) {
+		return fmt.Errorf("token exchange grant requires a confidential client")
+	}
+
+	if slices.Contains(client.GrantTypes, grantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return fmt.Errorf("authorization code grant requires at least one redirect uri")
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return err
+		}
+	}
+
+	if client.TokenEndpointAuthMethod == oauthstore.AuthMethodPrivateKeyJWT {
+		if _, err := parsePublicKey(client.PublicKeyPEM); err != nil {
+			return err
+		}
+	} else if client.PublicKeyPEM != "" {
+		return fmt.Errorf("public key is only used with the private_key_jwt auth method")
+	}
+
+	for _, audience := range client.Audience {
+		if strings.TrimSpace(audience) == "" {
+			return fmt.Errorf("audience must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// validateScopes checks that every scope of a client is an OpenID Connect scope or a
+// permission scope held by the user registering the client.
+func validateScopes(scopes, ownerPermissions []string) error {
+	for _, scope := range scopes {
+		switch {
+		case slices.Contains(defaultScopes, scope):
+		case !slices.Contains(permissionScopes, scope):
+			return fmt.Errorf("scope %q can't be granted to clients", scope)
+		case !slices.Contains(ownerPermissions, scope):
+			return fmt.Errorf("scope %q requires the registering user to hold the %s permission", scope, scope)
+		}
+	}
+	return nil
+}
+
+// grantedPermissions returns the permissions that are also among the scopes.
+func grantedPermissions(permissions, scopes []string) []string {
+	var granted []string
+	for _, permission := range permissions {
+		if slices.Contains(scopes, permission) {
+			granted = append(granted, permission)
+		}
+	}
+	return granted
+}
+
+// validateRedirectURI checks that a redirect URI is absolute and has no fragment (RFC 6749 section 3.1.2).
+func validateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("redirect uri %q must be an absolute uri", redirectURI)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("redirect uri %q must not contain a fragment", redirectURI)
+	}
+	return nil
+}
+
+// randomToken returns a URL safe random string with 256 bits of entropy.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret hashes a high entropy client secret for storage.
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
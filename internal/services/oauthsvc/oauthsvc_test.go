@@ -0,0 +1,975 @@
+package oauthsvc_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/groupsvc/membership"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/oauthsvc"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	oauthmemorystore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store/memory"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestOAuthService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OAuth Service Suite")
+}
+
+const (
+	redirectURI      = "http://localhost:3000/callback"
+	codeVerifier     = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	tokenEndpointURL = "http://localhost:8080/api/v1/oauth/token"
+)
+
+// oauthService is the generated service interface together with its JWTAuth handler.
+type oauthService interface {
+	genoauth.Service
+	genoauth.Auther
+}
+
+// idTokens is the ID token signer shared by every spec.
+var idTokens *idtoken.Signer
+
+var _ = Describe("OAuth service", func() {
+	var (
+		ctx        context.Context
+		userCtx    context.Context
+		ownerCtx   context.Context
+		svc        oauthService
+		tm         *tokenmgr.JWTTokenManager
+		sessions   *session.Manager
+		userStore  userstore.UserStorer
+		oauthStore oauthstore.OAuthStorer
+		auditStore auditstore.AuditStorer
+		userToken  string
+		clientID   string
+	)
+
+	challenge := func(verifier string) string {
+		sum := sha256.Sum256([]byte(verifier))
+		return base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	authorize := func(scope string) *url.URL {
+		res, err := svc.Authorize(userCtx, &genoauth.AuthorizeRequest{
+			ResponseType:        "code",
+			ClientID:            clientID,
+			RedirectURI:         ptr(redirectURI),
+			Scope:               ptr(scope),
+			State:               ptr("xyz"),
+			CodeChallenge:       ptr(challenge(codeVerifier)),
+			CodeChallengeMethod: ptr("S256"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		location, err := url.Parse(res.Data.RedirectTo)
+		Expect(err).NotTo(HaveOccurred())
+		return location
+	}
+
+	basicAuth := func(id, secret string) *string {
+		return ptr("Basic " + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(id)+":"+url.QueryEscape(secret))))
+	}
+
+	// Issued tokens only become valid one second after issuance.
+	waitNotBefore := func() {
+		time.Sleep(time.Second)
+	}
+
+	exchange := func(code, verifier string) (*genoauth.OAuthTokenResponse, error) {
+		return svc.Token(ctx, &genoauth.TokenRequest{
+			GrantType:    "authorization_code",
+			Code:         ptr(code),
+			RedirectURI:  ptr(redirectURI),
+			CodeVerifier: ptr(verifier),
+			ClientID:     ptr(clientID),
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions = session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+
+		userStore = usermemorystore.NewMemoryStore()
+		user, err := userStore.Create(ctx, "", &genuser.CreateUserRequest{
+			FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		sess, err := sessions.Create(ctx, user.ID, requestctx.Metadata{})
+		Expect(err).NotTo(HaveOccurred())
+		claims := tm.StandardClaims(user.ID, tokenmgr.AccessToken)
+		claims.SessionID = sess.ID
+		userCtx = tokenmgr.WithClaims(ctx, claims)
+		userToken, err = tm.Generate(context.Background(), claims)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Clients are registered by an admin, who holds the permission to manage clients.
+		owner, err := userStore.Create(ctx, "", &genuser.CreateUserRequest{
+			FirstName: "Margaret", LastName: "Hamilton", Email: "margaret@example.com", Password: "Passw0rd!23",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = userStore.UpdateRoles(ctx, owner.ID, []string{userdomain.RoleAdmin})
+		Expect(err).NotTo(HaveOccurred())
+		ownerClaims := tm.StandardClaims(owner.ID, tokenmgr.AccessToken)
+		ownerClaims.Roles = []string{userdomain.RoleAdmin}
+		ownerClaims.Permissions = userdomain.Permissions(ownerClaims.Roles)
+		ownerCtx = tokenmgr.WithClaims(ctx, ownerClaims)
+
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+		// Generating the RSA signing key is slow, so it's shared by every spec.
+		if idTokens == nil {
+			idTokens, err = idtoken.NewSigner(authCfg, &config.OIDC{IDTokenExpTime: time.Hour})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		oauthStore = oauthmemorystore.NewMemoryStore()
+		access := membership.NewResolver(userStore, groupmemorystore.NewMemoryStore())
+		auditStore = auditmemorystore.NewMemoryStore()
+		svc = oauthsvc.NewService(userStore, oauthStore, tm, idTokens, sessions, access, authn,
+			audit.NewRecorder(log, auditStore),
+			&config.OAuth{
+				AuthorizationCodeExpTime: time.Minute,
+				TokenEndpointURL:         tokenEndpointURL,
+				DeviceCodeExpTime:        time.Minute,
+				DevicePollInterval:       5 * time.Second,
+				DeviceVerificationURI:    "http://localhost:8080/device",
+			})
+
+		res, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+			Name:         "spa",
+			Type:         "public",
+			RedirectUris: []string{redirectURI},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.ClientSecret).To(BeNil())
+		clientID = res.Data.ClientID
+	})
+
+	It("requires consent before issuing a code", func() {
+		location := authorize("openid")
+		Expect(location.Query().Get("error")).To(Equal("consent_required"))
+		Expect(location.Query().Get("state")).To(Equal("xyz"))
+	})
+
+	It("requires PKCE for public clients", func() {
+		res, err := svc.Authorize(userCtx, &genoauth.AuthorizeRequest{ResponseType: "code", ClientID: clientID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.RedirectTo).To(ContainSubstring("error=invalid_request"))
+	})
+
+	It("rejects redirect URIs that weren't registered", func() {
+		_, err := svc.Authorize(userCtx, &genoauth.AuthorizeRequest{
+			ResponseType: "code",
+			ClientID:     clientID,
+			RedirectURI:  ptr("http://evil.example/callback"),
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("only registers clients for users allowed to manage them", func() {
+		_, err := svc.RegisterClient(userCtx, &genoauth.RegisterClientRequest{
+			Name:         "spa",
+			Type:         "public",
+			RedirectUris: []string{redirectURI},
+		})
+		Expect(err.(*goa.ServiceError).Name).To(Equal("forbidden"))
+	})
+
+	It("rejects scopes that can't be granted to clients", func() {
+		register := func(ctx context.Context, scope string) error {
+			_, err := svc.RegisterClient(ctx, &genoauth.RegisterClientRequest{
+				Name:       "service",
+				Type:       "confidential",
+				GrantTypes: []string{"client_credentials"},
+				Scopes:     []string{scope},
+			})
+			return err
+		}
+
+		err := register(ownerCtx, "users:write")
+		Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+		err = register(ownerCtx, userdomain.PermissionManageGroups)
+		Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+		Expect(register(ownerCtx, userdomain.PermissionCheckAccess)).To(Succeed())
+
+		// Owners may only grant the permissions they hold.
+		claims, _ := tokenmgr.UserClaimsFromContext(ownerCtx)
+		claims.Permissions = []string{userdomain.PermissionManageClients}
+		limitedCtx := tokenmgr.WithClaims(ctx, claims)
+		err = register(limitedCtx, userdomain.PermissionCheckAccess)
+		Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+		Expect(register(limitedCtx, idtoken.ScopeOpenID)).To(Succeed())
+	})
+
+	Context("with consent granted", func() {
+		BeforeEach(func() {
+			_, err := svc.GrantConsent(userCtx, &genoauth.GrantConsentRequest{ClientID: clientID, Scope: "openid profile"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("exchanges a code with a valid verifier for tokens", func() {
+			location := authorize("openid profile")
+			code := location.Query().Get("code")
+			Expect(code).NotTo(BeEmpty())
+
+			res, err := exchange(code, codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.TokenType).To(Equal("Bearer"))
+			Expect(*res.Scope).To(Equal("openid profile"))
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.ClientID).To(Equal(clientID))
+			Expect(claims.SessionID).NotTo(BeEmpty())
+		})
+
+		It("doesn't carry the user's permissions beyond the granted scopes", func() {
+			claims, _ := tokenmgr.UserClaimsFromContext(userCtx)
+			_, err := userStore.UpdateRoles(ctx, claims.Subject, []string{userdomain.RoleAdmin})
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := exchange(authorize("openid profile").Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			access, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(access.Roles).To(ConsistOf(userdomain.RoleAdmin))
+			Expect(access.Permissions).To(BeEmpty())
+		})
+
+		It("issues an OpenID Connect ID token for the openid scope", func() {
+			res, err := svc.Authorize(userCtx, &genoauth.AuthorizeRequest{
+				ResponseType:        "code",
+				ClientID:            clientID,
+				RedirectURI:         ptr(redirectURI),
+				Scope:               ptr("openid profile"),
+				Nonce:               ptr("n-0S6_WzA2Mj"),
+				CodeChallenge:       ptr(challenge(codeVerifier)),
+				CodeChallengeMethod: ptr("S256"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			location, err := url.Parse(res.Data.RedirectTo)
+			Expect(err).NotTo(HaveOccurred())
+
+			tokens, err := exchange(location.Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.IDToken).NotTo(BeNil())
+
+			claims, err := idTokens.Parse(*tokens.IDToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Audience).To(ConsistOf(clientID))
+			Expect(claims.Nonce).To(Equal("n-0S6_WzA2Mj"))
+			Expect(claims.AtHash).To(Equal(idtoken.AccessTokenHash(tokens.AccessToken)))
+			Expect(claims.AuthTime).NotTo(BeNil())
+			Expect(claims.GivenName).To(Equal("Ada"))
+			Expect(claims.FamilyName).To(Equal("Lovelace"))
+
+			// The email scope wasn't granted, so no email claims are released.
+			Expect(claims.Email).To(BeEmpty())
+			Expect(claims.EmailVerified).To(BeNil())
+
+			keys, err := svc.Jwks(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys.Keys).To(HaveLen(1))
+			Expect(keys.Keys[0].Alg).To(Equal("RS256"))
+		})
+
+		It("filters userinfo claims by the granted scopes", func() {
+			tokens, err := exchange(authorize("openid profile").Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			waitNotBefore()
+
+			tokenCtx, err := svc.JWTAuth(ctx, tokens.AccessToken, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := svc.Userinfo(tokenCtx, &genoauth.UserInfoRequest{Token: tokens.AccessToken})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*info.GivenName).To(Equal("Ada"))
+			Expect(*info.Name).To(Equal("Ada Lovelace"))
+			Expect(info.Email).To(BeNil())
+			Expect(info.EmailVerified).To(BeNil())
+
+			// First-party tokens release every claim.
+			info, err = svc.Userinfo(userCtx, &genoauth.UserInfoRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*info.Email).To(Equal("ada@example.com"))
+			Expect(*info.EmailVerified).To(BeFalse())
+		})
+
+		It("doesn't issue ID tokens without the openid scope", func() {
+			_, err := svc.GrantConsent(userCtx, &genoauth.GrantConsentRequest{ClientID: clientID, Scope: "email"})
+			Expect(err).NotTo(HaveOccurred())
+
+			tokens, err := exchange(authorize("email").Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.IDToken).To(BeNil())
+		})
+
+		It("rejects a wrong verifier", func() {
+			code := authorize("openid").Query().Get("code")
+
+			_, err := exchange(code, "wrong-verifier-wrong-verifier-wrong-verifier-00")
+			var oauthErr *genoauth.OAuthError
+			Expect(err).To(BeAssignableToTypeOf(oauthErr))
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_grant"))
+		})
+
+		It("only redeems a code once and revokes the tokens issued for a replayed code", func() {
+			code := authorize("openid").Query().Get("code")
+
+			tokens, err := exchange(code, codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			waitNotBefore()
+			_, err = svc.JWTAuth(ctx, tokens.AccessToken, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = exchange(code, codeVerifier)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_grant"))
+
+			_, err = svc.JWTAuth(ctx, tokens.AccessToken, nil)
+			Expect(err).To(HaveOccurred())
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:    "refresh_token",
+				RefreshToken: tokens.RefreshToken,
+				ClientID:     ptr(clientID),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_grant"))
+		})
+
+		It("audits client registration, issued tokens and failed redemptions", func() {
+			code := authorize("openid").Query().Get("code")
+			_, err := exchange(code, codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = exchange(code, codeVerifier)
+			Expect(err).To(HaveOccurred())
+
+			events, err := auditStore.Query(ctx, auditstore.Filter{
+				Types: []string{audit.EventClientRegister, audit.EventTokenIssue},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+
+			replayed, issued, registered := events[0], events[1], events[2]
+			Expect(replayed.Outcome).To(Equal(audit.OutcomeFailure))
+			Expect(replayed.Reason).To(Equal("invalid_grant: authorization code was already redeemed"))
+			Expect(replayed.TargetID).To(Equal(clientID))
+
+			claims, _ := tokenmgr.UserClaimsFromContext(userCtx)
+			Expect(issued.Outcome).To(Equal(audit.OutcomeSuccess))
+			Expect(issued.ActorID).To(Equal(claims.Subject))
+			Expect(issued.TargetID).To(Equal(clientID))
+			Expect(issued.Details).To(HaveKeyWithValue("grantType", "authorization_code"))
+
+			Expect(registered.TargetID).To(Equal(clientID))
+		})
+
+		It("refreshes tokens without widening the scope", func() {
+			tokens, err := exchange(authorize("openid").Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			waitNotBefore()
+
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:    "refresh_token",
+				RefreshToken: tokens.RefreshToken,
+				Scope:        ptr("openid profile"),
+				ClientID:     ptr(clientID),
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+
+			res, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:    "refresh_token",
+				RefreshToken: tokens.RefreshToken,
+				ClientID:     ptr(clientID),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*res.Scope).To(Equal("openid"))
+
+			// Refresh tokens are rotated, the one redeemed stops working.
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:    "refresh_token",
+				RefreshToken: tokens.RefreshToken,
+				ClientID:     ptr(clientID),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_grant"))
+
+			waitNotBefore()
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:    "refresh_token",
+				RefreshToken: res.RefreshToken,
+				ClientID:     ptr(clientID),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with a confidential service client", func() {
+		var (
+			serviceID     string
+			serviceSecret string
+		)
+
+		BeforeEach(func() {
+			res, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+				Name:       "billing",
+				Type:       "confidential",
+				GrantTypes: []string{"client_credentials"},
+				Scopes:     []string{"access:check", "relationships:manage"},
+				Audience:   []string{"test", "https://billing.test"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Data.TokenEndpointAuthMethod).To(Equal("client_secret_basic"))
+			Expect(res.Data.ClientSecret).NotTo(BeNil())
+
+			serviceID = res.Data.ClientID
+			serviceSecret = *res.Data.ClientSecret
+		})
+
+		It("issues client principal tokens without a refresh token", func() {
+			res, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, serviceSecret),
+				Scope:         ptr("access:check"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RefreshToken).To(BeNil())
+			Expect(*res.Scope).To(Equal("access:check"))
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Subject).To(Equal(serviceID))
+			Expect(claims.Principal()).To(Equal(tokenmgr.PrincipalClient))
+			Expect(claims.SessionID).To(BeEmpty())
+			// The token carries the permissions granted at registration that its scope covers.
+			Expect(claims.Permissions).To(ConsistOf(userdomain.PermissionCheckAccess))
+
+			// Client principals authenticate but can't act on a user's behalf.
+			clientCtx, err := svc.JWTAuth(ctx, res.AccessToken, nil)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = svc.RegisterClient(clientCtx, &genoauth.RegisterClientRequest{Name: "x", Type: "public"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("enforces the client's scopes and audiences", func() {
+			_, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, serviceSecret),
+				Scope:         ptr("audit:read"),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, serviceSecret),
+				Audience:      ptr("https://other.test"),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_request"))
+
+			res, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, serviceSecret),
+				Audience:      ptr("https://billing.test"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// The token isn't meant for this server, so it's rejected here.
+			waitNotBefore()
+			_, err = svc.JWTAuth(ctx, res.AccessToken, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects a wrong secret and grants the client isn't registered for", func() {
+			_, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, "wrong"),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_client"))
+
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "refresh_token",
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("unauthorized_client"))
+		})
+
+		It("introspects and revokes its tokens", func() {
+			res, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitNotBefore()
+
+			info, err := svc.Introspect(ctx, &genoauth.IntrospectRequest{
+				Token:         res.AccessToken,
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Active).To(BeTrue())
+			Expect(*info.Sub).To(Equal(serviceID))
+			Expect(*info.ClientID).To(Equal(serviceID))
+			Expect(*info.Scope).To(Equal("access:check relationships:manage"))
+			Expect(*info.TokenType).To(Equal("Bearer"))
+			Expect(info.Exp).NotTo(BeNil())
+
+			err = svc.Revoke(ctx, &genoauth.RevokeRequest{
+				Token:         res.AccessToken,
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err = svc.Introspect(ctx, &genoauth.IntrospectRequest{
+				Token:         res.AccessToken,
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Active).To(BeFalse())
+			Expect(info.Sub).To(BeNil())
+
+			_, err = svc.JWTAuth(ctx, res.AccessToken, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("reports garbage tokens as inactive and requires client authentication", func() {
+			info, err := svc.Introspect(ctx, &genoauth.IntrospectRequest{
+				Token:         "not-a-token",
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Active).To(BeFalse())
+
+			_, err = svc.Introspect(ctx, &genoauth.IntrospectRequest{Token: "not-a-token"})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_client"))
+		})
+
+		It("revokes a user's grant through its refresh token", func() {
+			_, err := svc.GrantConsent(userCtx, &genoauth.GrantConsentRequest{ClientID: clientID, Scope: "openid"})
+			Expect(err).NotTo(HaveOccurred())
+			tokens, err := exchange(authorize("openid").Query().Get("code"), codeVerifier)
+			Expect(err).NotTo(HaveOccurred())
+			waitNotBefore()
+
+			// Tokens can only be revoked by the client they were issued to.
+			err = svc.Revoke(ctx, &genoauth.RevokeRequest{
+				Token:         *tokens.RefreshToken,
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_request"))
+
+			err = svc.Revoke(ctx, &genoauth.RevokeRequest{
+				Token:         *tokens.RefreshToken,
+				TokenTypeHint: ptr("refresh_token"),
+				ClientID:      ptr(clientID),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := svc.Introspect(ctx, &genoauth.IntrospectRequest{
+				Token:         tokens.AccessToken,
+				Authorization: basicAuth(serviceID, serviceSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Active).To(BeFalse())
+		})
+
+		It("doesn't allow public clients to use the grant", func() {
+			_, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+				Name:       "cli",
+				Type:       "public",
+				GrantTypes: []string{"client_credentials"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with a private_key_jwt client", func() {
+		var (
+			key       *ecdsa.PrivateKey
+			serviceID string
+		)
+
+		assertion := func(jti string) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+				ID:        jti,
+				Issuer:    serviceID,
+				Subject:   serviceID,
+				Audience:  jwt.ClaimStrings{tokenEndpointURL},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			})
+			signed, err := token.SignedString(key)
+			Expect(err).NotTo(HaveOccurred())
+			return signed
+		}
+
+		BeforeEach(func() {
+			var err error
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+
+			der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+				Name:                    "reports",
+				Type:                    "confidential",
+				GrantTypes:              []string{"client_credentials"},
+				TokenEndpointAuthMethod: ptr("private_key_jwt"),
+				PublicKey:               ptr(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Data.ClientSecret).To(BeNil())
+			serviceID = res.Data.ClientID
+		})
+
+		It("authenticates with a signed assertion only once", func() {
+			req := &genoauth.TokenRequest{
+				GrantType:           "client_credentials",
+				ClientAssertionType: ptr("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"),
+				ClientAssertion:     ptr(assertion("jti-1")),
+			}
+
+			_, err := svc.Token(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Token(ctx, req)
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_client"))
+		})
+
+		It("rejects assertions signed with another key", func() {
+			var err error
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:           "client_credentials",
+				ClientAssertionType: ptr("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"),
+				ClientAssertion:     ptr(assertion("jti-2")),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_client"))
+		})
+	})
+
+	Context("with a device client", func() {
+		var (
+			deviceID string
+			device   *genoauth.DeviceAuthorizationResponse
+		)
+
+		poll := func() (*genoauth.OAuthTokenResponse, error) {
+			return svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:  "urn:ietf:params:oauth:grant-type:device_code",
+				DeviceCode: ptr(device.DeviceCode),
+				ClientID:   ptr(deviceID),
+			})
+		}
+
+		BeforeEach(func() {
+			res, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+				Name:       "cli",
+				Type:       "public",
+				GrantTypes: []string{"urn:ietf:params:oauth:grant-type:device_code", "refresh_token"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			deviceID = res.Data.ClientID
+
+			device, err = svc.DeviceAuthorization(ctx, &genoauth.DeviceAuthorizationRequest{
+				ClientID: ptr(deviceID),
+				Scope:    ptr("openid profile"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(device.UserCode).To(MatchRegexp(`^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`))
+			Expect(device.VerificationURIComplete).To(ContainSubstring("user_code=" + device.UserCode))
+			Expect(device.Interval).To(Equal(int64(5)))
+		})
+
+		It("reports pending authorizations and slows down fast pollers", func() {
+			_, err := poll()
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("authorization_pending"))
+
+			_, err = poll()
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("slow_down"))
+		})
+
+		It("issues tokens once the user approves the device", func() {
+			// User codes are matched regardless of case and separators.
+			userCode := strings.ToLower(strings.ReplaceAll(device.UserCode, "-", " "))
+			_, err := svc.VerifyDevice(userCtx, &genoauth.VerifyDeviceRequest{UserCode: userCode, Approve: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			tokens, err := poll()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*tokens.Scope).To(Equal("openid profile"))
+			Expect(tokens.RefreshToken).NotTo(BeNil())
+			Expect(tokens.IDToken).NotTo(BeNil())
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(tokens.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.ClientID).To(Equal(deviceID))
+
+			_, err = poll()
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_grant"))
+		})
+
+		It("returns access_denied when the user denies the device", func() {
+			_, err := svc.VerifyDevice(userCtx, &genoauth.VerifyDeviceRequest{UserCode: device.UserCode, Approve: false})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = poll()
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("access_denied"))
+
+			_, err = svc.VerifyDevice(userCtx, &genoauth.VerifyDeviceRequest{UserCode: device.UserCode, Approve: true})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects device codes redeemed by another client", func() {
+			_, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:  "urn:ietf:params:oauth:grant-type:device_code",
+				DeviceCode: ptr(device.DeviceCode),
+				ClientID:   ptr(clientID),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("unauthorized_client"))
+		})
+	})
+
+	Context("with a token exchange client", func() {
+		var (
+			exchangerID     string
+			exchangerSecret string
+			adminID         string
+			adminToken      string
+		)
+
+		exchange := func(req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+			req.GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+			req.SubjectTokenType = ptr("urn:ietf:params:oauth:token-type:access_token")
+			if req.ActorToken != nil {
+				req.ActorTokenType = ptr("urn:ietf:params:oauth:token-type:access_token")
+			}
+			req.Authorization = basicAuth(exchangerID, exchangerSecret)
+			return svc.Token(ctx, req)
+		}
+
+		BeforeEach(func() {
+			res, err := svc.RegisterClient(ownerCtx, &genoauth.RegisterClientRequest{
+				Name:       "support",
+				Type:       "confidential",
+				GrantTypes: []string{"urn:ietf:params:oauth:grant-type:token-exchange", "client_credentials"},
+				Scopes:     []string{"access:check", "relationships:manage"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			exchangerID = res.Data.ClientID
+			exchangerSecret = *res.Data.ClientSecret
+
+			admin, err := userStore.Create(ctx, "", &genuser.CreateUserRequest{
+				FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", Password: "Passw0rd!23",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = userStore.UpdateRoles(ctx, admin.ID, []string{userdomain.RoleAdmin})
+			Expect(err).NotTo(HaveOccurred())
+			adminID = admin.ID
+
+			sess, err := sessions.Create(ctx, admin.ID, requestctx.Metadata{})
+			Expect(err).NotTo(HaveOccurred())
+			claims := tm.StandardClaims(admin.ID, tokenmgr.AccessToken)
+			claims.SessionID = sess.ID
+			claims.Roles = []string{userdomain.RoleAdmin}
+			claims.Permissions = userdomain.Permissions(claims.Roles)
+			adminToken, err = tm.Generate(context.Background(), claims)
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+		})
+
+		It("downscopes a token without changing its subject or session", func() {
+			res, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(userToken), Scope: ptr("access:check")})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*res.Scope).To(Equal("access:check"))
+			Expect(*res.IssuedTokenType).To(Equal("urn:ietf:params:oauth:token-type:access_token"))
+			Expect(res.RefreshToken).To(BeNil())
+
+			subject, err := tm.ParseWithClaims(userToken)
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Subject).To(Equal(subject.Subject))
+			Expect(claims.SessionID).To(Equal(subject.SessionID))
+			Expect(claims.ClientID).To(Equal(exchangerID))
+			Expect(claims.Actor).To(BeNil())
+
+			// A downscoped token can't be widened again.
+			_, err = exchange(&genoauth.TokenRequest{SubjectToken: ptr(res.AccessToken), Scope: ptr("relationships:manage")})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_scope"))
+
+			_, err = exchange(&genoauth.TokenRequest{SubjectToken: ptr(userToken), Audience: ptr("https://other.test")})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_target"))
+
+			records, err := oauthStore.ListExchanges(ctx, subject.Subject)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Kind).To(Equal(oauthstore.ExchangeKindDownscope))
+			Expect(records[0].SourceID).To(Equal(subject.ID))
+			Expect(records[0].TokenID).To(Equal(claims.ID))
+		})
+
+		It("limits the permissions of exchanged user tokens to the granted scopes and audits the exchange", func() {
+			res, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(adminToken), Scope: ptr("access:check")})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Permissions).To(Equal([]string{userdomain.PermissionCheckAccess}))
+
+			delegated, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(adminToken), ActorToken: ptr(userToken)})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			claims, err = tm.ParseWithClaims(delegated.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Permissions).To(ConsistOf(userdomain.PermissionCheckAccess, userdomain.PermissionManageRelationships))
+
+			events, err := auditStore.Query(ctx, auditstore.Filter{Types: []string{audit.EventTokenExchange}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[0].Details["kind"]).To(Equal(oauthstore.ExchangeKindDelegation))
+			Expect(events[0].TargetID).To(Equal(adminID))
+			Expect(events[1].Details["kind"]).To(Equal(oauthstore.ExchangeKindDownscope))
+			Expect(events[1].ActorID).To(Equal(adminID))
+		})
+
+		It("builds an act claim chain through delegation", func() {
+			delegated, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(userToken), ActorToken: ptr(adminToken)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*delegated.Scope).To(Equal("access:check relationships:manage"))
+
+			service, err := svc.Token(ctx, &genoauth.TokenRequest{
+				GrantType:     "client_credentials",
+				Authorization: basicAuth(exchangerID, exchangerSecret),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			chained, err := exchange(&genoauth.TokenRequest{
+				SubjectToken: ptr(delegated.AccessToken),
+				ActorToken:   ptr(service.AccessToken),
+				Scope:        ptr("access:check"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(chained.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Actor).NotTo(BeNil())
+			Expect(claims.Actor.Subject).To(Equal(exchangerID))
+			Expect(claims.Actor.PrincipalType).To(Equal(tokenmgr.PrincipalClient))
+			Expect(claims.Actor.Actor).NotTo(BeNil())
+			Expect(claims.Actor.Actor.Subject).To(Equal(adminID))
+			Expect(claims.Actor.Actor.Actor).To(BeNil())
+
+			records, err := oauthStore.ListExchanges(ctx, adminID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			Expect(records[1].Kind).To(Equal(oauthstore.ExchangeKindDelegation))
+			Expect(records[1].Actors).To(Equal([]string{exchangerID, adminID}))
+		})
+
+		It("lets admins impersonate users in a session of their own", func() {
+			subject, err := tm.ParseWithClaims(userToken)
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(adminToken), RequestedSubject: ptr(subject.Subject)})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitNotBefore()
+			claims, err := tm.ParseWithClaims(res.AccessToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.Subject).To(Equal(subject.Subject))
+			Expect(claims.SessionID).NotTo(Equal(subject.SessionID))
+			Expect(claims.Actor.Subject).To(Equal(adminID))
+
+			// The impersonated token is accepted like any other token of the user.
+			_, err = svc.JWTAuth(ctx, res.AccessToken, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			records, err := oauthStore.ListExchanges(ctx, adminID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Kind).To(Equal(oauthstore.ExchangeKindImpersonation))
+		})
+
+		It("requires the impersonate permission", func() {
+			_, err := exchange(&genoauth.TokenRequest{SubjectToken: ptr(userToken), RequestedSubject: ptr(adminID)})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("access_denied"))
+
+			_, err = exchange(&genoauth.TokenRequest{
+				SubjectToken:     ptr(adminToken),
+				ActorToken:       ptr(userToken),
+				RequestedSubject: ptr(adminID),
+			})
+			Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_request"))
+		})
+	})
+
+	It("rejects unknown clients", func() {
+		_, err := svc.Token(ctx, &genoauth.TokenRequest{GrantType: "authorization_code", ClientID: ptr("unknown")})
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*genoauth.OAuthError).Code).To(Equal("invalid_client"))
+	})
+})
+
+func ptr[T any](v T) *T {
+	return &v
+}
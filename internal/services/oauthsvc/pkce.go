@@ -0,0 +1,49 @@
+package oauthsvc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+)
+
+// codeChallengeMethodS256 is the only PKCE transformation accepted by the server.
+const codeChallengeMethodS256 = "S256"
+
+// pkceValuePattern matches code verifiers and S256 challenges as defined in RFC 7636 section 4.1.
+var pkceValuePattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// resolveCodeChallenge validates the PKCE parameters of an authorization request.
+// Public clients must use PKCE and only the S256 method is supported.
+func resolveCodeChallenge(client *oauthstore.Client, challenge, method *string) (string, string, error) {
+	if challenge == nil || *challenge == "" {
+		if client.Type == oauthstore.ClientTypePublic {
+			return "", "", fmt.Errorf("code_challenge is required for public clients")
+		}
+		return "", "", nil
+	}
+
+	if method == nil || *method != codeChallengeMethodS256 {
+		return "", "", fmt.Errorf("code_challenge_method must be %s", codeChallengeMethodS256)
+	}
+	if !pkceValuePattern.MatchString(*challenge) {
+		return "", "", fmt.Errorf("code_challenge is malformed")
+	}
+
+	return *challenge, *method, nil
+}
+
+// verifyCodeVerifier checks a PKCE code verifier against the stored S256 challenge.
+func verifyCodeVerifier(challenge, verifier string) bool {
+	if !pkceValuePattern.MatchString(verifier) {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
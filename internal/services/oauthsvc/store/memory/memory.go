@@ -0,0 +1,285 @@
+// Package oauthstore provides an in-memory implementation of the OAuthStorer interface.
+package oauthstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+)
+
+// memory implements the OAuthStorer interface using in-memory maps.
+type memory struct {
+	mu        sync.RWMutex                               // protects access to all maps
+	clients   map[string]*oauthstore.Client              // stores clients by client ID
+	codes     map[string]*oauthstore.AuthorizationCode   // stores authorization codes by code
+	devices   map[string]*oauthstore.DeviceAuthorization // stores device authorizations by device code
+	exchanges []*oauthstore.TokenExchange                // append-only token exchange audit trail
+	consents  map[string]*oauthstore.Consent             // stores consents by user and client ID
+	jtis      map[string]time.Time                       // stores used client assertion IDs until they expire
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory OAuth store.
+func NewMemoryStore() *memory {
+	return &memory{
+		clients:  make(map[string]*oauthstore.Client),
+		codes:    make(map[string]*oauthstore.AuthorizationCode),
+		devices:  make(map[string]*oauthstore.DeviceAuthorization),
+		consents: make(map[string]*oauthstore.Consent),
+		jtis:     make(map[string]time.Time),
+	}
+}
+
+// CreateClient adds a new client to the in-memory store.
+func (m *memory) CreateClient(ctx context.Context, client *oauthstore.Client) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.clients[client.ID]; exists {
+		return fmt.Errorf("client with id %s already exists", client.ID)
+	}
+
+	stored := *client
+	m.clients[client.ID] = &stored
+
+	return nil
+}
+
+// QueryClientByID retrieves a client from memory by its client identifier.
+func (m *memory) QueryClientByID(ctx context.Context, clientID string) (*oauthstore.Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, ok := m.clients[clientID]
+	if !ok {
+		return nil, fmt.Errorf("client with id %s doesn't exist", clientID)
+	}
+
+	found := *client
+	return &found, nil
+}
+
+// CreateCode adds a new authorization code to the in-memory store.
+func (m *memory) CreateCode(ctx context.Context, code *oauthstore.AuthorizationCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.codes[code.Code]; exists {
+		return fmt.Errorf("authorization code already exists")
+	}
+
+	stored := *code
+	m.codes[code.Code] = &stored
+
+	return nil
+}
+
+// ConsumeCode retrieves an authorization code from memory and marks it redeemed. Other
+// codes that expired are removed meanwhile.
+func (m *memory) ConsumeCode(ctx context.Context, code string) (*oauthstore.AuthorizationCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, c := range m.codes {
+		if key != code && now.After(c.ExpiresAt) {
+			delete(m.codes, key)
+		}
+	}
+
+	found, ok := m.codes[code]
+	if !ok {
+		return nil, fmt.Errorf("authorization code doesn't exist")
+	}
+
+	consumed := *found
+	if found.Redeemed {
+		return &consumed, oauthstore.ErrCodeRedeemed
+	}
+	found.Redeemed = true
+	return &consumed, nil
+}
+
+// SetCodeSession records the session created for a redeemed authorization code in memory.
+func (m *memory) SetCodeSession(ctx context.Context, code, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found, ok := m.codes[code]
+	if !ok {
+		return fmt.Errorf("authorization code doesn't exist")
+	}
+	found.SessionID = sessionID
+	return nil
+}
+
+// CreateDeviceAuthorization adds a new device authorization to the in-memory store.
+func (m *memory) CreateDeviceAuthorization(ctx context.Context, auth *oauthstore.DeviceAuthorization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.devices[auth.DeviceCode]; exists {
+		return fmt.Errorf("device code already exists")
+	}
+	for _, existing := range m.devices {
+		if existing.UserCode == auth.UserCode {
+			return fmt.Errorf("user code already exists")
+		}
+	}
+
+	stored := *auth
+	m.devices[auth.DeviceCode] = &stored
+
+	return nil
+}
+
+// QueryDeviceByDeviceCode retrieves a device authorization from memory by its device code.
+func (m *memory) QueryDeviceByDeviceCode(ctx context.Context, deviceCode string) (*oauthstore.DeviceAuthorization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	auth, ok := m.devices[deviceCode]
+	if !ok {
+		return nil, fmt.Errorf("device code doesn't exist")
+	}
+
+	found := *auth
+	return &found, nil
+}
+
+// QueryDeviceByUserCode retrieves a device authorization from memory by its user code.
+func (m *memory) QueryDeviceByUserCode(ctx context.Context, userCode string) (*oauthstore.DeviceAuthorization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, auth := range m.devices {
+		if auth.UserCode == userCode {
+			found := *auth
+			return &found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user code %s doesn't exist", userCode)
+}
+
+// UpdateDeviceAuthorization replaces a device authorization in memory.
+func (m *memory) UpdateDeviceAuthorization(ctx context.Context, auth *oauthstore.DeviceAuthorization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.devices[auth.DeviceCode]; !ok {
+		return fmt.Errorf("device code doesn't exist")
+	}
+
+	stored := *auth
+	m.devices[auth.DeviceCode] = &stored
+
+	return nil
+}
+
+// DeleteDeviceAuthorization removes a device authorization from memory.
+func (m *memory) DeleteDeviceAuthorization(ctx context.Context, deviceCode string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.devices[deviceCode]; !ok {
+		return fmt.Errorf("device code doesn't exist")
+	}
+
+	delete(m.devices, deviceCode)
+	return nil
+}
+
+// RecordExchange appends a token exchange to the in-memory audit trail.
+func (m *memory) RecordExchange(ctx context.Context, exchange *oauthstore.TokenExchange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *exchange
+	stored.Actors = slices.Clone(exchange.Actors)
+	stored.Scopes = slices.Clone(exchange.Scopes)
+	stored.Audience = slices.Clone(exchange.Audience)
+	m.exchanges = append(m.exchanges, &stored)
+
+	return nil
+}
+
+// ListExchanges returns the exchanges involving the principal from the in-memory audit trail.
+func (m *memory) ListExchanges(ctx context.Context, principalID string) ([]*oauthstore.TokenExchange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var exchanges []*oauthstore.TokenExchange
+	for _, exchange := range m.exchanges {
+		if exchange.Subject == principalID || slices.Contains(exchange.Actors, principalID) {
+			found := *exchange
+			exchanges = append(exchanges, &found)
+		}
+	}
+
+	return exchanges, nil
+}
+
+// SaveConsent records a consent in memory, merging scopes with any previous consent.
+func (m *memory) SaveConsent(ctx context.Context, consent *oauthstore.Consent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := consentKey(consent.UserID, consent.ClientID)
+	stored := *consent
+
+	if existing, ok := m.consents[key]; ok {
+		stored.Scopes = slices.Clone(existing.Scopes)
+		for _, scope := range consent.Scopes {
+			if !slices.Contains(stored.Scopes, scope) {
+				stored.Scopes = append(stored.Scopes, scope)
+			}
+		}
+	}
+
+	m.consents[key] = &stored
+	return nil
+}
+
+// QueryConsent retrieves the consent a user granted to a client from memory.
+func (m *memory) QueryConsent(ctx context.Context, userID, clientID string) (*oauthstore.Consent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	consent, ok := m.consents[consentKey(userID, clientID)]
+	if !ok {
+		return nil, fmt.Errorf("consent for client %s doesn't exist", clientID)
+	}
+
+	found := *consent
+	return &found, nil
+}
+
+// UseAssertion records a client assertion ID in memory, pruning expired entries.
+func (m *memory) UseAssertion(ctx context.Context, clientID, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, exp := range m.jtis {
+		if now.After(exp) {
+			delete(m.jtis, key)
+		}
+	}
+
+	key := clientID + ":" + jti
+	if _, exists := m.jtis[key]; exists {
+		return fmt.Errorf("client assertion %s was already used", jti)
+	}
+
+	m.jtis[key] = expiresAt
+	return nil
+}
+
+// consentKey builds the map key for a user and client pair.
+func consentKey(userID, clientID string) string {
+	return userID + ":" + clientID
+}
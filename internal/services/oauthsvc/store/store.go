@@ -0,0 +1,186 @@
+// Package oauthstore defines the interfaces for interacting with the OAuth storage layer.
+package oauthstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCodeRedeemed is returned along with an authorization code that was already redeemed.
+var ErrCodeRedeemed = errors.New("authorization code was already redeemed")
+
+// Supported OAuth client types as defined in RFC 6749 section 2.1.
+const (
+	ClientTypePublic       = "public"
+	ClientTypeConfidential = "confidential"
+)
+
+// Supported token endpoint authentication methods as defined in RFC 7591 section 2.
+const (
+	AuthMethodNone              = "none"
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"
+)
+
+// Client represents a registered OAuth client application.
+type Client struct {
+	ID                      string    // Client identifier
+	SecretHash              []byte    // SHA-256 hash of the client secret, empty unless authenticating with a secret
+	PublicKeyPEM            string    // PEM encoded key verifying private_key_jwt assertions
+	Name                    string    // Human readable client name
+	Type                    string    // Client type, public or confidential
+	TokenEndpointAuthMethod string    // How the client authenticates at the token endpoint
+	GrantTypes              []string  // Grant types the client may use
+	RedirectURIs            []string  // Registered redirect URIs, matched exactly
+	Scopes                  []string  // Scopes the client may request
+	Permissions             []string  // Permissions the registering admin granted the client to act with on its own behalf
+	Audience                []string  // Audiences the client may request client credentials tokens for
+	OwnerID                 string    // ID of the user who registered the client
+	TenantID                string    // ID of the organization the client belongs to
+	CreatedAt               time.Time // Time the client was registered
+}
+
+// AuthorizationCode represents a short-lived code issued by the authorization endpoint.
+type AuthorizationCode struct {
+	Code                string    // Opaque authorization code
+	ClientID            string    // Client the code was issued to
+	UserID              string    // User who authorized the client
+	RedirectURI         string    // Redirect URI used in the authorization request
+	Scopes              []string  // Scopes granted to the client
+	CodeChallenge       string    // PKCE code challenge
+	CodeChallengeMethod string    // PKCE code challenge method
+	Nonce               string    // OpenID Connect nonce to echo in the ID token
+	AuthTime            time.Time // Time the user authenticated
+	ExpiresAt           time.Time // Time after which the code is no longer accepted
+	Redeemed            bool      // Whether the code was exchanged for tokens
+	SessionID           string    // Session the tokens issued for the code belong to
+}
+
+// Device authorization statuses.
+const (
+	DeviceStatusPending  = "pending"
+	DeviceStatusApproved = "approved"
+	DeviceStatusDenied   = "denied"
+)
+
+// DeviceAuthorization represents a pending device authorization grant (RFC 8628).
+type DeviceAuthorization struct {
+	DeviceCode   string        // Opaque code the device polls the token endpoint with
+	UserCode     string        // Normalized code the user enters to approve the device
+	ClientID     string        // Client that started the authorization
+	Scopes       []string      // Requested scopes
+	Status       string        // Pending, approved or denied
+	UserID       string        // User who approved or denied the request
+	AuthTime     time.Time     // Time the approving user authenticated
+	Interval     time.Duration // Minimum time between polling requests
+	LastPolledAt time.Time     // Time the device last polled the token endpoint
+	ExpiresAt    time.Time     // Time after which the codes are no longer accepted
+}
+
+// Token exchange kinds recorded in the audit trail.
+const (
+	ExchangeKindDownscope     = "downscope"
+	ExchangeKindDelegation    = "delegation"
+	ExchangeKindImpersonation = "impersonation"
+)
+
+// TokenExchange is an audit record of a token issued through a token exchange (RFC 8693).
+type TokenExchange struct {
+	ID        string    // Unique record identifier
+	Kind      string    // Downscope, delegation or impersonation
+	ClientID  string    // Client that performed the exchange
+	Subject   string    // Subject of the issued token
+	Actors    []string  // Actor chain of the issued token, current actor first
+	Scopes    []string  // Scopes of the issued token
+	Audience  []string  // Audience of the issued token
+	TokenID   string    // ID (jti) of the issued token
+	SourceID  string    // ID (jti) of the subject token that was exchanged
+	CreatedAt time.Time // Time of the exchange
+}
+
+// Consent represents the scopes a user has allowed a client to access.
+type Consent struct {
+	UserID    string    // User who granted the consent
+	ClientID  string    // Client the consent was granted to
+	Scopes    []string  // Scopes covered by the consent
+	GrantedAt time.Time // Time the consent was last granted
+}
+
+// ClientStorer defines the contract for managing OAuth clients in a storage backend.
+type ClientStorer interface {
+	// CreateClient stores a newly registered client.
+	CreateClient(ctx context.Context, client *Client) error
+
+	// QueryClientByID retrieves a client by its client identifier.
+	QueryClientByID(ctx context.Context, clientID string) (*Client, error)
+}
+
+// CodeStorer defines the contract for managing authorization codes in a storage backend.
+type CodeStorer interface {
+	// CreateCode stores a newly issued authorization code.
+	CreateCode(ctx context.Context, code *AuthorizationCode) error
+
+	// ConsumeCode retrieves an authorization code and marks it redeemed so it can only be
+	// redeemed once. Redeemed codes are kept until they expire, a code redeemed again is
+	// returned along with ErrCodeRedeemed so the tokens issued for it can be revoked.
+	ConsumeCode(ctx context.Context, code string) (*AuthorizationCode, error)
+
+	// SetCodeSession records the session the tokens issued for a redeemed code belong to.
+	SetCodeSession(ctx context.Context, code, sessionID string) error
+}
+
+// DeviceStorer defines the contract for managing device authorizations in a storage backend.
+type DeviceStorer interface {
+	// CreateDeviceAuthorization stores a newly started device authorization.
+	CreateDeviceAuthorization(ctx context.Context, auth *DeviceAuthorization) error
+
+	// QueryDeviceByDeviceCode retrieves a device authorization by its device code.
+	QueryDeviceByDeviceCode(ctx context.Context, deviceCode string) (*DeviceAuthorization, error)
+
+	// QueryDeviceByUserCode retrieves a device authorization by its user code.
+	QueryDeviceByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+
+	// UpdateDeviceAuthorization replaces a stored device authorization.
+	UpdateDeviceAuthorization(ctx context.Context, auth *DeviceAuthorization) error
+
+	// DeleteDeviceAuthorization removes a device authorization by its device code.
+	DeleteDeviceAuthorization(ctx context.Context, deviceCode string) error
+}
+
+// ExchangeStorer defines the contract for the token exchange audit trail.
+type ExchangeStorer interface {
+	// RecordExchange appends a token exchange to the audit trail.
+	RecordExchange(ctx context.Context, exchange *TokenExchange) error
+
+	// ListExchanges returns the recorded exchanges that involved the given principal,
+	// either as the subject or as one of the actors, oldest first.
+	ListExchanges(ctx context.Context, principalID string) ([]*TokenExchange, error)
+}
+
+// ConsentStorer defines the contract for managing user consents in a storage backend.
+type ConsentStorer interface {
+	// SaveConsent records the scopes a user granted to a client, merging with any previous consent.
+	SaveConsent(ctx context.Context, consent *Consent) error
+
+	// QueryConsent retrieves the consent a user granted to a client.
+	QueryConsent(ctx context.Context, userID, clientID string) (*Consent, error)
+}
+
+// AssertionStorer defines the contract for tracking client assertions already used.
+type AssertionStorer interface {
+	// UseAssertion records the jti of a client assertion until it expires and fails
+	// if the same client already used it.
+	UseAssertion(ctx context.Context, clientID, jti string, expiresAt time.Time) error
+}
+
+// OAuthStorer groups the storage contracts used by the OAuth service.
+type OAuthStorer interface {
+	ClientStorer
+	CodeStorer
+	DeviceStorer
+	ConsentStorer
+	ExchangeStorer
+	AssertionStorer
+}
@@ -0,0 +1,383 @@
+package oauthsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	oauthstore "github.com/iamBelugaa/goa-iam/internal/services/oauthsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Grant types supported by the token endpoint.
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Token endpoint error codes from RFC 6749 section 5.2.
+const (
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errUnauthorizedClient   = "unauthorized_client"
+	errUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// Token exchanges an authorization grant for an access token.
+func (s *service) Token(ctx context.Context, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	logger.FromContext(ctx).Infow("token request received", "grantType", req.GrantType)
+
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		Authorization:       req.Authorization,
+		ClientID:            req.ClientID,
+		ClientSecret:        req.ClientSecret,
+		ClientAssertionType: req.ClientAssertionType,
+		ClientAssertion:     req.ClientAssertion,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("client authentication error", "error", err)
+		return nil, err
+	}
+
+	var res *genoauth.OAuthTokenResponse
+	switch req.GrantType {
+	case grantTypeAuthorizationCode, grantTypeRefreshToken, grantTypeClientCredentials, grantTypeDeviceCode, grantTypeTokenExchange:
+		if !slices.Contains(client.GrantTypes, req.GrantType) {
+			err = oauthError(errUnauthorizedClient, fmt.Sprintf("client may not use the %s grant", req.GrantType))
+			break
+		}
+
+		switch req.GrantType {
+		case grantTypeAuthorizationCode:
+			res, err = s.exchangeAuthorizationCode(ctx, client, req)
+		case grantTypeRefreshToken:
+			res, err = s.exchangeRefreshToken(ctx, client, req)
+		case grantTypeClientCredentials:
+			res, err = s.exchangeClientCredentials(ctx, client, req)
+		case grantTypeDeviceCode:
+			res, err = s.exchangeDeviceCode(ctx, client, req)
+		case grantTypeTokenExchange:
+			res, err = s.exchangeToken(ctx, client, req)
+		}
+	default:
+		err = oauthError(errUnsupportedGrantType, fmt.Sprintf("grant type %q is not supported", req.GrantType))
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Infow("token request error", "clientId", client.ID, "grantType", req.GrantType, "error", err)
+		s.recordTokenError(ctx, client, req.GrantType, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("token request successful", "clientId", client.ID, "grantType", req.GrantType)
+	return res, nil
+}
+
+// exchangeAuthorizationCode redeems an authorization code (RFC 6749 section 4.1.3).
+func (s *service) exchangeAuthorizationCode(ctx context.Context, client *oauthstore.Client, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	if req.Code == nil || *req.Code == "" {
+		return nil, oauthError(errInvalidRequest, "code is required")
+	}
+
+	code, err := s.store.ConsumeCode(ctx, *req.Code)
+	if errors.Is(err, oauthstore.ErrCodeRedeemed) {
+		// A replayed code may have been stolen, so the tokens issued for it stop working.
+		if code.SessionID != "" {
+			if err := s.sessions.Revoke(ctx, code.UserID, code.SessionID); err != nil {
+				logger.FromContext(ctx).Infow("revoke session of replayed code error", "sessionId", code.SessionID, "error", err)
+			}
+		}
+		return nil, oauthError(errInvalidGrant, "authorization code was already redeemed")
+	}
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "authorization code is invalid")
+	}
+	if time.Now().After(code.ExpiresAt) {
+		return nil, oauthError(errInvalidGrant, "authorization code has expired")
+	}
+	if code.ClientID != client.ID {
+		return nil, oauthError(errInvalidGrant, "authorization code was issued to another client")
+	}
+	if req.RedirectURI == nil || *req.RedirectURI != code.RedirectURI {
+		return nil, oauthError(errInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+
+	switch {
+	case code.CodeChallenge != "":
+		if req.CodeVerifier == nil || !verifyCodeVerifier(code.CodeChallenge, *req.CodeVerifier) {
+			return nil, oauthError(errInvalidGrant, "code_verifier is invalid")
+		}
+	case req.CodeVerifier != nil:
+		return nil, oauthError(errInvalidGrant, "code_verifier given for a request without code_challenge")
+	}
+
+	user, err := s.resourceOwner(ctx, client, code.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each authorization grant gets its own session so it can be listed and revoked independently.
+	sess, err := s.sessions.Create(ctx, code.UserID, requestctx.MetadataFromContext(ctx))
+	if err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+	if err := s.store.SetCodeSession(ctx, code.Code, sess.ID); err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+
+	return s.issueTokens(ctx, userGrant{
+		GrantType: grantTypeAuthorizationCode,
+		User:      user,
+		SessionID: sess.ID,
+		ClientID:  client.ID,
+		Scopes:    code.Scopes,
+		AuthTime:  code.AuthTime,
+		Nonce:     code.Nonce,
+	})
+}
+
+// exchangeRefreshToken issues a new token pair for a refresh token (RFC 6749 section 6),
+// revoking the refresh token presented.
+func (s *service) exchangeRefreshToken(ctx context.Context, client *oauthstore.Client, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	if req.RefreshToken == nil || *req.RefreshToken == "" {
+		return nil, oauthError(errInvalidRequest, "refresh_token is required")
+	}
+
+	claims, err := s.authn.Validate(ctx, *req.RefreshToken)
+	if err != nil || claims.TokenType != tokenmgr.RefreshToken {
+		return nil, oauthError(errInvalidGrant, "refresh token is invalid, expired or revoked")
+	}
+	if claims.ClientID != client.ID {
+		return nil, oauthError(errInvalidGrant, "refresh token was issued to another client")
+	}
+
+	// The client may narrow, but never widen, the originally granted scope.
+	granted := strings.Fields(claims.Scope)
+	scopes := granted
+	if req.Scope != nil && strings.TrimSpace(*req.Scope) != "" {
+		scopes = strings.Fields(*req.Scope)
+		if !containsAll(granted, scopes) {
+			return nil, oauthError(errInvalidScope, "requested scope exceeds the original grant")
+		}
+	}
+
+	user, err := s.resourceOwner(ctx, client, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := userGrant{
+		GrantType: grantTypeRefreshToken, User: user, SessionID: claims.SessionID, ClientID: client.ID, Scopes: scopes,
+	}
+	if claims.AuthTime != nil {
+		grant.AuthTime = claims.AuthTime.Time
+	}
+	res, err := s.issueTokens(ctx, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh tokens are rotated, the one presented stops working once exchanged.
+	if err := s.authn.Redeem(ctx, claims); err != nil {
+		return nil, genoauth.MakeInternalServerError(err)
+	}
+	return res, nil
+}
+
+// exchangeClientCredentials issues an access token to the client itself (RFC 6749 section 4.4).
+// The token's subject is the client, it carries no session and no refresh token is issued.
+// It carries the permissions granted to the client at registration that the scope covers.
+func (s *service) exchangeClientCredentials(ctx context.Context, client *oauthstore.Client, req *genoauth.TokenRequest) (*genoauth.OAuthTokenResponse, error) {
+	if client.Type != oauthstore.ClientTypeConfidential {
+		return nil, oauthError(errUnauthorizedClient, "client credentials grant requires a confidential client")
+	}
+
+	scopes := client.Scopes
+	if req.Scope != nil && strings.TrimSpace(*req.Scope) != "" {
+		scopes = strings.Fields(*req.Scope)
+		if !containsAll(client.Scopes, scopes) {
+			return nil, oauthError(errInvalidScope, "requested scope is not allowed for this client")
+		}
+	}
+
+	claims := s.tm.StandardClaims(client.ID, tokenmgr.AccessToken)
+	claims.PrincipalType = tokenmgr.PrincipalClient
+	claims.ClientID = client.ID
+	claims.TenantID = client.TenantID
+	claims.Scope = strings.Join(scopes, " ")
+	claims.Permissions = grantedPermissions(client.Permissions, scopes)
+
+	// Clients without registered audiences may only obtain tokens for this server.
+	allowed := client.Audience
+	if len(allowed) == 0 {
+		allowed = claims.Audience
+	}
+	claims.Audience = jwt.ClaimStrings(allowed)
+	if req.Audience != nil && strings.TrimSpace(*req.Audience) != "" {
+		audience := strings.Fields(*req.Audience)
+		if !containsAll(allowed, audience) {
+			return nil, oauthError(errInvalidRequest, "requested audience is not allowed for this client")
+		}
+		claims.Audience = jwt.ClaimStrings(audience)
+	}
+
+	accessToken, err := s.tm.Generate(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &genoauth.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.tm.TTL(tokenmgr.AccessToken).Seconds()),
+		Scope:       &claims.Scope,
+	}, nil
+}
+
+// userGrant describes the authorization of a client by a user that tokens are issued for.
+type userGrant struct {
+	GrantType string        // Grant the tokens are issued for
+	User      *genuser.User // User who authorized the client
+	SessionID string        // Session created for the grant
+	ClientID  string        // Client the tokens are issued to
+	Scopes    []string      // Granted scopes
+	AuthTime  time.Time     // Time the user authenticated
+	Nonce     string        // OpenID Connect nonce from the authorization request
+}
+
+// resourceOwner retrieves the user of the client's tenant who authorized a grant, failing
+// unless the account is still active, so inactive and suspended users get no new tokens.
+func (s *service) resourceOwner(ctx context.Context, client *oauthstore.Client, userID string) (*genuser.User, error) {
+	user, err := userstore.QueryTenantUser(ctx, s.userStore, client.TenantID, userID)
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "resource owner no longer exists")
+	}
+	if user.Status != userdomain.UserStatusActive {
+		logger.FromContext(ctx).Infow("resource owner not active", "userId", userID, "status", user.Status)
+		return nil, oauthError(errInvalidGrant, "resource owner is "+user.Status)
+	}
+	return user, nil
+}
+
+// issueTokens generates an access and refresh token pair for a grant, and an ID token
+// when the openid scope was granted. The access token carries the roles the user holds
+// directly or through groups at the time of issuance, but only those of the user's
+// permissions that the client was granted as scopes.
+func (s *service) issueTokens(ctx context.Context, grant userGrant) (*genoauth.OAuthTokenResponse, error) {
+	scope := strings.Join(grant.Scopes, " ")
+
+	access, err := s.access.Resolve(ctx, grant.User.ID)
+	if err != nil {
+		return nil, oauthError(errInvalidGrant, "resource owner no longer exists")
+	}
+
+	var authTime *jwt.NumericDate
+	if !grant.AuthTime.IsZero() {
+		authTime = jwt.NewNumericDate(grant.AuthTime)
+	}
+
+	accessClaims := s.tm.StandardClaims(grant.User.ID, tokenmgr.AccessToken)
+	accessClaims.SessionID = grant.SessionID
+	accessClaims.ClientID = grant.ClientID
+	accessClaims.TenantID = grant.User.TenantID
+	accessClaims.Scope = scope
+	accessClaims.AuthTime = authTime
+	accessClaims.Roles = access.Roles
+	accessClaims.Permissions = grantedPermissions(access.Permissions, grant.Scopes)
+
+	accessToken, err := s.tm.Generate(ctx, accessClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshClaims := s.tm.StandardClaims(grant.User.ID, tokenmgr.RefreshToken)
+	refreshClaims.SessionID = grant.SessionID
+	refreshClaims.ClientID = grant.ClientID
+	refreshClaims.TenantID = grant.User.TenantID
+	refreshClaims.Scope = scope
+	refreshClaims.AuthTime = authTime
+
+	refreshToken, err := s.tm.Generate(ctx, refreshClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &genoauth.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.tm.TTL(tokenmgr.AccessToken).Seconds()),
+		RefreshToken: &refreshToken,
+		Scope:        &scope,
+	}
+
+	if slices.Contains(grant.Scopes, idtoken.ScopeOpenID) {
+		idToken, err := s.idTokens.Issue(idtoken.Params{
+			User:        grant.User,
+			Audience:    grant.ClientID,
+			Scopes:      grant.Scopes,
+			Nonce:       grant.Nonce,
+			AuthTime:    grant.AuthTime,
+			SessionID:   grant.SessionID,
+			AccessToken: accessToken,
+		})
+		if err != nil {
+			return nil, genoauth.MakeInternalServerError(err)
+		}
+		res.IDToken = &idToken
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventTokenIssue, ActorID: grant.User.ID, TargetID: grant.ClientID,
+		Details: map[string]string{"grantType": grant.GrantType, "scope": scope, "sessionId": grant.SessionID},
+	})
+	return res, nil
+}
+
+// recordTokenError audits a failed token request for a grant issuing user tokens. Device
+// clients polling a pending authorization aren't recorded.
+func (s *service) recordTokenError(ctx context.Context, client *oauthstore.Client, grantType string, err error) {
+	switch grantType {
+	case grantTypeAuthorizationCode, grantTypeRefreshToken, grantTypeDeviceCode:
+	default:
+		return
+	}
+
+	var oauthErr *genoauth.OAuthError
+	if errors.As(err, &oauthErr) {
+		if oauthErr.Code == errAuthorizationPending || oauthErr.Code == errSlowDown {
+			return
+		}
+		reason := oauthErr.Code
+		if oauthErr.ErrorDescription != nil {
+			reason += ": " + *oauthErr.ErrorDescription
+		}
+		err = errors.New(reason)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventTokenIssue, TargetID: client.ID, Err: err,
+		Details: map[string]string{"grantType": grantType},
+	})
+}
+
+// oauthError builds an RFC 6749 error response.
+func oauthError(code, description string) *genoauth.OAuthError {
+	return &genoauth.OAuthError{Code: code, ErrorDescription: &description}
+}
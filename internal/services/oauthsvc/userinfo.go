@@ -0,0 +1,76 @@
+package oauthsvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	genoauth "github.com/iamBelugaa/goa-iam/gen/oauth"
+
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/idtoken"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Userinfo returns the standard claims of the user the access token was issued for
+// (OpenID Connect Core section 5.3). Tokens issued to OAuth clients release only the
+// claims covered by their scopes and require the openid scope, while first-party
+// tokens release every claim.
+func (s *service) Userinfo(ctx context.Context, req *genoauth.UserInfoRequest) (*genoauth.UserInfo, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return nil, genoauth.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+
+	logger.FromContext(ctx).Infow("userinfo request received", "userId", claims.Subject, "clientId", claims.ClientID)
+
+	scopes := idtoken.AllScopes
+	if claims.ClientID != "" {
+		scopes = strings.Fields(claims.Scope)
+		if !slices.Contains(scopes, idtoken.ScopeOpenID) {
+			logger.FromContext(ctx).Infow("userinfo error", "userId", claims.Subject, "error", "openid scope not granted")
+			return nil, genoauth.MakeUnauthorized(fmt.Errorf("access token lacks the openid scope"))
+		}
+	}
+
+	user, err := userstore.QueryTenantUser(ctx, s.userStore, claims.TenantID, claims.Subject)
+	if err != nil {
+		logger.FromContext(ctx).Infow("query user error", "userId", claims.Subject, "error", err)
+		return nil, genoauth.MakeNotFound(err)
+	}
+
+	released := idtoken.UserClaimsFor(user, scopes)
+	res := &genoauth.UserInfo{Sub: user.ID, EmailVerified: released.EmailVerified}
+	if released.Name != "" {
+		res.Name = &released.Name
+		res.GivenName = &released.GivenName
+		res.FamilyName = &released.FamilyName
+	}
+	if released.Email != "" {
+		res.Email = &released.Email
+	}
+
+	logger.FromContext(ctx).Infow("userinfo request successful", "userId", claims.Subject)
+	return res, nil
+}
+
+// Jwks returns the public keys verifying ID token signatures.
+func (s *service) Jwks(ctx context.Context) (*genoauth.JWKSResponse, error) {
+	keys := s.idTokens.JWKS()
+
+	res := &genoauth.JWKSResponse{Keys: make([]*genoauth.JWK, 0, len(keys))}
+	for _, key := range keys {
+		res.Keys = append(res.Keys, &genoauth.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   key.N,
+			E:   key.E,
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,204 @@
+// Package orgsvc provides organization management business logic for the IAM system.
+// Organizations are the tenants of the system. They're managed by the platform admins,
+// the admins of the default organization every deployment starts with.
+package orgsvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"goa.design/goa/v3/security"
+
+	genorganization "github.com/iamBelugaa/goa-iam/gen/organization"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// service implements organization management on top of the organization store.
+type service struct {
+	log          *logger.Logger              // Logger for structured logging
+	store        orgstore.OrganizationStorer // Interface to the organization data store
+	defaultOrgID string                      // ID of the default organization, whose admins manage every organization
+	authn        *jwtauth.Authenticator      // Token authenticator for secured methods
+	audit        *audit.Recorder             // Recorder of security events
+}
+
+// NewService initializes and returns a new organization service instance.
+func NewService(
+	log *logger.Logger, store orgstore.OrganizationStorer, defaultOrgID string, authn *jwtauth.Authenticator,
+	recorder *audit.Recorder,
+) *service {
+	return &service{
+		log:          log,
+		store:        store,
+		defaultOrgID: defaultOrgID,
+		authn:        authn,
+		audit:        recorder,
+	}
+}
+
+// Bootstrap returns the default organization, creating it when it doesn't exist yet.
+// Requests that name no tenant are routed to it, and the owners of the given admin emails
+// are granted the admin role in it, see GrantAdminEmail.
+func Bootstrap(ctx context.Context, store orgstore.OrganizationStorer, cfg *config.Tenancy, adminEmails []string) (*orgstore.Organization, error) {
+	if org, err := store.QueryBySlug(ctx, cfg.DefaultOrganization); err == nil {
+		return org, nil
+	}
+
+	now := time.Now()
+	org := &orgstore.Organization{
+		ID:          uuid.New().String(),
+		Slug:        cfg.DefaultOrganization,
+		Name:        cfg.DefaultOrganization,
+		AdminEmails: adminEmails,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := store.Create(ctx, org); err != nil {
+		return nil, fmt.Errorf("creating default organization: %w", err)
+	}
+	return org, nil
+}
+
+// GrantAdminEmail grants the admin role to a user whose email address is one of the admin
+// emails of the user's organization. Anyone can register with any address, so it must only
+// be called once the user proved owning the address, such as by accepting an invitation
+// sent to it or signing in through an identity provider that verified it.
+func GrantAdminEmail(
+	ctx context.Context, store orgstore.OrganizationStorer, userStore userstore.UserStorer, user *genuser.User,
+) (*genuser.User, error) {
+	org, err := store.QueryByID(ctx, user.TenantID)
+	if err != nil || !slices.Contains(org.AdminEmails, user.Email) || slices.Contains(user.Roles, userdomain.RoleAdmin) {
+		return user, nil
+	}
+	return userStore.UpdateRoles(ctx, user.ID, append(slices.Clone(user.Roles), userdomain.RoleAdmin))
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// List returns every organization ordered by slug.
+func (s *service) List(ctx context.Context, req *genorganization.ListOrganizationsRequest) (*genorganization.ListOrganizationsResponse, error) {
+	claims, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list organizations request received", "userId", claims.Subject)
+
+	orgs, err := s.store.List(ctx)
+	if err != nil {
+		s.log.Infow("list organizations error", "error", err)
+		return nil, genorganization.MakeInternalServerError(err)
+	}
+	slices.SortFunc(orgs, func(a, b *orgstore.Organization) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+
+	data := make([]*genorganization.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		data = append(data, toOrganization(org))
+	}
+
+	s.log.Infow("list organizations request successful", "totalOrganizations", len(data))
+	return &genorganization.ListOrganizationsResponse{
+		Success: true,
+		Message: "Organizations fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// Get retrieves an organization by its ID.
+func (s *service) Get(ctx context.Context, req *genorganization.OrganizationRequest) (*genorganization.OrganizationResponse, error) {
+	claims, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("get organization request received", "userId", claims.Subject, "organizationId", req.ID)
+
+	org, err := s.store.QueryByID(ctx, req.ID)
+	if err != nil {
+		s.log.Infow("get organization error", "organizationId", req.ID, "error", err)
+		return nil, genorganization.MakeNotFound(err)
+	}
+
+	s.log.Infow("get organization request successful", "organizationId", req.ID)
+	return organizationResponse(org, "Organization fetched successfully"), nil
+}
+
+// Create creates an organization. Requests are routed to it by its slug.
+func (s *service) Create(ctx context.Context, req *genorganization.CreateOrganizationRequest) (*genorganization.OrganizationResponse, error) {
+	claims, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("create organization request received", "userId", claims.Subject, "slug", req.Slug)
+
+	now := time.Now()
+	org := &orgstore.Organization{
+		ID:          uuid.New().String(),
+		Slug:        req.Slug,
+		Name:        req.Name,
+		AdminEmails: req.AdminEmails,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.store.Create(ctx, org); err != nil {
+		s.log.Infow("create organization error", "slug", req.Slug, "error", err)
+		return nil, genorganization.MakeConflict(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventOrganizationCreate, TargetID: org.ID, Details: map[string]string{"slug": org.Slug},
+	})
+
+	s.log.Infow("create organization request successful", "organizationId", org.ID, "slug", org.Slug)
+	return organizationResponse(org, "Organization created successfully"), nil
+}
+
+// authorize returns the claims of the caller, failing unless it's a user of the default
+// organization holding the organizations:manage permission.
+func (s *service) authorize(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, genorganization.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if claims.TenantID != s.defaultOrgID || !slices.Contains(claims.Permissions, userdomain.PermissionManageOrganizations) {
+		return tokenmgr.Claims{}, genorganization.MakeForbidden(fmt.Errorf("caller isn't a platform admin"))
+	}
+	return claims, nil
+}
+
+// organizationResponse wraps an organization in a response with the given message.
+func organizationResponse(org *orgstore.Organization, message string) *genorganization.OrganizationResponse {
+	return &genorganization.OrganizationResponse{Success: true, Message: message, Data: toOrganization(org)}
+}
+
+// toOrganization converts a stored organization into its API representation.
+func toOrganization(org *orgstore.Organization) *genorganization.Organization {
+	adminEmails := org.AdminEmails
+	if adminEmails == nil {
+		adminEmails = []string{}
+	}
+	return &genorganization.Organization{
+		ID:          org.ID,
+		Slug:        org.Slug,
+		Name:        org.Name,
+		AdminEmails: adminEmails,
+		CreatedAt:   org.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   org.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,86 @@
+// Package orgstore provides an in-memory implementation of the OrganizationStorer interface.
+package orgstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+)
+
+// memory implements the OrganizationStorer interface using in-memory maps.
+type memory struct {
+	mu          sync.RWMutex                      // protects access to orgs and slugToIdMap
+	slugToIdMap map[string]string                 // maps slugs to organization IDs
+	orgs        map[string]*orgstore.Organization // stores organizations by ID
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory organization store.
+func NewMemoryStore() *memory {
+	return &memory{
+		slugToIdMap: make(map[string]string),
+		orgs:        make(map[string]*orgstore.Organization),
+	}
+}
+
+// Create adds a new organization to the in-memory store.
+func (m *memory) Create(ctx context.Context, org *orgstore.Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.orgs[org.ID]; exists {
+		return fmt.Errorf("organization with id %s already exists", org.ID)
+	}
+	if _, exists := m.slugToIdMap[org.Slug]; exists {
+		return fmt.Errorf("organization with slug %s already exists", org.Slug)
+	}
+
+	stored := *org
+	m.slugToIdMap[org.Slug] = org.ID
+	m.orgs[org.ID] = &stored
+
+	return nil
+}
+
+// QueryByID retrieves an organization from memory by its ID.
+func (m *memory) QueryByID(ctx context.Context, orgID string) (*orgstore.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	org, ok := m.orgs[orgID]
+	if !ok {
+		return nil, fmt.Errorf("organization with id %s doesn't exist", orgID)
+	}
+
+	found := *org
+	return &found, nil
+}
+
+// QueryBySlug retrieves an organization from memory by its slug.
+func (m *memory) QueryBySlug(ctx context.Context, slug string) (*orgstore.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	org, ok := m.orgs[m.slugToIdMap[slug]]
+	if !ok {
+		return nil, fmt.Errorf("organization with slug %s doesn't exist", slug)
+	}
+
+	found := *org
+	return &found, nil
+}
+
+// List returns all organizations currently stored in memory.
+func (m *memory) List(ctx context.Context) ([]*orgstore.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orgs := make([]*orgstore.Organization, 0, len(m.orgs))
+	for _, org := range m.orgs {
+		found := *org
+		orgs = append(orgs, &found)
+	}
+
+	return orgs, nil
+}
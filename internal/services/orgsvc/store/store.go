@@ -0,0 +1,33 @@
+// Package orgstore defines the interface for interacting with the organization data storage layer.
+package orgstore
+
+import (
+	"context"
+	"time"
+)
+
+// Organization is a tenant of the IAM system. Users, groups and OAuth clients belong to
+// exactly one organization and are invisible to every other.
+type Organization struct {
+	ID          string    // Unique identifier of the organization
+	Slug        string    // Unique URL safe name routing requests to the organization
+	Name        string    // Human readable name of the organization
+	AdminEmails []string  // Email addresses granted the admin role once their owner proves owning them
+	CreatedAt   time.Time // Time the organization was created
+	UpdatedAt   time.Time // Time the organization was last modified
+}
+
+// OrganizationStorer defines the contract for managing organizations in a storage backend.
+type OrganizationStorer interface {
+	// Create stores a new organization. Slugs are unique.
+	Create(ctx context.Context, org *Organization) error
+
+	// QueryByID retrieves an organization by its unique ID.
+	QueryByID(ctx context.Context, orgID string) (*Organization, error)
+
+	// QueryBySlug retrieves an organization by its slug.
+	QueryBySlug(ctx context.Context, slug string) (*Organization, error)
+
+	// List returns all organizations.
+	List(ctx context.Context) ([]*Organization, error)
+}
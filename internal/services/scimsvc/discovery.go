@@ -0,0 +1,196 @@
+package scimsvc
+
+import (
+	"context"
+	"fmt"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// schemaAttribute describes an attribute of a resource schema (RFC 7643 section 7).
+type schemaAttribute struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	MultiValued   bool              `json:"multiValued"`
+	Description   string            `json:"description"`
+	Required      bool              `json:"required"`
+	CaseExact     bool              `json:"caseExact"`
+	Mutability    string            `json:"mutability"`
+	Returned      string            `json:"returned"`
+	Uniqueness    string            `json:"uniqueness"`
+	SubAttributes []schemaAttribute `json:"subAttributes,omitempty"`
+}
+
+// attribute returns a single-valued, optional, case-insensitive read-write string attribute
+// that is always returned and not unique, which callers adjust as needed.
+func attribute(name, description string) schemaAttribute {
+	return schemaAttribute{
+		Name:        name,
+		Type:        "string",
+		Description: description,
+		Mutability:  "readWrite",
+		Returned:    "default",
+		Uniqueness:  "none",
+	}
+}
+
+// schemas describes the resources served by the API, in the order they are listed.
+var schemas = []struct {
+	id, name, description string
+	attributes            []schemaAttribute
+}{
+	{
+		id:          schemaUser,
+		name:        "User",
+		description: "User Account",
+		attributes: func() []schemaAttribute {
+			userName := attribute("userName", "Unique identifier of the user, its email address.")
+			userName.Required, userName.Uniqueness = true, "server"
+
+			name := attribute("name", "Components of the user's name.")
+			name.Type = "complex"
+			name.SubAttributes = []schemaAttribute{
+				attribute("formatted", "Full name of the user."),
+				attribute("givenName", "Given name of the user."),
+				attribute("familyName", "Family name of the user."),
+			}
+
+			emails := attribute("emails", "Email addresses of the user, mirroring the userName.")
+			emails.Type, emails.MultiValued = "complex", true
+			emails.SubAttributes = []schemaAttribute{
+				attribute("value", "Email address."),
+				attribute("type", "Label of the address."),
+				{Name: "primary", Type: "boolean", Description: "Whether this is the primary address.", Mutability: "readWrite", Returned: "default"},
+			}
+
+			active := attribute("active", "Whether the user can sign in.")
+			active.Type = "boolean"
+
+			password := attribute("password", "Initial password of the user.")
+			password.Mutability, password.Returned = "writeOnly", "never"
+
+			groups := attribute("groups", "Groups the user directly belongs to.")
+			groups.Type, groups.MultiValued, groups.Mutability = "complex", true, "readOnly"
+			groups.SubAttributes = []schemaAttribute{
+				attribute("value", "Identifier of the group."),
+				attribute("display", "Display name of the group."),
+				attribute("type", "Membership type."),
+			}
+			for i := range groups.SubAttributes {
+				groups.SubAttributes[i].Mutability = "readOnly"
+			}
+
+			return []schemaAttribute{
+				userName, name, attribute("displayName", "Name of the user suitable for display."),
+				emails, active, password, groups,
+			}
+		}(),
+	},
+	{
+		id:          schemaGroup,
+		name:        "Group",
+		description: "Group",
+		attributes: func() []schemaAttribute {
+			displayName := attribute("displayName", "Unique name of the group.")
+			displayName.Required, displayName.Uniqueness = true, "server"
+
+			members := attribute("members", "Users belonging to the group.")
+			members.Type, members.MultiValued = "complex", true
+			value := attribute("value", "Identifier of the member user.")
+			value.CaseExact, value.Mutability = true, "immutable"
+			display := attribute("display", "Name of the member user.")
+			display.Mutability = "readOnly"
+			members.SubAttributes = []schemaAttribute{value, display}
+
+			return []schemaAttribute{displayName, members}
+		}(),
+	},
+}
+
+// ServiceProviderConfig describes the SCIM features supported by the service provider.
+func (s *service) ServiceProviderConfig(ctx context.Context) (*genscim.ScimServiceProviderConfig, error) {
+	logger.FromContext(ctx).Infow("scim service provider config request received")
+
+	primary := true
+	name, description := "OAuth Bearer Token", "Access token issued through the client credentials grant with the scim:provision scope."
+	res := &genscim.ScimServiceProviderConfig{
+		Schemas:        []string{schemaServiceProviderConfig},
+		Patch:          &genscim.ScimSupported{Supported: true},
+		Bulk:           &genscim.ScimBulk{Supported: false},
+		Filter:         &genscim.ScimFilter{Supported: true, MaxResults: s.cfg.MaxResults},
+		ChangePassword: &genscim.ScimSupported{Supported: false},
+		Sort:           &genscim.ScimSupported{Supported: false},
+		Etag:           &genscim.ScimSupported{Supported: false},
+		AuthenticationSchemes: []*genscim.ScimAuthenticationScheme{
+			{Type: "oauthbearertoken", Name: name, Description: description, Primary: &primary},
+		},
+		Meta: s.discoveryMeta("ServiceProviderConfig", "/ServiceProviderConfig"),
+	}
+
+	logger.FromContext(ctx).Infow("scim service provider config request successful")
+	return res, nil
+}
+
+// ListSchemas lists the resource schemas supported by the service provider.
+func (s *service) ListSchemas(ctx context.Context) (*genscim.ScimSchemaList, error) {
+	logger.FromContext(ctx).Infow("scim list schemas request received")
+
+	resources := make([]*genscim.ScimSchema, 0, len(schemas))
+	for i := range schemas {
+		resources = append(resources, s.schema(i))
+	}
+
+	logger.FromContext(ctx).Infow("scim list schemas request successful", "totalResults", len(resources))
+	return &genscim.ScimSchemaList{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetSchema retrieves a resource schema by its URN.
+func (s *service) GetSchema(ctx context.Context, req *genscim.ScimSchemaRequest) (*genscim.ScimSchema, error) {
+	logger.FromContext(ctx).Infow("scim get schema request received", "schemaId", req.ID)
+
+	for i := range schemas {
+		if schemas[i].id == req.ID {
+			logger.FromContext(ctx).Infow("scim get schema request successful", "schemaId", req.ID)
+			return s.schema(i), nil
+		}
+	}
+
+	err := fmt.Errorf("schema %s doesn't exist", req.ID)
+	logger.FromContext(ctx).Infow("scim get schema error", "schemaId", req.ID, "error", err)
+	return nil, genscim.MakeNotFound(err)
+}
+
+// schema converts the i-th schema definition into a schema resource.
+func (s *service) schema(i int) *genscim.ScimSchema {
+	def := schemas[i]
+	description := def.description
+
+	attributes := make([]any, 0, len(def.attributes))
+	for _, attr := range def.attributes {
+		attributes = append(attributes, attr)
+	}
+
+	return &genscim.ScimSchema{
+		Schemas:     []string{schemaSchema},
+		ID:          def.id,
+		Name:        def.name,
+		Description: &description,
+		Attributes:  attributes,
+		Meta:        s.discoveryMeta("Schema", "/Schemas/"+def.id),
+	}
+}
+
+// discoveryMeta returns the metadata of a discovery resource, which carries no timestamps.
+func (s *service) discoveryMeta(resourceType, path string) *genscim.ScimMeta {
+	meta := s.meta(resourceType, path, "", "")
+	meta.Created, meta.LastModified = nil, nil
+	return meta
+}
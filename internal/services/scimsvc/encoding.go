@@ -0,0 +1,95 @@
+package scimsvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	goahttp "goa.design/goa/v3/http"
+	goa "goa.design/goa/v3/pkg"
+)
+
+// ContentType is the media type of SCIM requests and responses (RFC 7644 section 3.1).
+const ContentType = "application/scim+json"
+
+// scimTypes maps error names to the scimType of the error messages they are rendered as.
+var scimTypes = map[string]string{
+	"uniqueness":     "uniqueness",
+	"invalid_filter": "invalidFilter",
+	"invalid_value":  "invalidValue",
+	"invalid_syntax": "invalidSyntax",
+	"invalid_path":   "invalidPath",
+	"no_target":      "noTarget",
+	"mutability":     "mutability",
+}
+
+// errorStatuses maps error names to the HTTP status of the error messages they are rendered as.
+var errorStatuses = map[string]int{
+	"unauthorized":    http.StatusUnauthorized,
+	"invalid_token":   http.StatusUnauthorized,
+	"session_expired": http.StatusUnauthorized,
+	"forbidden":       http.StatusForbidden,
+	"not_found":       http.StatusNotFound,
+	"uniqueness":      http.StatusConflict,
+	"invalid_filter":  http.StatusBadRequest,
+	"invalid_value":   http.StatusBadRequest,
+	"invalid_syntax":  http.StatusBadRequest,
+	"invalid_path":    http.StatusBadRequest,
+	"no_target":       http.StatusBadRequest,
+	"mutability":      http.StatusBadRequest,
+
+	goa.UnsupportedMediaType: http.StatusUnsupportedMediaType,
+}
+
+// ErrorResponse is a SCIM error message (RFC 7644 section 3.12).
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+	status   int
+}
+
+// StatusCode returns the HTTP status of the error message.
+func (e *ErrorResponse) StatusCode() int { return e.status }
+
+// FormatError renders errors as SCIM error messages. Payload validation errors raised by the
+// generated decoders are reported as invalid values, and malformed bodies as invalid syntax.
+func FormatError(ctx context.Context, err error) goahttp.Statuser {
+	name := ""
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		name = serviceErr.Name
+	}
+
+	switch name {
+	case "decode_payload":
+		name = "invalid_syntax"
+	case "missing_payload", goa.MissingField, goa.InvalidFieldType, goa.InvalidFormat,
+		goa.InvalidPattern, goa.InvalidRange, goa.InvalidLength, goa.InvalidEnumValue:
+		name = "invalid_value"
+	}
+
+	status, ok := errorStatuses[name]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	return &ErrorResponse{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimTypes[name],
+		Detail:   err.Error(),
+		status:   status,
+	}
+}
+
+// ResponseEncoder encodes responses as SCIM messages. Error responses don't carry a content
+// type of their own, so they would otherwise be rendered as plain JSON.
+func ResponseEncoder(ctx context.Context, w http.ResponseWriter) goahttp.Encoder {
+	if ctx.Value(goahttp.ContentTypeKey) == nil {
+		ctx = context.WithValue(ctx, goahttp.ContentTypeKey, ContentType)
+	}
+	return goahttp.ResponseEncoder(ctx, w)
+}
@@ -0,0 +1,316 @@
+package scimsvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// attrType is the type of a filterable attribute.
+type attrType int
+
+// Types of filterable attributes.
+const (
+	attrString attrType = iota
+	attrBoolean
+)
+
+// attrDef describes an attribute filters may reference.
+type attrDef struct {
+	typ       attrType // Type of the attribute's values
+	caseExact bool     // Whether string comparisons are case sensitive
+}
+
+// attrSet maps lower-cased attribute paths to their definitions. A multi-valued complex
+// attribute is also addressable by its name alone, which refers to its value sub-attribute.
+type attrSet map[string]attrDef
+
+// attrValues holds the values of a resource by lower-cased attribute path.
+type attrValues map[string][]any
+
+// filter is a parsed filter expression (RFC 7644 section 3.4.2.2).
+type filter interface {
+	matches(values attrValues) bool
+}
+
+// logicalFilter joins two filters with "and" or "or".
+type logicalFilter struct {
+	or          bool
+	left, right filter
+}
+
+// matches reports whether both, or for "or" either, of the joined filters match.
+func (f logicalFilter) matches(values attrValues) bool {
+	if f.or {
+		return f.left.matches(values) || f.right.matches(values)
+	}
+	return f.left.matches(values) && f.right.matches(values)
+}
+
+// compareFilter compares the values of an attribute with a literal.
+type compareFilter struct {
+	path  string
+	def   attrDef
+	op    string
+	value any
+}
+
+// matches reports whether any value of the attribute satisfies the comparison.
+func (f compareFilter) matches(values attrValues) bool {
+	for _, v := range values[f.path] {
+		switch actual := v.(type) {
+		case bool:
+			if expected, ok := f.value.(bool); ok && actual == expected {
+				return true
+			}
+		case string:
+			expected, ok := f.value.(string)
+			if !ok {
+				continue
+			}
+			if !f.def.caseExact {
+				actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+			}
+			switch f.op {
+			case "eq":
+				if actual == expected {
+					return true
+				}
+			case "co":
+				if strings.Contains(actual, expected) {
+					return true
+				}
+			case "sw":
+				if strings.HasPrefix(actual, expected) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseFilter parses a filter expression referencing the given attributes. Attribute names
+// may be qualified with the resource's schema URN. Only the eq, co and sw operators and the
+// and and or logical operators are supported, grouped with parentheses.
+func parseFilter(expr string, attrs attrSet, schema string) (filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("filter is empty")
+	}
+
+	p := &filterParser{tokens: tokens, attrs: attrs, schema: strings.ToLower(schema) + ":"}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in filter", p.peek().text)
+	}
+	return f, nil
+}
+
+// token is a lexical element of a filter expression.
+type token struct {
+	text   string // Raw text of the token
+	quoted bool   // Whether the token is a string literal
+}
+
+// tokenize splits a filter expression into parentheses, string literals and words.
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+
+			var literal string
+			if err := json.Unmarshal([]byte(expr[i:end+1]), &literal); err != nil {
+				return nil, fmt.Errorf("invalid string in filter: %w", err)
+			}
+			tokens = append(tokens, token{text: literal, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expr) && !unicode.IsSpace(rune(expr[end])) && expr[end] != '(' && expr[end] != ')' && expr[end] != '"' {
+				end++
+			}
+			tokens = append(tokens, token{text: expr[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser over filter tokens.
+type filterParser struct {
+	tokens []token
+	pos    int
+	attrs  attrSet
+	schema string // Lower-cased schema URN prefix attribute names may carry
+}
+
+func (p *filterParser) done() bool  { return p.pos >= len(p.tokens) }
+func (p *filterParser) peek() token { return p.tokens[p.pos] }
+
+// next consumes and returns the next token, failing at the end of the expression.
+func (p *filterParser) next() (token, error) {
+	if p.done() {
+		return token{}, fmt.Errorf("filter ends unexpectedly")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+// keyword consumes the next token if it's the given unquoted keyword.
+func (p *filterParser) keyword(word string) bool {
+	if p.done() || p.peek().quoted || !strings.EqualFold(p.peek().text, word) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// parseOr parses expressions joined with "or", which binds weaker than "and".
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses expressions joined with "and".
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAtom parses a parenthesized expression or an attribute comparison.
+func (p *filterParser) parseAtom() (filter, error) {
+	if p.keyword("(") {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing closing parenthesis in filter")
+		}
+		return f, nil
+	}
+
+	attr, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	path, def, err := p.attribute(attr)
+	if err != nil {
+		return nil, err
+	}
+
+	opToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(opToken.text)
+	switch {
+	case opToken.quoted:
+		return nil, fmt.Errorf("expected an operator after %s", attr.text)
+	case op != "eq" && op != "co" && op != "sw":
+		return nil, fmt.Errorf("operator %q isn't supported", opToken.text)
+	}
+
+	literal, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := compareValue(literal, def, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return compareFilter{path: path, def: def, op: op, value: value}, nil
+}
+
+// attribute resolves an attribute path token to a known attribute.
+func (p *filterParser) attribute(t token) (string, attrDef, error) {
+	if t.quoted || t.text == "(" || t.text == ")" {
+		return "", attrDef{}, fmt.Errorf("expected an attribute, got %q", t.text)
+	}
+
+	path := strings.TrimPrefix(strings.ToLower(t.text), p.schema)
+	if def, ok := p.attrs[path]; ok {
+		return path, def, nil
+	}
+	if def, ok := p.attrs[path+".value"]; ok {
+		return path + ".value", def, nil
+	}
+	return "", attrDef{}, fmt.Errorf("attribute %q isn't filterable", t.text)
+}
+
+// compareValue converts a literal token to a value comparable with the attribute.
+func compareValue(t token, def attrDef, op string) (any, error) {
+	switch def.typ {
+	case attrBoolean:
+		if t.quoted || op != "eq" {
+			return nil, fmt.Errorf("boolean attributes only support eq true or false")
+		}
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected true or false, got %q", t.text)
+	default:
+		if !t.quoted {
+			return nil, fmt.Errorf("expected a string, got %q", t.text)
+		}
+		return t.text, nil
+	}
+}
+
+// parseListFilter parses the optional filter of a list request, returning nil when absent.
+func parseListFilter(expr *string, attrs attrSet, schema string) (filter, error) {
+	if expr == nil || strings.TrimSpace(*expr) == "" {
+		return nil, nil
+	}
+	return parseFilter(*expr, attrs, schema)
+}
@@ -0,0 +1,292 @@
+package scimsvc
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// groupFields are the group attributes provisioning clients can write.
+type groupFields struct {
+	displayName string   // Unique name of the group
+	externalID  string   // Identifier of the group in the provisioning client
+	members     []string // IDs of the member users
+}
+
+// ListGroups returns a page of the groups matching the filter, ordered by creation time.
+func (s *service) ListGroups(ctx context.Context, req *genscim.ScimListRequest) (*genscim.ScimGroupList, error) {
+	logger.FromContext(ctx).Infow("scim list groups request received", "filter", req.Filter, "startIndex", req.StartIndex, "count", req.Count)
+
+	f, err := parseListFilter(req.Filter, groupAttributes, schemaGroup)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim list groups error", "error", err)
+		return nil, genscim.MakeInvalidFilter(err)
+	}
+
+	groups, err := s.groupStore.List(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim list groups error", "error", err)
+		return nil, genscim.MakeInternalServerError(err)
+	}
+	slices.SortFunc(groups, func(a, b *groupstore.Group) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	matched := make([]*groupstore.Group, 0, len(groups))
+	for _, g := range groups {
+		if f == nil || f.matches(groupValues(g)) {
+			matched = append(matched, g)
+		}
+	}
+
+	selected, startIndex := page(s, matched, req.StartIndex, req.Count)
+	resources := make([]*genscim.ScimGroup, 0, len(selected))
+	for _, g := range selected {
+		res, err := s.groupResource(ctx, g)
+		if err != nil {
+			logger.FromContext(ctx).Infow("scim list groups error", "groupId", g.ID, "error", err)
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+
+	logger.FromContext(ctx).Infow("scim list groups request successful", "totalResults", len(matched), "itemsPerPage", len(resources))
+	return &genscim.ScimGroupList{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetGroup retrieves a group.
+func (s *service) GetGroup(ctx context.Context, req *genscim.ScimResourceRequest) (*genscim.ScimGroup, error) {
+	logger.FromContext(ctx).Infow("scim get group request received", "groupId", req.ID)
+
+	g, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim get group error", "groupId", req.ID, "error", err)
+		return nil, genscim.MakeNotFound(err)
+	}
+
+	res, err := s.groupResource(ctx, g)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim get group error", "groupId", req.ID, "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("scim get group request successful", "groupId", req.ID)
+	return res, nil
+}
+
+// CreateGroup provisions a group. Members must be existing users.
+func (s *service) CreateGroup(ctx context.Context, req *genscim.ScimGroupRequest) (*genscim.ScimGroup, error) {
+	logger.FromContext(ctx).Infow("scim create group request received", "displayName", req.DisplayName, "externalId", req.ExternalID)
+
+	fields, err := s.groupFieldsFromRequest(ctx, req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create group error", "displayName", req.DisplayName, "error", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	g := &groupstore.Group{
+		ID:          uuid.New().String(),
+		TenantID:    tenancy.FromContext(ctx),
+		DisplayName: fields.displayName,
+		ExternalID:  fields.externalID,
+		Members:     fields.members,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.groupStore.Create(ctx, g); err != nil {
+		logger.FromContext(ctx).Infow("scim create group error", "displayName", req.DisplayName, "error", err)
+		return nil, genscim.MakeUniqueness(err)
+	}
+
+	res, err := s.groupResource(ctx, g)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create group error", "groupId", g.ID, "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("scim create group request successful", "groupId", g.ID)
+	return res, nil
+}
+
+// ReplaceGroup replaces the attributes and members of a group.
+func (s *service) ReplaceGroup(ctx context.Context, req *genscim.ScimGroupRequest) (*genscim.ScimGroup, error) {
+	groupID := ""
+	if req.ID != nil {
+		groupID = *req.ID
+	}
+	logger.FromContext(ctx).Infow("scim replace group request received", "groupId", groupID, "displayName", req.DisplayName)
+
+	existing, err := s.queryGroup(ctx, groupID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace group error", "groupId", groupID, "error", err)
+		return nil, genscim.MakeNotFound(err)
+	}
+
+	fields, err := s.groupFieldsFromRequest(ctx, req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace group error", "groupId", groupID, "error", err)
+		return nil, err
+	}
+
+	res, err := s.saveGroup(ctx, existing, fields)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace group error", "groupId", groupID, "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("scim replace group request successful", "groupId", groupID)
+	return res, nil
+}
+
+// PatchGroup applies the operations of a PATCH request to a group in order. The request
+// fails without modifying the group if any operation is invalid.
+func (s *service) PatchGroup(ctx context.Context, req *genscim.ScimPatchRequest) (*genscim.ScimGroup, error) {
+	logger.FromContext(ctx).Infow("scim patch group request received", "groupId", req.ID, "operations", len(req.Operations))
+
+	existing, err := s.queryGroup(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim patch group error", "groupId", req.ID, "error", err)
+		return nil, genscim.MakeNotFound(err)
+	}
+
+	fields := groupFields{
+		displayName: existing.DisplayName,
+		externalID:  existing.ExternalID,
+		members:     slices.Clone(existing.Members),
+	}
+	if err := validatePatchRequest(req); err != nil {
+		logger.FromContext(ctx).Infow("scim patch group error", "groupId", req.ID, "error", err)
+		return nil, err
+	}
+	for _, op := range req.Operations {
+		if err := fields.apply(op); err != nil {
+			logger.FromContext(ctx).Infow("scim patch group error", "groupId", req.ID, "op", op.Op, "path", op.Path, "error", err)
+			return nil, err
+		}
+	}
+	if err := s.validateGroupFields(ctx, &fields); err != nil {
+		logger.FromContext(ctx).Infow("scim patch group error", "groupId", req.ID, "error", err)
+		return nil, err
+	}
+
+	res, err := s.saveGroup(ctx, existing, fields)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim patch group error", "groupId", req.ID, "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("scim patch group request successful", "groupId", req.ID)
+	return res, nil
+}
+
+// DeleteGroup deprovisions a group. Its members are left untouched.
+func (s *service) DeleteGroup(ctx context.Context, req *genscim.ScimResourceRequest) error {
+	logger.FromContext(ctx).Infow("scim delete group request received", "groupId", req.ID)
+
+	if _, err := s.queryGroup(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete group error", "groupId", req.ID, "error", err)
+		return genscim.MakeNotFound(err)
+	}
+
+	if err := s.groupStore.Delete(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete group error", "groupId", req.ID, "error", err)
+		return genscim.MakeNotFound(err)
+	}
+
+	logger.FromContext(ctx).Infow("scim delete group request successful", "groupId", req.ID)
+	return nil
+}
+
+// groupFieldsFromRequest extracts and validates the writable attributes of a group resource.
+func (s *service) groupFieldsFromRequest(ctx context.Context, req *genscim.ScimGroupRequest) (groupFields, error) {
+	fields := groupFields{displayName: req.DisplayName}
+	if req.ExternalID != nil {
+		fields.externalID = *req.ExternalID
+	}
+	for _, member := range req.Members {
+		if !slices.Contains(fields.members, member.Value) {
+			fields.members = append(fields.members, member.Value)
+		}
+	}
+	return fields, s.validateGroupFields(ctx, &fields)
+}
+
+// validateGroupFields checks that the group has a display name and that every member is an
+// existing user.
+func (s *service) validateGroupFields(ctx context.Context, fields *groupFields) error {
+	fields.displayName = strings.TrimSpace(fields.displayName)
+	if fields.displayName == "" {
+		return genscim.MakeInvalidValue(fmt.Errorf("displayName is required"))
+	}
+
+	for _, id := range fields.members {
+		if _, err := userstore.QueryTenantUser(ctx, s.userStore, tenancy.FromContext(ctx), id); err != nil {
+			return genscim.MakeInvalidValue(fmt.Errorf("member %s isn't an existing user", id))
+		}
+	}
+	return nil
+}
+
+// saveGroup writes the fields to a stored group and returns the updated resource.
+func (s *service) saveGroup(ctx context.Context, existing *groupstore.Group, fields groupFields) (*genscim.ScimGroup, error) {
+	updated := *existing
+	updated.DisplayName = fields.displayName
+	updated.ExternalID = fields.externalID
+	updated.Members = fields.members
+
+	if err := s.groupStore.Update(ctx, &updated); err != nil {
+		return nil, genscim.MakeUniqueness(err)
+	}
+
+	g, err := s.groupStore.QueryByID(ctx, updated.ID)
+	if err != nil {
+		return nil, genscim.MakeInternalServerError(err)
+	}
+	return s.groupResource(ctx, g)
+}
+
+// groupResource converts a stored group into a SCIM group resource, displaying members by name.
+func (s *service) groupResource(ctx context.Context, g *groupstore.Group) (*genscim.ScimGroup, error) {
+	users := make(map[string]*genuser.User, len(g.Members))
+	for _, id := range g.Members {
+		u, err := userstore.QueryTenantUser(ctx, s.userStore, g.TenantID, id)
+		if err != nil {
+			continue
+		}
+		users[id] = u
+	}
+	return s.toScimGroup(g, users), nil
+}
+
+// queryGroup retrieves a group of the request's tenant. Groups of other tenants are reported
+// as missing.
+func (s *service) queryGroup(ctx context.Context, groupID string) (*groupstore.Group, error) {
+	g, err := s.groupStore.QueryByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if g.TenantID != tenancy.FromContext(ctx) {
+		return nil, fmt.Errorf("group with id %s doesn't exist", groupID)
+	}
+	return g, nil
+}
@@ -0,0 +1,421 @@
+package scimsvc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+)
+
+// PATCH operation types (RFC 7644 section 3.5.2).
+const (
+	opAdd     = "add"
+	opRemove  = "remove"
+	opReplace = "replace"
+)
+
+// emailAttributes are the sub-attributes value filters on emails may reference.
+var emailAttributes = attrSet{
+	"value":   {},
+	"type":    {},
+	"primary": {typ: attrBoolean},
+}
+
+// memberAttributes are the sub-attributes value filters on members may reference.
+var memberAttributes = attrSet{
+	"value": {caseExact: true},
+}
+
+// patchPath is a parsed PATCH operation path such as name.givenName or
+// emails[type eq "work"].value, with attribute names lower-cased.
+type patchPath struct {
+	attr   string // Attribute the operation targets
+	filter string // Value filter selecting elements of a multi-valued attribute, if any
+	sub    string // Sub-attribute the operation targets, if any
+}
+
+// String returns the path in its canonical lower-cased form.
+func (p patchPath) String() string {
+	s := p.attr
+	if p.filter != "" {
+		s += "[" + p.filter + "]"
+	}
+	if p.sub != "" {
+		s += "." + p.sub
+	}
+	return s
+}
+
+// parsePatchPath parses an operation path, which may be qualified with the resource's schema URN.
+func parsePatchPath(path, schema string) (patchPath, error) {
+	path = strings.TrimSpace(path)
+	if prefix := schema + ":"; len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+		path = path[len(prefix):]
+	}
+
+	var p patchPath
+	rest := path
+	if open := strings.IndexByte(path, '['); open >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < open {
+			return patchPath{}, genscim.MakeInvalidPath(fmt.Errorf("path %q has an unterminated filter", path))
+		}
+		p.attr, p.filter, rest = path[:open], path[open+1:end], path[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, ".") {
+			return patchPath{}, genscim.MakeInvalidPath(fmt.Errorf("path %q is malformed", path))
+		}
+		rest = strings.TrimPrefix(rest, ".")
+		p.sub = rest
+	} else {
+		p.attr, p.sub, _ = strings.Cut(path, ".")
+	}
+
+	p.attr, p.sub = strings.ToLower(p.attr), strings.ToLower(p.sub)
+	if p.attr == "" {
+		return patchPath{}, genscim.MakeInvalidPath(fmt.Errorf("path %q is malformed", path))
+	}
+	return p, nil
+}
+
+// validatePatchRequest checks that a request is a PATCH message with at least one operation
+// of a known type.
+func validatePatchRequest(req *genscim.ScimPatchRequest) error {
+	if !slices.Contains(req.Schemas, schemaPatchOp) {
+		return genscim.MakeInvalidSyntax(fmt.Errorf("schemas must contain %s", schemaPatchOp))
+	}
+	if len(req.Operations) == 0 {
+		return genscim.MakeInvalidSyntax(fmt.Errorf("request contains no operations"))
+	}
+	for _, op := range req.Operations {
+		switch strings.ToLower(op.Op) {
+		case opAdd, opRemove, opReplace:
+		default:
+			return genscim.MakeInvalidSyntax(fmt.Errorf("operation %q isn't supported", op.Op))
+		}
+	}
+	return nil
+}
+
+// patchTargets calls set for every attribute an operation targets. Operations without a
+// path carry an object whose members are the attributes to modify.
+func patchTargets(op *genscim.ScimPatchOperation, schema string, set func(kind string, path patchPath, value any) error) error {
+	kind := strings.ToLower(op.Op)
+
+	if op.Path == nil || strings.TrimSpace(*op.Path) == "" {
+		if kind == opRemove {
+			return genscim.MakeNoTarget(fmt.Errorf("remove operations require a path"))
+		}
+
+		values, ok := op.Value.(map[string]any)
+		if !ok {
+			return genscim.MakeInvalidValue(fmt.Errorf("operations without a path require an object value"))
+		}
+		for key, value := range values {
+			path, err := parsePatchPath(key, schema)
+			if err != nil {
+				return err
+			}
+			if err := set(kind, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := parsePatchPath(*op.Path, schema)
+	if err != nil {
+		return err
+	}
+	return set(kind, path, op.Value)
+}
+
+// apply applies a PATCH operation to the fields of a user.
+func (f *userFields) apply(op *genscim.ScimPatchOperation) error {
+	return patchTargets(op, schemaUser, func(kind string, path patchPath, value any) error {
+		if kind == opRemove {
+			return f.remove(path)
+		}
+		return f.set(path, value)
+	})
+}
+
+// set adds or replaces a user attribute. Both have the same effect, as every writable
+// attribute is single-valued.
+func (f *userFields) set(path patchPath, value any) error {
+	switch path.attr {
+	case "username":
+		return setString(path, value, &f.email)
+	case "externalid":
+		s, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		f.externalID = &s
+	case "active":
+		active, err := boolValue(path, value)
+		if err != nil {
+			return err
+		}
+		f.active = active
+	case "displayname":
+		s, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		f.firstName, f.lastName = splitName(s)
+	case "name":
+		return f.setName(path, value)
+	case "emails":
+		return f.setEmail(path, value)
+	case "password":
+		s, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		_, err = provisionedPassword(&s)
+		return err
+	case "id", "groups", "meta", "schemas":
+		return genscim.MakeMutability(fmt.Errorf("%s can't be modified", path))
+	default:
+		return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+	}
+	return nil
+}
+
+// setName sets the name of a user, either as a whole or one component at a time.
+func (f *userFields) setName(path patchPath, value any) error {
+	switch path.sub {
+	case "":
+		components, ok := value.(map[string]any)
+		if !ok {
+			return genscim.MakeInvalidValue(fmt.Errorf("%s must be an object", path))
+		}
+		for key, component := range components {
+			if err := f.setName(patchPath{attr: path.attr, sub: strings.ToLower(key)}, component); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "givenname":
+		return setString(path, value, &f.firstName)
+	case "familyname":
+		return setString(path, value, &f.lastName)
+	case "formatted":
+		s, err := stringValue(path, value)
+		if err != nil {
+			return err
+		}
+		f.firstName, f.lastName = splitName(s)
+		return nil
+	default:
+		return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+	}
+}
+
+// setEmail sets the email address of a user. Users have a single work email address, which
+// is also their userName, so every email attribute path targets it.
+func (f *userFields) setEmail(path patchPath, value any) error {
+	if path.filter != "" {
+		matched, err := f.emailMatches(path)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return genscim.MakeNoTarget(fmt.Errorf("%s matches no email address", path))
+		}
+	}
+
+	switch path.sub {
+	case "value":
+		return setString(path, value, &f.email)
+	case "":
+	default:
+		return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+	}
+
+	// Without a sub-attribute the value is an email object, or a list of them when no filter
+	// selects an element, of which the primary or else the first address is used.
+	emails, ok := value.([]any)
+	if !ok || path.filter != "" {
+		emails = []any{value}
+	}
+
+	var email map[string]any
+	for _, v := range emails {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return genscim.MakeInvalidValue(fmt.Errorf("%s must contain email objects", path))
+		}
+		if email == nil || obj["primary"] == true {
+			email = obj
+		}
+	}
+	if email == nil {
+		return genscim.MakeInvalidValue(fmt.Errorf("%s must contain an email address", path))
+	}
+	return setString(patchPath{attr: path.attr, sub: "value"}, email["value"], &f.email)
+}
+
+// emailMatches reports whether the value filter of an emails path selects the user's address.
+func (f *userFields) emailMatches(path patchPath) (bool, error) {
+	selector, err := parseFilter(path.filter, emailAttributes, "")
+	if err != nil {
+		return false, genscim.MakeInvalidFilter(err)
+	}
+	return selector.matches(attrValues{"value": {f.email}, "type": {emailTypeWork}, "primary": {true}}), nil
+}
+
+// remove removes a user attribute. The userName and email address are required.
+func (f *userFields) remove(path patchPath) error {
+	switch {
+	case path.attr == "externalid":
+		f.externalID = nil
+	case path.attr == "displayname", path.attr == "name" && (path.sub == "" || path.sub == "formatted"):
+		f.firstName, f.lastName = "", ""
+	case path.attr == "name" && path.sub == "givenname":
+		f.firstName = ""
+	case path.attr == "name" && path.sub == "familyname":
+		f.lastName = ""
+	case path.attr == "username", path.attr == "emails", path.attr == "active",
+		path.attr == "id", path.attr == "groups", path.attr == "meta", path.attr == "schemas":
+		return genscim.MakeMutability(fmt.Errorf("%s can't be removed", path))
+	default:
+		return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+	}
+	return nil
+}
+
+// apply applies a PATCH operation to the fields of a group.
+func (f *groupFields) apply(op *genscim.ScimPatchOperation) error {
+	return patchTargets(op, schemaGroup, func(kind string, path patchPath, value any) error {
+		switch path.attr {
+		case "displayname":
+			if kind == opRemove {
+				return genscim.MakeMutability(fmt.Errorf("%s can't be removed", path))
+			}
+			return setString(path, value, &f.displayName)
+		case "externalid":
+			if kind == opRemove {
+				f.externalID = ""
+				return nil
+			}
+			return setString(path, value, &f.externalID)
+		case "members":
+			return f.patchMembers(kind, path, value)
+		case "id", "meta", "schemas":
+			return genscim.MakeMutability(fmt.Errorf("%s can't be modified", path))
+		default:
+			return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+		}
+	})
+}
+
+// patchMembers adds, replaces or removes group members. Members to remove are selected by
+// a value filter in the path or listed in the value; removing without either removes all.
+func (f *groupFields) patchMembers(kind string, path patchPath, value any) error {
+	if path.sub != "" && path.sub != "value" {
+		return genscim.MakeInvalidPath(fmt.Errorf("attribute %s isn't supported", path))
+	}
+
+	if kind != opRemove {
+		if path.filter != "" {
+			return genscim.MakeInvalidPath(fmt.Errorf("%s can only be used to remove members", path))
+		}
+		ids, err := memberValues(path, value)
+		if err != nil {
+			return err
+		}
+		if kind == opReplace {
+			f.members = nil
+		}
+		for _, id := range ids {
+			if !slices.Contains(f.members, id) {
+				f.members = append(f.members, id)
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case path.filter != "":
+		selector, err := parseFilter(path.filter, memberAttributes, "")
+		if err != nil {
+			return genscim.MakeInvalidFilter(err)
+		}
+		remaining := slices.DeleteFunc(slices.Clone(f.members), func(id string) bool {
+			return selector.matches(attrValues{"value": {id}})
+		})
+		if len(remaining) == len(f.members) {
+			return genscim.MakeNoTarget(fmt.Errorf("%s matches no member", path))
+		}
+		f.members = remaining
+	case value != nil:
+		ids, err := memberValues(path, value)
+		if err != nil {
+			return err
+		}
+		f.members = slices.DeleteFunc(f.members, func(id string) bool { return slices.Contains(ids, id) })
+	default:
+		f.members = nil
+	}
+	return nil
+}
+
+// memberValues returns the user IDs of a member object or a list of them.
+func memberValues(path patchPath, value any) ([]string, error) {
+	members, ok := value.([]any)
+	if !ok {
+		members = []any{value}
+	}
+
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		obj, ok := member.(map[string]any)
+		if !ok {
+			return nil, genscim.MakeInvalidValue(fmt.Errorf("%s must contain member objects", path))
+		}
+		id, ok := obj["value"].(string)
+		if !ok || id == "" {
+			return nil, genscim.MakeInvalidValue(fmt.Errorf("%s members must have a value", path))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// setString sets a string attribute to the operation's value.
+func setString(path patchPath, value any, dst *string) error {
+	s, err := stringValue(path, value)
+	if err != nil {
+		return err
+	}
+	*dst = s
+	return nil
+}
+
+// stringValue returns an operation's value as a string.
+func stringValue(path patchPath, value any) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", genscim.MakeInvalidValue(fmt.Errorf("%s must be a string", path))
+	}
+	return s, nil
+}
+
+// boolValue returns an operation's value as a boolean. Some identity providers send booleans
+// as the strings "True" and "False", which are accepted as well.
+func boolValue(path patchPath, value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, genscim.MakeInvalidValue(fmt.Errorf("%s must be a boolean", path))
+}
@@ -0,0 +1,165 @@
+package scimsvc
+
+import (
+	"strings"
+	"time"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	groupstore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store"
+)
+
+// Types of email addresses and group references reported in resources.
+const (
+	emailTypeWork    = "work"
+	memberTypeUser   = "User"
+	memberTypeDirect = "direct"
+)
+
+// userAttributes are the user attributes filters may reference.
+var userAttributes = attrSet{
+	"id":                {caseExact: true},
+	"externalid":        {caseExact: true},
+	"username":          {},
+	"name.givenname":    {},
+	"name.familyname":   {},
+	"name.formatted":    {},
+	"displayname":       {},
+	"emails.value":      {},
+	"emails.type":       {},
+	"active":            {typ: attrBoolean},
+	"groups.value":      {caseExact: true},
+	"groups.display":    {},
+	"meta.created":      {caseExact: true},
+	"meta.lastmodified": {caseExact: true},
+}
+
+// groupAttributes are the group attributes filters may reference.
+var groupAttributes = attrSet{
+	"id":                {caseExact: true},
+	"externalid":        {caseExact: true},
+	"displayname":       {},
+	"members.value":     {caseExact: true},
+	"meta.created":      {caseExact: true},
+	"meta.lastmodified": {caseExact: true},
+}
+
+// userValues returns the filterable attribute values of a user belonging to the given groups.
+func userValues(u *genuser.User, groups []*groupstore.Group) attrValues {
+	values := attrValues{
+		"id":                {u.ID},
+		"username":          {u.Email},
+		"name.givenname":    {u.FirstName},
+		"name.familyname":   {u.LastName},
+		"name.formatted":    {formatName(u)},
+		"displayname":       {formatName(u)},
+		"emails.value":      {u.Email},
+		"emails.type":       {emailTypeWork},
+		"active":            {u.Status == userdomain.UserStatusActive},
+		"meta.created":      {u.CreatedAt},
+		"meta.lastmodified": {u.UpdatedAt},
+	}
+	if u.ExternalID != nil {
+		values["externalid"] = []any{*u.ExternalID}
+	}
+	for _, g := range groups {
+		values["groups.value"] = append(values["groups.value"], g.ID)
+		values["groups.display"] = append(values["groups.display"], g.DisplayName)
+	}
+	return values
+}
+
+// groupValues returns the filterable attribute values of a group.
+func groupValues(g *groupstore.Group) attrValues {
+	values := attrValues{
+		"id":                {g.ID},
+		"displayname":       {g.DisplayName},
+		"meta.created":      {g.CreatedAt.Format(time.RFC3339)},
+		"meta.lastmodified": {g.UpdatedAt.Format(time.RFC3339)},
+	}
+	if g.ExternalID != "" {
+		values["externalid"] = []any{g.ExternalID}
+	}
+	for _, member := range g.Members {
+		values["members.value"] = append(values["members.value"], member)
+	}
+	return values
+}
+
+// toScimUser converts a user belonging to the given groups into a SCIM user resource.
+// The user's email address doubles as its userName.
+func (s *service) toScimUser(u *genuser.User, groups []*groupstore.Group) *genscim.ScimUser {
+	formatted := formatName(u)
+	active := u.Status == userdomain.UserStatusActive
+	emailType, primary := emailTypeWork, true
+
+	res := &genscim.ScimUser{
+		Schemas:     []string{schemaUser},
+		ID:          &u.ID,
+		ExternalID:  u.ExternalID,
+		UserName:    &u.Email,
+		Name:        &genscim.ScimName{Formatted: &formatted, GivenName: &u.FirstName, FamilyName: &u.LastName},
+		DisplayName: &formatted,
+		Emails:      []*genscim.ScimEmail{{Value: u.Email, Type: &emailType, Primary: &primary}},
+		Active:      &active,
+		Groups:      make([]*genscim.ScimMember, 0, len(groups)),
+		Meta:        s.meta(resourceUser, "/Users/"+u.ID, u.CreatedAt, u.UpdatedAt),
+	}
+
+	for _, g := range groups {
+		display, memberType := g.DisplayName, memberTypeDirect
+		res.Groups = append(res.Groups, &genscim.ScimMember{Value: g.ID, Display: &display, Type: &memberType})
+	}
+	return res
+}
+
+// toScimGroup converts a group into a SCIM group resource. Members are displayed with the
+// names of the given users, keyed by ID.
+func (s *service) toScimGroup(g *groupstore.Group, users map[string]*genuser.User) *genscim.ScimGroup {
+	res := &genscim.ScimGroup{
+		Schemas:     []string{schemaGroup},
+		ID:          &g.ID,
+		DisplayName: &g.DisplayName,
+		Members:     make([]*genscim.ScimMember, 0, len(g.Members)),
+		Meta:        s.meta(resourceGroup, "/Groups/"+g.ID, g.CreatedAt.Format(time.RFC3339), g.UpdatedAt.Format(time.RFC3339)),
+	}
+	if g.ExternalID != "" {
+		res.ExternalID = &g.ExternalID
+	}
+
+	for _, id := range g.Members {
+		member := &genscim.ScimMember{Value: id}
+		memberType := memberTypeUser
+		member.Type = &memberType
+		if u, ok := users[id]; ok {
+			display := formatName(u)
+			member.Display = &display
+		}
+		res.Members = append(res.Members, member)
+	}
+	return res
+}
+
+// meta returns the metadata of a resource located at the given path below the base URL.
+func (s *service) meta(resourceType, path, created, lastModified string) *genscim.ScimMeta {
+	location := strings.TrimSuffix(s.cfg.BaseURL, "/") + path
+	return &genscim.ScimMeta{
+		ResourceType: resourceType,
+		Created:      &created,
+		LastModified: &lastModified,
+		Location:     &location,
+	}
+}
+
+// formatName returns the full name of a user.
+func formatName(u *genuser.User) string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// splitName splits a display name into a given and a family name at the first space.
+func splitName(name string) (string, string) {
+	given, family, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return given, strings.TrimSpace(family)
+}
@@ -0,0 +1,332 @@
+package scimsvc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/requestctx"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditstore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	groupmemorystore "github.com/iamBelugaa/goa-iam/internal/services/groupsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/scimsvc"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestSCIMService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SCIM Service Suite")
+}
+
+const (
+	schemaUser    = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaPatchOp = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+)
+
+// scimService is the generated service interface together with its JWTAuth handler.
+type scimService interface {
+	genscim.Service
+	genscim.Auther
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+var _ = Describe("SCIM service", func() {
+	var (
+		ctx        context.Context
+		svc        scimService
+		tm         *tokenmgr.JWTTokenManager
+		sessions   *session.Manager
+		auditStore auditstore.AuditStorer
+	)
+
+	createUser := func(email, given, family string) *genscim.ScimUser {
+		res, err := svc.CreateUser(ctx, &genscim.ScimUserRequest{
+			UserName: email,
+			Name:     &genscim.ScimName{GivenName: ptr(given), FamilyName: ptr(family)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	patch := func(ops ...*genscim.ScimPatchOperation) *genscim.ScimPatchRequest {
+		return &genscim.ScimPatchRequest{Schemas: []string{schemaPatchOp}, Operations: ops}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		log, err := logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions = session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn := jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		auditStore = auditmemorystore.NewMemoryStore()
+		svc = scimsvc.NewService(
+			usermemorystore.NewMemoryStore(), groupmemorystore.NewMemoryStore(), sessions, authn,
+			audit.NewRecorder(log, auditStore),
+			&config.SCIM{BaseURL: "https://iam.test/scim/v2", MaxResults: 2},
+		)
+	})
+
+	Describe("authentication", func() {
+		clientToken := func(scope string, permissions ...string) string {
+			claims := tm.StandardClaims("provisioner", tokenmgr.AccessToken)
+			claims.PrincipalType = tokenmgr.PrincipalClient
+			claims.ClientID = "provisioner"
+			claims.Scope = scope
+			claims.Permissions = permissions
+			token, err := tm.Generate(context.Background(), claims)
+			Expect(err).NotTo(HaveOccurred())
+			return token
+		}
+
+		It("accepts client tokens granted the provisioning permission and rejects others", func() {
+			allowed := clientToken("openid "+scimsvc.ScopeProvisioning, userdomain.PermissionProvision)
+			denied := clientToken("openid")
+			// The scope alone doesn't grant the permission.
+			scopeOnly := clientToken("openid " + scimsvc.ScopeProvisioning)
+
+			claims := tm.StandardClaims("user-1", tokenmgr.AccessToken)
+			sess, err := sessions.Create(ctx, "user-1", requestctx.Metadata{})
+			Expect(err).NotTo(HaveOccurred())
+			claims.SessionID = sess.ID
+			claims.Scope = scimsvc.ScopeProvisioning
+			claims.Permissions = []string{userdomain.PermissionProvision}
+			user, err := tm.Generate(context.Background(), claims)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Issued tokens only become valid one second after issuance.
+			time.Sleep(time.Second)
+
+			_, err = svc.JWTAuth(ctx, allowed, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.JWTAuth(ctx, denied, nil)
+			Expect(errorName(err)).To(Equal("forbidden"))
+
+			_, err = svc.JWTAuth(ctx, scopeOnly, nil)
+			Expect(errorName(err)).To(Equal("forbidden"))
+
+			_, err = svc.JWTAuth(ctx, user, nil)
+			Expect(errorName(err)).To(Equal("forbidden"))
+		})
+	})
+
+	Describe("users", func() {
+		It("provisions users with their email as userName", func() {
+			created := createUser("ada@example.com", "Ada", "Lovelace")
+			Expect(created.Schemas).To(ConsistOf(schemaUser))
+			Expect(*created.UserName).To(Equal("ada@example.com"))
+			Expect(*created.Active).To(BeTrue())
+			Expect(created.Emails).To(HaveLen(1))
+			Expect(*created.Meta.Location).To(Equal("https://iam.test/scim/v2/Users/" + *created.ID))
+
+			fetched, err := svc.GetUser(ctx, &genscim.ScimResourceRequest{ID: *created.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*fetched.DisplayName).To(Equal("Ada Lovelace"))
+		})
+
+		It("rejects duplicate and malformed userNames", func() {
+			createUser("ada@example.com", "Ada", "Lovelace")
+
+			_, err := svc.CreateUser(ctx, &genscim.ScimUserRequest{UserName: "ada@example.com"})
+			Expect(errorName(err)).To(Equal("uniqueness"))
+
+			_, err = svc.CreateUser(ctx, &genscim.ScimUserRequest{UserName: "Ada <ada@example.org>"})
+			Expect(errorName(err)).To(Equal("invalid_value"))
+		})
+
+		It("filters and paginates users in creation order", func() {
+			createUser("ada@example.com", "Ada", "Lovelace")
+			createUser("alan@example.com", "Alan", "Turing")
+			createUser("grace@example.org", "Grace", "Hopper")
+
+			res, err := svc.ListUsers(ctx, &genscim.ScimListRequest{Filter: ptr(`userName EQ "ADA@example.com"`), StartIndex: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.TotalResults).To(Equal(1))
+			Expect(*res.Resources[0].UserName).To(Equal("ada@example.com"))
+
+			res, err = svc.ListUsers(ctx, &genscim.ScimListRequest{
+				Filter:     ptr(`(name.givenName sw "a" and emails co "example.com") or urn:ietf:params:scim:schemas:core:2.0:User:userName eq "grace@example.org"`),
+				StartIndex: 1,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.TotalResults).To(Equal(3))
+			Expect(res.ItemsPerPage).To(Equal(2))
+
+			res, err = svc.ListUsers(ctx, &genscim.ScimListRequest{StartIndex: 3, Count: ptr(10)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.StartIndex).To(Equal(3))
+			Expect(res.Resources).To(HaveLen(1))
+
+			for _, expr := range []string{`userName gt "a"`, `title eq "x"`, `active eq "true"`, `(userName eq "a"`} {
+				_, err = svc.ListUsers(ctx, &genscim.ScimListRequest{Filter: ptr(expr), StartIndex: 1})
+				Expect(errorName(err)).To(Equal("invalid_filter"), expr)
+			}
+		})
+
+		It("applies patch operations in order", func() {
+			created := createUser("ada@example.com", "Ada", "Lovelace")
+
+			req := patch(
+				&genscim.ScimPatchOperation{Op: "Replace", Path: ptr("active"), Value: "False"},
+				&genscim.ScimPatchOperation{Op: "replace", Path: ptr(`emails[type eq "work"].value`), Value: "ada@example.org"},
+				&genscim.ScimPatchOperation{Op: "add", Value: map[string]any{
+					"externalId": "ext-1",
+					"name":       map[string]any{"familyName": "King"},
+				}},
+			)
+			req.ID = *created.ID
+			res, err := svc.PatchUser(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*res.Active).To(BeFalse())
+			Expect(*res.UserName).To(Equal("ada@example.org"))
+			Expect(*res.ExternalID).To(Equal("ext-1"))
+			Expect(*res.Name.FamilyName).To(Equal("King"))
+
+			req.ID = "missing"
+			_, err = svc.PatchUser(ctx, req)
+			Expect(errorName(err)).To(Equal("not_found"))
+
+			req = patch(&genscim.ScimPatchOperation{Op: "remove", Path: ptr("userName")})
+			req.ID = *created.ID
+			_, err = svc.PatchUser(ctx, req)
+			Expect(errorName(err)).To(Equal("mutability"))
+
+			req = patch(&genscim.ScimPatchOperation{Op: "replace", Path: ptr(`emails[type eq "home"].value`), Value: "x@example.org"})
+			req.ID = *created.ID
+			_, err = svc.PatchUser(ctx, req)
+			Expect(errorName(err)).To(Equal("no_target"))
+		})
+
+		It("ends the sessions of deactivated users", func() {
+			created := createUser("ada@example.com", "Ada", "Lovelace")
+			_, err := sessions.Create(ctx, *created.ID, requestctx.Metadata{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = svc.ReplaceUser(ctx, &genscim.ScimUserRequest{ID: created.ID, UserName: "ada@example.com", Active: ptr(false)})
+			Expect(err).NotTo(HaveOccurred())
+
+			active, err := sessions.List(ctx, *created.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(active).To(BeEmpty())
+		})
+
+		It("audits the provisioning of users", func() {
+			created := createUser("ada@example.com", "Ada", "Lovelace")
+			_, err := svc.PatchUser(ctx, &genscim.ScimPatchRequest{
+				ID:         *created.ID,
+				Schemas:    []string{schemaPatchOp},
+				Operations: []*genscim.ScimPatchOperation{{Op: "replace", Path: ptr("active"), Value: false}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(svc.DeleteUser(ctx, &genscim.ScimResourceRequest{ID: *created.ID})).To(Succeed())
+
+			events, err := auditStore.Query(ctx, auditstore.Filter{})
+			Expect(err).NotTo(HaveOccurred())
+			types := make([]string, 0, len(events))
+			for _, event := range events {
+				Expect(event.TargetID).To(Equal(*created.ID))
+				types = append(types, event.Type)
+			}
+			Expect(types).To(Equal([]string{
+				audit.EventUserDelete, audit.EventUserUpdate, audit.EventUserDeactivate, audit.EventUserCreate,
+			}))
+			Expect(events[3].Details["email"]).NotTo(ContainSubstring("ada@"))
+		})
+	})
+
+	Describe("groups", func() {
+		It("manages members and drops deleted users", func() {
+			ada := createUser("ada@example.com", "Ada", "Lovelace")
+			alan := createUser("alan@example.com", "Alan", "Turing")
+
+			group, err := svc.CreateGroup(ctx, &genscim.ScimGroupRequest{
+				DisplayName: "Engineering",
+				Members:     []*genscim.ScimMember{{Value: *ada.ID}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.Members).To(HaveLen(1))
+			Expect(*group.Members[0].Display).To(Equal("Ada Lovelace"))
+
+			_, err = svc.CreateGroup(ctx, &genscim.ScimGroupRequest{DisplayName: "engineering"})
+			Expect(errorName(err)).To(Equal("uniqueness"))
+
+			_, err = svc.CreateGroup(ctx, &genscim.ScimGroupRequest{DisplayName: "Ghosts", Members: []*genscim.ScimMember{{Value: "missing"}}})
+			Expect(errorName(err)).To(Equal("invalid_value"))
+
+			req := patch(
+				&genscim.ScimPatchOperation{Op: "add", Path: ptr("members"), Value: []any{map[string]any{"value": *alan.ID}}},
+				&genscim.ScimPatchOperation{Op: "remove", Path: ptr(`members[value eq "` + *ada.ID + `"]`)},
+			)
+			req.ID = *group.ID
+			group, err = svc.PatchGroup(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.Members).To(HaveLen(1))
+			Expect(group.Members[0].Value).To(Equal(*alan.ID))
+
+			fetched, err := svc.GetUser(ctx, &genscim.ScimResourceRequest{ID: *alan.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetched.Groups).To(HaveLen(1))
+
+			res, err := svc.ListGroups(ctx, &genscim.ScimListRequest{Filter: ptr(`members eq "` + *alan.ID + `"`), StartIndex: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.TotalResults).To(Equal(1))
+
+			Expect(svc.DeleteUser(ctx, &genscim.ScimResourceRequest{ID: *alan.ID})).To(Succeed())
+			group, err = svc.GetGroup(ctx, &genscim.ScimResourceRequest{ID: *group.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.Members).To(BeEmpty())
+		})
+	})
+
+	Describe("FormatError", func() {
+		It("renders SCIM error messages", func() {
+			res := scimsvc.FormatError(ctx, genscim.MakeUniqueness(errors.New("taken"))).(*scimsvc.ErrorResponse)
+			Expect(res.StatusCode()).To(Equal(http.StatusConflict))
+			Expect(res.Status).To(Equal("409"))
+			Expect(res.ScimType).To(Equal("uniqueness"))
+			Expect(res.Detail).To(Equal("taken"))
+
+			res = scimsvc.FormatError(ctx, goa.MissingFieldError("userName", "body")).(*scimsvc.ErrorResponse)
+			Expect(res.StatusCode()).To(Equal(http.StatusBadRequest))
+			Expect(res.ScimType).To(Equal("invalidValue"))
+		})
+	})
+})
@@ -0,0 +1,400 @@
+package scimsvc
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	genscim "github.com/iamBelugaa/goa-iam/gen/scim"
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
+)
+
+// Bounds of the length of passwords set through provisioning, matching user registration.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 128
+)
+
+// userFields are the user attributes provisioning clients can write.
+type userFields struct {
+	email      string  // Email address, provisioned as the userName
+	firstName  string  // Given name of the user
+	lastName   string  // Family name of the user
+	externalID *string // Identifier of the user in the provisioning client
+	active     bool    // Whether the user can sign in
+}
+
+// ListUsers returns a page of the users matching the filter, ordered by creation time.
+func (s *service) ListUsers(ctx context.Context, req *genscim.ScimListRequest) (*genscim.ScimUserList, error) {
+	logger.FromContext(ctx).Infow("scim list users request received", "filter", req.Filter, "startIndex", req.StartIndex, "count", req.Count)
+
+	f, err := parseListFilter(req.Filter, userAttributes, schemaUser)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim list users error", "error", err)
+		return nil, genscim.MakeInvalidFilter(err)
+	}
+
+	users, err := s.userStore.List(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim list users error", "error", err)
+		return nil, genscim.MakeInternalServerError(err)
+	}
+	slices.SortFunc(users, func(a, b *genuser.User) int {
+		return cmp.Or(strings.Compare(a.CreatedAt, b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	matched := make([]*genscim.ScimUser, 0, len(users))
+	for _, u := range users {
+		groups, err := s.groupStore.QueryByMember(ctx, u.ID)
+		if err != nil {
+			logger.FromContext(ctx).Infow("scim list users error", "userId", u.ID, "error", err)
+			return nil, genscim.MakeInternalServerError(err)
+		}
+		if f == nil || f.matches(userValues(u, groups)) {
+			matched = append(matched, s.toScimUser(u, groups))
+		}
+	}
+
+	resources, startIndex := page(s, matched, req.StartIndex, req.Count)
+
+	logger.FromContext(ctx).Infow("scim list users request successful", "totalResults", len(matched), "itemsPerPage", len(resources))
+	return &genscim.ScimUserList{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: len(matched),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetUser retrieves a user.
+func (s *service) GetUser(ctx context.Context, req *genscim.ScimResourceRequest) (*genscim.ScimUser, error) {
+	logger.FromContext(ctx).Infow("scim get user request received", "userId", req.ID)
+
+	u, err := s.queryUser(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim get user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+
+	res, err := s.userResource(ctx, u)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim get user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infow("scim get user request successful", "userId", req.ID)
+	return res, nil
+}
+
+// CreateUser provisions a user. Users provisioned without a password sign in through
+// their identity provider, so they get a random one.
+func (s *service) CreateUser(ctx context.Context, req *genscim.ScimUserRequest) (*genscim.ScimUser, error) {
+	logger.FromContext(ctx).Infow(
+		"scim create user request received",
+		"userName", redact.RedactEmail(req.UserName), "externalId", req.ExternalID,
+	)
+
+	fields, err := fieldsFromRequest(req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create user error", "userName", redact.RedactEmail(req.UserName), "error", err)
+		return nil, genscim.MakeInvalidValue(err)
+	}
+
+	password, err := provisionedPassword(req.Password)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create user error", "userName", redact.RedactEmail(req.UserName), "error", err)
+		return nil, err
+	}
+
+	if err := s.checkUserName(ctx, fields.email, ""); err != nil {
+		logger.FromContext(ctx).Infow("scim create user error", "userName", redact.RedactEmail(req.UserName), "error", err)
+		return nil, err
+	}
+
+	created, err := s.userStore.Create(ctx, tenancy.FromContext(ctx), &genuser.CreateUserRequest{
+		FirstName: fields.firstName,
+		LastName:  fields.lastName,
+		Email:     fields.email,
+		Password:  password,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create user error", "userName", redact.RedactEmail(req.UserName), "error", err)
+		return nil, genscim.MakeUniqueness(err)
+	}
+
+	res, err := s.saveUser(ctx, created, fields)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim create user error", "userId", created.ID, "error", err)
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventUserCreate, TargetID: created.ID, Details: provisioningDetails(fields)})
+
+	logger.FromContext(ctx).Infow("scim create user request successful", "userId", created.ID)
+	return res, nil
+}
+
+// ReplaceUser replaces the writable attributes of a user. Attributes missing from the
+// request are cleared, and a missing active attribute activates the user.
+func (s *service) ReplaceUser(ctx context.Context, req *genscim.ScimUserRequest) (*genscim.ScimUser, error) {
+	userID := ""
+	if req.ID != nil {
+		userID = *req.ID
+	}
+	logger.FromContext(ctx).Infow("scim replace user request received", "userId", userID, "userName", redact.RedactEmail(req.UserName))
+
+	existing, err := s.queryUser(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace user error", "userId", userID, "error", err)
+		return nil, err
+	}
+
+	fields, err := fieldsFromRequest(req)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace user error", "userId", userID, "error", err)
+		return nil, genscim.MakeInvalidValue(err)
+	}
+	if req.Password != nil {
+		if _, err := provisionedPassword(req.Password); err != nil {
+			logger.FromContext(ctx).Infow("scim replace user error", "userId", userID, "error", err)
+			return nil, err
+		}
+	}
+
+	if err := s.checkUserName(ctx, fields.email, existing.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim replace user error", "userId", userID, "error", err)
+		return nil, err
+	}
+
+	res, err := s.saveUser(ctx, existing, fields)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim replace user error", "userId", userID, "error", err)
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventUserUpdate, TargetID: userID, Details: provisioningDetails(fields)})
+
+	logger.FromContext(ctx).Infow("scim replace user request successful", "userId", userID)
+	return res, nil
+}
+
+// PatchUser applies the operations of a PATCH request to a user in order. The request
+// fails without modifying the user if any operation is invalid.
+func (s *service) PatchUser(ctx context.Context, req *genscim.ScimPatchRequest) (*genscim.ScimUser, error) {
+	logger.FromContext(ctx).Infow("scim patch user request received", "userId", req.ID, "operations", len(req.Operations))
+
+	existing, err := s.queryUser(ctx, req.ID)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+
+	fields := userFields{
+		email:      existing.Email,
+		firstName:  existing.FirstName,
+		lastName:   existing.LastName,
+		externalID: existing.ExternalID,
+		active:     existing.Status == userdomain.UserStatusActive,
+	}
+	if err := validatePatchRequest(req); err != nil {
+		logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+	for _, op := range req.Operations {
+		if err := fields.apply(op); err != nil {
+			logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "op", op.Op, "path", op.Path, "error", err)
+			return nil, err
+		}
+	}
+	if err := fields.validate(); err != nil {
+		logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "error", err)
+		return nil, genscim.MakeInvalidValue(err)
+	}
+
+	if err := s.checkUserName(ctx, fields.email, existing.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+
+	res, err := s.saveUser(ctx, existing, fields)
+	if err != nil {
+		logger.FromContext(ctx).Infow("scim patch user error", "userId", req.ID, "error", err)
+		return nil, err
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventUserUpdate, TargetID: req.ID, Details: provisioningDetails(fields)})
+
+	logger.FromContext(ctx).Infow("scim patch user request successful", "userId", req.ID)
+	return res, nil
+}
+
+// DeleteUser deprovisions a user, removing it from every group and ending its sessions.
+func (s *service) DeleteUser(ctx context.Context, req *genscim.ScimResourceRequest) error {
+	logger.FromContext(ctx).Infow("scim delete user request received", "userId", req.ID)
+
+	if _, err := s.queryUser(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete user error", "userId", req.ID, "error", err)
+		return err
+	}
+
+	if err := s.userStore.Delete(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete user error", "userId", req.ID, "error", err)
+		return genscim.MakeNotFound(err)
+	}
+
+	if err := s.groupStore.RemoveMember(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete user error", "userId", req.ID, "error", err)
+		return genscim.MakeInternalServerError(err)
+	}
+
+	if _, err := s.sessions.RevokeAll(ctx, req.ID); err != nil {
+		logger.FromContext(ctx).Infow("scim delete user error", "userId", req.ID, "error", err)
+		return genscim.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventUserDelete, TargetID: req.ID, Details: map[string]string{"source": "scim"}})
+
+	logger.FromContext(ctx).Infow("scim delete user request successful", "userId", req.ID)
+	return nil
+}
+
+// queryUser retrieves a user, failing with not_found if it doesn't exist.
+func (s *service) queryUser(ctx context.Context, userID string) (*genuser.User, error) {
+	u, err := userstore.QueryTenantUser(ctx, s.userStore, tenancy.FromContext(ctx), userID)
+	if err != nil {
+		return nil, genscim.MakeNotFound(err)
+	}
+	return u, nil
+}
+
+// checkUserName fails with uniqueness if a user other than the given one has the email address.
+func (s *service) checkUserName(ctx context.Context, email, userID string) error {
+	if other, err := s.userStore.QueryByEmail(ctx, tenancy.FromContext(ctx), email); err == nil && other != nil && other.ID != userID {
+		return genscim.MakeUniqueness(fmt.Errorf("user with userName %s already exists", redact.RedactEmail(email)))
+	}
+	return nil
+}
+
+// saveUser writes the fields to a stored user and returns the updated resource. Deactivating
+// a user ends its sessions, while suspended users stay suspended until deactivated.
+func (s *service) saveUser(ctx context.Context, existing *genuser.User, fields userFields) (*genscim.ScimUser, error) {
+	updated := *existing
+	updated.Email = fields.email
+	updated.FirstName = fields.firstName
+	updated.LastName = fields.lastName
+	updated.ExternalID = fields.externalID
+
+	switch {
+	case !fields.active:
+		updated.Status = userdomain.UserStatusInactive
+	case existing.Status == userdomain.UserStatusInactive:
+		updated.Status = userdomain.UserStatusActive
+	}
+
+	u, err := s.userStore.Update(ctx, &updated)
+	if err != nil {
+		return nil, genscim.MakeInternalServerError(err)
+	}
+
+	if u.Status != userdomain.UserStatusActive && existing.Status == userdomain.UserStatusActive {
+		_, err := s.sessions.RevokeAll(ctx, u.ID)
+		s.audit.Record(ctx, audit.Entry{
+			Type: audit.EventUserDeactivate, TargetID: u.ID, Err: err,
+			Details: map[string]string{"source": "scim", "status": u.Status},
+		})
+		if err != nil {
+			return nil, genscim.MakeInternalServerError(err)
+		}
+	}
+	return s.userResource(ctx, u)
+}
+
+// provisioningDetails describes a provisioned user in the audit log, with the email address redacted.
+func provisioningDetails(fields userFields) map[string]string {
+	return map[string]string{
+		"source": "scim",
+		"email":  redact.RedactEmail(fields.email),
+		"active": strconv.FormatBool(fields.active),
+	}
+}
+
+// userResource converts a stored user into a SCIM user resource listing its groups.
+func (s *service) userResource(ctx context.Context, u *genuser.User) (*genscim.ScimUser, error) {
+	groups, err := s.groupStore.QueryByMember(ctx, u.ID)
+	if err != nil {
+		return nil, genscim.MakeInternalServerError(err)
+	}
+	return s.toScimUser(u, groups), nil
+}
+
+// fieldsFromRequest extracts the writable attributes of a user resource. Names are taken from
+// the name components, falling back to splitting the formatted name or the display name.
+func fieldsFromRequest(req *genscim.ScimUserRequest) (userFields, error) {
+	fields := userFields{
+		email:      strings.TrimSpace(req.UserName),
+		externalID: req.ExternalID,
+		active:     req.Active == nil || *req.Active,
+	}
+
+	switch {
+	case req.Name != nil && (req.Name.GivenName != nil || req.Name.FamilyName != nil):
+		fields.firstName = deref(req.Name.GivenName)
+		fields.lastName = deref(req.Name.FamilyName)
+	case req.Name != nil && req.Name.Formatted != nil:
+		fields.firstName, fields.lastName = splitName(*req.Name.Formatted)
+	case req.DisplayName != nil:
+		fields.firstName, fields.lastName = splitName(*req.DisplayName)
+	}
+
+	return fields, fields.validate()
+}
+
+// validate checks that the userName is a bare email address.
+func (f *userFields) validate() error {
+	addr, err := mail.ParseAddress(f.email)
+	if err != nil || addr.Address != f.email {
+		return fmt.Errorf("userName must be an email address")
+	}
+	return nil
+}
+
+// provisionedPassword validates the password given by the provisioning client, or generates
+// a random one when none is given.
+func provisionedPassword(password *string) (string, error) {
+	if password != nil {
+		if n := len(*password); n < minPasswordLength || n > maxPasswordLength {
+			return "", genscim.MakeInvalidValue(
+				fmt.Errorf("password must be between %d and %d characters", minPasswordLength, maxPasswordLength),
+			)
+		}
+		return *password, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", genscim.MakeInternalServerError(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// deref returns the value of an optional string, or the empty string.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -4,6 +4,7 @@ package userstore
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -11,20 +12,32 @@ import (
 
 	"github.com/iamBelugaa/goa-iam/gen/user"
 	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/events"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
 )
 
-// memory implements the UserStorer interface using in-memory maps.
+// tenantEmail identifies an email address within a tenant.
+type tenantEmail struct {
+	tenantID string
+	email    string
+}
+
+// memory implements the UserStorer and events.Outbox interfaces using in-memory maps. The
+// domain events of a change are recorded in the outbox under the same lock as the change.
 type memory struct {
-	mu           sync.RWMutex          // protects access to users and emailToIdMap
-	emailToIdMap map[string]string     // maps email addresses to user IDs
-	users        map[string]*user.User // stores user data by ID
+	mu           sync.RWMutex           // protects access to users, emailToIdMap, passwords and outbox
+	emailToIdMap map[tenantEmail]string // maps email addresses within a tenant to user IDs
+	users        map[string]*user.User  // stores user data by ID
+	passwords    map[string]string      // stores password hashes by user ID
+	outbox       []*events.Event        // unpublished domain events, oldest first
 }
 
 // NewMemoryStore creates and returns a new instance of the in-memory user store.
 func NewMemoryStore() *memory {
 	return &memory{
-		emailToIdMap: make(map[string]string),
+		emailToIdMap: make(map[tenantEmail]string),
 		users:        make(map[string]*user.User),
+		passwords:    make(map[string]string),
 	}
 }
 
@@ -40,37 +53,51 @@ func (m *memory) QueryById(ctx context.Context, userID string) (*user.User, erro
 	return user, nil
 }
 
-// QueryByEmail retrieves a user from memory by their email address.
-func (m *memory) QueryByEmail(ctx context.Context, email string) (*user.User, error) {
+// QueryByEmail retrieves a user of a tenant from memory by their email address.
+func (m *memory) QueryByEmail(ctx context.Context, tenantID, email string) (*user.User, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	userID, ok := m.emailToIdMap[email]
+	userID, ok := m.emailToIdMap[tenantEmail{tenantID, email}]
 	if !ok {
-		return nil, fmt.Errorf("user with email %s doesn't exist", email)
+		return nil, fmt.Errorf("user with email %s doesn't exist", redact.RedactEmail(email))
 	}
 
 	user, ok := m.users[userID]
 	if !ok {
-		return nil, fmt.Errorf("user with email %s doesn't exist", email)
+		return nil, fmt.Errorf("user with email %s doesn't exist", redact.RedactEmail(email))
 	}
 
 	return user, nil
 }
 
-// Create adds a new user to the in-memory store.
-func (m *memory) Create(ctx context.Context, cmd *user.CreateUserRequest) (*user.User, error) {
+// Create adds a new user of a tenant to the in-memory store, keeping the hash of its
+// password.
+func (m *memory) Create(ctx context.Context, tenantID string, cmd *user.CreateUserRequest) (*user.User, error) {
+	key := tenantEmail{tenantID, cmd.Email}
+
 	// Check for duplicate email with read lock.
 	m.mu.RLock()
-	if _, exists := m.emailToIdMap[cmd.Email]; exists {
+	if _, exists := m.emailToIdMap[key]; exists {
 		m.mu.RUnlock()
-		return nil, fmt.Errorf("user with email %s already exists", cmd.Email)
+		return nil, fmt.Errorf("user with email %s already exists", redact.RedactEmail(cmd.Email))
 	}
 	m.mu.RUnlock()
 
+	// Hash outside the lock, hashing is deliberately slow.
+	var passwordHash string
+	if cmd.Password != "" {
+		hash, err := userdomain.HashPassword(cmd.Password)
+		if err != nil {
+			return nil, err
+		}
+		passwordHash = hash
+	}
+
 	// Create new user object.
 	newUser := &user.User{
 		ID:        uuid.New().String(),
+		TenantID:  tenantID,
 		FirstName: cmd.FirstName,
 		LastName:  cmd.LastName,
 		Email:     cmd.Email,
@@ -83,29 +110,171 @@ func (m *memory) Create(ctx context.Context, cmd *user.CreateUserRequest) (*user
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.emailToIdMap[cmd.Email]; exists {
-		return nil, fmt.Errorf("user with email %s already exists", cmd.Email)
+	if _, exists := m.emailToIdMap[key]; exists {
+		return nil, fmt.Errorf("user with email %s already exists", redact.RedactEmail(cmd.Email))
 	}
 
-	m.emailToIdMap[cmd.Email] = newUser.ID
+	m.emailToIdMap[key] = newUser.ID
 	m.users[newUser.ID] = newUser
+	if passwordHash != "" {
+		m.passwords[newUser.ID] = passwordHash
+	}
+	m.outbox = append(m.outbox, events.New(events.TypeUserCreated, tenantID, newUser.ID, map[string]string{
+		"email": newUser.Email, "firstName": newUser.FirstName, "lastName": newUser.LastName,
+	}))
 
 	return newUser, nil
 }
 
-// List returns a slice containing all users currently stored in memory.
-func (s *memory) List(ctx context.Context) ([]*user.User, error) {
+// VerifyPassword checks a password against the hash stored for a user.
+func (m *memory) VerifyPassword(ctx context.Context, userID, password string) error {
+	m.mu.RLock()
+	hash, ok := m.passwords[userID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return userdomain.ErrPasswordMismatch
+	}
+	return userdomain.CheckPassword(hash, password)
+}
+
+// UpdateRoles replaces the roles of a user in memory.
+func (m *memory) UpdateRoles(ctx context.Context, userID string, roles []string) (*user.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user with id %s doesn't exist", userID)
+	}
+
+	// Replace the stored user so previously returned values aren't modified.
+	updated := *existing
+	updated.Roles = append([]string(nil), roles...)
+	updated.UpdatedAt = time.Now().Format(time.RFC3339)
+	m.users[userID] = &updated
+
+	return &updated, nil
+}
+
+// VerifyEmail marks the email address of a user in memory as verified.
+func (m *memory) VerifyEmail(ctx context.Context, userID string) (*user.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user with id %s doesn't exist", userID)
+	}
+
+	// Replace the stored user so previously returned values aren't modified.
+	updated := *existing
+	updated.EmailVerified = true
+	updated.UpdatedAt = time.Now().Format(time.RFC3339)
+	m.users[userID] = &updated
+
+	return &updated, nil
+}
+
+// Update replaces the name, email, status and external ID of a user in memory.
+func (m *memory) Update(ctx context.Context, u *user.User) (*user.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[u.ID]
+	if !ok {
+		return nil, fmt.Errorf("user with id %s doesn't exist", u.ID)
+	}
+
+	if u.Email != existing.Email {
+		key := tenantEmail{existing.TenantID, u.Email}
+		if _, exists := m.emailToIdMap[key]; exists {
+			return nil, fmt.Errorf("user with email %s already exists", redact.RedactEmail(u.Email))
+		}
+		delete(m.emailToIdMap, tenantEmail{existing.TenantID, existing.Email})
+		m.emailToIdMap[key] = u.ID
+	}
+
+	// Replace the stored user so previously returned values aren't modified.
+	updated := *existing
+	updated.FirstName = u.FirstName
+	updated.LastName = u.LastName
+	updated.Email = u.Email
+	updated.EmailVerified = existing.EmailVerified && u.Email == existing.Email
+	updated.Status = u.Status
+	updated.ExternalID = u.ExternalID
+	updated.UpdatedAt = time.Now().Format(time.RFC3339)
+	m.users[u.ID] = &updated
+
+	if updated.Email != existing.Email {
+		m.outbox = append(m.outbox, events.New(events.TypeUserEmailChanged, updated.TenantID, updated.ID, map[string]string{
+			"previousEmail": existing.Email, "email": updated.Email,
+		}))
+	}
+	if existing.Status == userdomain.UserStatusActive && updated.Status != userdomain.UserStatusActive {
+		m.outbox = append(m.outbox, events.New(events.TypeUserSuspended, updated.TenantID, updated.ID, map[string]string{
+			"status": updated.Status,
+		}))
+	}
+
+	return &updated, nil
+}
+
+// Delete removes a user from memory.
+func (m *memory) Delete(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[userID]
+	if !ok {
+		return fmt.Errorf("user with id %s doesn't exist", userID)
+	}
+
+	delete(m.emailToIdMap, tenantEmail{existing.TenantID, existing.Email})
+	delete(m.users, userID)
+	delete(m.passwords, userID)
+	return nil
+}
+
+// List returns a slice containing all users of a tenant currently stored in memory.
+func (s *memory) List(ctx context.Context, tenantID string) ([]*user.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Prepare a slice with the exact length of the stored users map.
-	users := make([]*user.User, len(s.users))
-	var index int
-
+	users := make([]*user.User, 0)
 	for _, user := range s.users {
-		users[index] = user
-		index++
+		if user.TenantID == tenantID {
+			users = append(users, user)
+		}
 	}
 
 	return users, nil
 }
+
+// Ping reports whether the store answers, taking its lock so a store stuck holding it fails
+// health checks.
+func (m *memory) Ping(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return ctx.Err()
+}
+
+// Pending returns up to limit unpublished domain events, oldest first.
+func (m *memory) Pending(ctx context.Context, limit int) ([]*events.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pending := m.outbox[:min(limit, len(m.outbox))]
+	return append([]*events.Event(nil), pending...), nil
+}
+
+// MarkPublished removes the domain events with the given IDs from the outbox.
+func (m *memory) MarkPublished(ctx context.Context, eventIDs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outbox = slices.DeleteFunc(m.outbox, func(e *events.Event) bool {
+		return slices.Contains(eventIDs, e.ID)
+	})
+	return nil
+}
@@ -0,0 +1,73 @@
+package userstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamBelugaa/goa-iam/gen/user"
+	"github.com/iamBelugaa/goa-iam/internal/metrics"
+)
+
+// instrumented implements the UserStorer interface by timing the operations of another store.
+type instrumented struct {
+	store UserStorer // Store the operations are delegated to
+}
+
+// WithMetrics returns a store recording the latency of each operation of the given store.
+func WithMetrics(store UserStorer) UserStorer {
+	return &instrumented{store: store}
+}
+
+// QueryById times the QueryById operation of the underlying store.
+func (i *instrumented) QueryById(ctx context.Context, userID string) (*user.User, error) {
+	defer metrics.ObserveUserStore("QueryById", time.Now())
+	return i.store.QueryById(ctx, userID)
+}
+
+// QueryByEmail times the QueryByEmail operation of the underlying store.
+func (i *instrumented) QueryByEmail(ctx context.Context, tenantID, email string) (*user.User, error) {
+	defer metrics.ObserveUserStore("QueryByEmail", time.Now())
+	return i.store.QueryByEmail(ctx, tenantID, email)
+}
+
+// Create times the Create operation of the underlying store.
+func (i *instrumented) Create(ctx context.Context, tenantID string, cmd *user.CreateUserRequest) (*user.User, error) {
+	defer metrics.ObserveUserStore("Create", time.Now())
+	return i.store.Create(ctx, tenantID, cmd)
+}
+
+// VerifyPassword times the VerifyPassword operation of the underlying store.
+func (i *instrumented) VerifyPassword(ctx context.Context, userID, password string) error {
+	defer metrics.ObserveUserStore("VerifyPassword", time.Now())
+	return i.store.VerifyPassword(ctx, userID, password)
+}
+
+// UpdateRoles times the UpdateRoles operation of the underlying store.
+func (i *instrumented) UpdateRoles(ctx context.Context, userID string, roles []string) (*user.User, error) {
+	defer metrics.ObserveUserStore("UpdateRoles", time.Now())
+	return i.store.UpdateRoles(ctx, userID, roles)
+}
+
+// VerifyEmail times the VerifyEmail operation of the underlying store.
+func (i *instrumented) VerifyEmail(ctx context.Context, userID string) (*user.User, error) {
+	defer metrics.ObserveUserStore("VerifyEmail", time.Now())
+	return i.store.VerifyEmail(ctx, userID)
+}
+
+// Update times the Update operation of the underlying store.
+func (i *instrumented) Update(ctx context.Context, u *user.User) (*user.User, error) {
+	defer metrics.ObserveUserStore("Update", time.Now())
+	return i.store.Update(ctx, u)
+}
+
+// Delete times the Delete operation of the underlying store.
+func (i *instrumented) Delete(ctx context.Context, userID string) error {
+	defer metrics.ObserveUserStore("Delete", time.Now())
+	return i.store.Delete(ctx, userID)
+}
+
+// List times the List operation of the underlying store.
+func (i *instrumented) List(ctx context.Context, tenantID string) ([]*user.User, error) {
+	defer metrics.ObserveUserStore("List", time.Now())
+	return i.store.List(ctx, tenantID)
+}
@@ -3,21 +3,55 @@ package userstore
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/iamBelugaa/goa-iam/gen/user"
 )
 
-// UserStorer defines the contract for managing user data in a storage backend.
+// UserStorer defines the contract for managing user data in a storage backend. Every user
+// belongs to one tenant, and email addresses are only unique within a tenant.
 type UserStorer interface {
-	// QueryById retrieves a user by their unique user ID.
+	// QueryById retrieves a user by their unique user ID, regardless of its tenant.
 	QueryById(ctx context.Context, userID string) (*user.User, error)
 
-	// QueryByEmail retrieves a user by their email address.
-	QueryByEmail(ctx context.Context, email string) (*user.User, error)
+	// QueryByEmail retrieves a user of the given tenant by their email address.
+	QueryByEmail(ctx context.Context, tenantID, email string) (*user.User, error)
 
-	// Create stores a new user in the storage backend using the provided request payload.
-	Create(ctx context.Context, cmd *user.CreateUserRequest) (*user.User, error)
+	// Create stores a new user of the given tenant using the provided request payload. Only
+	// a hash of the password is kept.
+	Create(ctx context.Context, tenantID string, cmd *user.CreateUserRequest) (*user.User, error)
 
-	// List returns a slice containing all users.
-	List(ctx context.Context) ([]*user.User, error)
+	// VerifyPassword checks a password against the one the user was created with, returning
+	// userdomain.ErrPasswordMismatch when it doesn't match or the user has no password.
+	VerifyPassword(ctx context.Context, userID, password string) error
+
+	// UpdateRoles replaces the roles granted to a user.
+	UpdateRoles(ctx context.Context, userID string, roles []string) (*user.User, error)
+
+	// VerifyEmail marks the email address of a user as verified, once the user proved
+	// owning it. Changing the address clears the mark, see Update.
+	VerifyEmail(ctx context.Context, userID string) (*user.User, error)
+
+	// Update replaces the name, email, status and external ID of an existing user. The email
+	// address stays verified only while it's unchanged.
+	Update(ctx context.Context, u *user.User) (*user.User, error)
+
+	// Delete removes a user from the storage backend.
+	Delete(ctx context.Context, userID string) error
+
+	// List returns a slice containing all users of the given tenant.
+	List(ctx context.Context, tenantID string) ([]*user.User, error)
+}
+
+// QueryTenantUser retrieves a user of the given tenant by their unique user ID. Users of
+// other tenants are reported as missing, so user IDs never reach across tenants.
+func QueryTenantUser(ctx context.Context, store UserStorer, tenantID, userID string) (*user.User, error) {
+	u, err := store.QueryById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || u.TenantID != tenantID {
+		return nil, fmt.Errorf("user with id %s doesn't exist", userID)
+	}
+	return u, nil
 }
@@ -0,0 +1,81 @@
+package userstore
+
+import (
+	"context"
+
+	"github.com/iamBelugaa/goa-iam/gen/user"
+	"github.com/iamBelugaa/goa-iam/internal/tracing"
+)
+
+// traced implements the UserStorer interface by tracing the operations of another store.
+type traced struct {
+	store UserStorer // Store the operations are delegated to
+}
+
+// WithTracing returns a store recording a span for each operation of the given store.
+func WithTracing(store UserStorer) UserStorer {
+	return &traced{store: store}
+}
+
+// QueryById traces the QueryById operation of the underlying store.
+func (t *traced) QueryById(ctx context.Context, userID string) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.QueryById")
+	defer func() { tracing.End(span, err) }()
+	return t.store.QueryById(ctx, userID)
+}
+
+// QueryByEmail traces the QueryByEmail operation of the underlying store.
+func (t *traced) QueryByEmail(ctx context.Context, tenantID, email string) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.QueryByEmail")
+	defer func() { tracing.End(span, err) }()
+	return t.store.QueryByEmail(ctx, tenantID, email)
+}
+
+// Create traces the Create operation of the underlying store.
+func (t *traced) Create(ctx context.Context, tenantID string, cmd *user.CreateUserRequest) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.Create")
+	defer func() { tracing.End(span, err) }()
+	return t.store.Create(ctx, tenantID, cmd)
+}
+
+// VerifyPassword traces the VerifyPassword operation of the underlying store.
+func (t *traced) VerifyPassword(ctx context.Context, userID, password string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.VerifyPassword")
+	defer func() { tracing.End(span, err) }()
+	return t.store.VerifyPassword(ctx, userID, password)
+}
+
+// UpdateRoles traces the UpdateRoles operation of the underlying store.
+func (t *traced) UpdateRoles(ctx context.Context, userID string, roles []string) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.UpdateRoles")
+	defer func() { tracing.End(span, err) }()
+	return t.store.UpdateRoles(ctx, userID, roles)
+}
+
+// VerifyEmail traces the VerifyEmail operation of the underlying store.
+func (t *traced) VerifyEmail(ctx context.Context, userID string) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.VerifyEmail")
+	defer func() { tracing.End(span, err) }()
+	return t.store.VerifyEmail(ctx, userID)
+}
+
+// Update traces the Update operation of the underlying store.
+func (t *traced) Update(ctx context.Context, u *user.User) (_ *user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.Update")
+	defer func() { tracing.End(span, err) }()
+	return t.store.Update(ctx, u)
+}
+
+// Delete traces the Delete operation of the underlying store.
+func (t *traced) Delete(ctx context.Context, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.Delete")
+	defer func() { tracing.End(span, err) }()
+	return t.store.Delete(ctx, userID)
+}
+
+// List traces the List operation of the underlying store.
+func (t *traced) List(ctx context.Context, tenantID string) (_ []*user.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStorer.List")
+	defer func() { tracing.End(span, err) }()
+	return t.store.List(ctx, tenantID)
+}
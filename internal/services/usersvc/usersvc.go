@@ -4,36 +4,41 @@ package usersvc
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	genuser "github.com/iamBelugaa/goa-iam/gen/user"
 
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
 	userstore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
 	"github.com/iamBelugaa/goa-iam/pkg/logger"
 	"github.com/iamBelugaa/goa-iam/pkg/redact"
 )
 
-// service implements user-related operations backed by a user store.
+// service implements user-related operations backed by a user store. Every operation is
+// scoped to the tenant the request was routed to.
 type service struct {
-	log   *logger.Logger       // Logger for structured logging
 	store userstore.UserStorer // Interface to the underlying user storage
+	audit *audit.Recorder      // Recorder of security events
 }
 
-// NewService creates a new user service instance with the provided store.
-func NewService(log *logger.Logger, userStore userstore.UserStorer) *service {
-	return &service{store: userStore, log: log}
+// NewService creates a new user service instance with the provided store. Operations log
+// through the logger of the request they serve.
+func NewService(userStore userstore.UserStorer, recorder *audit.Recorder) *service {
+	return &service{store: userStore, audit: recorder}
 }
 
-// List returns all users in the system.
+// List returns all users of the tenant.
 func (s *service) List(ctx context.Context) (*genuser.ListUsersResponse, error) {
-	s.log.Infow("list users request received")
+	logger.FromContext(ctx).Infow("list users request received")
 
-	users, err := s.store.List(ctx)
+	users, err := s.store.List(ctx, tenancy.FromContext(ctx))
 	if err != nil {
-		s.log.Infow("list users error", "error", err)
+		logger.FromContext(ctx).Infow("list users error", "error", err)
 		return nil, genuser.MakeInternalServerError(err)
 	}
 
-	s.log.Infow("list users request successful", "totalUsers", len(users))
+	logger.FromContext(ctx).Infow("list users request successful", "totalUsers", len(users))
 	return &genuser.ListUsersResponse{
 		Success: true,
 		Data:    users,
@@ -41,21 +46,21 @@ func (s *service) List(ctx context.Context) (*genuser.ListUsersResponse, error)
 	}, nil
 }
 
-// GetByID retrieves a user by their unique ID.
+// GetByID retrieves a user of the tenant by their unique ID.
 func (s *service) GetByID(ctx context.Context, req *genuser.GetUserByIDPayload) (*genuser.GetUserByIDResponse, error) {
-	s.log.Infow("getUserById request received", "userId", req.ID)
+	logger.FromContext(ctx).Infow("getUserById request received", "userId", req.ID)
 
-	user, err := s.store.QueryById(ctx, req.ID)
+	user, err := userstore.QueryTenantUser(ctx, s.store, tenancy.FromContext(ctx), req.ID)
 	if err != nil {
-		s.log.Infow("getUserById error", "userId", req.ID, "error", err)
+		logger.FromContext(ctx).Infow("getUserById error", "userId", req.ID, "error", err)
 		return nil, genuser.MakeUserNotFound(err)
 	}
 	if user == nil {
-		s.log.Infow("getUserById error", "userId", req.ID, "error", err)
+		logger.FromContext(ctx).Infow("getUserById error", "userId", req.ID, "error", err)
 		return nil, genuser.MakeUserNotFound(fmt.Errorf("user with id %s doesn't exist", req.ID))
 	}
 
-	s.log.Infow("getUserById request successful", "user", *user)
+	logger.FromContext(ctx).Infow("getUserById request successful", "user", *user)
 	return &genuser.GetUserByIDResponse{
 		Success: true,
 		Data:    user,
@@ -63,22 +68,30 @@ func (s *service) GetByID(ctx context.Context, req *genuser.GetUserByIDPayload)
 	}, nil
 }
 
-// Create registers a new user in the system.
+// Create registers a new user in the tenant. Registering doesn't prove owning the email
+// address, so the admin emails of the tenant aren't granted the admin role here.
 func (s *service) Create(ctx context.Context, req *genuser.CreateUserRequest) (*genuser.CreateUserResponse, error) {
-	s.log.Infow(
+	logger.FromContext(ctx).Infow(
 		"create user request received",
-		"email", redact.RedactEmail(req.Email),
+		"email", req.Email,
 		"firstName", req.FirstName, "lastName", req.LastName,
-		"password", redact.RedactSensitiveData(req.Password),
 	)
 
-	user, err := s.store.Create(ctx, req)
+	details := map[string]string{"email": redact.RedactEmail(req.Email)}
+	tenantID := tenancy.FromContext(ctx)
+	user, err := s.store.Create(ctx, tenantID, req)
 	if err != nil {
-		s.log.Infow("create user error", "email", redact.RedactEmail(req.Email), "error", err)
+		logger.FromContext(ctx).Infow("create user error", "email", req.Email, "error", err)
+		s.audit.Record(ctx, audit.Entry{
+			Type: audit.EventUserCreate, Err: fmt.Errorf("user with email %s already exists", details["email"]), Details: details,
+		})
 		return nil, genuser.MakeEmailExists(err)
 	}
 
-	s.log.Infow("create user request successful", "user", *user)
+	details["roles"] = strings.Join(user.Roles, ",")
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventUserCreate, TargetID: user.ID, Details: details})
+
+	logger.FromContext(ctx).Infow("create user request successful", "user", *user)
 	return &genuser.CreateUserResponse{
 		Success: true,
 		Data:    user,
@@ -0,0 +1,278 @@
+// Package dispatch delivers domain events to the endpoints of webhook subscriptions. Each
+// request is signed with the subscription's secret, failed requests are retried with an
+// exponential backoff and deliveries running out of attempts are dead-lettered. Requests are
+// only sent to public addresses and redirects aren't followed, so subscriptions can't reach
+// the internal network.
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/internal/events"
+	webhookstore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// Headers sent along with each delivery. The signature is the hex encoded HMAC-SHA256 of the
+// timestamp, a period and the body, keyed with the subscription's secret, prefixed with
+// "sha256=". Receivers should reject requests whose timestamp is too old to prevent replays.
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// ErrPrivateAddress is returned for endpoints resolving to addresses of the internal network.
+var ErrPrivateAddress = errors.New("endpoint resolves to a private, loopback or link-local address")
+
+// Dispatcher turns published events into deliveries and attempts the deliveries when due.
+type Dispatcher struct {
+	log    *logger.Logger             // Logger for structured logging
+	store  webhookstore.WebhookStorer // Interface to the webhook data store
+	client *http.Client               // Client the requests are sent with
+	cfg    *config.Webhooks           // Webhook settings
+}
+
+// New creates a dispatcher delivering the deliveries of the store. The address of each
+// connection is checked once resolved, so endpoints can't switch to an internal address after
+// being validated, and requests aren't sent through a proxy, whose address would be checked
+// instead.
+func New(log *logger.Logger, store webhookstore.WebhookStorer, cfg *config.Webhooks) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = control
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		log:   log,
+		store: store,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+	}
+}
+
+// CheckEndpoint resolves the host of an endpoint and returns ErrPrivateAddress if any of its
+// addresses belongs to the internal network.
+func CheckEndpoint(ctx context.Context, cfg *config.Webhooks, host string) error {
+	if cfg.AllowPrivateNetworks {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !public(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// Handle creates a delivery of the event for each subscription of its tenant to its type. It
+// is subscribed to the event bus. Events are handled again when creating a delivery fails or
+// the relay fails to mark them published, so the ID of a delivery is derived from its event
+// and subscription and creating it again is a no-op.
+func (d *Dispatcher) Handle(ctx context.Context, event *events.Event) error {
+	subs, err := d.store.ListSubscriptions(ctx, event.TenantID)
+	if err != nil {
+		return fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		if !slices.Contains(sub.EventTypes, event.Type) {
+			continue
+		}
+		delivery := &webhookstore.Delivery{
+			ID:             deliveryID(event.ID, sub.ID),
+			TenantID:       event.TenantID,
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         webhookstore.StatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := d.store.CreateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("create webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeliverDue attempts the deliveries that are due and returns how many were attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	due, err := d.store.Due(ctx, time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+	for i, delivery := range due {
+		if err := d.attempt(ctx, delivery); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+// Run attempts the deliveries that are due every poll interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DeliverDue(ctx); err != nil {
+				d.log.Errorw("deliver webhooks error", "error", err)
+			}
+		}
+	}
+}
+
+// attempt posts the delivery to its subscription's endpoint and records the outcome. A
+// delivery whose subscription was removed meanwhile is dead-lettered.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *webhookstore.Delivery) error {
+	var result webhookstore.Attempt
+	sub, err := d.store.QuerySubscription(ctx, delivery.TenantID, delivery.SubscriptionID)
+	if err != nil {
+		result = webhookstore.Attempt{AttemptedAt: time.Now(), Error: err.Error()}
+		delivery.Attempts = append(delivery.Attempts, result)
+		delivery.Status, delivery.NextAttemptAt = webhookstore.StatusDead, time.Time{}
+	} else {
+		result = d.post(ctx, sub, delivery)
+		delivery.Attempts = append(delivery.Attempts, result)
+		switch {
+		case result.Error == "":
+			delivery.Status, delivery.NextAttemptAt = webhookstore.StatusSucceeded, time.Time{}
+		case len(delivery.Attempts) >= d.cfg.MaxAttempts:
+			delivery.Status, delivery.NextAttemptAt = webhookstore.StatusDead, time.Time{}
+		default:
+			delivery.NextAttemptAt = time.Now().Add(Backoff(d.cfg, len(delivery.Attempts)))
+		}
+	}
+	delivery.UpdatedAt = time.Now()
+
+	if delivery.Status == webhookstore.StatusDead {
+		d.log.Warnw("webhook delivery dead-lettered", "deliveryId", delivery.ID, "subscriptionId", delivery.SubscriptionID, "error", result.Error)
+	}
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// post sends the signed payload of the delivery to the endpoint. Any 2xx response counts
+// as delivered, redirects aren't followed and count as failures.
+func (d *Dispatcher) post(ctx context.Context, sub *webhookstore.Subscription, delivery *webhookstore.Delivery) webhookstore.Attempt {
+	start := time.Now()
+	attempt := webhookstore.Attempt{AttemptedAt: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	res, err := d.client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("endpoint responded with status %d", res.StatusCode)
+	}
+	return attempt
+}
+
+// control refuses connections to addresses of the internal network.
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !public(addr) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// public reports whether the address is a unicast address outside of private, loopback and
+// link-local ranges.
+func public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// deliveryID returns the ID of the delivery of an event to a subscription.
+func deliveryID(eventID, subscriptionID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("webhook-delivery:"+eventID+":"+subscriptionID)).String()
+}
+
+// Sign returns the signature of a payload sent at the given Unix timestamp.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait after the given number of failed attempts before trying
+// again.
+func Backoff(cfg *config.Webhooks, failures int) time.Duration {
+	wait := cfg.InitialBackoff
+	for i := 1; i < failures && wait < cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, cfg.MaxBackoff)
+}
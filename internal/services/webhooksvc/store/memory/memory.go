@@ -0,0 +1,203 @@
+// Package webhookstore provides an in-memory implementation of the WebhookStorer interface.
+package webhookstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	webhookstore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store"
+)
+
+// eventSubscription identifies the delivery of an event to a subscription.
+type eventSubscription struct {
+	eventID        string
+	subscriptionID string
+}
+
+// memory implements the WebhookStorer interface using in-memory maps.
+type memory struct {
+	mu            sync.RWMutex                          // protects access to subscriptions, deliveries and delivered
+	subscriptions map[string]*webhookstore.Subscription // stores subscriptions by ID
+	deliveries    map[string]*webhookstore.Delivery     // stores deliveries by ID
+	delivered     map[eventSubscription]string          // maps events delivered to a subscription to delivery IDs
+}
+
+// NewMemoryStore creates and returns a new instance of the in-memory webhook store.
+func NewMemoryStore() *memory {
+	return &memory{
+		subscriptions: make(map[string]*webhookstore.Subscription),
+		deliveries:    make(map[string]*webhookstore.Delivery),
+		delivered:     make(map[eventSubscription]string),
+	}
+}
+
+// CreateSubscription adds a new subscription to the in-memory store.
+func (m *memory) CreateSubscription(ctx context.Context, sub *webhookstore.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.subscriptions[sub.ID]; exists {
+		return fmt.Errorf("subscription with id %s already exists", sub.ID)
+	}
+	m.subscriptions[sub.ID] = copySubscription(sub)
+
+	return nil
+}
+
+// QuerySubscription retrieves a subscription of a tenant from memory by its ID.
+func (m *memory) QuerySubscription(ctx context.Context, tenantID, subscriptionID string) (*webhookstore.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sub, ok := m.subscriptions[subscriptionID]
+	if !ok || sub.TenantID != tenantID {
+		return nil, fmt.Errorf("subscription with id %s doesn't exist", subscriptionID)
+	}
+	return copySubscription(sub), nil
+}
+
+// ListSubscriptions returns the subscriptions of a tenant stored in memory, oldest first.
+func (m *memory) ListSubscriptions(ctx context.Context, tenantID string) ([]*webhookstore.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := make([]*webhookstore.Subscription, 0)
+	for _, sub := range m.subscriptions {
+		if sub.TenantID == tenantID {
+			subs = append(subs, copySubscription(sub))
+		}
+	}
+	slices.SortFunc(subs, func(a, b *webhookstore.Subscription) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	return subs, nil
+}
+
+// DeleteSubscription removes a subscription of a tenant and its pending deliveries from memory.
+func (m *memory) DeleteSubscription(ctx context.Context, tenantID, subscriptionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[subscriptionID]
+	if !ok || sub.TenantID != tenantID {
+		return fmt.Errorf("subscription with id %s doesn't exist", subscriptionID)
+	}
+
+	delete(m.subscriptions, subscriptionID)
+	maps.DeleteFunc(m.deliveries, func(_ string, d *webhookstore.Delivery) bool {
+		return d.SubscriptionID == subscriptionID && d.Status == webhookstore.StatusPending
+	})
+	return nil
+}
+
+// CreateDelivery adds a new delivery to the in-memory store, unless the event was already
+// delivered to the subscription.
+func (m *memory) CreateDelivery(ctx context.Context, d *webhookstore.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := eventSubscription{d.EventID, d.SubscriptionID}
+	if _, exists := m.delivered[key]; exists {
+		return nil
+	}
+	if _, exists := m.deliveries[d.ID]; exists {
+		return fmt.Errorf("delivery with id %s already exists", d.ID)
+	}
+
+	m.delivered[key] = d.ID
+	m.deliveries[d.ID] = copyDelivery(d)
+
+	return nil
+}
+
+// QueryDelivery retrieves a delivery of a tenant from memory by its ID.
+func (m *memory) QueryDelivery(ctx context.Context, tenantID, deliveryID string) (*webhookstore.Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d, ok := m.deliveries[deliveryID]
+	if !ok || d.TenantID != tenantID {
+		return nil, fmt.Errorf("delivery with id %s doesn't exist", deliveryID)
+	}
+	return copyDelivery(d), nil
+}
+
+// UpdateDelivery replaces an existing delivery in memory.
+func (m *memory) UpdateDelivery(ctx context.Context, d *webhookstore.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deliveries[d.ID]; !ok {
+		return fmt.Errorf("delivery with id %s doesn't exist", d.ID)
+	}
+	m.deliveries[d.ID] = copyDelivery(d)
+
+	return nil
+}
+
+// ListDeliveries returns the deliveries stored in memory that match the filter, newest first.
+func (m *memory) ListDeliveries(ctx context.Context, filter webhookstore.Filter) ([]*webhookstore.Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	deliveries := make([]*webhookstore.Delivery, 0)
+	for _, d := range m.deliveries {
+		if d.TenantID != filter.TenantID ||
+			(filter.SubscriptionID != "" && d.SubscriptionID != filter.SubscriptionID) ||
+			(filter.Status != "" && d.Status != filter.Status) {
+			continue
+		}
+		deliveries = append(deliveries, copyDelivery(d))
+	}
+	slices.SortFunc(deliveries, func(a, b *webhookstore.Delivery) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	if filter.Limit > 0 && len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, nil
+}
+
+// Due returns up to limit pending deliveries stored in memory whose next attempt is due.
+func (m *memory) Due(ctx context.Context, now time.Time, limit int) ([]*webhookstore.Delivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	due := make([]*webhookstore.Delivery, 0)
+	for _, d := range m.deliveries {
+		if d.Status == webhookstore.StatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, copyDelivery(d))
+		}
+	}
+	slices.SortFunc(due, func(a, b *webhookstore.Delivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), strings.Compare(a.ID, b.ID))
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// copySubscription returns a copy of the subscription that shares no memory with it.
+func copySubscription(sub *webhookstore.Subscription) *webhookstore.Subscription {
+	c := *sub
+	c.EventTypes = slices.Clone(sub.EventTypes)
+	return &c
+}
+
+// copyDelivery returns a copy of the delivery that shares no memory with it.
+func copyDelivery(d *webhookstore.Delivery) *webhookstore.Delivery {
+	c := *d
+	c.Payload = slices.Clone(d.Payload)
+	c.Attempts = slices.Clone(d.Attempts)
+	return &c
+}
@@ -0,0 +1,91 @@
+// Package webhookstore defines the interface for interacting with the webhook data storage layer.
+package webhookstore
+
+import (
+	"context"
+	"time"
+)
+
+// Delivery statuses. Pending deliveries are attempted again until they succeed or run out of
+// attempts, after which they are dead-lettered until retried by hand.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// Subscription represents an endpoint of a tenant receiving events of some types.
+type Subscription struct {
+	ID         string    // Unique identifier of the subscription
+	TenantID   string    // ID of the organization the subscription belongs to
+	URL        string    // Endpoint events are posted to
+	EventTypes []string  // Types of the events delivered to the endpoint
+	Secret     string    // Key payloads are signed with
+	CreatedAt  time.Time // Time the subscription was created
+}
+
+// Attempt represents one request made to deliver an event.
+type Attempt struct {
+	AttemptedAt time.Time     // Time the request was sent
+	StatusCode  int           // HTTP status of the response, zero when there was none
+	Error       string        // Why the attempt failed, empty if it succeeded
+	Duration    time.Duration // Time the endpoint took to respond
+}
+
+// Delivery represents an event to post to the endpoint of a subscription, along with the
+// log of attempts made so far.
+type Delivery struct {
+	ID             string    // Unique identifier of the delivery
+	TenantID       string    // ID of the organization the delivery belongs to
+	SubscriptionID string    // ID of the subscription the event is delivered to
+	EventID        string    // ID of the event delivered
+	EventType      string    // Type of the event delivered
+	Payload        []byte    // Body of the requests, the event encoded as JSON
+	Status         string    // Pending, succeeded or dead
+	Attempts       []Attempt // Requests made so far, oldest first
+	NextAttemptAt  time.Time // Time the next request is due, zero unless pending
+	CreatedAt      time.Time // Time the delivery was created
+	UpdatedAt      time.Time // Time the delivery was last modified
+}
+
+// Filter restricts the deliveries returned by a query.
+type Filter struct {
+	TenantID       string // Only deliveries of this organization
+	SubscriptionID string // Only deliveries to this subscription, if set
+	Status         string // Only deliveries with this status, if set
+	Limit          int    // Maximum number of deliveries returned, all if zero
+}
+
+// WebhookStorer defines the contract for managing webhook subscriptions and deliveries in a
+// storage backend.
+type WebhookStorer interface {
+	// CreateSubscription stores a new subscription.
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+
+	// QuerySubscription retrieves a subscription of the given tenant by its unique ID.
+	QuerySubscription(ctx context.Context, tenantID, subscriptionID string) (*Subscription, error)
+
+	// ListSubscriptions returns the subscriptions of the given tenant, oldest first.
+	ListSubscriptions(ctx context.Context, tenantID string) ([]*Subscription, error)
+
+	// DeleteSubscription removes a subscription of the given tenant along with its pending
+	// deliveries. Delivered and dead-lettered deliveries are kept for the log.
+	DeleteSubscription(ctx context.Context, tenantID, subscriptionID string) error
+
+	// CreateDelivery stores a new delivery. An event is delivered at most once per
+	// subscription, so storing it again is a no-op.
+	CreateDelivery(ctx context.Context, d *Delivery) error
+
+	// QueryDelivery retrieves a delivery of the given tenant by its unique ID.
+	QueryDelivery(ctx context.Context, tenantID, deliveryID string) (*Delivery, error)
+
+	// UpdateDelivery replaces an existing delivery.
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+
+	// ListDeliveries returns the deliveries matching the filter, newest first.
+	ListDeliveries(ctx context.Context, filter Filter) ([]*Delivery, error)
+
+	// Due returns up to limit pending deliveries whose next attempt is due at the given
+	// time, of every tenant, most overdue first.
+	Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+}
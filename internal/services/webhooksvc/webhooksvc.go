@@ -0,0 +1,282 @@
+// Package webhooksvc provides webhook subscription management for the IAM system. Admins
+// subscribe endpoints of their organization to domain events, which package dispatch then
+// delivers, and inspect the log of deliveries, retrying dead-lettered ones.
+package webhooksvc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"goa.design/goa/v3/security"
+
+	genwebhook "github.com/iamBelugaa/goa-iam/gen/webhook"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	"github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/dispatch"
+	webhookstore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// service implements webhook management on top of the webhook store.
+type service struct {
+	log   *logger.Logger             // Logger for structured logging
+	store webhookstore.WebhookStorer // Interface to the webhook data store
+	authn *jwtauth.Authenticator     // Token authenticator for secured methods
+	audit *audit.Recorder            // Recorder of security events
+	cfg   *config.Webhooks           // Webhook settings
+}
+
+// NewService initializes and returns a new webhook service instance.
+func NewService(
+	log *logger.Logger, store webhookstore.WebhookStorer, authn *jwtauth.Authenticator, recorder *audit.Recorder, cfg *config.Webhooks,
+) *service {
+	return &service{log: log, store: store, authn: authn, audit: recorder, cfg: cfg}
+}
+
+// JWTAuth validates a JWT and attaches the corresponding user context.
+func (s *service) JWTAuth(ctx context.Context, token string, schema *security.JWTScheme) (context.Context, error) {
+	return s.authn.Authenticate(ctx, token)
+}
+
+// ListSubscriptions returns the webhook subscriptions of the tenant, oldest first.
+func (s *service) ListSubscriptions(ctx context.Context, req *genwebhook.ListWebhookSubscriptionsRequest) (*genwebhook.ListWebhookSubscriptionsResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list webhook subscriptions request received", "userId", claims.Subject)
+
+	subs, err := s.store.ListSubscriptions(ctx, tenancy.FromContext(ctx))
+	if err != nil {
+		s.log.Infow("list webhook subscriptions error", "error", err)
+		return nil, genwebhook.MakeInternalServerError(err)
+	}
+
+	data := make([]*genwebhook.WebhookSubscription, 0, len(subs))
+	for _, sub := range subs {
+		data = append(data, toSubscription(sub))
+	}
+
+	s.log.Infow("list webhook subscriptions request successful", "totalSubscriptions", len(data))
+	return &genwebhook.ListWebhookSubscriptionsResponse{
+		Success: true,
+		Message: "Webhook subscriptions fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// CreateSubscription subscribes an endpoint to events of the tenant. Endpoints resolving to
+// addresses of the internal network are rejected. The signing secret is only returned in the
+// response.
+func (s *service) CreateSubscription(ctx context.Context, req *genwebhook.CreateWebhookSubscriptionRequest) (*genwebhook.CreatedWebhookSubscriptionResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("create webhook subscription request received", "userId", claims.Subject, "url", req.URL, "events", req.Events)
+
+	endpoint, err := url.Parse(req.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		s.log.Infow("create webhook subscription error", "url", req.URL, "error", "invalid url")
+		return nil, genwebhook.MakeBadRequest(fmt.Errorf("url must be an absolute http or https url"))
+	}
+	if err := dispatch.CheckEndpoint(ctx, s.cfg, endpoint.Hostname()); err != nil {
+		s.log.Infow("create webhook subscription error", "url", req.URL, "error", err)
+		return nil, genwebhook.MakeBadRequest(fmt.Errorf("url must resolve to a public address"))
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return nil, genwebhook.MakeInternalServerError(err)
+	}
+
+	sub := &webhookstore.Subscription{
+		ID:         uuid.New().String(),
+		TenantID:   tenancy.FromContext(ctx),
+		URL:        endpoint.String(),
+		EventTypes: slices.Compact(slices.Sorted(slices.Values(req.Events))),
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.store.CreateSubscription(ctx, sub); err != nil {
+		s.log.Infow("create webhook subscription error", "error", err)
+		return nil, genwebhook.MakeInternalServerError(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		Type: audit.EventWebhookCreate, TargetID: sub.ID, Details: map[string]string{"url": sub.URL},
+	})
+
+	s.log.Infow("create webhook subscription request successful", "subscriptionId", sub.ID)
+	return &genwebhook.CreatedWebhookSubscriptionResponse{
+		Success: true,
+		Message: "Webhook subscription created successfully",
+		Data:    toSubscription(sub),
+		Secret:  secret,
+	}, nil
+}
+
+// DeleteSubscription removes a webhook subscription of the tenant along with its pending
+// deliveries.
+func (s *service) DeleteSubscription(ctx context.Context, req *genwebhook.WebhookSubscriptionRequest) (*genwebhook.DeleteWebhookSubscriptionResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("delete webhook subscription request received", "userId", claims.Subject, "subscriptionId", req.ID)
+
+	if err := s.store.DeleteSubscription(ctx, tenancy.FromContext(ctx), req.ID); err != nil {
+		s.log.Infow("delete webhook subscription error", "subscriptionId", req.ID, "error", err)
+		return nil, genwebhook.MakeNotFound(err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{Type: audit.EventWebhookDelete, TargetID: req.ID})
+
+	s.log.Infow("delete webhook subscription request successful", "subscriptionId", req.ID)
+	return &genwebhook.DeleteWebhookSubscriptionResponse{
+		Success: true,
+		Message: "Webhook subscription deleted successfully",
+	}, nil
+}
+
+// ListDeliveries returns the deliveries of the tenant matching the filters, newest first.
+func (s *service) ListDeliveries(ctx context.Context, req *genwebhook.ListWebhookDeliveriesRequest) (*genwebhook.ListWebhookDeliveriesResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("list webhook deliveries request received", "userId", claims.Subject, "subscriptionId", req.SubscriptionID, "status", req.Status)
+
+	filter := webhookstore.Filter{TenantID: tenancy.FromContext(ctx), Limit: req.Limit}
+	if req.SubscriptionID != nil {
+		filter.SubscriptionID = *req.SubscriptionID
+	}
+	if req.Status != nil {
+		filter.Status = *req.Status
+	}
+
+	deliveries, err := s.store.ListDeliveries(ctx, filter)
+	if err != nil {
+		s.log.Infow("list webhook deliveries error", "error", err)
+		return nil, genwebhook.MakeInternalServerError(err)
+	}
+
+	data := make([]*genwebhook.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		data = append(data, toDelivery(d))
+	}
+
+	s.log.Infow("list webhook deliveries request successful", "totalDeliveries", len(data))
+	return &genwebhook.ListWebhookDeliveriesResponse{
+		Success: true,
+		Message: "Webhook deliveries fetched successfully",
+		Data:    data,
+	}, nil
+}
+
+// RetryDelivery takes a dead-lettered delivery of the tenant out of the dead-letter queue. It
+// is attempted once more by the dispatcher, and dead-lettered again if that attempt fails.
+func (s *service) RetryDelivery(ctx context.Context, req *genwebhook.WebhookDeliveryRequest) (*genwebhook.WebhookDeliveryResponse, error) {
+	claims, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("retry webhook delivery request received", "userId", claims.Subject, "deliveryId", req.ID)
+
+	d, err := s.store.QueryDelivery(ctx, tenancy.FromContext(ctx), req.ID)
+	if err != nil {
+		s.log.Infow("retry webhook delivery error", "deliveryId", req.ID, "error", err)
+		return nil, genwebhook.MakeNotFound(err)
+	}
+	if d.Status != webhookstore.StatusDead {
+		s.log.Infow("retry webhook delivery error", "deliveryId", req.ID, "status", d.Status)
+		return nil, genwebhook.MakeConflict(fmt.Errorf("delivery with id %s isn't dead-lettered", req.ID))
+	}
+
+	now := time.Now()
+	d.Status, d.NextAttemptAt, d.UpdatedAt = webhookstore.StatusPending, now, now
+	if err := s.store.UpdateDelivery(ctx, d); err != nil {
+		s.log.Infow("retry webhook delivery error", "deliveryId", req.ID, "error", err)
+		return nil, genwebhook.MakeInternalServerError(err)
+	}
+
+	s.log.Infow("retry webhook delivery request successful", "deliveryId", req.ID)
+	return &genwebhook.WebhookDeliveryResponse{
+		Success: true,
+		Message: "Webhook delivery queued for retry successfully",
+		Data:    toDelivery(d),
+	}, nil
+}
+
+// authorize returns the caller's claims, requiring a user token holding the permission to manage webhooks.
+func authorize(ctx context.Context) (tokenmgr.Claims, error) {
+	claims, ok := tokenmgr.UserClaimsFromContext(ctx)
+	if !ok {
+		return tokenmgr.Claims{}, genwebhook.MakeUnauthorized(fmt.Errorf("user access token required"))
+	}
+	if !slices.Contains(claims.Permissions, userdomain.PermissionManageWebhooks) {
+		return tokenmgr.Claims{}, genwebhook.MakeForbidden(fmt.Errorf("caller lacks the %s permission", userdomain.PermissionManageWebhooks))
+	}
+	return claims, nil
+}
+
+// randomSecret returns a new secret for signing the payloads of a subscription.
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// toSubscription converts a stored subscription into its API representation, without its secret.
+func toSubscription(sub *webhookstore.Subscription) *genwebhook.WebhookSubscription {
+	return &genwebhook.WebhookSubscription{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Events:    sub.EventTypes,
+		CreatedAt: sub.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// toDelivery converts a stored delivery into its API representation.
+func toDelivery(d *webhookstore.Delivery) *genwebhook.WebhookDelivery {
+	res := &genwebhook.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       make([]*genwebhook.WebhookAttempt, 0, len(d.Attempts)),
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if !d.NextAttemptAt.IsZero() {
+		next := d.NextAttemptAt.Format(time.RFC3339)
+		res.NextAttemptAt = &next
+	}
+	for _, a := range d.Attempts {
+		attempt := &genwebhook.WebhookAttempt{
+			AttemptedAt: a.AttemptedAt.Format(time.RFC3339),
+			DurationMs:  a.Duration.Milliseconds(),
+		}
+		if a.StatusCode != 0 {
+			attempt.StatusCode = &a.StatusCode
+		}
+		if a.Error != "" {
+			attempt.Error = &a.Error
+		}
+		res.Attempts = append(res.Attempts, attempt)
+	}
+	return res
+}
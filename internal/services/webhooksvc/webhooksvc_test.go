@@ -0,0 +1,389 @@
+package webhooksvc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
+
+	genuser "github.com/iamBelugaa/goa-iam/gen/user"
+	genwebhook "github.com/iamBelugaa/goa-iam/gen/webhook"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	userdomain "github.com/iamBelugaa/goa-iam/internal/domain/user"
+	"github.com/iamBelugaa/goa-iam/internal/events"
+	"github.com/iamBelugaa/goa-iam/internal/services/auditsvc/audit"
+	auditmemorystore "github.com/iamBelugaa/goa-iam/internal/services/auditsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth"
+	revocationmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/jwtauth/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/session"
+	sessionmemorystore "github.com/iamBelugaa/goa-iam/internal/services/authsvc/session/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/authsvc/tokenmgr"
+	usermemorystore "github.com/iamBelugaa/goa-iam/internal/services/usersvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/services/webhooksvc"
+	"github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/dispatch"
+	webhookstore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store"
+	webhookmemorystore "github.com/iamBelugaa/goa-iam/internal/services/webhooksvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+func TestWebhookService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Webhook Service Suite")
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorName returns the name of a service error, or the empty string for other errors.
+func errorName(err error) string {
+	var serviceErr *goa.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Name
+	}
+	return ""
+}
+
+// request is a webhook request received by the test endpoint.
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is an endpoint recording the requests it receives and responding with a status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []request
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request{header: req.Header.Clone(), body: body})
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request(nil), r.requests...)
+}
+
+// flakyStore is a webhook store failing to create the delivery of the given call once.
+type flakyStore struct {
+	webhookstore.WebhookStorer
+	failOn int
+	calls  int
+}
+
+func (s *flakyStore) CreateDelivery(ctx context.Context, d *webhookstore.Delivery) error {
+	s.calls++
+	if s.calls == s.failOn {
+		return errors.New("connection reset")
+	}
+	return s.WebhookStorer.CreateDelivery(ctx, d)
+}
+
+var _ = Describe("Webhook service", func() {
+	const tenantID = "acme"
+
+	var (
+		ctx        context.Context
+		log        *logger.Logger
+		authn      *jwtauth.Authenticator
+		adminCtx   context.Context
+		tm         *tokenmgr.JWTTokenManager
+		users      = usermemorystore.NewMemoryStore()
+		store      webhookstore.WebhookStorer
+		relay      *events.Relay
+		dispatcher *dispatch.Dispatcher
+		endpoint   *receiver
+		server     *httptest.Server
+		svc        genwebhook.Service
+		cfg        *config.Webhooks
+	)
+
+	userCtx := func(userID string, permissions ...string) context.Context {
+		claims := tm.StandardClaims(userID, tokenmgr.AccessToken)
+		claims.TenantID = tenantID
+		claims.Permissions = permissions
+		return tokenmgr.WithClaims(tenancy.WithTenant(ctx, tenantID), claims)
+	}
+
+	subscribe := func(eventTypes ...string) *genwebhook.CreatedWebhookSubscriptionResponse {
+		res, err := svc.CreateSubscription(adminCtx, &genwebhook.CreateWebhookSubscriptionRequest{URL: server.URL, Events: eventTypes})
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	// deliver publishes the pending domain events and attempts the deliveries that are due.
+	deliver := func() {
+		_, err := relay.Flush(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = dispatcher.DeliverDue(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	deliveries := func(status string) []*genwebhook.WebhookDelivery {
+		res, err := svc.ListDeliveries(adminCtx, &genwebhook.ListWebhookDeliveriesRequest{Status: ptr(status), Limit: 100})
+		Expect(err).NotTo(HaveOccurred())
+		return res.Data
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		log, err = logger.NewWithConfig("test", "1.0.0", config.EnvironmentDevelopment, &config.Logging{Level: "error"})
+		Expect(err).NotTo(HaveOccurred())
+
+		authCfg := &config.Auth{
+			Issuer:              "https://issuer.test",
+			Secret:              "test-secret",
+			Audience:            "test",
+			AccessTokenExpTime:  time.Hour,
+			RefreshTokenExpTime: 24 * time.Hour,
+		}
+		tm = tokenmgr.NewJWTManager(authCfg)
+		sessions := session.NewManager(sessionmemorystore.NewMemoryStore(), authCfg)
+		authn = jwtauth.New(tm, sessions, revocationmemorystore.NewMemoryStore())
+
+		cfg = &config.Webhooks{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     4 * time.Millisecond,
+			Timeout:        time.Second,
+			PollInterval:   10 * time.Millisecond,
+			// The test endpoints listen on the loopback interface.
+			AllowPrivateNetworks: true,
+		}
+		store = webhookmemorystore.NewMemoryStore()
+		dispatcher = dispatch.New(log, store, cfg)
+		bus := events.NewBus()
+		bus.Subscribe(dispatcher.Handle)
+
+		users = usermemorystore.NewMemoryStore()
+		relay = events.NewRelay(log, bus, users)
+
+		endpoint = &receiver{status: http.StatusNoContent}
+		server = httptest.NewServer(endpoint)
+		DeferCleanup(server.Close)
+
+		svc = webhooksvc.NewService(log, store, authn, audit.NewRecorder(log, auditmemorystore.NewMemoryStore()), cfg)
+		adminCtx = userCtx("admin", userdomain.PermissionManageWebhooks)
+	})
+
+	It("delivers signed user events recorded by the user store to subscribed endpoints", func() {
+		sub := subscribe(events.TypeUserCreated, events.TypeUserSuspended)
+		Expect(sub.Data.Events).To(Equal([]string{events.TypeUserCreated, events.TypeUserSuspended}))
+
+		u, err := users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = users.Create(ctx, "globex", &genuser.CreateUserRequest{FirstName: "John", LastName: "Doe", Email: "john@globex.com"})
+		Expect(err).NotTo(HaveOccurred())
+
+		changed := *u
+		changed.Email = "jane.doe@acme.com"
+		changed.Status = userdomain.UserStatusInactive
+		_, err = users.Update(ctx, &changed)
+		Expect(err).NotTo(HaveOccurred())
+		deliver()
+
+		received := endpoint.received()
+		Expect(received).To(HaveLen(2))
+
+		created := received[0]
+		timestamp := created.header.Get(dispatch.HeaderTimestamp)
+		Expect(created.header.Get(dispatch.HeaderSignature)).To(Equal(dispatch.Sign(sub.Secret, timestamp, created.body)))
+		Expect(created.header.Get(dispatch.HeaderSignature)).NotTo(Equal(dispatch.Sign("other-secret", timestamp, created.body)))
+		Expect(created.header.Get(dispatch.HeaderEvent)).To(Equal(events.TypeUserCreated))
+
+		var event events.Event
+		Expect(json.Unmarshal(created.body, &event)).To(Succeed())
+		Expect(event.TenantID).To(Equal(tenantID))
+		Expect(event.SubjectID).To(Equal(u.ID))
+		Expect(event.Data).To(HaveKeyWithValue("email", "jane@acme.com"))
+
+		Expect(json.Unmarshal(received[1].body, &event)).To(Succeed())
+		Expect(event.Type).To(Equal(events.TypeUserSuspended))
+		Expect(event.Data).To(HaveKeyWithValue("status", userdomain.UserStatusInactive))
+
+		Expect(deliveries("succeeded")).To(HaveLen(2))
+		pending, err := users.Pending(ctx, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(BeEmpty())
+
+		// Published events aren't delivered again.
+		deliver()
+		Expect(endpoint.received()).To(HaveLen(2))
+	})
+
+	It("creates the deliveries of an event once when handling it again after a failure", func() {
+		subscribe(events.TypeUserCreated)
+		subscribe(events.TypeUserCreated)
+
+		dispatcher = dispatch.New(log, &flakyStore{WebhookStorer: store, failOn: 2}, cfg)
+		bus := events.NewBus()
+		bus.Subscribe(dispatcher.Handle)
+		relay = events.NewRelay(log, bus, users)
+
+		_, err := users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = relay.Flush(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(deliveries("pending")).To(HaveLen(1))
+
+		// The event stays in the outbox and is handled again on the next flush.
+		deliver()
+		Expect(deliveries("succeeded")).To(HaveLen(2))
+		Expect(endpoint.received()).To(HaveLen(2))
+
+		deliver()
+		Expect(deliveries("")).To(HaveLen(2))
+		Expect(endpoint.received()).To(HaveLen(2))
+	})
+
+	It("retries failed deliveries with exponential backoff and dead-letters them", func() {
+		Expect(dispatch.Backoff(cfg, 1)).To(Equal(time.Millisecond))
+		Expect(dispatch.Backoff(cfg, 2)).To(Equal(2 * time.Millisecond))
+		Expect(dispatch.Backoff(cfg, 5)).To(Equal(4 * time.Millisecond))
+
+		endpoint.setStatus(http.StatusServiceUnavailable)
+		subscribe(events.TypeUserEmailChanged)
+
+		u, err := users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		changed := *u
+		changed.Email = "jane.doe@acme.com"
+		_, err = users.Update(ctx, &changed)
+		Expect(err).NotTo(HaveOccurred())
+
+		deliver()
+		Expect(deliveries("pending")).To(HaveLen(1))
+		Eventually(func() []*genwebhook.WebhookDelivery {
+			deliver()
+			return deliveries("dead")
+		}).Should(HaveLen(1))
+
+		dead := deliveries("dead")[0]
+		Expect(dead.EventType).To(Equal(events.TypeUserEmailChanged))
+		Expect(dead.Attempts).To(HaveLen(cfg.MaxAttempts))
+		Expect(dead.Attempts[0].StatusCode).To(Equal(ptr(http.StatusServiceUnavailable)))
+		Expect(dead.Attempts[0].Error).To(Equal(ptr("endpoint responded with status 503")))
+		Expect(dead.NextAttemptAt).To(BeNil())
+		Expect(endpoint.received()).To(HaveLen(cfg.MaxAttempts))
+
+		// Dead-lettered deliveries are only attempted again when retried.
+		deliver()
+		Expect(endpoint.received()).To(HaveLen(cfg.MaxAttempts))
+
+		endpoint.setStatus(http.StatusOK)
+		res, err := svc.RetryDelivery(adminCtx, &genwebhook.WebhookDeliveryRequest{ID: dead.ID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Data.Status).To(Equal("pending"))
+
+		deliver()
+		Expect(deliveries("succeeded")).To(HaveLen(1))
+
+		_, err = svc.RetryDelivery(adminCtx, &genwebhook.WebhookDeliveryRequest{ID: dead.ID})
+		Expect(errorName(err)).To(Equal("conflict"))
+	})
+
+	It("refuses endpoints of the internal network and doesn't follow redirects", func() {
+		strict := *cfg
+		strict.AllowPrivateNetworks = false
+		strictSvc := webhooksvc.NewService(log, store, authn, audit.NewRecorder(log, auditmemorystore.NewMemoryStore()), &strict)
+
+		for _, target := range []string{server.URL, "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hooks", "http://[::1]/hooks"} {
+			_, err := strictSvc.CreateSubscription(adminCtx, &genwebhook.CreateWebhookSubscriptionRequest{
+				URL: target, Events: []string{events.TypeUserCreated},
+			})
+			Expect(errorName(err)).To(Equal("bad_request"), target)
+		}
+
+		// Endpoints resolving to the internal network once subscribed are refused when dialed.
+		sub := subscribe(events.TypeUserCreated)
+		_, err := users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = relay.Flush(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = dispatch.New(log, store, &strict).DeliverDue(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoint.received()).To(BeEmpty())
+
+		pending := deliveries("pending")
+		Expect(pending).To(HaveLen(1))
+		Expect(*pending[0].Attempts[0].Error).To(ContainSubstring(dispatch.ErrPrivateAddress.Error()))
+		_, err = svc.DeleteSubscription(adminCtx, &genwebhook.WebhookSubscriptionRequest{ID: sub.Data.ID})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Redirects count as failed attempts rather than being followed.
+		redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusTemporaryRedirect))
+		DeferCleanup(redirect.Close)
+		_, err = svc.CreateSubscription(adminCtx, &genwebhook.CreateWebhookSubscriptionRequest{
+			URL: redirect.URL, Events: []string{events.TypeUserCreated},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "John", LastName: "Doe", Email: "john@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		deliver()
+
+		redirected := deliveries("pending")
+		Expect(redirected).To(HaveLen(1))
+		Expect(redirected[0].Attempts[0].StatusCode).To(Equal(ptr(http.StatusTemporaryRedirect)))
+		Expect(endpoint.received()).To(BeEmpty())
+	})
+
+	It("stops delivering to removed subscriptions", func() {
+		sub := subscribe(events.TypeUserCreated)
+		_, err := users.Create(ctx, tenantID, &genuser.CreateUserRequest{FirstName: "Jane", LastName: "Doe", Email: "jane@acme.com"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = relay.Flush(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = svc.DeleteSubscription(adminCtx, &genwebhook.WebhookSubscriptionRequest{ID: sub.Data.ID})
+		Expect(err).NotTo(HaveOccurred())
+		deliver()
+		Expect(endpoint.received()).To(BeEmpty())
+
+		list, err := svc.ListSubscriptions(adminCtx, &genwebhook.ListWebhookSubscriptionsRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Data).To(BeEmpty())
+
+		_, err = svc.DeleteSubscription(adminCtx, &genwebhook.WebhookSubscriptionRequest{ID: sub.Data.ID})
+		Expect(errorName(err)).To(Equal("not_found"))
+	})
+
+	It("validates endpoints and requires the permission to manage webhooks", func() {
+		_, err := svc.CreateSubscription(adminCtx, &genwebhook.CreateWebhookSubscriptionRequest{
+			URL: "ftp://hooks.acme.com", Events: []string{events.TypeUserCreated},
+		})
+		Expect(errorName(err)).To(Equal("bad_request"))
+
+		_, err = svc.CreateSubscription(userCtx("jane"), &genwebhook.CreateWebhookSubscriptionRequest{
+			URL: server.URL, Events: []string{events.TypeUserCreated},
+		})
+		Expect(errorName(err)).To(Equal("forbidden"))
+
+		_, err = svc.ListDeliveries(tenancy.WithTenant(ctx, tenantID), &genwebhook.ListWebhookDeliveriesRequest{Limit: 100})
+		Expect(errorName(err)).To(Equal("unauthorized"))
+	})
+})
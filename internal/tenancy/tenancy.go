@@ -0,0 +1,106 @@
+// Package tenancy routes HTTP requests to the organization they are addressed to and
+// carries the organization into the service layer, which scopes every user, group and
+// OAuth client lookup to it.
+package tenancy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+	"github.com/iamBelugaa/goa-iam/pkg/logger"
+)
+
+// tenantContextKey is the context key under which the tenant ID is stored.
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of ctx scoped to the organization with the given ID.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// FromContext returns the ID of the organization ctx is scoped to, or the empty string
+// outside of a routed request.
+func FromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// Router resolves the organization each request is addressed to.
+type Router struct {
+	orgs      orgstore.OrganizationStorer // Store of the organizations requests are routed to
+	cfg       *config.Tenancy             // Routing settings
+	defaultID string                      // ID of the organization requests default to
+}
+
+// NewRouter creates a router over the given organizations, defaulting requests that
+// name no organization to the one with the given ID.
+func NewRouter(orgs orgstore.OrganizationStorer, cfg *config.Tenancy, defaultID string) *Router {
+	return &Router{orgs: orgs, cfg: cfg, defaultID: defaultID}
+}
+
+// DefaultOrganizationID returns the ID of the organization requests default to.
+func (r *Router) DefaultOrganizationID() string {
+	return r.defaultID
+}
+
+// Middleware scopes each request to the organization it is addressed to, rejecting
+// requests addressed to organizations that don't exist.
+func (r *Router) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tenantID, err := r.resolve(req)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"name": "tenant_not_found", "message": err.Error()})
+			return
+		}
+		ctx := logger.WithFields(WithTenant(req.Context(), tenantID), "tenantId", tenantID)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// resolve returns the ID of the organization named by the tenant header, which holds a
+// slug or an ID, or else by the subdomain of the base domain the request was sent to.
+func (r *Router) resolve(req *http.Request) (string, error) {
+	if ref := strings.TrimSpace(req.Header.Get(r.cfg.Header)); ref != "" {
+		if org, err := r.orgs.QueryBySlug(req.Context(), ref); err == nil {
+			return org.ID, nil
+		}
+		if org, err := r.orgs.QueryByID(req.Context(), ref); err == nil {
+			return org.ID, nil
+		}
+		return "", fmt.Errorf("organization %s doesn't exist", ref)
+	}
+
+	if slug, ok := r.subdomain(req.Host); ok {
+		org, err := r.orgs.QueryBySlug(req.Context(), slug)
+		if err != nil {
+			return "", err
+		}
+		return org.ID, nil
+	}
+
+	return r.defaultID, nil
+}
+
+// subdomain returns the label the host adds in front of the base domain, if any.
+func (r *Router) subdomain(host string) (string, bool) {
+	if r.cfg.BaseDomain == "" {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(r.cfg.BaseDomain))
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
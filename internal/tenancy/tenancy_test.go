@@ -0,0 +1,91 @@
+package tenancy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/iamBelugaa/goa-iam/internal/config"
+	orgstore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store"
+	orgmemorystore "github.com/iamBelugaa/goa-iam/internal/services/orgsvc/store/memory"
+	"github.com/iamBelugaa/goa-iam/internal/tenancy"
+)
+
+func TestTenancy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tenancy Suite")
+}
+
+var _ = Describe("Router", func() {
+	const defaultID = "default-org-id"
+
+	var (
+		acme *orgstore.Organization
+
+		// route sends a request through the router and returns the response and the tenant
+		// the request was scoped to.
+		route func(req *http.Request) (*httptest.ResponseRecorder, string)
+	)
+
+	BeforeEach(func() {
+		orgs := orgmemorystore.NewMemoryStore()
+		acme = &orgstore.Organization{ID: "acme-org-id", Slug: "acme", Name: "Acme", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		Expect(orgs.Create(context.Background(), acme)).To(Succeed())
+
+		router := tenancy.NewRouter(orgs, &config.Tenancy{Header: "X-Tenant-ID", BaseDomain: "iam.example.com"}, defaultID)
+		route = func(req *http.Request) (*httptest.ResponseRecorder, string) {
+			tenantID := ""
+			handler := router.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tenantID = tenancy.FromContext(r.Context())
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec, tenantID
+		}
+	})
+
+	It("routes requests naming no tenant to the default organization", func() {
+		rec, tenantID := route(httptest.NewRequest(http.MethodGet, "http://localhost/api/v1/users", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(tenantID).To(Equal(defaultID))
+	})
+
+	It("routes requests by the slug or ID in the tenant header", func() {
+		for _, ref := range []string{"acme", acme.ID} {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v1/users", nil)
+			req.Header.Set("X-Tenant-ID", ref)
+
+			rec, tenantID := route(req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(tenantID).To(Equal(acme.ID))
+		}
+	})
+
+	It("routes requests by the subdomain of the base domain", func() {
+		rec, tenantID := route(httptest.NewRequest(http.MethodGet, "http://acme.iam.example.com:8080/api/v1/users", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(tenantID).To(Equal(acme.ID))
+
+		// The base domain itself and deeper subdomains name no tenant.
+		for _, host := range []string{"iam.example.com", "a.acme.iam.example.com"} {
+			_, tenantID = route(httptest.NewRequest(http.MethodGet, "http://"+host+"/api/v1/users", nil))
+			Expect(tenantID).To(Equal(defaultID))
+		}
+	})
+
+	It("rejects requests addressed to unknown organizations", func() {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v1/users", nil)
+		req.Header.Set("X-Tenant-ID", "globex")
+		rec, _ := route(req)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).To(ContainSubstring("tenant_not_found"))
+
+		rec, _ = route(httptest.NewRequest(http.MethodGet, "http://globex.iam.example.com/api/v1/users", nil))
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+})
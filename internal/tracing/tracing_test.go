@@ -0,0 +1,92 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	goahttp "goa.design/goa/v3/http"
+	goa "goa.design/goa/v3/pkg"
+
+	"github.com/iamBelugaa/goa-iam/internal/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+var _ = Describe("Tracing", func() {
+	var recorder *tracetest.SpanRecorder
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	It("continues the incoming trace and names spans after the route and Goa method", func() {
+		endpoint := tracing.Endpoint(func(ctx context.Context, req any) (any, error) {
+			_, span := tracing.Start(ctx, "UserStorer.QueryById")
+			tracing.End(span, nil)
+			return nil, goa.PermanentError("not_found", "user doesn't exist")
+		})
+
+		mux := goahttp.NewMuxer()
+		mux.Use(tracing.Middleware(mux))
+		mux.Handle(http.MethodGet, "/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), goa.ServiceKey, "user")
+			ctx = context.WithValue(ctx, goa.MethodKey, "getById")
+			_, _ = endpoint(ctx, nil)
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(3))
+		store, method, server := spans[0], spans[1], spans[2]
+
+		Expect(server.Name()).To(Equal("GET /api/v1/users/{id}"))
+		Expect(server.SpanContext().TraceID().String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+		Expect(server.Parent().SpanID().String()).To(Equal("00f067aa0ba902b7"))
+		Expect(server.Parent().IsRemote()).To(BeTrue())
+
+		Expect(method.Name()).To(Equal("user.getById"))
+		Expect(method.Parent().SpanID()).To(Equal(server.SpanContext().SpanID()))
+		Expect(method.Status().Code).To(Equal(codes.Error))
+
+		Expect(store.Name()).To(Equal("UserStorer.QueryById"))
+		Expect(store.Parent().SpanID()).To(Equal(method.SpanContext().SpanID()))
+		Expect(store.Status().Code).To(Equal(codes.Unset))
+	})
+
+	It("starts a new trace for requests without trace context", func() {
+		mux := goahttp.NewMuxer()
+		mux.Use(tracing.Middleware(mux))
+		mux.Handle(http.MethodGet, "/healthz", func(w http.ResponseWriter, r *http.Request) {
+			_, span := tracing.Start(r.Context(), "check")
+			tracing.End(span, errors.New("unavailable"))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[1].Parent().IsValid()).To(BeFalse())
+		Expect(spans[1].Status().Code).To(Equal(codes.Error))
+		Expect(spans[0].Parent().SpanID()).To(Equal(spans[1].SpanContext().SpanID()))
+	})
+})
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	goa "goa.design/goa/v3/pkg"
+)
+
+// loggerContextKey is the context key under which the request logger is stored.
+type loggerContextKey struct{}
+
+// nop discards the entries logged through contexts without a logger.
+var nop = &Logger{SugaredLogger: zap.NewNop().Sugar()}
+
+// WithContext returns a copy of ctx carrying the given logger.
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// WithFields returns a copy of ctx whose logger adds the given key-value pairs to every
+// entry, such as the tenant or user a request is made for once it is known.
+func WithFields(ctx context.Context, keysAndValues ...any) context.Context {
+	l := stored(ctx)
+	return WithContext(ctx, l.derive(l.With(keysAndValues...)))
+}
+
+// FromContext returns the logger of the request ctx belongs to. Besides the fields added to
+// the context, its entries carry the Goa service and method handling the request and the IDs
+// of the current trace and span. The logger is named after the service, so level overrides
+// of the service apply to it. Entries logged through a context without a logger are discarded.
+func FromContext(ctx context.Context) *Logger {
+	l := stored(ctx).WithTrace(ctx)
+
+	service, _ := ctx.Value(goa.ServiceKey).(string)
+	method, _ := ctx.Value(goa.MethodKey).(string)
+	if service == "" || method == "" {
+		return l
+	}
+	named := l.Named(service)
+	return named.derive(named.With("route", service+"."+method))
+}
+
+// stored returns the logger carried by ctx, without the fields derived from ctx at logging time.
+func stored(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*Logger); ok {
+		return l
+	}
+	return nop
+}
@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels controls, at runtime, the minimum level of the entries a logger and the loggers
+// derived from it write. Besides the global level, overrides apply to named loggers, such
+// as the logger of one service, and to the loggers named after them: an override for "auth"
+// also applies to "auth.passkey". A level change may be reverted automatically once a TTL
+// elapses, so debug logging turned on during an incident doesn't outlive it.
+type Levels struct {
+	mu        sync.RWMutex
+	global    zap.AtomicLevel          // Level of loggers without an override
+	overrides map[string]zapcore.Level // Levels of named loggers
+	reverts   map[string]*revert       // Pending reverts by override name, "" for the global level
+}
+
+// revert restores a level once the TTL of a change elapses.
+type revert struct {
+	timer    *time.Timer    // Timer running the revert
+	at       time.Time      // Time of the revert
+	previous *zapcore.Level // Level before the change, nil for an override that didn't exist
+}
+
+// LevelState describes a level and when it reverts, if ever.
+type LevelState struct {
+	Level    zapcore.Level
+	RevertAt *time.Time
+}
+
+// newLevels creates level controls with the given global level and no override.
+func newLevels(level zapcore.Level) *Levels {
+	return &Levels{
+		global:    zap.NewAtomicLevelAt(level),
+		overrides: make(map[string]zapcore.Level),
+		reverts:   make(map[string]*revert),
+	}
+}
+
+// ParseLevel parses a level name, such as debug or INFO.
+func ParseLevel(name string) (zapcore.Level, error) {
+	level, err := zapcore.ParseLevel(strings.ToLower(name))
+	if err != nil {
+		return level, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// Global returns the global level.
+func (l *Levels) Global() LevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return LevelState{Level: l.global.Level(), RevertAt: l.revertAt("")}
+}
+
+// Overrides returns the levels of named loggers by name.
+func (l *Levels) Overrides() map[string]LevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	states := make(map[string]LevelState, len(l.overrides))
+	for name, level := range l.overrides {
+		states[name] = LevelState{Level: level, RevertAt: l.revertAt(name)}
+	}
+	return states
+}
+
+// SetGlobal changes the global level. With a positive ttl, the level in effect before the
+// change is restored once ttl elapses.
+func (l *Levels) SetGlobal(level zapcore.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous := l.global.Level()
+	l.schedule("", &previous, ttl)
+	l.global.SetLevel(level)
+}
+
+// SetOverride changes the level of the named logger and the loggers named after it. With a
+// positive ttl, the override in effect before the change, if any, is restored once ttl elapses.
+func (l *Levels) SetOverride(name string, level zapcore.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var previous *zapcore.Level
+	if current, ok := l.overrides[name]; ok {
+		previous = &current
+	}
+	l.schedule(name, previous, ttl)
+	l.overrides[name] = level
+}
+
+// RemoveOverride removes the override of the named logger, which then logs at the level of
+// the closest override of a parent name, or the global level.
+func (l *Levels) RemoveOverride(name string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.overrides[name]; !ok {
+		return false
+	}
+	l.schedule(name, nil, 0)
+	delete(l.overrides, name)
+	return true
+}
+
+// schedule replaces the pending revert of the key by one restoring previous after ttl, or
+// cancels it when ttl isn't positive. Successive timed changes restore the level in effect
+// before the first of them. Callers must hold the lock.
+func (l *Levels) schedule(key string, previous *zapcore.Level, ttl time.Duration) {
+	if pending, ok := l.reverts[key]; ok {
+		pending.timer.Stop()
+		delete(l.reverts, key)
+		previous = pending.previous
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	r := &revert{at: time.Now().Add(ttl), previous: previous}
+	r.timer = time.AfterFunc(ttl, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.reverts[key] != r {
+			return
+		}
+		delete(l.reverts, key)
+		switch {
+		case key == "":
+			l.global.SetLevel(*r.previous)
+		case r.previous == nil:
+			delete(l.overrides, key)
+		default:
+			l.overrides[key] = *r.previous
+		}
+	})
+	l.reverts[key] = r
+}
+
+// revertAt returns the time the level of the key reverts, if a revert is pending. Callers
+// must hold the lock.
+func (l *Levels) revertAt(key string) *time.Time {
+	if r, ok := l.reverts[key]; ok {
+		at := r.at
+		return &at
+	}
+	return nil
+}
+
+// enabled reports whether entries of the level are written by the named logger.
+func (l *Levels) enabled(name string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for name != "" {
+		if override, ok := l.overrides[name]; ok {
+			return override.Enabled(level)
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.global.Enabled(level)
+}
+
+// anyEnabled reports whether entries of the level are written by some logger.
+func (l *Levels) anyEnabled(level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.global.Enabled(level) {
+		return true
+	}
+	for _, override := range l.overrides {
+		if override.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelCore filters the entries of a core by the level of the logger writing them.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+// Enabled reports whether a logger may write entries of the level, leaving the decision for
+// a given logger to Check.
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.anyEnabled(level)
+}
+
+// With adds fields to the underlying core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check adds the underlying core to the checked entry if the logger that wrote the entry
+// logs at its level.
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
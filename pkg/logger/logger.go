@@ -3,18 +3,23 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/iamBelugaa/goa-iam/internal/config"
+	"github.com/iamBelugaa/goa-iam/pkg/redact"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// Logger wraps zap.SugaredLogger and holds logging config information.
+// Logger wraps zap.SugaredLogger and holds logging config information, along with the
+// levels controlling at runtime which entries it and the loggers derived from it write.
 type Logger struct {
 	*zap.SugaredLogger
 	config *config.Logging
+	levels *Levels
 }
 
 // NewWithConfig initializes a new logger using the provided service name,
@@ -43,7 +48,9 @@ func NewWithConfig(service, version string, environment config.Environment, cfg
 	zapConfig.Encoding = "json"
 	zapConfig.DisableCaller = false
 	zapConfig.DisableStacktrace = false
-	zapConfig.Level = zap.NewAtomicLevelAt(level)
+	// Entries are filtered by the runtime levels, which the core built from the config defers to.
+	levels := newLevels(level)
+	zapConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 
 	zapConfig.OutputPaths = []string{"stderr"}
 	zapConfig.ErrorOutputPaths = []string{"stderr"}
@@ -54,6 +61,7 @@ func NewWithConfig(service, version string, environment config.Environment, cfg
 	zapConfig.EncoderConfig.CallerKey = "caller"
 	zapConfig.EncoderConfig.TimeKey = "timestamp"
 	zapConfig.EncoderConfig.MessageKey = "message"
+	zapConfig.EncoderConfig.NameKey = "logger"
 	zapConfig.EncoderConfig.StacktraceKey = "stacktrace"
 	zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
@@ -64,14 +72,53 @@ func NewWithConfig(service, version string, environment config.Environment, cfg
 		"pid":     os.Getpid(),
 	}
 
+	// Sensitive data is redacted from the fields of every entry before they're encoded.
+	policy, err := redact.NewPolicy(redact.Mode(cfg.RedactionMode), []byte(cfg.RedactionKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create redaction policy : %w", err)
+	}
+
+	wrapCore := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: policy.Core(core), levels: levels}
+	})
+
 	return &Logger{
 		config: cfg,
+		levels: levels,
 		SugaredLogger: zap.Must(
-			zapConfig.Build(zap.AddCallerSkip(1), zap.AddStacktrace(zap.ErrorLevel)),
+			zapConfig.Build(zap.AddCallerSkip(1), zap.AddStacktrace(zap.ErrorLevel), wrapCore),
 		).Sugar(),
 	}, nil
 }
 
+// Levels returns the runtime level controls shared by the logger and the loggers derived
+// from it, or nil for loggers not built by NewWithConfig.
+func (l *Logger) Levels() *Levels {
+	return l.levels
+}
+
+// Named returns a logger adding the name to its own, such as the name of the service it logs
+// for. Level overrides of the name apply to its entries.
+func (l *Logger) Named(name string) *Logger {
+	return l.derive(l.SugaredLogger.Named(name))
+}
+
+// derive returns a logger writing through the given sugared logger, derived from l's.
+func (l *Logger) derive(sugared *zap.SugaredLogger) *Logger {
+	return &Logger{config: l.config, levels: l.levels, SugaredLogger: sugared}
+}
+
+// WithTrace returns a logger adding the IDs of the trace and span found in ctx to every entry,
+// correlating the entries with the trace of the request they were logged for. The logger
+// itself is returned when ctx carries no span.
+func (l *Logger) WithTrace(ctx context.Context) *Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return l.derive(l.With("traceId", sc.TraceID().String(), "spanId", sc.SpanID().String()))
+}
+
 // Close ensures that any buffered logs are flushed to the output.
 func (l *Logger) Close() error {
 	return l.Sync()
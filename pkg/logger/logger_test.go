@@ -2,6 +2,7 @@ package logger_test
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	goa "goa.design/goa/v3/pkg"
 
 	"github.com/iamBelugaa/goa-iam/internal/config"
 	"github.com/iamBelugaa/goa-iam/pkg/logger"
@@ -131,6 +133,34 @@ var _ = Describe("Logger", func() {
 		})
 	})
 
+	Describe("FromContext", func() {
+		It("should enrich entries with the fields of the request context", func() {
+			log, err := logger.NewWithConfig(service, version, environment, cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx := logger.WithContext(context.Background(), log)
+			ctx = logger.WithFields(ctx, "requestId", "req-1")
+			ctx = logger.WithFields(ctx, "tenantId", "org-1")
+			ctx = context.WithValue(ctx, goa.ServiceKey, "auth")
+			ctx = context.WithValue(ctx, goa.MethodKey, "signin")
+
+			logger.FromContext(ctx).Infow("signin request received")
+			time.Sleep(30 * time.Millisecond)
+
+			output := logBuffer.String()
+			Expect(output).To(ContainSubstring(`"requestId":"req-1"`))
+			Expect(output).To(ContainSubstring(`"tenantId":"org-1"`))
+			Expect(output).To(ContainSubstring(`"route":"auth.signin"`))
+		})
+
+		It("should discard entries of contexts without a logger", func() {
+			Expect(func() { logger.FromContext(context.Background()).Infow("dropped") }).NotTo(Panic())
+			time.Sleep(30 * time.Millisecond)
+
+			Expect(logBuffer.String()).NotTo(ContainSubstring("dropped"))
+		})
+	})
+
 	Describe("Close", func() {
 		XIt("should sync the logger without error", func() {
 			logger, err := logger.NewWithConfig(service, version, environment, cfg)
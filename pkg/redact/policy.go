@@ -0,0 +1,411 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mode defines how a policy redacts the values that identify a person or grant access, such
+// as email addresses and tokens. Passwords and secrets are always removed, whatever the mode.
+type Mode string
+
+const (
+	// ModeMask partially masks values, keeping enough of them, such as the domain of an email
+	// address, to help debugging.
+	ModeMask Mode = "mask"
+	// ModeHash replaces values by a keyed hash, so entries about the same value can be
+	// correlated without revealing it.
+	ModeHash Mode = "hash"
+	// ModeTokenize replaces values by a keyed token keeping their shape, such as the domain of
+	// an email address, so entries about the same value can be correlated and stay readable.
+	ModeTokenize Mode = "tokenize"
+)
+
+// Kind identifies the kind of sensitive data a field holds.
+type Kind string
+
+const (
+	KindEmail    Kind = "email"
+	KindPassword Kind = "password"
+	KindToken    Kind = "token"
+	KindSecret   Kind = "secret"
+	KindPhone    Kind = "phone"
+	KindIP       Kind = "ip"
+)
+
+// TagName is the struct tag marking a field as holding a kind of sensitive data, such as
+// `redact:"email"`, whatever its name. The tag `redact:"-"` exempts a field from the policy.
+const TagName = "redact"
+
+// maxDepth bounds the depth values are walked to, guarding against cycles.
+const maxDepth = 10
+
+// removed replaces the values nothing may be kept of.
+const removed = "[REDACTED]"
+
+// emailPattern matches the email addresses found in free text, such as error messages.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)+`)
+
+// fieldWords maps the words of field names to the kind of data the field holds. Names are
+// split into words at case changes and separators, so "refreshToken", "refresh_token" and
+// "RefreshToken" all hold a token, while "tokenType" doesn't.
+var fieldWords = map[string]Kind{
+	"email":    KindEmail,
+	"password": KindPassword,
+	"passwd":   KindPassword,
+	"token":    KindToken,
+	"secret":   KindSecret,
+	"phone":    KindPhone,
+	"ip":       KindIP,
+}
+
+// fieldPhrases maps the last two words of field names to the kind of data the field holds.
+var fieldPhrases = map[string]Kind{
+	"email address": KindEmail,
+	"phone number":  KindPhone,
+	"ip address":    KindIP,
+}
+
+// Policy redacts the sensitive data found in values about to be logged. Fields are identified
+// by their name, such as the email, password, token, phone and IP fields of Goa-generated
+// types, or by their redact struct tag. Structs, pointers, maps and slices are walked, so
+// sensitive data nested in them is redacted too.
+type Policy struct {
+	mode Mode   // Redaction of values that aren't removed
+	key  []byte // Key of hashes and tokens
+}
+
+// NewPolicy creates a policy redacting values with the given mode, ModeMask when empty. Hashes
+// and tokens are keyed with key, so they can't be reversed by hashing guesses without it; a
+// random key is used when it is empty, making them stable only for the life of the process.
+func NewPolicy(mode Mode, key []byte) (*Policy, error) {
+	switch mode {
+	case "":
+		mode = ModeMask
+	case ModeMask, ModeHash, ModeTokenize:
+	default:
+		return nil, fmt.Errorf("unsupported redaction mode %q", mode)
+	}
+
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate redaction key : %w", err)
+		}
+	}
+
+	return &Policy{mode: mode, key: key}, nil
+}
+
+// FieldKind returns the kind of sensitive data a field of the given name holds, if any.
+func FieldKind(name string) (Kind, bool) {
+	words := splitWords(name)
+	for _, word := range words {
+		if kind := fieldWords[word]; kind == KindPassword || kind == KindSecret {
+			return kind, true
+		}
+	}
+	if n := len(words); n >= 2 {
+		if kind, ok := fieldPhrases[words[n-2]+" "+words[n-1]]; ok {
+			return kind, true
+		}
+	}
+	if n := len(words); n > 0 {
+		last := words[n-1]
+		if kind, ok := fieldWords[last]; ok {
+			return kind, true
+		}
+		// Plural names, such as adminEmails, hold several values of the same kind.
+		if kind, ok := fieldWords[strings.TrimSuffix(last, "s")]; ok && last != "s" {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// String redacts a value of the given kind.
+func (p *Policy) String(kind Kind, value string) string {
+	if kind == KindPassword || kind == KindSecret || value == "" {
+		return removed
+	}
+
+	switch p.mode {
+	case ModeHash:
+		return "sha256:" + p.digest(value)
+	case ModeTokenize:
+		return p.tokenize(kind, value)
+	default:
+		return mask(kind, value)
+	}
+}
+
+// Text redacts the email addresses found in free text, such as the message of an error,
+// whose name doesn't tell that it holds one.
+func (p *Policy) Text(text string) string {
+	if !strings.Contains(text, "@") {
+		return text
+	}
+	return emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		return p.String(KindEmail, email)
+	})
+}
+
+// Field redacts the value of a field of the given name. Values of sensitive fields are
+// redacted whole, while the fields of other values, such as structs and maps, are redacted
+// according to their own name or tag.
+func (p *Policy) Field(name string, value any) any {
+	if kind, ok := FieldKind(name); ok {
+		return p.redactKind(kind, reflect.ValueOf(value), 0)
+	}
+	return p.Value(value)
+}
+
+// Value returns a copy of v in which the sensitive fields of structs and maps are redacted.
+// Structs are returned as maps keyed by the name their fields are encoded to JSON with.
+func (p *Policy) Value(v any) any {
+	return p.walk(reflect.ValueOf(v), 0)
+}
+
+// walk returns a copy of the value with its sensitive fields redacted.
+func (p *Policy) walk(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxDepth {
+		return removed
+	}
+	if opaque(v) {
+		if err, ok := v.Interface().(error); ok {
+			return p.Text(err.Error())
+		}
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return p.walk(v.Elem(), depth+1)
+
+	case reflect.Struct:
+		t := v.Type()
+		fields := make(map[string]any, t.NumField())
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, omitEmpty, ok := jsonName(f)
+			if !ok || (omitEmpty && v.Field(i).IsZero()) {
+				continue
+			}
+			fields[name] = p.structField(f, name, v.Field(i), depth)
+		}
+		return fields
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if kind, ok := FieldKind(key); ok {
+				entries[key] = p.redactKind(kind, iter.Value(), depth+1)
+				continue
+			}
+			entries[key] = p.walk(iter.Value(), depth+1)
+		}
+		return entries
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		items := make([]any, v.Len())
+		for i := range v.Len() {
+			items[i] = p.walk(v.Index(i), depth+1)
+		}
+		return items
+
+	default:
+		return v.Interface()
+	}
+}
+
+// structField redacts the value of a struct field according to its tag, its Go name or the
+// name it is encoded to JSON with.
+func (p *Policy) structField(f reflect.StructField, name string, v reflect.Value, depth int) any {
+	tag := f.Tag.Get(TagName)
+	switch {
+	case tag == "-":
+		return v.Interface()
+	case tag != "":
+		return p.redactKind(Kind(tag), v, depth+1)
+	}
+	for _, n := range []string{f.Name, name} {
+		if kind, ok := FieldKind(n); ok {
+			return p.redactKind(kind, v, depth+1)
+		}
+	}
+	return p.walk(v, depth+1)
+}
+
+// redactKind redacts a value of the given kind. Strings are redacted one by one, so lists
+// of addresses keep their length, while any other value is removed.
+func (p *Policy) redactKind(kind Kind, v reflect.Value, depth int) any {
+	if !v.IsValid() || depth > maxDepth {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return p.redactKind(kind, v.Elem(), depth+1)
+	case reflect.String:
+		return p.String(kind, v.String())
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return p.String(kind, s.String())
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]any, v.Len())
+		for i := range v.Len() {
+			items[i] = p.redactKind(kind, v.Index(i), depth+1)
+		}
+		return items
+	default:
+		return removed
+	}
+}
+
+// digest returns the first 16 hexadecimal digits of the keyed hash of a value, short enough
+// to read and long enough for the values of a log to be told apart.
+func (p *Policy) digest(value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// tokenize replaces a value by a keyed token keeping its shape: the domain of email
+// addresses and the version of IP addresses.
+func (p *Policy) tokenize(kind Kind, value string) string {
+	token := "tok_" + p.digest(value)[:12]
+	switch kind {
+	case KindEmail:
+		if i := strings.LastIndex(value, "@"); i >= 0 {
+			return token + value[i:]
+		}
+	case KindIP:
+		if ip := net.ParseIP(value); ip != nil && ip.To4() == nil {
+			return token + ":v6"
+		}
+	}
+	return token
+}
+
+// mask partially masks a value according to its kind.
+func mask(kind Kind, value string) string {
+	switch kind {
+	case KindEmail:
+		return RedactEmail(value)
+	case KindPhone:
+		if len(value) <= 4 {
+			return removed
+		}
+		return "***" + value[len(value)-2:]
+	case KindIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return removed
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return fmt.Sprintf("%d.%d.%d.0", v4[0], v4[1], v4[2])
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	default:
+		return RedactSensitiveData(value)
+	}
+}
+
+// opaque reports whether a value encodes itself, such as a time or an error, so its fields
+// aren't walked. Errors are logged as their message, with the email addresses in it redacted.
+func opaque(v reflect.Value) bool {
+	if v.Kind() == reflect.Pointer && v.IsNil() || !v.CanInterface() {
+		return false
+	}
+	switch v.Interface().(type) {
+	case json.Marshaler, encoding.TextMarshaler, error:
+		return true
+	}
+	return false
+}
+
+// jsonName returns the name a struct field is encoded to JSON with, whether it is omitted
+// when empty, and false for fields JSON ignores.
+func jsonName(f reflect.StructField) (string, bool, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(options, "omitempty"), true
+}
+
+// splitWords splits a field name into lowercase words at case changes, digits excepted, and
+// at separators, keeping acronyms such as ID or IP whole.
+func splitWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
@@ -1,6 +1,9 @@
 package redact
 
-import "strings"
+import (
+	"net/url"
+	"strings"
+)
 
 // RedactEmail redacts the user portion of an email address, keeping the domain intact.
 func RedactEmail(email string) string {
@@ -23,3 +26,55 @@ func RedactSensitiveData(data string) string {
 	}
 	return string(data[:2]) + "***" + string(data[len(data)-2:])
 }
+
+// sensitiveParams lists the names of query parameters carrying credentials or secrets, such
+// as OAuth codes and tokens, compared case-insensitively.
+var sensitiveParams = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"assertion":     true,
+	"client_secret": true,
+	"code":          true,
+	"code_verifier": true,
+	"id_token":      true,
+	"id_token_hint": true,
+	"password":      true,
+	"refresh_token": true,
+	"samlrequest":   true,
+	"samlresponse":  true,
+	"secret":        true,
+	"state":         true,
+	"token":         true,
+	"user_code":     true,
+}
+
+// RedactAuthorization redacts the credentials of an Authorization header value, keeping the
+// authentication scheme, such as Bearer, intact.
+func RedactAuthorization(header string) string {
+	if header == "" {
+		return ""
+	}
+	scheme, credentials, ok := strings.Cut(header, " ")
+	if !ok {
+		return "[REDACTED]"
+	}
+	return scheme + " " + RedactSensitiveData(strings.TrimSpace(credentials))
+}
+
+// RedactQuery returns the encoded query string with the values of sensitive parameters
+// replaced by REDACTED, which needs no escaping. Other parameters are kept as they are.
+func RedactQuery(query url.Values) string {
+	redacted := make(url.Values, len(query))
+	for name, values := range query {
+		if !sensitiveParams[strings.ToLower(name)] {
+			redacted[name] = values
+			continue
+		}
+		masked := make([]string, len(values))
+		for i := range values {
+			masked[i] = "REDACTED"
+		}
+		redacted[name] = masked
+	}
+	return redacted.Encode()
+}
@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core returns a core redacting, with the policy, the fields added to core and the fields of
+// the entries written to it before they reach its encoder. It guards against sensitive values
+// logged by mistake, log sites still mustn't log secrets such as passwords and tokens. It is
+// meant to be installed with zap.WrapCore.
+func (p *Policy) Core(core zapcore.Core) zapcore.Core {
+	return &fieldCore{Core: core, policy: p}
+}
+
+// fieldCore redacts the fields of an underlying core.
+type fieldCore struct {
+	zapcore.Core
+	policy *Policy
+}
+
+// With adds the redacted fields to the underlying core.
+func (c *fieldCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldCore{Core: c.Core.With(c.policy.fields(fields)), policy: c.policy}
+}
+
+// Check adds the core to the checked entry if the underlying core would write the entry.
+// The underlying core is asked through its own Check, so samplers it wraps still drop
+// entries, while the core is added in its place so the fields of the entry are redacted
+// before being written.
+func (c *fieldCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Core.Check(entry, nil) == nil {
+		return checked
+	}
+	return checked.AddCore(entry, c)
+}
+
+// Write writes the entry with its fields redacted to the underlying core.
+func (c *fieldCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.policy.fields(fields))
+}
+
+// fields returns the fields with the sensitive ones redacted, copying them only when one is.
+func (p *Policy) fields(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		r, ok := p.field(f)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = r
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// field redacts a field, reporting whether it was changed. Strings are redacted when their
+// key names sensitive data, while values encoded by reflection, such as structs and maps,
+// are walked for sensitive fields. The email addresses found in errors and other strings,
+// such as "user with email john@doe.com already exists", are redacted as well.
+func (p *Policy) field(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.ReflectType:
+		return zap.Reflect(f.Key, p.Field(f.Key, f.Interface)), true
+	case zapcore.StringType:
+		if kind, ok := FieldKind(f.Key); ok {
+			return zap.String(f.Key, p.String(kind, f.String)), true
+		}
+		return p.text(f, f.String)
+	case zapcore.StringerType:
+		if kind, ok := FieldKind(f.Key); ok {
+			return zap.String(f.Key, p.String(kind, fmt.Sprint(f.Interface))), true
+		}
+	case zapcore.ArrayMarshalerType:
+		if kind, ok := FieldKind(f.Key); ok {
+			return zap.Reflect(f.Key, p.redactKind(kind, reflect.ValueOf(f.Interface), 0)), true
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return p.text(f, err.Error())
+		}
+	}
+	return f, false
+}
+
+// text replaces a field by the text it holds when the text contains email addresses, which
+// are redacted.
+func (p *Policy) text(f zapcore.Field, text string) (zapcore.Field, bool) {
+	if redacted := p.Text(text); redacted != text {
+		return zap.String(f.Key, redacted), true
+	}
+	return f, false
+}